@@ -0,0 +1,17 @@
+// Command outline-ai runs the Outline AI assistant. Configuration comes
+// from defaults, a YAML file, OUTLINE_AI_* environment variables and flags;
+// run with -h to list every setting.
+package main
+
+import (
+	"os"
+
+	"github.com/yourusername/outline-ai/pkg/outlineai"
+)
+
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+func main() {
+	os.Exit(outlineai.Main(os.Args[1:], outlineai.WithVersion(version)))
+}
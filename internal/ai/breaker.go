@@ -0,0 +1,409 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultCoolDown         = 5 * time.Minute
+)
+
+// State is the position of a circuit breaker
+type State int
+
+const (
+	// StateClosed lets every call through and counts consecutive failures
+	StateClosed State = iota
+	// StateHalfOpen lets a single probe through after the cool-down
+	StateHalfOpen
+	// StateOpen rejects calls with ErrCircuitBreakerOpen until the cool-down ends
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// Scope decides whether methods share one breaker or trip independently
+type Scope int
+
+const (
+	// ScopeGlobal trips every method when the provider keeps failing
+	ScopeGlobal Scope = iota
+	// ScopePerMethod keeps a breaker per method, so a prompt that keeps
+	// producing invalid JSON does not block the others
+	ScopePerMethod
+)
+
+// globalBreaker is the key used for the shared breaker in ScopeGlobal
+const globalBreaker = "global"
+
+// StateChangeFunc is called after a breaker moves between states
+type StateChangeFunc func(name string, from, to State)
+
+// CircuitBreaker tracks consecutive failures for one call path
+type CircuitBreaker struct {
+	mu sync.Mutex
+
+	name      string
+	threshold int
+	coolDown  time.Duration
+	now       func() time.Time
+	onChange  StateChangeFunc
+
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker creates a closed breaker that opens after threshold
+// consecutive failures and allows a probe once coolDown has elapsed
+func NewCircuitBreaker(threshold int, coolDown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		name:      globalBreaker,
+		threshold: threshold,
+		coolDown:  coolDown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a call may proceed. A true result in half-open state
+// reserves the single probe, which must be settled with RecordSuccess or
+// RecordFailure.
+func (cb *CircuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case StateOpen:
+		if cb.now().Sub(cb.openedAt) < cb.coolDown {
+			return false
+		}
+		cb.transition(StateHalfOpen)
+		cb.probing = true
+		return true
+	case StateHalfOpen:
+		if cb.probing {
+			return false
+		}
+		cb.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordSuccess closes the breaker and clears the failure count
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures = 0
+	cb.probing = false
+	if cb.state != StateClosed {
+		cb.transition(StateClosed)
+	}
+}
+
+// RecordFailure counts a failure, opening the breaker at the threshold or
+// immediately when a half-open probe fails
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probing = false
+	cb.failures++
+	if cb.state == StateHalfOpen || cb.failures >= cb.threshold {
+		cb.openedAt = cb.now()
+		if cb.state != StateOpen {
+			cb.transition(StateOpen)
+		}
+	}
+}
+
+// release frees a half-open probe whose outcome says nothing about the
+// provider, such as a call cancelled by the caller
+func (cb *CircuitBreaker) release() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.probing = false
+}
+
+// State returns the current state, reporting an expired open breaker as
+// half-open since the next call would be let through
+func (cb *CircuitBreaker) State() State {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == StateOpen && cb.now().Sub(cb.openedAt) >= cb.coolDown {
+		return StateHalfOpen
+	}
+	return cb.state
+}
+
+// Reset forces the breaker closed
+func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures = 0
+	cb.probing = false
+	if cb.state != StateClosed {
+		cb.transition(StateClosed)
+	}
+}
+
+// transition must be called with cb.mu held
+func (cb *CircuitBreaker) transition(to State) {
+	from := cb.state
+	cb.state = to
+	if cb.onChange != nil {
+		cb.onChange(cb.name, from, to)
+	}
+}
+
+var _ Client = (*BreakerClient)(nil)
+
+// BreakerClient decorates a Client with circuit breaking. While a breaker is
+// open, calls fail fast with ErrCircuitBreakerOpen instead of reaching the
+// provider.
+type BreakerClient struct {
+	next Client
+
+	threshold     int
+	coolDown      time.Duration
+	scope         Scope
+	now           func() time.Time
+	onChange      StateChangeFunc
+	countsFailure func(error) bool
+
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+// BreakerOption configures a BreakerClient
+type BreakerOption func(*BreakerClient)
+
+// WithFailureThreshold sets how many consecutive failures open a breaker
+func WithFailureThreshold(threshold int) BreakerOption {
+	return func(b *BreakerClient) {
+		b.threshold = threshold
+	}
+}
+
+// WithCoolDown sets how long a breaker stays open before allowing a probe
+func WithCoolDown(coolDown time.Duration) BreakerOption {
+	return func(b *BreakerClient) {
+		b.coolDown = coolDown
+	}
+}
+
+// WithScope selects a global breaker or one breaker per method
+func WithScope(scope Scope) BreakerOption {
+	return func(b *BreakerClient) {
+		b.scope = scope
+	}
+}
+
+// WithStateChangeFunc registers a callback for breaker transitions. It runs
+// with the breaker's lock held and must not call back into the BreakerClient.
+func WithStateChangeFunc(fn StateChangeFunc) BreakerOption {
+	return func(b *BreakerClient) {
+		b.onChange = fn
+	}
+}
+
+// WithFailureClassifier replaces the rule deciding which errors count
+// towards opening a breaker
+func WithFailureClassifier(fn func(error) bool) BreakerOption {
+	return func(b *BreakerClient) {
+		b.countsFailure = fn
+	}
+}
+
+// WithClock replaces time.Now, for tests
+func WithClock(now func() time.Time) BreakerOption {
+	return func(b *BreakerClient) {
+		b.now = now
+	}
+}
+
+// NewBreakerClient wraps next with circuit breakers. Defaults are a global
+// breaker opening after 5 consecutive failures with a 5 minute cool-down.
+func NewBreakerClient(next Client, opts ...BreakerOption) *BreakerClient {
+	b := &BreakerClient{
+		next:          next,
+		threshold:     defaultFailureThreshold,
+		coolDown:      defaultCoolDown,
+		scope:         ScopeGlobal,
+		now:           time.Now,
+		countsFailure: IsProviderFailure,
+		breakers:      make(map[string]*CircuitBreaker),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// IsProviderFailure is the default failure classifier. Timeouts, rate
+// limiting, malformed output and transport errors count; caller
+// cancellation, oversized prompts and a breaker rejection do not.
+func IsProviderFailure(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled),
+		errors.Is(err, ErrTokenLimitExceeded),
+		errors.Is(err, ErrCircuitBreakerOpen):
+		return false
+	default:
+		return true
+	}
+}
+
+// State returns the most severe state across all breakers, for health reporting
+func (b *BreakerClient) State() State {
+	worst := StateClosed
+	for _, state := range b.States() {
+		if state > worst {
+			worst = state
+		}
+	}
+	return worst
+}
+
+// States returns the state of every breaker created so far, keyed by method
+// name, or by "global" in ScopeGlobal
+func (b *BreakerClient) States() map[string]State {
+	b.mu.Lock()
+	breakers := make(map[string]*CircuitBreaker, len(b.breakers))
+	for name, cb := range b.breakers {
+		breakers[name] = cb
+	}
+	b.mu.Unlock()
+
+	states := make(map[string]State, len(breakers))
+	for name, cb := range breakers {
+		states[name] = cb.State()
+	}
+	return states
+}
+
+// Reset closes every breaker
+func (b *BreakerClient) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, cb := range b.breakers {
+		cb.Reset()
+	}
+}
+
+func (b *BreakerClient) breaker(method string) *CircuitBreaker {
+	name := globalBreaker
+	if b.scope == ScopePerMethod {
+		name = method
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cb, ok := b.breakers[name]
+	if !ok {
+		cb = NewCircuitBreaker(b.threshold, b.coolDown)
+		cb.name = name
+		cb.now = b.now
+		cb.onChange = b.onChange
+		b.breakers[name] = cb
+	}
+	return cb
+}
+
+func guard[T any](b *BreakerClient, method string, fn func() (T, error)) (T, error) {
+	cb := b.breaker(method)
+	if !cb.Allow() {
+		var zero T
+		return zero, ErrCircuitBreakerOpen
+	}
+
+	resp, err := fn()
+	switch {
+	case err == nil:
+		cb.RecordSuccess()
+	case b.countsFailure(err):
+		cb.RecordFailure()
+	case errors.Is(err, context.Canceled):
+		cb.release()
+	default:
+		// The provider answered; the request itself was at fault
+		cb.RecordSuccess()
+	}
+	return resp, err
+}
+
+// Interface Implementation
+
+// ClassifyDocument forwards to the wrapped client unless its breaker is open
+func (b *BreakerClient) ClassifyDocument(ctx context.Context, req *ClassificationRequest) (*ClassificationResponse, error) {
+	return guard(b, "ClassifyDocument", func() (*ClassificationResponse, error) {
+		return b.next.ClassifyDocument(ctx, req)
+	})
+}
+
+// AnswerQuestion forwards to the wrapped client unless its breaker is open
+func (b *BreakerClient) AnswerQuestion(ctx context.Context, req *QuestionRequest) (*QuestionResponse, error) {
+	return guard(b, "AnswerQuestion", func() (*QuestionResponse, error) {
+		return b.next.AnswerQuestion(ctx, req)
+	})
+}
+
+// GenerateSummary forwards to the wrapped client unless its breaker is open
+func (b *BreakerClient) GenerateSummary(ctx context.Context, req *SummaryRequest) (*SummaryResponse, error) {
+	return guard(b, "GenerateSummary", func() (*SummaryResponse, error) {
+		return b.next.GenerateSummary(ctx, req)
+	})
+}
+
+// EnhanceTitle forwards to the wrapped client unless its breaker is open
+func (b *BreakerClient) EnhanceTitle(ctx context.Context, req *TitleRequest) (*TitleResponse, error) {
+	return guard(b, "EnhanceTitle", func() (*TitleResponse, error) {
+		return b.next.EnhanceTitle(ctx, req)
+	})
+}
+
+// GenerateSearchTerms forwards to the wrapped client unless its breaker is open
+func (b *BreakerClient) GenerateSearchTerms(ctx context.Context, req *SearchTermsRequest) (*SearchTermsResponse, error) {
+	return guard(b, "GenerateSearchTerms", func() (*SearchTermsResponse, error) {
+		return b.next.GenerateSearchTerms(ctx, req)
+	})
+}
+
+// FindRelatedDocuments forwards to the wrapped client unless its breaker is open
+func (b *BreakerClient) FindRelatedDocuments(ctx context.Context, req *RelatedDocsRequest) (*RelatedDocsResponse, error) {
+	return guard(b, "FindRelatedDocuments", func() (*RelatedDocsResponse, error) {
+		return b.next.FindRelatedDocuments(ctx, req)
+	})
+}
+
+// Ping always reaches the provider so health checks see its real status;
+// use State to report the breakers
+func (b *BreakerClient) Ping(ctx context.Context) error {
+	return b.next.Ping(ctx)
+}
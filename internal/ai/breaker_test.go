@@ -0,0 +1,260 @@
+package ai_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+// fakeClock is a manually advanced time source
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var summaryReq = &ai.SummaryRequest{DocumentTitle: "Doc", DocumentContent: "Content"}
+
+func TestBreakerClient_OpensAfterThreshold(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewAIMock()
+	clock := newFakeClock()
+	client := ai.NewBreakerClient(mock,
+		ai.WithFailureThreshold(3),
+		ai.WithCoolDown(time.Minute),
+		ai.WithClock(clock.Now),
+	)
+
+	mock.SetTimeoutError(true)
+	for i := 0; i < 3; i++ {
+		if _, err := client.GenerateSummary(ctx, summaryReq); !errors.Is(err, ai.ErrTimeout) {
+			t.Fatalf("Call %d: expected ErrTimeout, got %v", i+1, err)
+		}
+	}
+
+	if client.State() != ai.StateOpen {
+		t.Fatalf("Expected open breaker, got %s", client.State())
+	}
+
+	_, err := client.GenerateSummary(ctx, summaryReq)
+	if !errors.Is(err, ai.ErrCircuitBreakerOpen) {
+		t.Errorf("Expected ErrCircuitBreakerOpen, got %v", err)
+	}
+	if calls := mock.GetCallCount("GenerateSummary"); calls != 3 {
+		t.Errorf("Expected open breaker to stop calls at 3, got %d", calls)
+	}
+}
+
+func TestBreakerClient_SuccessResetsFailureCount(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewAIMock()
+	client := ai.NewBreakerClient(mock, ai.WithFailureThreshold(3))
+
+	for round := 0; round < 3; round++ {
+		mock.SetTimeoutError(true)
+		client.GenerateSummary(ctx, summaryReq)
+		client.GenerateSummary(ctx, summaryReq)
+		mock.SetTimeoutError(false)
+		if _, err := client.GenerateSummary(ctx, summaryReq); err != nil {
+			t.Fatalf("Round %d: unexpected error %v", round, err)
+		}
+	}
+
+	if client.State() != ai.StateClosed {
+		t.Errorf("Expected non-consecutive failures to keep breaker closed, got %s", client.State())
+	}
+}
+
+func TestBreakerClient_HalfOpen(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*ai.BreakerClient, *mocks.AIMock, *fakeClock) {
+		mock := mocks.NewAIMock()
+		clock := newFakeClock()
+		client := ai.NewBreakerClient(mock,
+			ai.WithFailureThreshold(2),
+			ai.WithCoolDown(time.Minute),
+			ai.WithClock(clock.Now),
+		)
+		mock.SetRateLimited(true)
+		client.GenerateSummary(ctx, summaryReq)
+		client.GenerateSummary(ctx, summaryReq)
+		return client, mock, clock
+	}
+
+	t.Run("stays open during cool-down", func(t *testing.T) {
+		client, _, clock := setup()
+		clock.Advance(59 * time.Second)
+		if client.State() != ai.StateOpen {
+			t.Errorf("Expected open, got %s", client.State())
+		}
+	})
+
+	t.Run("successful probe closes", func(t *testing.T) {
+		client, mock, clock := setup()
+		clock.Advance(time.Minute)
+		if client.State() != ai.StateHalfOpen {
+			t.Errorf("Expected half-open after cool-down, got %s", client.State())
+		}
+
+		mock.SetRateLimited(false)
+		if _, err := client.GenerateSummary(ctx, summaryReq); err != nil {
+			t.Fatalf("Expected probe to succeed, got %v", err)
+		}
+		if client.State() != ai.StateClosed {
+			t.Errorf("Expected closed after successful probe, got %s", client.State())
+		}
+	})
+
+	t.Run("failed probe reopens for a full cool-down", func(t *testing.T) {
+		client, _, clock := setup()
+		clock.Advance(time.Minute)
+
+		if _, err := client.GenerateSummary(ctx, summaryReq); !errors.Is(err, ai.ErrRateLimited) {
+			t.Fatalf("Expected probe to reach provider, got %v", err)
+		}
+		if client.State() != ai.StateOpen {
+			t.Fatalf("Expected open after failed probe, got %s", client.State())
+		}
+
+		clock.Advance(30 * time.Second)
+		if _, err := client.GenerateSummary(ctx, summaryReq); !errors.Is(err, ai.ErrCircuitBreakerOpen) {
+			t.Errorf("Expected ErrCircuitBreakerOpen, got %v", err)
+		}
+	})
+
+	t.Run("only one probe at a time", func(t *testing.T) {
+		cb := ai.NewCircuitBreaker(1, time.Millisecond)
+		cb.RecordFailure()
+		time.Sleep(2 * time.Millisecond)
+
+		if !cb.Allow() {
+			t.Fatal("Expected first probe to be allowed")
+		}
+		if cb.Allow() {
+			t.Error("Expected second concurrent probe to be rejected")
+		}
+		cb.RecordSuccess()
+		if cb.State() != ai.StateClosed || !cb.Allow() {
+			t.Error("Expected breaker to close after probe succeeded")
+		}
+	})
+}
+
+func TestBreakerClient_Scope(t *testing.T) {
+	ctx := context.Background()
+	classifyReq := &ai.ClassificationRequest{DocumentTitle: "Doc", DocumentContent: "Content"}
+
+	t.Run("global scope trips every method", func(t *testing.T) {
+		mock := mocks.NewAIMock()
+		client := ai.NewBreakerClient(mock, ai.WithFailureThreshold(2))
+
+		mock.SetMethodError("GenerateSummary", ai.ErrInvalidResponse)
+		client.GenerateSummary(ctx, summaryReq)
+		client.GenerateSummary(ctx, summaryReq)
+
+		if _, err := client.ClassifyDocument(ctx, classifyReq); !errors.Is(err, ai.ErrCircuitBreakerOpen) {
+			t.Errorf("Expected ErrCircuitBreakerOpen, got %v", err)
+		}
+		if states := client.States(); len(states) != 1 || states["global"] != ai.StateOpen {
+			t.Errorf("Expected a single open global breaker, got %v", states)
+		}
+	})
+
+	t.Run("per-method scope isolates methods", func(t *testing.T) {
+		mock := mocks.NewAIMock()
+		client := ai.NewBreakerClient(mock, ai.WithFailureThreshold(2), ai.WithScope(ai.ScopePerMethod))
+
+		mock.SetMethodError("GenerateSummary", ai.ErrInvalidResponse)
+		client.GenerateSummary(ctx, summaryReq)
+		client.GenerateSummary(ctx, summaryReq)
+
+		if _, err := client.ClassifyDocument(ctx, classifyReq); err != nil {
+			t.Errorf("Expected ClassifyDocument to be unaffected, got %v", err)
+		}
+
+		states := client.States()
+		if states["GenerateSummary"] != ai.StateOpen || states["ClassifyDocument"] != ai.StateClosed {
+			t.Errorf("Unexpected states: %v", states)
+		}
+		if client.State() != ai.StateOpen {
+			t.Errorf("Expected aggregate state to report the open breaker, got %s", client.State())
+		}
+	})
+}
+
+func TestBreakerClient_IgnoredErrors(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewAIMock()
+	client := ai.NewBreakerClient(mock, ai.WithFailureThreshold(1))
+
+	mock.SetTokenLimitExceeded(true)
+	client.GenerateSummary(ctx, summaryReq)
+	mock.SetTokenLimitExceeded(false)
+
+	mock.SetMethodError("GenerateSummary", context.Canceled)
+	client.GenerateSummary(ctx, summaryReq)
+
+	if client.State() != ai.StateClosed {
+		t.Errorf("Expected caller-side errors not to open the breaker, got %s", client.State())
+	}
+}
+
+func TestBreakerClient_StateChangesAndReset(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewAIMock()
+
+	var transitions []string
+	client := ai.NewBreakerClient(mock,
+		ai.WithFailureThreshold(1),
+		ai.WithStateChangeFunc(func(name string, from, to ai.State) {
+			transitions = append(transitions, name+":"+from.String()+"->"+to.String())
+		}),
+	)
+
+	mock.SetTimeoutError(true)
+	client.GenerateSummary(ctx, summaryReq)
+	client.Reset()
+
+	want := []string{"global:closed->open", "global:open->closed"}
+	if len(transitions) != len(want) || transitions[0] != want[0] || transitions[1] != want[1] {
+		t.Errorf("Expected transitions %v, got %v", want, transitions)
+	}
+}
+
+func TestBreakerClient_PingBypassesBreaker(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewAIMock()
+	client := ai.NewBreakerClient(mock, ai.WithFailureThreshold(1))
+
+	mock.SetTimeoutError(true)
+	client.GenerateSummary(ctx, summaryReq)
+	mock.SetTimeoutError(false)
+
+	if err := client.Ping(ctx); err != nil {
+		t.Errorf("Expected Ping to reach the provider, got %v", err)
+	}
+	if client.State() != ai.StateOpen {
+		t.Errorf("Expected Ping not to affect breaker state, got %s", client.State())
+	}
+}
@@ -0,0 +1,23 @@
+package ai
+
+import "context"
+
+// Client is the contract every AI backend and decorator implements
+type Client interface {
+	// Document classification for filing
+	ClassifyDocument(ctx context.Context, req *ClassificationRequest) (*ClassificationResponse, error)
+
+	// Question answering with context
+	AnswerQuestion(ctx context.Context, req *QuestionRequest) (*QuestionResponse, error)
+
+	// Content enhancement
+	GenerateSummary(ctx context.Context, req *SummaryRequest) (*SummaryResponse, error)
+	EnhanceTitle(ctx context.Context, req *TitleRequest) (*TitleResponse, error)
+	GenerateSearchTerms(ctx context.Context, req *SearchTermsRequest) (*SearchTermsResponse, error)
+
+	// Related documents
+	FindRelatedDocuments(ctx context.Context, req *RelatedDocsRequest) (*RelatedDocsResponse, error)
+
+	// Health
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,14 @@
+package ai
+
+import "errors"
+
+// Package-level errors for AI clients
+var (
+	ErrCircuitBreakerOpen = errors.New("ai: circuit breaker open")
+	ErrInvalidResponse    = errors.New("ai: invalid response")
+	ErrTimeout            = errors.New("ai: request timeout")
+	ErrTokenLimitExceeded = errors.New("ai: token limit exceeded")
+	ErrRateLimited        = errors.New("ai: rate limited by provider")
+	ErrServerError        = errors.New("ai: provider server error")
+	ErrUnreachable        = errors.New("ai: provider unreachable")
+)
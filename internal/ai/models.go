@@ -0,0 +1,117 @@
+package ai
+
+// TaxonomyCollection represents a collection in taxonomy context
+type TaxonomyCollection struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Description     string   `json:"description"`
+	SampleDocuments []string `json:"sample_documents,omitempty"`
+}
+
+// TaxonomyContext wraps taxonomy information
+type TaxonomyContext struct {
+	Collections []TaxonomyCollection `json:"collections"`
+}
+
+// ClassificationRequest is the request for document classification
+type ClassificationRequest struct {
+	DocumentTitle   string           `json:"document_title"`
+	DocumentContent string           `json:"document_content"`
+	UserGuidance    string           `json:"user_guidance,omitempty"`
+	Taxonomy        *TaxonomyContext `json:"taxonomy"`
+}
+
+// ClassificationResponse is the response from classification
+type ClassificationResponse struct {
+	CollectionID string                      `json:"collection_id"`
+	Confidence   float64                     `json:"confidence"`
+	Reasoning    string                      `json:"reasoning"`
+	Alternatives []AlternativeClassification `json:"alternatives,omitempty"`
+	SearchTerms  []string                    `json:"search_terms"`
+}
+
+// AlternativeClassification represents an alternative classification
+type AlternativeClassification struct {
+	CollectionID string  `json:"collection_id"`
+	Confidence   float64 `json:"confidence"`
+	Reasoning    string  `json:"reasoning"`
+}
+
+// ContextDocument represents a document for context
+type ContextDocument struct {
+	Title   string `json:"title"`
+	Excerpt string `json:"excerpt"`
+	URL     string `json:"url"`
+}
+
+// QuestionRequest is the request for question answering
+type QuestionRequest struct {
+	Question    string            `json:"question"`
+	ContextDocs []ContextDocument `json:"context_documents"`
+}
+
+// QuestionResponse is the response from question answering
+type QuestionResponse struct {
+	Answer     string         `json:"answer"`
+	Citations  []CitationInfo `json:"citations"`
+	Confidence float64        `json:"confidence"`
+}
+
+// CitationInfo represents a citation
+type CitationInfo struct {
+	DocumentTitle string `json:"document_title"`
+	DocumentURL   string `json:"document_url"`
+}
+
+// SummaryRequest is the request for summary generation
+type SummaryRequest struct {
+	DocumentTitle   string `json:"document_title"`
+	DocumentContent string `json:"document_content"`
+}
+
+// SummaryResponse is the response from summary generation
+type SummaryResponse struct {
+	Summary string `json:"summary"`
+}
+
+// TitleRequest is the request for title enhancement
+type TitleRequest struct {
+	CurrentTitle    string `json:"current_title"`
+	DocumentContent string `json:"document_content"`
+}
+
+// TitleResponse is the response from title enhancement
+type TitleResponse struct {
+	SuggestedTitle string  `json:"suggested_title"`
+	Confidence     float64 `json:"confidence"`
+}
+
+// SearchTermsRequest is the request for search terms generation
+type SearchTermsRequest struct {
+	DocumentTitle   string `json:"document_title"`
+	DocumentContent string `json:"document_content"`
+}
+
+// SearchTermsResponse is the response from search terms generation
+type SearchTermsResponse struct {
+	SearchTerms []string `json:"search_terms"`
+}
+
+// RelatedDocsRequest is the request for finding related documents
+type RelatedDocsRequest struct {
+	DocumentTitle   string   `json:"document_title"`
+	DocumentContent string   `json:"document_content"`
+	AvailableDocs   []string `json:"available_documents"`
+}
+
+// RelatedDocsResponse is the response from finding related documents
+type RelatedDocsResponse struct {
+	RelatedDocuments []RelatedDocument `json:"related_documents"`
+}
+
+// RelatedDocument represents a related document
+type RelatedDocument struct {
+	Title     string  `json:"title"`
+	Relevance float64 `json:"relevance"`
+	Reason    string  `json:"reason"`
+}
@@ -0,0 +1,462 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ratelimit"
+)
+
+const (
+	defaultTimeout     = 60 * time.Second
+	defaultMaxTokens   = 1024
+	defaultTemperature = 0.3
+)
+
+// ResponseFormat selects how structured output is requested from the provider
+type ResponseFormat string
+
+const (
+	// ResponseFormatJSONSchema sends the method's JSON schema (OpenAI, vLLM, recent Ollama)
+	ResponseFormatJSONSchema ResponseFormat = "json_schema"
+	// ResponseFormatJSONObject only asks for a JSON object, for servers without schema support
+	ResponseFormatJSONObject ResponseFormat = "json_object"
+)
+
+var _ Client = (*OpenAIClient)(nil)
+
+// OpenAIClient implements Client against any OpenAI-compatible /v1/chat/completions endpoint
+type OpenAIClient struct {
+	httpClient     *http.Client
+	baseURL        string
+	apiKey         string
+	model          string
+	maxTokens      int
+	temperature    float64
+	timeout        time.Duration
+	responseFormat ResponseFormat
+
+	// Prices in USD per million tokens
+	promptPrice     float64
+	completionPrice float64
+}
+
+// Option configures an OpenAIClient
+type Option func(*OpenAIClient)
+
+// WithHTTPClient replaces the underlying *http.Client
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *OpenAIClient) {
+		c.httpClient = client
+	}
+}
+
+// WithTimeout bounds each completion request
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *OpenAIClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithMaxTokens caps the completion length
+func WithMaxTokens(maxTokens int) Option {
+	return func(c *OpenAIClient) {
+		c.maxTokens = maxTokens
+	}
+}
+
+// WithTemperature sets the sampling temperature
+func WithTemperature(temperature float64) Option {
+	return func(c *OpenAIClient) {
+		c.temperature = temperature
+	}
+}
+
+// WithResponseFormat selects how structured output is requested
+func WithResponseFormat(format ResponseFormat) Option {
+	return func(c *OpenAIClient) {
+		c.responseFormat = format
+	}
+}
+
+// WithPricing sets the model's prices in USD per million prompt and
+// completion tokens, so recorded usage carries its cost
+func WithPricing(promptPerMillion, completionPerMillion float64) Option {
+	return func(c *OpenAIClient) {
+		c.promptPrice = promptPerMillion
+		c.completionPrice = completionPerMillion
+	}
+}
+
+// NewOpenAIClient creates a client rooted at baseURL, e.g. "https://api.openai.com/v1"
+// or "http://localhost:11434/v1". apiKey may be empty for local servers.
+func NewOpenAIClient(baseURL, apiKey, model string, opts ...Option) *OpenAIClient {
+	c := &OpenAIClient{
+		httpClient:     &http.Client{},
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		apiKey:         apiKey,
+		model:          model,
+		maxTokens:      defaultMaxTokens,
+		temperature:    defaultTemperature,
+		timeout:        defaultTimeout,
+		responseFormat: ResponseFormatJSONSchema,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Wire formats
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type jsonSchemaFormat struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+type responseFormat struct {
+	Type       ResponseFormat    `json:"type"`
+	JSONSchema *jsonSchemaFormat `json:"json_schema,omitempty"`
+}
+
+type chatCompletionRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Temperature    float64         `json:"temperature"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message      chatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+type providerError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    any    `json:"code"`
+	} `json:"error"`
+}
+
+// completion describes one structured chat call
+type completion struct {
+	name         string
+	systemPrompt string
+	userPrompt   string
+	schema       json.RawMessage
+}
+
+// Interface Implementation
+
+// ClassifyDocument picks the best collection for a document from the taxonomy
+func (c *OpenAIClient) ClassifyDocument(ctx context.Context, req *ClassificationRequest) (*ClassificationResponse, error) {
+	content, err := c.complete(ctx, completion{
+		name:         "classification",
+		systemPrompt: classifySystemPrompt,
+		userPrompt:   buildClassifyUserPrompt(req),
+		schema:       classificationSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp ClassificationResponse
+	if err := decodeStrict(content, &resp, "collection_id", "confidence", "reasoning", "search_terms"); err != nil {
+		return nil, err
+	}
+	if err := validateClassificationResponse(&resp, req.Taxonomy); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AnswerQuestion answers a question from the supplied context documents
+func (c *OpenAIClient) AnswerQuestion(ctx context.Context, req *QuestionRequest) (*QuestionResponse, error) {
+	content, err := c.complete(ctx, completion{
+		name:         "question_answer",
+		systemPrompt: answerQuestionSystemPrompt,
+		userPrompt:   buildAnswerQuestionUserPrompt(req),
+		schema:       questionSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp QuestionResponse
+	if err := decodeStrict(content, &resp, "answer", "confidence"); err != nil {
+		return nil, err
+	}
+	if err := validateQuestionResponse(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GenerateSummary writes a short summary of a document
+func (c *OpenAIClient) GenerateSummary(ctx context.Context, req *SummaryRequest) (*SummaryResponse, error) {
+	content, err := c.complete(ctx, completion{
+		name:         "summary",
+		systemPrompt: summarySystemPrompt,
+		userPrompt:   buildSummaryUserPrompt(req),
+		schema:       summarySchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp SummaryResponse
+	if err := decodeStrict(content, &resp, "summary"); err != nil {
+		return nil, err
+	}
+	if err := validateSummaryResponse(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// EnhanceTitle suggests a more descriptive title
+func (c *OpenAIClient) EnhanceTitle(ctx context.Context, req *TitleRequest) (*TitleResponse, error) {
+	content, err := c.complete(ctx, completion{
+		name:         "title",
+		systemPrompt: enhanceTitleSystemPrompt,
+		userPrompt:   buildEnhanceTitleUserPrompt(req),
+		schema:       titleSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp TitleResponse
+	if err := decodeStrict(content, &resp, "suggested_title", "confidence"); err != nil {
+		return nil, err
+	}
+	if err := validateTitleResponse(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GenerateSearchTerms extracts keywords that help users find a document
+func (c *OpenAIClient) GenerateSearchTerms(ctx context.Context, req *SearchTermsRequest) (*SearchTermsResponse, error) {
+	content, err := c.complete(ctx, completion{
+		name:         "search_terms",
+		systemPrompt: searchTermsSystemPrompt,
+		userPrompt:   buildSearchTermsUserPrompt(req),
+		schema:       searchTermsSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp SearchTermsResponse
+	if err := decodeStrict(content, &resp, "search_terms"); err != nil {
+		return nil, err
+	}
+	if err := validateSearchTermsResponse(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// FindRelatedDocuments picks related titles from the available documents
+func (c *OpenAIClient) FindRelatedDocuments(ctx context.Context, req *RelatedDocsRequest) (*RelatedDocsResponse, error) {
+	content, err := c.complete(ctx, completion{
+		name:         "related_documents",
+		systemPrompt: relatedDocsSystemPrompt,
+		userPrompt:   buildRelatedDocsUserPrompt(req),
+		schema:       relatedDocsSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp RelatedDocsResponse
+	if err := decodeStrict(content, &resp, "related_documents"); err != nil {
+		return nil, err
+	}
+	if err := validateRelatedDocsResponse(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Ping checks that the endpoint is reachable and accepts the API key
+func (c *OpenAIClient) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("ai: failed to create ping request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return classifyTransportError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return classifyHTTPError(resp.StatusCode, resp.Header, body)
+	}
+	return nil
+}
+
+// Transport
+
+func (c *OpenAIClient) complete(ctx context.Context, call completion) (string, error) {
+	payload := chatCompletionRequest{
+		Model: c.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: call.systemPrompt},
+			{Role: "user", Content: call.userPrompt},
+		},
+		MaxTokens:   c.maxTokens,
+		Temperature: c.temperature,
+	}
+	switch c.responseFormat {
+	case ResponseFormatJSONSchema:
+		payload.ResponseFormat = &responseFormat{
+			Type:       ResponseFormatJSONSchema,
+			JSONSchema: &jsonSchemaFormat{Name: call.name, Schema: call.schema},
+		}
+	case ResponseFormatJSONObject:
+		payload.ResponseFormat = &responseFormat{Type: ResponseFormatJSONObject}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("ai: failed to marshal request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("ai: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", classifyTransportError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", classifyTransportError(ctx, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", classifyHTTPError(resp.StatusCode, resp.Header, respBody)
+	}
+
+	var completionResp chatCompletionResponse
+	if err := json.Unmarshal(respBody, &completionResp); err != nil {
+		return "", fmt.Errorf("%w: malformed completion envelope: %v", ErrInvalidResponse, err)
+	}
+	if u := completionResp.Usage; u != nil {
+		RecordUsage(ctx, c.usage(u.PromptTokens, u.CompletionTokens))
+	}
+	if len(completionResp.Choices) == 0 {
+		return "", fmt.Errorf("%w: no choices returned", ErrInvalidResponse)
+	}
+
+	choice := completionResp.Choices[0]
+	if choice.FinishReason == "length" {
+		return "", fmt.Errorf("%w: completion truncated at max_tokens=%d", ErrTokenLimitExceeded, c.maxTokens)
+	}
+	return choice.Message.Content, nil
+}
+
+// usage prices a response's token counts
+func (c *OpenAIClient) usage(promptTokens, completionTokens int) Usage {
+	return Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		CostUSD:          (float64(promptTokens)*c.promptPrice + float64(completionTokens)*c.completionPrice) / 1e6,
+	}
+}
+
+func (c *OpenAIClient) setHeaders(req *http.Request) {
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+}
+
+func classifyTransportError(ctx context.Context, err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
+	}
+	return fmt.Errorf("%w: %w", ErrUnreachable, err)
+}
+
+func classifyHTTPError(statusCode int, header http.Header, body []byte) error {
+	var envelope providerError
+	message := strings.TrimSpace(string(body))
+	code := ""
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error.Message != "" {
+		message = envelope.Error.Message
+		code = fmt.Sprint(envelope.Error.Code)
+	}
+	if len(message) > 200 {
+		message = message[:200]
+	}
+
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return &ratelimit.RetryAfterError{
+			Err:   fmt.Errorf("%w: %s", ErrRateLimited, message),
+			After: ratelimit.ParseRetryAfter(header, time.Now()),
+		}
+	case statusCode == http.StatusRequestTimeout, statusCode == http.StatusGatewayTimeout:
+		return fmt.Errorf("%w: HTTP %d", ErrTimeout, statusCode)
+	case isTokenLimitError(code, message):
+		return fmt.Errorf("%w: %s", ErrTokenLimitExceeded, message)
+	case statusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: HTTP %d: %s", ErrServerError, statusCode, message)
+	default:
+		return fmt.Errorf("ai: provider returned HTTP %d: %s", statusCode, message)
+	}
+}
+
+func isTokenLimitError(code, message string) bool {
+	if code == "context_length_exceeded" {
+		return true
+	}
+	lower := strings.ToLower(message)
+	return strings.Contains(lower, "context length") ||
+		strings.Contains(lower, "maximum context") ||
+		strings.Contains(lower, "too many tokens")
+}
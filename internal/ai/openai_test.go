@@ -0,0 +1,516 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ratelimit"
+)
+
+const fixturesDir = "../../test/fixtures"
+
+// fakeProvider is an OpenAI-compatible /chat/completions endpoint that replies
+// with a configured message content, status code or finish reason
+type fakeProvider struct {
+	mu           sync.Mutex
+	content      string
+	status       int
+	body         string
+	finishReason string
+	delay        time.Duration
+	lastRequest  chatCompletionRequest
+	lastHeaders  http.Header
+}
+
+func (f *fakeProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.lastHeaders = r.Header.Clone()
+	status, body, content, finish, delay := f.status, f.body, f.content, f.finishReason, f.delay
+	f.mu.Unlock()
+
+	// Drain the body first so the server notices a client disconnect
+	raw, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	_ = json.Unmarshal(raw, &f.lastRequest)
+	f.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if r.URL.Path == "/models" {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.WriteString(w, `{"object":"list","data":[{"id":"test-model"}]}`)
+		return
+	}
+
+	if status != 0 {
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+		return
+	}
+	if finish == "" {
+		finish = "stop"
+	}
+
+	resp := map[string]any{
+		"choices": []map[string]any{{
+			"message":       map[string]string{"role": "assistant", "content": content},
+			"finish_reason": finish,
+		}},
+		"usage": map[string]int{"prompt_tokens": 1200, "completion_tokens": 300},
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (f *fakeProvider) reply(content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.content, f.status, f.body, f.finishReason = content, 0, "", ""
+}
+
+func (f *fakeProvider) fail(status int, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status, f.body = status, body
+}
+
+func setupClient(t *testing.T, opts ...Option) (*OpenAIClient, *fakeProvider) {
+	t.Helper()
+	provider := &fakeProvider{}
+	server := httptest.NewServer(provider)
+	t.Cleanup(server.Close)
+	return NewOpenAIClient(server.URL, "test-key", "test-model", opts...), provider
+}
+
+func readFixture(t *testing.T, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(fixturesDir, rel))
+	if err != nil {
+		t.Fatalf("Failed to load fixture %s: %v", rel, err)
+	}
+	return string(data)
+}
+
+// declaredFields reduces a fixture to the fields of resp, as a model bound
+// to the response schema would reply
+func declaredFields(t *testing.T, rel string, resp any) string {
+	t.Helper()
+	if err := json.Unmarshal([]byte(readFixture(t, rel)), resp); err != nil {
+		t.Fatalf("Failed to parse fixture %s: %v", rel, err)
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Failed to encode fixture %s: %v", rel, err)
+	}
+	return string(data)
+}
+
+func loadTaxonomy(t *testing.T) *TaxonomyContext {
+	t.Helper()
+	var collections []TaxonomyCollection
+	if err := json.Unmarshal([]byte(readFixture(t, "collections/sample_collections.json")), &collections); err != nil {
+		t.Fatalf("Failed to parse collections fixture: %v", err)
+	}
+	return &TaxonomyContext{Collections: collections}
+}
+
+func TestOpenAIClient_ClassifyDocument(t *testing.T) {
+	ctx := context.Background()
+	taxonomy := loadTaxonomy(t)
+	req := &ClassificationRequest{
+		DocumentTitle:   "Mobile App API",
+		DocumentContent: "Endpoints for the iOS and Android apps",
+		Taxonomy:        taxonomy,
+	}
+
+	t.Run("high confidence fixture", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.reply(readFixture(t, "ai_responses/filing_high_confidence.json"))
+
+		resp, err := client.ClassifyDocument(ctx, req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if resp.CollectionID != "col-engineering-001" {
+			t.Errorf("Expected col-engineering-001, got %s", resp.CollectionID)
+		}
+		if resp.Confidence != 0.95 {
+			t.Errorf("Expected confidence 0.95, got %v", resp.Confidence)
+		}
+		if len(resp.SearchTerms) == 0 {
+			t.Error("Expected search terms")
+		}
+	})
+
+	t.Run("low confidence fixture keeps alternatives", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.reply(readFixture(t, "ai_responses/filing_low_confidence.json"))
+
+		resp, err := client.ClassifyDocument(ctx, req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if resp.Confidence != 0.55 {
+			t.Errorf("Expected confidence 0.55, got %v", resp.Confidence)
+		}
+		if len(resp.Alternatives) != 2 || resp.Alternatives[0].CollectionID != "col-product-001" {
+			t.Errorf("Expected product alternative first, got %+v", resp.Alternatives)
+		}
+	})
+
+	t.Run("sends model, prompts and schema", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.reply(readFixture(t, "ai_responses/filing_high_confidence.json"))
+
+		if _, err := client.ClassifyDocument(ctx, req); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		sent := provider.lastRequest
+		if sent.Model != "test-model" {
+			t.Errorf("Expected model test-model, got %s", sent.Model)
+		}
+		if len(sent.Messages) != 2 || sent.Messages[0].Role != "system" || sent.Messages[1].Role != "user" {
+			t.Fatalf("Expected system and user messages, got %+v", sent.Messages)
+		}
+		if !strings.Contains(sent.Messages[1].Content, "col-engineering-001") {
+			t.Error("Expected user prompt to list collection IDs")
+		}
+		if sent.ResponseFormat == nil || sent.ResponseFormat.Type != ResponseFormatJSONSchema ||
+			sent.ResponseFormat.JSONSchema == nil || sent.ResponseFormat.JSONSchema.Name != "classification" {
+			t.Errorf("Expected json_schema response format, got %+v", sent.ResponseFormat)
+		}
+		if got := provider.lastHeaders.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Expected bearer token, got %q", got)
+		}
+	})
+
+	t.Run("json_object fallback omits schema", func(t *testing.T) {
+		client, provider := setupClient(t, WithResponseFormat(ResponseFormatJSONObject))
+		provider.reply(readFixture(t, "ai_responses/filing_high_confidence.json"))
+
+		if _, err := client.ClassifyDocument(ctx, req); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		format := provider.lastRequest.ResponseFormat
+		if format == nil || format.Type != ResponseFormatJSONObject || format.JSONSchema != nil {
+			t.Errorf("Expected bare json_object format, got %+v", format)
+		}
+	})
+
+	t.Run("accepts code-fenced output", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.reply("```json\n" + readFixture(t, "ai_responses/filing_high_confidence.json") + "\n```")
+
+		if _, err := client.ClassifyDocument(ctx, req); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestOpenAIClient_InvalidResponses(t *testing.T) {
+	ctx := context.Background()
+	taxonomy := loadTaxonomy(t)
+	req := &ClassificationRequest{DocumentTitle: "Doc", DocumentContent: "Content", Taxonomy: taxonomy}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"prose instead of JSON", "I think this belongs in Engineering."},
+		{"unknown collection", `{"collection_id":"col-made-up","confidence":0.9,"reasoning":"r","search_terms":["a"]}`},
+		{"confidence out of range", `{"collection_id":"col-engineering-001","confidence":1.5,"reasoning":"r","search_terms":["a"]}`},
+		{"missing required field", `{"collection_id":"col-engineering-001","confidence":0.9,"search_terms":["a"]}`},
+		{"null required field", `{"collection_id":"col-engineering-001","confidence":null,"reasoning":"r","search_terms":["a"]}`},
+		{"wrong type", `{"collection_id":"col-engineering-001","confidence":"high","reasoning":"r","search_terms":["a"]}`},
+		{"misspelled field", `{"collection_id":"col-engineering-001","confidence":0.9,"reasoning":"r","search_terms":["a"],"alternative":[]}`},
+		{"extra field", `{"collection_id":"col-engineering-001","confidence":0.9,"reasoning":"r","search_terms":["a"],"notes":"n"}`},
+		{"trailing data", `{"collection_id":"col-engineering-001","confidence":0.9,"reasoning":"r","search_terms":["a"]} {"collection_id":"x"}`},
+		{"unknown alternative", `{"collection_id":"col-engineering-001","confidence":0.6,"reasoning":"r","search_terms":["a"],"alternatives":[{"collection_id":"col-nope","confidence":0.3,"reasoning":"r"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, provider := setupClient(t)
+			provider.reply(tt.content)
+
+			_, err := client.ClassifyDocument(ctx, req)
+			if !errors.Is(err, ErrInvalidResponse) {
+				t.Errorf("Expected ErrInvalidResponse, got %v", err)
+			}
+		})
+	}
+
+	t.Run("no choices", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.fail(http.StatusOK, `{"choices":[]}`)
+
+		_, err := client.ClassifyDocument(ctx, req)
+		if !errors.Is(err, ErrInvalidResponse) {
+			t.Errorf("Expected ErrInvalidResponse, got %v", err)
+		}
+	})
+}
+
+func TestOpenAIClient_RecordsUsage(t *testing.T) {
+	client, provider := setupClient(t, WithPricing(0.15, 0.60))
+	provider.reply(`{"summary":"A summary."}`)
+
+	var recorder UsageRecorder
+	ctx := WithUsageRecorder(context.Background(), &recorder)
+	if _, err := client.GenerateSummary(ctx, &SummaryRequest{DocumentTitle: "Doc", DocumentContent: "Content"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Truncated completions are billed too
+	provider.mu.Lock()
+	provider.finishReason = "length"
+	provider.mu.Unlock()
+	if _, err := client.GenerateSummary(ctx, &SummaryRequest{DocumentTitle: "Doc", DocumentContent: "Content"}); !errors.Is(err, ErrTokenLimitExceeded) {
+		t.Fatalf("Expected ErrTokenLimitExceeded, got %v", err)
+	}
+
+	usage := recorder.Usage()
+	if usage.PromptTokens != 2400 || usage.CompletionTokens != 600 || usage.TotalTokens() != 3000 {
+		t.Errorf("Expected the tokens of both calls, got %+v", usage)
+	}
+	if want := (2400*0.15 + 600*0.60) / 1e6; math.Abs(usage.CostUSD-want) > 1e-12 {
+		t.Errorf("Expected a cost of %g, got %g", want, usage.CostUSD)
+	}
+
+	// Without a recorder the usage is dropped
+	if _, err := client.GenerateSummary(context.Background(), &SummaryRequest{DocumentTitle: "Doc"}); !errors.Is(err, ErrTokenLimitExceeded) {
+		t.Fatalf("Expected ErrTokenLimitExceeded, got %v", err)
+	}
+}
+
+func TestOpenAIClient_OtherMethods(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("AnswerQuestion decodes fixture", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.reply(declaredFields(t, "ai_responses/qna_answer.json", &QuestionResponse{}))
+
+		resp, err := client.AnswerQuestion(ctx, &QuestionRequest{
+			Question:    "What is our rate limiting policy?",
+			ContextDocs: []ContextDocument{{Title: "API Design", Excerpt: "Token bucket", URL: "https://outline.example.com/doc/api"}},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if resp.Confidence != 0.92 || !strings.Contains(resp.Answer, "token bucket") {
+			t.Errorf("Unexpected answer: %+v", resp)
+		}
+		if !strings.Contains(provider.lastRequest.Messages[1].Content, "https://outline.example.com/doc/api") {
+			t.Error("Expected context document URL in prompt")
+		}
+	})
+
+	t.Run("GenerateSummary decodes fixture", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.reply(declaredFields(t, "ai_responses/summary.json", &SummaryResponse{}))
+
+		resp, err := client.GenerateSummary(ctx, &SummaryRequest{DocumentTitle: "API Guidelines", DocumentContent: "..."})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.HasPrefix(resp.Summary, "This document establishes") {
+			t.Errorf("Unexpected summary: %q", resp.Summary)
+		}
+	})
+
+	t.Run("EnhanceTitle", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.reply(`{"suggested_title":"API Rate Limiting Policy","confidence":0.8}`)
+
+		resp, err := client.EnhanceTitle(ctx, &TitleRequest{CurrentTitle: "Notes", DocumentContent: "..."})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if resp.SuggestedTitle != "API Rate Limiting Policy" {
+			t.Errorf("Unexpected title: %q", resp.SuggestedTitle)
+		}
+	})
+
+	t.Run("GenerateSearchTerms rejects empty list", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.reply(`{"search_terms":[]}`)
+
+		_, err := client.GenerateSearchTerms(ctx, &SearchTermsRequest{DocumentTitle: "Doc", DocumentContent: "..."})
+		if !errors.Is(err, ErrInvalidResponse) {
+			t.Errorf("Expected ErrInvalidResponse, got %v", err)
+		}
+	})
+
+	t.Run("FindRelatedDocuments", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.reply(`{"related_documents":[{"title":"API Design","relevance":0.9,"reason":"Same topic"}]}`)
+
+		resp, err := client.FindRelatedDocuments(ctx, &RelatedDocsRequest{
+			DocumentTitle: "Doc", DocumentContent: "...", AvailableDocs: []string{"API Design"},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(resp.RelatedDocuments) != 1 {
+			t.Errorf("Expected 1 related document, got %d", len(resp.RelatedDocuments))
+		}
+	})
+}
+
+func TestOpenAIClient_ErrorMapping(t *testing.T) {
+	ctx := context.Background()
+	req := &SummaryRequest{DocumentTitle: "Doc", DocumentContent: "Content"}
+
+	t.Run("429 maps to ErrRateLimited", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.fail(http.StatusTooManyRequests, `{"error":{"message":"Rate limit reached","type":"requests"}}`)
+
+		_, err := client.GenerateSummary(ctx, req)
+		if !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Expected ErrRateLimited, got %v", err)
+		}
+	})
+
+	t.Run("429 carries the Retry-After hint", func(t *testing.T) {
+		provider := &fakeProvider{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "20")
+			provider.fail(http.StatusTooManyRequests, `{"error":{"message":"Rate limit reached"}}`)
+			provider.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+		client := NewOpenAIClient(server.URL, "test-key", "test-model")
+
+		_, err := client.GenerateSummary(ctx, req)
+		if !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("Expected ErrRateLimited, got %v", err)
+		}
+		if pause, ok := ratelimit.RetryAfter(err); !ok || pause != 20*time.Second {
+			t.Errorf("Expected a 20s Retry-After hint, got %s (%v)", pause, ok)
+		}
+	})
+
+	t.Run("context length maps to ErrTokenLimitExceeded", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.fail(http.StatusBadRequest, `{"error":{"message":"This model's maximum context length is 8192 tokens","code":"context_length_exceeded"}}`)
+
+		_, err := client.GenerateSummary(ctx, req)
+		if !errors.Is(err, ErrTokenLimitExceeded) {
+			t.Errorf("Expected ErrTokenLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("truncated completion maps to ErrTokenLimitExceeded", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.reply(`{"summary":"cut`)
+		provider.mu.Lock()
+		provider.finishReason = "length"
+		provider.mu.Unlock()
+
+		_, err := client.GenerateSummary(ctx, req)
+		if !errors.Is(err, ErrTokenLimitExceeded) {
+			t.Errorf("Expected ErrTokenLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("slow provider maps to ErrTimeout", func(t *testing.T) {
+		client, provider := setupClient(t, WithTimeout(20*time.Millisecond))
+		provider.reply(`{"summary":"late"}`)
+		provider.mu.Lock()
+		provider.delay = time.Second
+		provider.mu.Unlock()
+
+		_, err := client.GenerateSummary(ctx, req)
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("Expected ErrTimeout, got %v", err)
+		}
+	})
+
+	t.Run("caller cancellation is not a timeout", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.reply(`{"summary":"late"}`)
+		provider.mu.Lock()
+		provider.delay = time.Second
+		provider.mu.Unlock()
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+
+		_, err := client.GenerateSummary(cancelCtx, req)
+		if !errors.Is(err, context.Canceled) || errors.Is(err, ErrTimeout) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("5xx maps to ErrServerError", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.fail(http.StatusInternalServerError, "upstream exploded")
+
+		_, err := client.GenerateSummary(ctx, req)
+		if !errors.Is(err, ErrServerError) || !strings.Contains(err.Error(), "HTTP 500") {
+			t.Errorf("Expected ErrServerError with HTTP 500, got %v", err)
+		}
+	})
+
+	t.Run("connection failure maps to ErrUnreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+		client := NewOpenAIClient(server.URL, "test-key", "test-model")
+
+		_, err := client.GenerateSummary(ctx, req)
+		if !errors.Is(err, ErrUnreachable) || errors.Is(err, ErrTimeout) {
+			t.Errorf("Expected ErrUnreachable, got %v", err)
+		}
+	})
+
+	t.Run("other statuses are plain errors", func(t *testing.T) {
+		client, provider := setupClient(t)
+		provider.fail(http.StatusForbidden, "model access denied")
+
+		_, err := client.GenerateSummary(ctx, req)
+		if err == nil || errors.Is(err, ErrServerError) || !strings.Contains(err.Error(), "HTTP 403") {
+			t.Errorf("Expected HTTP 403 error, got %v", err)
+		}
+	})
+}
+
+func TestOpenAIClient_Ping(t *testing.T) {
+	ctx := context.Background()
+
+	client, _ := setupClient(t)
+	if err := client.Ping(ctx); err != nil {
+		t.Errorf("Expected successful ping, got %v", err)
+	}
+
+	provider := &fakeProvider{}
+	server := httptest.NewServer(provider)
+	defer server.Close()
+
+	unauthorized := NewOpenAIClient(server.URL, "wrong-key", "test-model")
+	if err := unauthorized.Ping(ctx); err == nil {
+		t.Error("Expected ping with wrong key to fail")
+	}
+}
@@ -0,0 +1,223 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Content budgets in characters (roughly four characters per token)
+const (
+	classifyContentLimit    = 8000
+	excerptContentLimit     = 4000
+	summaryContentLimit     = 6000
+	titleContentLimit       = 4000
+	searchTermsContentLimit = 5000
+	relatedContentLimit     = 4000
+)
+
+const classifySystemPrompt = `You are a document classification assistant for a knowledge management system. Your task is to analyze documents and determine which collection they belong to based on their content, title, and optional user guidance.
+
+RESPONSE FORMAT:
+You MUST respond with a valid JSON object matching this exact structure:
+{
+  "collection_id": "the ID of the best matching collection",
+  "confidence": 0.85,
+  "reasoning": "brief explanation of why this collection fits (1-2 sentences)",
+  "alternatives": [
+    {
+      "collection_id": "alternative_collection_id",
+      "confidence": 0.65,
+      "reasoning": "why this is also potentially relevant"
+    }
+  ],
+  "search_terms": ["term1", "term2", "term3", "term4", "term5"]
+}
+
+CLASSIFICATION GUIDELINES:
+1. Analyze the document title, content, and collection descriptions
+2. If user guidance is provided, it should HEAVILY influence your decision
+3. Return confidence between 0.0 (completely uncertain) and 1.0 (completely certain)
+4. Include up to 3 alternatives ONLY if your primary confidence is below 0.9
+5. Generate 5-10 relevant search terms that capture key topics and concepts
+6. Be concise but clear in your reasoning
+
+IMPORTANT:
+- You must choose from the provided collection IDs only
+- Do not make up collection IDs
+- Do not include explanations outside the JSON structure`
+
+const answerQuestionSystemPrompt = `You are a helpful knowledge base assistant that answers questions based on provided context documents. Your goal is to provide accurate, well-sourced answers using ONLY the information available in the context.
+
+RESPONSE FORMAT:
+You MUST respond with a valid JSON object matching this exact structure:
+{
+  "answer": "Your detailed answer here, formatted in markdown",
+  "citations": [
+    {
+      "document_title": "Title of source document",
+      "document_url": "URL from the context"
+    }
+  ],
+  "confidence": 0.9
+}
+
+ANSWERING GUIDELINES:
+1. Base your answer ONLY on the provided context documents
+2. Cite all sources by including them in the citations array, using the exact title and URL from the context
+3. Format your answer in markdown
+4. If the context doesn't contain enough information, say so explicitly and set confidence low
+5. Do not fabricate information or cite documents that weren't used`
+
+const summarySystemPrompt = `You are a document summarization assistant. Your task is to create concise, informative summaries that help users quickly understand document content.
+
+RESPONSE FORMAT:
+You MUST respond with a valid JSON object:
+{
+  "summary": "Your 2-3 sentence summary here."
+}
+
+SUMMARIZATION GUIDELINES:
+1. Create a 2-3 sentence summary (50-100 words typical)
+2. Capture the main topic, key points, and purpose of the document
+3. Write in clear, professional language
+4. Do not use phrases like "This document discusses..."; get straight to the content`
+
+const enhanceTitleSystemPrompt = `You are a title enhancement assistant. Your task is to improve vague or generic document titles to make them more descriptive, specific, and discoverable.
+
+RESPONSE FORMAT:
+You MUST respond with a valid JSON object:
+{
+  "suggested_title": "Your improved title here",
+  "confidence": 0.85
+}
+
+TITLE ENHANCEMENT GUIDELINES:
+1. Make titles specific and descriptive, concise (50-80 characters ideal, max 100)
+2. Use title case and avoid generic words like "Document", "Notes", "Untitled"
+3. If the current title is already good, return it unchanged with high confidence
+4. Confidence is how sure you are that the suggested title is better than, or as good as, the current one`
+
+const searchTermsSystemPrompt = `You are a search term extraction assistant. Your task is to identify 5-10 relevant keywords and phrases that will help users find this document.
+
+RESPONSE FORMAT:
+You MUST respond with a valid JSON object:
+{
+  "search_terms": ["term1", "term2", "term3", "term4", "term5"]
+}
+
+EXTRACTION GUIDELINES:
+1. Extract 5-10 search terms, 1-3 words each, in lowercase
+2. Mix specific technical terms, topic areas and acronyms used in the document
+3. Avoid overly generic terms like "document", "information", "content"`
+
+const relatedDocsSystemPrompt = `You are a document recommendation assistant. Your task is to find related documents from a provided list that would be relevant to users reading the current document.
+
+RESPONSE FORMAT:
+You MUST respond with a valid JSON object:
+{
+  "related_documents": [
+    {
+      "title": "Exact title from available documents list",
+      "relevance": 0.85,
+      "reason": "Brief explanation of relevance (1 sentence)"
+    }
+  ]
+}
+
+RECOMMENDATION GUIDELINES:
+1. Identify up to 5 related documents from the provided list, most relevant first
+2. Only include documents with relevance >= 0.5
+3. Use the EXACT title from the available documents list; never make up titles
+4. Return an empty array if no documents are sufficiently related`
+
+func buildClassifyUserPrompt(req *ClassificationRequest) string {
+	var sb strings.Builder
+
+	sb.WriteString("DOCUMENT TO CLASSIFY:\n")
+	fmt.Fprintf(&sb, "Title: %s\n\n", req.DocumentTitle)
+	fmt.Fprintf(&sb, "Content:\n%s\n\n", truncate(req.DocumentContent, classifyContentLimit))
+
+	if req.UserGuidance != "" {
+		sb.WriteString("USER GUIDANCE (IMPORTANT - prioritize this):\n")
+		fmt.Fprintf(&sb, "%s\n\n", req.UserGuidance)
+	}
+
+	sb.WriteString("AVAILABLE COLLECTIONS:\n")
+	if req.Taxonomy != nil {
+		for i, col := range req.Taxonomy.Collections {
+			fmt.Fprintf(&sb, "\n[%d] ID: %s\n", i+1, col.ID)
+			fmt.Fprintf(&sb, "    Name: %s\n", col.Name)
+			fmt.Fprintf(&sb, "    Description: %s\n", col.Description)
+			if len(col.SampleDocuments) > 0 {
+				// Samples may carry an excerpt with commas, so one per line
+				sb.WriteString("    Sample documents:\n")
+				for _, sample := range col.SampleDocuments {
+					fmt.Fprintf(&sb, "      - %s\n", sample)
+				}
+			}
+		}
+	}
+
+	sb.WriteString("\nProvide your classification in JSON format as specified.")
+	return sb.String()
+}
+
+func buildAnswerQuestionUserPrompt(req *QuestionRequest) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "QUESTION: %s\n\n", req.Question)
+	sb.WriteString("CONTEXT DOCUMENTS:\n\n")
+	for i, doc := range req.ContextDocs {
+		fmt.Fprintf(&sb, "--- Document %d ---\n", i+1)
+		fmt.Fprintf(&sb, "Title: %s\n", doc.Title)
+		fmt.Fprintf(&sb, "URL: %s\n\n", doc.URL)
+		fmt.Fprintf(&sb, "%s\n\n", truncate(doc.Excerpt, excerptContentLimit))
+	}
+
+	sb.WriteString("Answer the question in JSON format as specified.")
+	return sb.String()
+}
+
+func buildSummaryUserPrompt(req *SummaryRequest) string {
+	return fmt.Sprintf("Document Title: %s\n\nDocument Content:\n%s\n\nProvide a 2-3 sentence summary in JSON format.",
+		req.DocumentTitle, truncate(req.DocumentContent, summaryContentLimit))
+}
+
+func buildEnhanceTitleUserPrompt(req *TitleRequest) string {
+	return fmt.Sprintf("Current Title: %s\n\nDocument Content (excerpt):\n%s\n\nProvide an improved title in JSON format.",
+		req.CurrentTitle, truncate(req.DocumentContent, titleContentLimit))
+}
+
+func buildSearchTermsUserPrompt(req *SearchTermsRequest) string {
+	return fmt.Sprintf("Document Title: %s\n\nDocument Content:\n%s\n\nExtract 5-10 search terms in JSON format.",
+		req.DocumentTitle, truncate(req.DocumentContent, searchTermsContentLimit))
+}
+
+func buildRelatedDocsUserPrompt(req *RelatedDocsRequest) string {
+	var sb strings.Builder
+
+	sb.WriteString("CURRENT DOCUMENT:\n")
+	fmt.Fprintf(&sb, "Title: %s\n\n", req.DocumentTitle)
+	fmt.Fprintf(&sb, "Content:\n%s\n\n", truncate(req.DocumentContent, relatedContentLimit))
+
+	sb.WriteString("AVAILABLE DOCUMENTS:\n")
+	for i, title := range req.AvailableDocs {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, title)
+	}
+
+	sb.WriteString("\nFind up to 5 related documents and provide response in JSON format.")
+	return sb.String()
+}
+
+// truncate cuts content to at most limit bytes on a rune boundary
+func truncate(content string, limit int) string {
+	if len(content) <= limit {
+		return content
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	return content[:cut] + "\n\n... (content truncated) ..."
+}
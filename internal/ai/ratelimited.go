@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"context"
+
+	"github.com/yourusername/outline-ai/internal/ratelimit"
+)
+
+var _ Client = (*RateLimitedClient)(nil)
+
+// RateLimitedClient decorates a Client with a token bucket. A Retry-After
+// hint on a rate-limited response pauses the bucket for every caller.
+type RateLimitedClient struct {
+	next    Client
+	limiter *ratelimit.Limiter
+}
+
+// NewRateLimitedClient wraps next with limiter
+func NewRateLimitedClient(next Client, limiter *ratelimit.Limiter) *RateLimitedClient {
+	return &RateLimitedClient{next: next, limiter: limiter}
+}
+
+func throttle[T any](ctx context.Context, c *RateLimitedClient, fn func() (T, error)) (T, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	resp, err := fn()
+	if pause, ok := ratelimit.RetryAfter(err); ok {
+		c.limiter.PauseFor(pause)
+	}
+	return resp, err
+}
+
+// Interface Implementation
+
+// ClassifyDocument waits for a token before forwarding
+func (c *RateLimitedClient) ClassifyDocument(ctx context.Context, req *ClassificationRequest) (*ClassificationResponse, error) {
+	return throttle(ctx, c, func() (*ClassificationResponse, error) {
+		return c.next.ClassifyDocument(ctx, req)
+	})
+}
+
+// AnswerQuestion waits for a token before forwarding
+func (c *RateLimitedClient) AnswerQuestion(ctx context.Context, req *QuestionRequest) (*QuestionResponse, error) {
+	return throttle(ctx, c, func() (*QuestionResponse, error) {
+		return c.next.AnswerQuestion(ctx, req)
+	})
+}
+
+// GenerateSummary waits for a token before forwarding
+func (c *RateLimitedClient) GenerateSummary(ctx context.Context, req *SummaryRequest) (*SummaryResponse, error) {
+	return throttle(ctx, c, func() (*SummaryResponse, error) {
+		return c.next.GenerateSummary(ctx, req)
+	})
+}
+
+// EnhanceTitle waits for a token before forwarding
+func (c *RateLimitedClient) EnhanceTitle(ctx context.Context, req *TitleRequest) (*TitleResponse, error) {
+	return throttle(ctx, c, func() (*TitleResponse, error) {
+		return c.next.EnhanceTitle(ctx, req)
+	})
+}
+
+// GenerateSearchTerms waits for a token before forwarding
+func (c *RateLimitedClient) GenerateSearchTerms(ctx context.Context, req *SearchTermsRequest) (*SearchTermsResponse, error) {
+	return throttle(ctx, c, func() (*SearchTermsResponse, error) {
+		return c.next.GenerateSearchTerms(ctx, req)
+	})
+}
+
+// FindRelatedDocuments waits for a token before forwarding
+func (c *RateLimitedClient) FindRelatedDocuments(ctx context.Context, req *RelatedDocsRequest) (*RelatedDocsResponse, error) {
+	return throttle(ctx, c, func() (*RelatedDocsResponse, error) {
+		return c.next.FindRelatedDocuments(ctx, req)
+	})
+}
+
+// Ping bypasses the bucket so health checks never queue behind real work
+func (c *RateLimitedClient) Ping(ctx context.Context) error {
+	return c.next.Ping(ctx)
+}
@@ -0,0 +1,68 @@
+package ai_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/ratelimit"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+func TestRateLimitedClient_Throttles(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewAIMock()
+	client := ai.NewRateLimitedClient(mock, ratelimit.NewLimiter(60, 2))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GenerateSummary(ctx, summaryReq); err != nil {
+			t.Fatalf("Call %d: unexpected error %v", i+1, err)
+		}
+	}
+
+	shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GenerateSummary(shortCtx, summaryReq); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected exhausted bucket to hit the deadline, got %v", err)
+	}
+	if got := mock.GetCallCount("GenerateSummary"); got != 2 {
+		t.Errorf("Expected 2 calls to reach the provider, got %d", got)
+	}
+}
+
+func TestRateLimitedClient_HonorsRetryAfter(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewAIMock()
+	limiter := ratelimit.NewLimiter(6000, 10)
+	client := ai.NewRateLimitedClient(mock, limiter)
+
+	mock.SetMethodError("GenerateSummary", &ratelimit.RetryAfterError{Err: ai.ErrRateLimited, After: 80 * time.Millisecond})
+	if _, err := client.GenerateSummary(ctx, summaryReq); !errors.Is(err, ai.ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+	mock.SetMethodError("GenerateSummary", nil)
+
+	start := time.Now()
+	if _, err := client.GenerateSummary(ctx, summaryReq); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("Expected next call to wait out the Retry-After pause, returned after %s", elapsed)
+	}
+}
+
+func TestRateLimitedClient_PlainRateLimitDoesNotPause(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewAIMock()
+	limiter := ratelimit.NewLimiter(60, 5)
+	client := ai.NewRateLimitedClient(mock, limiter)
+
+	mock.SetRateLimited(true)
+	client.GenerateSummary(ctx, summaryReq)
+
+	if !limiter.PausedUntil().IsZero() {
+		t.Error("Expected no pause without a Retry-After hint")
+	}
+}
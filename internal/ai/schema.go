@@ -0,0 +1,248 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSON schemas sent as the structured-output response_format for each method
+var (
+	classificationSchema = json.RawMessage(`{
+  "type": "object",
+  "required": ["collection_id", "confidence", "reasoning", "search_terms"],
+  "properties": {
+    "collection_id": {"type": "string"},
+    "confidence": {"type": "number", "minimum": 0, "maximum": 1},
+    "reasoning": {"type": "string"},
+    "alternatives": {
+      "type": "array",
+      "maxItems": 3,
+      "items": {
+        "type": "object",
+        "required": ["collection_id", "confidence", "reasoning"],
+        "properties": {
+          "collection_id": {"type": "string"},
+          "confidence": {"type": "number", "minimum": 0, "maximum": 1},
+          "reasoning": {"type": "string"}
+        }
+      }
+    },
+    "search_terms": {"type": "array", "items": {"type": "string"}, "maxItems": 10}
+  }
+}`)
+
+	questionSchema = json.RawMessage(`{
+  "type": "object",
+  "required": ["answer", "confidence"],
+  "properties": {
+    "answer": {"type": "string"},
+    "citations": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["document_title", "document_url"],
+        "properties": {
+          "document_title": {"type": "string"},
+          "document_url": {"type": "string"}
+        }
+      }
+    },
+    "confidence": {"type": "number", "minimum": 0, "maximum": 1}
+  }
+}`)
+
+	summarySchema = json.RawMessage(`{
+  "type": "object",
+  "required": ["summary"],
+  "properties": {
+    "summary": {"type": "string"}
+  }
+}`)
+
+	titleSchema = json.RawMessage(`{
+  "type": "object",
+  "required": ["suggested_title", "confidence"],
+  "properties": {
+    "suggested_title": {"type": "string"},
+    "confidence": {"type": "number", "minimum": 0, "maximum": 1}
+  }
+}`)
+
+	searchTermsSchema = json.RawMessage(`{
+  "type": "object",
+  "required": ["search_terms"],
+  "properties": {
+    "search_terms": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 10}
+  }
+}`)
+
+	relatedDocsSchema = json.RawMessage(`{
+  "type": "object",
+  "required": ["related_documents"],
+  "properties": {
+    "related_documents": {
+      "type": "array",
+      "maxItems": 5,
+      "items": {
+        "type": "object",
+        "required": ["title", "relevance", "reason"],
+        "properties": {
+          "title": {"type": "string"},
+          "relevance": {"type": "number", "minimum": 0, "maximum": 1},
+          "reason": {"type": "string"}
+        }
+      }
+    }
+  }
+}`)
+)
+
+// decodeStrict parses model output into out. It tolerates a surrounding
+// markdown code fence, but requires a single JSON object with no fields out
+// does not declare, carrying every key in required with a non-null value of
+// the right type.
+func decodeStrict(content string, out any, required ...string) error {
+	body := []byte(stripCodeFence(content))
+
+	var fields map[string]json.RawMessage
+	if err := decodeOne(body, &fields, false); err != nil {
+		return fmt.Errorf("%w: not a JSON object: %v", ErrInvalidResponse, err)
+	}
+	for _, key := range required {
+		raw, ok := fields[key]
+		if !ok || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			return fmt.Errorf("%w: missing required field %q", ErrInvalidResponse, key)
+		}
+	}
+
+	if err := decodeOne(body, out, true); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	return nil
+}
+
+// decodeOne decodes the single JSON value in body, rejecting anything after
+// it and, when strict, any field out does not declare
+func decodeOne(body []byte, out any, strict bool) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(out); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON object")
+	}
+	return nil
+}
+
+func stripCodeFence(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if !strings.HasPrefix(trimmed, "```") {
+		return trimmed
+	}
+	trimmed = strings.TrimPrefix(trimmed, "```")
+	if newline := strings.IndexByte(trimmed, '\n'); newline >= 0 {
+		trimmed = trimmed[newline+1:]
+	}
+	trimmed = strings.TrimSuffix(strings.TrimSpace(trimmed), "```")
+	return strings.TrimSpace(trimmed)
+}
+
+func invalidf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidResponse, fmt.Sprintf(format, args...))
+}
+
+func validConfidence(v float64) bool {
+	return v >= 0 && v <= 1
+}
+
+func validateClassificationResponse(resp *ClassificationResponse, taxonomy *TaxonomyContext) error {
+	if strings.TrimSpace(resp.CollectionID) == "" {
+		return invalidf("collection_id is empty")
+	}
+	if !validConfidence(resp.Confidence) {
+		return invalidf("confidence %v outside 0..1", resp.Confidence)
+	}
+
+	known := make(map[string]bool)
+	if taxonomy != nil {
+		for _, col := range taxonomy.Collections {
+			known[col.ID] = true
+		}
+	}
+	if len(known) > 0 && !known[resp.CollectionID] {
+		return invalidf("unknown collection_id %q", resp.CollectionID)
+	}
+
+	for _, alt := range resp.Alternatives {
+		if !validConfidence(alt.Confidence) {
+			return invalidf("alternative %q confidence %v outside 0..1", alt.CollectionID, alt.Confidence)
+		}
+		if len(known) > 0 && !known[alt.CollectionID] {
+			return invalidf("unknown alternative collection_id %q", alt.CollectionID)
+		}
+	}
+	return nil
+}
+
+func validateQuestionResponse(resp *QuestionResponse) error {
+	if strings.TrimSpace(resp.Answer) == "" {
+		return invalidf("answer is empty")
+	}
+	if !validConfidence(resp.Confidence) {
+		return invalidf("confidence %v outside 0..1", resp.Confidence)
+	}
+	for _, citation := range resp.Citations {
+		if strings.TrimSpace(citation.DocumentTitle) == "" {
+			return invalidf("citation without document_title")
+		}
+	}
+	return nil
+}
+
+func validateSummaryResponse(resp *SummaryResponse) error {
+	if strings.TrimSpace(resp.Summary) == "" {
+		return invalidf("summary is empty")
+	}
+	return nil
+}
+
+func validateTitleResponse(resp *TitleResponse) error {
+	if strings.TrimSpace(resp.SuggestedTitle) == "" {
+		return invalidf("suggested_title is empty")
+	}
+	if !validConfidence(resp.Confidence) {
+		return invalidf("confidence %v outside 0..1", resp.Confidence)
+	}
+	return nil
+}
+
+func validateSearchTermsResponse(resp *SearchTermsResponse) error {
+	if len(resp.SearchTerms) == 0 {
+		return invalidf("search_terms is empty")
+	}
+	for _, term := range resp.SearchTerms {
+		if strings.TrimSpace(term) == "" {
+			return invalidf("search_terms contains an empty term")
+		}
+	}
+	return nil
+}
+
+func validateRelatedDocsResponse(resp *RelatedDocsResponse) error {
+	for _, doc := range resp.RelatedDocuments {
+		if strings.TrimSpace(doc.Title) == "" {
+			return invalidf("related document without title")
+		}
+		if !validConfidence(doc.Relevance) {
+			return invalidf("related document %q relevance %v outside 0..1", doc.Title, doc.Relevance)
+		}
+	}
+	return nil
+}
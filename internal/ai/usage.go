@@ -0,0 +1,59 @@
+package ai
+
+import (
+	"context"
+	"sync"
+)
+
+// Usage counts the tokens billed for one or more calls and what they cost
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	// CostUSD is priced with WithPricing, or zero without prices
+	CostUSD float64
+}
+
+// TotalTokens returns prompt and completion tokens together
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// UsageRecorder adds up the usage of every call made with a context it
+// was attached to by WithUsageRecorder. It is safe for concurrent use.
+type UsageRecorder struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+// Add adds u to the total
+func (r *UsageRecorder) Add(u Usage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usage.PromptTokens += u.PromptTokens
+	r.usage.CompletionTokens += u.CompletionTokens
+	r.usage.CostUSD += u.CostUSD
+}
+
+// Usage returns the total so far
+func (r *UsageRecorder) Usage() Usage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.usage
+}
+
+type usageKey struct{}
+
+// WithUsageRecorder returns a context under which AI calls add their usage
+// to r
+func WithUsageRecorder(ctx context.Context, r *UsageRecorder) context.Context {
+	return context.WithValue(ctx, usageKey{}, r)
+}
+
+// RecordUsage adds u to the recorder attached to ctx, if there is one.
+// Clients call it for every response the provider bills, including ones
+// that are then rejected, such as truncated completions.
+func RecordUsage(ctx context.Context, u Usage) {
+	if r, ok := ctx.Value(usageKey{}).(*UsageRecorder); ok {
+		r.Add(u)
+	}
+}
@@ -0,0 +1,223 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/persistence"
+)
+
+var _ outline.Client = (*Client)(nil)
+
+// Recorder stores audit entries. persistence.Storage satisfies it.
+type Recorder interface {
+	RecordAudit(ctx context.Context, entry *persistence.AuditEntry) error
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithSlog sets where entries that could not be stored are reported
+func WithSlog(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.slog = logger
+	}
+}
+
+// Client records every successful UpdateDocument, MoveDocument and
+// CreateComment made through it; other calls pass straight through.
+// Changes made under a Scope are attributed to its command. The text
+// before a change comes from the Scope when the command has seen the
+// document, and is fetched otherwise.
+//
+// Recording happens after the change, which Outline has already applied,
+// so a failure to store the entry is logged rather than returned.
+type Client struct {
+	next     outline.Client
+	recorder Recorder
+	slog     *slog.Logger
+}
+
+// NewClient wraps next to record its changes in recorder
+func NewClient(next outline.Client, recorder Recorder, opts ...Option) *Client {
+	c := &Client{
+		next:     next,
+		recorder: recorder,
+		slog:     slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// before returns the document as it is before a change, or false when it
+// cannot be fetched
+func (c *Client) before(ctx context.Context, id string) (outline.Document, bool) {
+	if s := scopeFrom(ctx); s != nil {
+		if doc, ok := s.document(id); ok {
+			return doc, true
+		}
+	}
+	doc, err := c.next.GetDocument(ctx, id)
+	if err != nil {
+		c.slog.Warn("audit: failed to fetch document before change", "document_id", id, "error", err)
+		return outline.Document{ID: id}, false
+	}
+	return *doc, true
+}
+
+// record stores entry with the scope's attribution
+func (c *Client) record(ctx context.Context, entry *persistence.AuditEntry) {
+	if s := scopeFrom(ctx); s != nil {
+		entry.CommandType = s.Command
+		entry.CorrelationID = s.CorrelationID
+		entry.Reasoning = s.reasons()
+	}
+	if err := c.recorder.RecordAudit(context.WithoutCancel(ctx), entry); err != nil {
+		c.slog.Error("audit: failed to record change",
+			"document_id", entry.DocumentID,
+			"action", entry.Action,
+			"error", err)
+	}
+}
+
+// remember updates the scope's copy of doc
+func remember(ctx context.Context, doc outline.Document) {
+	if s := scopeFrom(ctx); s != nil {
+		s.remember(&doc)
+	}
+}
+
+// textHash hashes the text of a document, or returns "" when it is unknown
+func textHash(doc outline.Document, known bool) string {
+	if !known {
+		return ""
+	}
+	return hash(doc.Text)
+}
+
+// Interface Implementation
+
+// UpdateDocument records the title and text changes
+func (c *Client) UpdateDocument(ctx context.Context, id string, req *outline.UpdateDocumentRequest) (*outline.Document, error) {
+	before, known := c.before(ctx, id)
+	updated, err := c.next.UpdateDocument(ctx, id, req)
+	if err != nil {
+		return updated, err
+	}
+
+	// Outline returns the document as saved, which is what changed; the
+	// request is only a guess, as an empty title and nil text are left
+	// unchanged
+	after := before
+	if updated != nil {
+		after.Title, after.Text = updated.Title, updated.Text
+	} else {
+		if req.Title != "" {
+			after.Title = req.Title
+		}
+		if req.Text != nil {
+			after.Text = *req.Text
+		}
+	}
+	remember(ctx, after)
+
+	var diff string
+	if after.Title != before.Title {
+		diff = changeLine("title", before.Title, after.Title)
+	}
+	diff += lineDiff(before.Text, after.Text)
+	c.record(ctx, &persistence.AuditEntry{
+		DocumentID: id,
+		Action:     persistence.AuditActionUpdate,
+		BeforeHash: textHash(before, known),
+		AfterHash:  hash(after.Text),
+		Diff:       diff,
+	})
+	return updated, nil
+}
+
+// MoveDocument records the old and new collection
+func (c *Client) MoveDocument(ctx context.Context, id string, collectionID string) error {
+	before, known := c.before(ctx, id)
+	if err := c.next.MoveDocument(ctx, id, collectionID); err != nil {
+		return err
+	}
+
+	after := before
+	after.CollectionID = collectionID
+	remember(ctx, after)
+
+	c.record(ctx, &persistence.AuditEntry{
+		DocumentID: id,
+		Action:     persistence.AuditActionMove,
+		BeforeHash: textHash(before, known),
+		AfterHash:  textHash(after, known),
+		Diff:       changeLine("collection", before.CollectionID, collectionID),
+	})
+	return nil
+}
+
+// CreateComment records the comment's text
+func (c *Client) CreateComment(ctx context.Context, req *outline.CreateCommentRequest) (*outline.Comment, error) {
+	doc, known := c.before(ctx, req.DocumentID)
+	created, err := c.next.CreateComment(ctx, req)
+	if err != nil {
+		return created, err
+	}
+
+	c.record(ctx, &persistence.AuditEntry{
+		DocumentID: req.DocumentID,
+		Action:     persistence.AuditActionComment,
+		BeforeHash: textHash(doc, known),
+		AfterHash:  textHash(doc, known),
+		Diff:       addedLines(req.Data.PlainText()),
+	})
+	return created, nil
+}
+
+// GetDocument passes the call through, noting the document in the scope
+func (c *Client) GetDocument(ctx context.Context, id string) (*outline.Document, error) {
+	doc, err := c.next.GetDocument(ctx, id)
+	if err == nil {
+		remember(ctx, *doc)
+	}
+	return doc, err
+}
+
+// ListCollections passes the call through
+func (c *Client) ListCollections(ctx context.Context) ([]*outline.Collection, error) {
+	return c.next.ListCollections(ctx)
+}
+
+// GetCollection passes the call through
+func (c *Client) GetCollection(ctx context.Context, id string) (*outline.Collection, error) {
+	return c.next.GetCollection(ctx, id)
+}
+
+// ListDocuments passes the call through
+func (c *Client) ListDocuments(ctx context.Context, collectionID string) ([]*outline.Document, error) {
+	return c.next.ListDocuments(ctx, collectionID)
+}
+
+// CreateDocument passes the call through
+func (c *Client) CreateDocument(ctx context.Context, req *outline.CreateDocumentRequest) (*outline.Document, error) {
+	return c.next.CreateDocument(ctx, req)
+}
+
+// SearchDocuments passes the call through
+func (c *Client) SearchDocuments(ctx context.Context, query string, opts *outline.SearchOptions) (*outline.SearchResult, error) {
+	return c.next.SearchDocuments(ctx, query, opts)
+}
+
+// ListComments passes the call through
+func (c *Client) ListComments(ctx context.Context, documentID string) ([]*outline.Comment, error) {
+	return c.next.ListComments(ctx, documentID)
+}
+
+// Ping passes the call through
+func (c *Client) Ping(ctx context.Context) error {
+	return c.next.Ping(ctx)
+}
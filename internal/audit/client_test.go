@@ -0,0 +1,223 @@
+package audit_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yourusername/outline-ai/internal/audit"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/pkg/commands"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+func sum(text string) string {
+	h := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(h[:])
+}
+
+func trail(t *testing.T, storage *mocks.StorageMock, documentID string) []*persistence.AuditEntry {
+	t.Helper()
+	entries, err := storage.GetAuditTrail(context.Background(), documentID, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit trail: %v", err)
+	}
+	return entries
+}
+
+func TestClient_RecordsChangesUnderScope(t *testing.T) {
+	outlineMock := mocks.NewOutlineMock()
+	storage := mocks.NewStorageMock()
+	outlineMock.AddCollection("col-sales", "Sales", "Deals and customers")
+	doc := outlineMock.AddDocument("doc-1", "col-engineering", "Deal notes", "# Deal notes\n\nACME renewal\n/ai-file")
+	client := audit.NewClient(outlineMock, storage)
+
+	scope := audit.NewScope("/ai-file", doc)
+	ctx := audit.WithScope(context.Background(), scope)
+	audit.AddReasoning(ctx, "Customer renewal terms belong in Sales")
+
+	if err := client.MoveDocument(ctx, "doc-1", "col-sales"); err != nil {
+		t.Fatalf("Failed to move: %v", err)
+	}
+	if _, err := client.UpdateDocument(ctx, "doc-1", &outline.UpdateDocumentRequest{Text: outline.String("# Deal notes\n\nACME renewal")}); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if _, err := client.CreateComment(ctx, &outline.CreateCommentRequest{DocumentID: "doc-1", Data: outline.NewCommentParagraphs("Filed to Sales", "Reasoning: renewal")}); err != nil {
+		t.Fatalf("Failed to comment: %v", err)
+	}
+
+	if outlineMock.GetCallCount("GetDocument") != 0 {
+		t.Errorf("Expected the scope to provide the text, got %d fetches", outlineMock.GetCallCount("GetDocument"))
+	}
+	entries := trail(t, storage, "doc-1")
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.CommandType != "/ai-file" || entry.CorrelationID != scope.CorrelationID || entry.Reasoning != "Customer renewal terms belong in Sales" {
+			t.Errorf("Expected the scope's attribution, got %+v", entry)
+		}
+	}
+
+	comment, update, move := entries[0], entries[1], entries[2]
+	original, edited := sum("# Deal notes\n\nACME renewal\n/ai-file"), sum("# Deal notes\n\nACME renewal")
+	if move.Action != persistence.AuditActionMove || move.Diff != "-collection: col-engineering\n+collection: col-sales\n" || move.BeforeHash != original || move.AfterHash != original {
+		t.Errorf("Expected the move with unchanged hashes, got %+v", move)
+	}
+	if update.Action != persistence.AuditActionUpdate || update.BeforeHash != original || update.AfterHash != edited || update.Diff != "@@ -4,1 +4,0 @@\n-/ai-file\n" {
+		t.Errorf("Expected the removed marker, got %+v", update)
+	}
+	if comment.Action != persistence.AuditActionComment || comment.Diff != "+Filed to Sales\n+Reasoning: renewal\n" || comment.BeforeHash != edited {
+		t.Errorf("Expected the comment against the edited text, got %+v", comment)
+	}
+}
+
+func TestClient_FetchesOutsideScope(t *testing.T) {
+	outlineMock := mocks.NewOutlineMock()
+	storage := mocks.NewStorageMock()
+	outlineMock.AddDocument("doc-1", "col-1", "Old title", "Body")
+	client := audit.NewClient(outlineMock, storage)
+
+	if _, err := client.UpdateDocument(context.Background(), "doc-1", &outline.UpdateDocumentRequest{Title: "New title"}); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+
+	entries := trail(t, storage, "doc-1")
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.CommandType != "" || entry.CorrelationID != "" {
+		t.Errorf("Expected no attribution outside a scope, got %+v", entry)
+	}
+	if entry.Diff != "-title: Old title\n+title: New title\n" || entry.BeforeHash != sum("Body") || entry.AfterHash != sum("Body") {
+		t.Errorf("Expected only the title changed, got %+v", entry)
+	}
+}
+
+// normalizingOutline saves text with a trailing newline, the way Outline
+// normalizes markdown
+type normalizingOutline struct {
+	*mocks.OutlineMock
+}
+
+func (o normalizingOutline) UpdateDocument(ctx context.Context, id string, req *outline.UpdateDocumentRequest) (*outline.Document, error) {
+	if req.Text != nil {
+		req = &outline.UpdateDocumentRequest{Title: req.Title, Text: outline.String(*req.Text + "\n"), Done: req.Done}
+	}
+	return o.OutlineMock.UpdateDocument(ctx, id, req)
+}
+
+func TestClient_RecordsTheSavedDocument(t *testing.T) {
+	outlineMock := mocks.NewOutlineMock()
+	storage := mocks.NewStorageMock()
+	doc := outlineMock.AddDocument("doc-1", "col-1", "Notes", "Body\n")
+	client := audit.NewClient(normalizingOutline{outlineMock}, storage)
+	ctx := audit.WithScope(context.Background(), audit.NewScope("/summarize", doc))
+
+	if _, err := client.UpdateDocument(ctx, "doc-1", &outline.UpdateDocumentRequest{Text: outline.String("Body\nSummary")}); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if _, err := client.UpdateDocument(ctx, "doc-1", &outline.UpdateDocumentRequest{Text: outline.String("Body\nSummary")}); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+
+	// Newest first
+	entries := trail(t, storage, "doc-1")
+	if len(entries) != 2 || entries[1].AfterHash != sum("Body\nSummary\n") {
+		t.Fatalf("Expected the saved text hashed, got %+v", entries)
+	}
+	if second := entries[0]; second.BeforeHash != second.AfterHash || second.Diff != "" {
+		t.Errorf("Expected repeating the update to change nothing, got %+v", second)
+	}
+}
+
+func TestClient_FailuresAreNotRecordedOrReturned(t *testing.T) {
+	outlineMock := mocks.NewOutlineMock()
+	storage := mocks.NewStorageMock()
+	doc := outlineMock.AddDocument("doc-1", "col-1", "Notes", "Body")
+	client := audit.NewClient(outlineMock, storage, audit.WithSlog(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	ctx := audit.WithScope(context.Background(), audit.NewScope("/summarize", doc))
+
+	outlineMock.SetRateLimited(true)
+	if _, err := client.UpdateDocument(ctx, "doc-1", &outline.UpdateDocumentRequest{Text: outline.String("Changed")}); err == nil {
+		t.Fatal("Expected the update to fail")
+	}
+	if storage.GetCallCount("RecordAudit") != 0 {
+		t.Error("Expected a failed change not to be recorded")
+	}
+
+	outlineMock.SetRateLimited(false)
+	storage.SetMethodError("RecordAudit", errors.New("disk full"))
+	if _, err := client.UpdateDocument(ctx, "doc-1", &outline.UpdateDocumentRequest{Text: outline.String("Changed")}); err != nil {
+		t.Errorf("Expected a failed recording not to fail the change, got %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	storage := mocks.NewStorageMock()
+	for _, entry := range []*persistence.AuditEntry{
+		{DocumentID: "doc-1", Action: persistence.AuditActionMove, CommandType: "/ai-file", Diff: "-collection: a\n+collection: b\n", Reasoning: "Sales content"},
+		{DocumentID: "doc-1", Action: persistence.AuditActionComment, Diff: "+Filed\n"},
+		{DocumentID: "doc-2", Action: persistence.AuditActionComment, Diff: "+Other\n"},
+	} {
+		storage.RecordAudit(context.Background(), entry)
+	}
+	handler := audit.Handler(storage)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, audit.Path+"?document_id=doc-1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var got audit.Trail
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode trail: %v", err)
+	}
+	if len(got.Entries) != 2 || got.Entries[1].Reasoning != "Sales content" || got.Entries[1].Command != "/ai-file" {
+		t.Errorf("Expected doc-1's entries newest first, got %+v", got.Entries)
+	}
+
+	for _, query := range []string{"", "?document_id=doc-1&limit=-1"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, audit.Path+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %q, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestHook_CorrelatesCommandLog(t *testing.T) {
+	outlineMock := mocks.NewOutlineMock()
+	storage := mocks.NewStorageMock()
+	outlineMock.AddDocument("doc-1", "col-1", "API Design", "Body\n/summarize")
+	client := audit.NewClient(outlineMock, storage)
+
+	router := commands.NewRouter(storage, commands.WithHook(audit.Hook))
+	router.Register(commands.CommandSummarize, commands.HandlerFunc(
+		func(ctx context.Context, d *commands.Document, cmd commands.Command) (commands.Result, error) {
+			_, err := client.UpdateDocument(ctx, d.ID, &outline.UpdateDocumentRequest{Text: outline.String("Body")})
+			return commands.Result{}, err
+		}))
+	router.Route(context.Background(), &commands.Document{ID: "doc-1", Title: "API Design", Text: "Body\n/summarize"}, commands.Command{Type: commands.CommandSummarize})
+
+	history, _ := storage.GetCommandHistory(context.Background(), "doc-1", 1)
+	entries := trail(t, storage, "doc-1")
+	if len(history) != 1 || history[0].CorrelationID == "" || len(entries) != 1 || entries[0].CorrelationID != history[0].CorrelationID {
+		t.Fatalf("Expected the change to share the command's correlation ID, got %+v and %+v", history, entries)
+	}
+	if entries[0].CommandType != string(commands.CommandSummarize) || entries[0].Diff != "@@ -2,1 +2,0 @@\n-/summarize\n" {
+		t.Errorf("Expected the change attributed to /summarize, got %+v", entries[0])
+	}
+	if outlineMock.GetCallCount("GetDocument") != 0 {
+		t.Errorf("Expected the routed document to provide the text before, got %d fetches", outlineMock.GetCallCount("GetDocument"))
+	}
+}
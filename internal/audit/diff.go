@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// maxDiffCells bounds the LCS table. Changes larger than this are shown as
+// the old lines replaced by the new ones.
+const maxDiffCells = 1 << 20
+
+// hash returns the hex SHA-256 of text
+func hash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// lineDiff shows the lines that differ between before and after as
+// unified diff hunks without context lines
+func lineDiff(before, after string) string {
+	a, b := splitLines(before), splitLines(after)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var out strings.Builder
+	hunk := func(aStart int, removed []string, bStart int, added []string) {
+		if len(removed) == 0 && len(added) == 0 {
+			return
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart+1, len(removed), bStart+1, len(added))
+		for _, line := range removed {
+			out.WriteString("-" + line + "\n")
+		}
+		for _, line := range added {
+			out.WriteString("+" + line + "\n")
+		}
+	}
+
+	am, bm := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(am)*len(bm) > maxDiffCells {
+		hunk(prefix, am, prefix, bm)
+		return out.String()
+	}
+
+	// lcs[i][j] is the longest common subsequence of am[i:] and bm[j:]
+	lcs := make([][]int32, len(am)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(bm)+1)
+	}
+	for i := len(am) - 1; i >= 0; i-- {
+		for j := len(bm) - 1; j >= 0; j-- {
+			if am[i] == bm[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(am) || j < len(bm) {
+		if i < len(am) && j < len(bm) && am[i] == bm[j] {
+			i++
+			j++
+			continue
+		}
+		// Collect one run of changes up to the next common line
+		startI, startJ := i, j
+		for i < len(am) || j < len(bm) {
+			if i < len(am) && j < len(bm) && am[i] == bm[j] {
+				break
+			}
+			if j == len(bm) || (i < len(am) && lcs[i+1][j] >= lcs[i][j+1]) {
+				i++
+			} else {
+				j++
+			}
+		}
+		hunk(prefix+startI, am[startI:i], prefix+startJ, bm[startJ:j])
+	}
+	return out.String()
+}
+
+// splitLines splits text into lines without their terminators. Empty text
+// has no lines.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
+
+// changeLine shows a single value that changed, e.g. a title
+func changeLine(name, before, after string) string {
+	return fmt.Sprintf("-%s: %s\n+%s: %s\n", name, before, name, after)
+}
+
+// addedLines shows text that was added as a whole, e.g. a comment
+func addedLines(text string) string {
+	var out strings.Builder
+	for _, line := range splitLines(text) {
+		out.WriteString("+" + line + "\n")
+	}
+	return out.String()
+}
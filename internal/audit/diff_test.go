@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{"unchanged", "a\nb", "a\nb", ""},
+		{"added", "", "a\nb", "@@ -1,0 +1,2 @@\n+a\n+b\n"},
+		{"removed in the middle", "a\nb\nc", "a\nc", "@@ -2,1 +2,0 @@\n-b\n"},
+		{"replaced", "a\nb\nc", "a\nB\nc", "@@ -2,1 +2,1 @@\n-b\n+B\n"},
+		{"two hunks", "a\nb\nc\nd\ne", "A\nb\nc\nd\nE", "@@ -1,1 +1,1 @@\n-a\n+A\n@@ -5,1 +5,1 @@\n-e\n+E\n"},
+		{"moved line", "x\na\nb\nc", "a\nb\nc\nx", "@@ -1,1 +1,0 @@\n-x\n@@ -5,0 +4,1 @@\n+x\n"},
+		{"line endings", "a\r\nb", "a\nb", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lineDiff(tt.before, tt.after); got != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLineDiff_LargeChange(t *testing.T) {
+	before := strings.Repeat("old\n", 2000) + "end"
+	after := strings.Repeat("new\n", 2000) + "end"
+
+	got := lineDiff(before, after)
+	if !strings.HasPrefix(got, "@@ -1,2000 +1,2000 @@\n-old\n") || strings.Count(got, "\n+new") != 2000 {
+		t.Errorf("Expected the old lines replaced wholesale, got %.80s", got)
+	}
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/persistence"
+)
+
+// Path is where audit trails are served
+const Path = "/audit"
+
+const defaultTrailLimit = 100
+
+// Store reads audit entries. persistence.Storage satisfies it.
+type Store interface {
+	GetAuditTrail(ctx context.Context, documentID string, limit int) ([]*persistence.AuditEntry, error)
+}
+
+// Entry is one change in an audit trail
+type Entry struct {
+	ID            int64     `json:"id"`
+	Action        string    `json:"action"`
+	Command       string    `json:"command,omitempty"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	BeforeHash    string    `json:"before_hash"`
+	AfterHash     string    `json:"after_hash"`
+	Diff          string    `json:"diff"`
+	Reasoning     string    `json:"reasoning,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Trail is the body of an audit trail response
+type Trail struct {
+	DocumentID string  `json:"document_id"`
+	Entries    []Entry `json:"entries"`
+}
+
+// Handler serves a document's audit trail as JSON, newest first. Query
+// parameters:
+//
+//	document_id  the document (required)
+//	limit        the most entries to return (default 100, 0 for all)
+func Handler(store Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		values := r.URL.Query()
+		documentID := values.Get("document_id")
+		if documentID == "" {
+			http.Error(w, "document_id is required", http.StatusBadRequest)
+			return
+		}
+		limit := defaultTrailLimit
+		if raw := values.Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 {
+				http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		entries, err := store.GetAuditTrail(r.Context(), documentID, limit)
+		if err != nil {
+			http.Error(w, "failed to read audit trail", http.StatusInternalServerError)
+			return
+		}
+		trail := Trail{DocumentID: documentID, Entries: make([]Entry, 0, len(entries))}
+		for _, e := range entries {
+			trail.Entries = append(trail.Entries, Entry{
+				ID:            e.ID,
+				Action:        e.Action,
+				Command:       e.CommandType,
+				CorrelationID: e.CorrelationID,
+				BeforeHash:    e.BeforeHash,
+				AfterHash:     e.AfterHash,
+				Diff:          e.Diff,
+				Reasoning:     e.Reasoning,
+				CreatedAt:     e.CreatedAt,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(trail)
+	})
+}
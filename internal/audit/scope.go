@@ -0,0 +1,107 @@
+// Package audit records every change the service makes to Outline
+// documents: updates, moves and comments. Each entry carries hashes of the
+// text before and after, a diff, the command that made the change, its
+// correlation ID and the AI's reasoning, so a change can be explained long
+// after the command ran.
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+// Scope describes the command whose changes are being recorded. It also
+// remembers the documents the command has seen, so the text before a
+// change is known without fetching it again. It is safe for concurrent
+// use.
+type Scope struct {
+	Command       string
+	CorrelationID string
+
+	mu        sync.Mutex
+	reasoning []string
+	docs      map[string]outline.Document
+}
+
+// NewScope creates a Scope for a command found in doc, with a new
+// correlation ID
+func NewScope(command string, doc *outline.Document) *Scope {
+	s := &Scope{
+		Command:       command,
+		CorrelationID: newCorrelationID(),
+		docs:          make(map[string]outline.Document),
+	}
+	if doc != nil {
+		s.remember(doc)
+	}
+	return s
+}
+
+type scopeKey struct{}
+
+// WithScope returns a context under which changes are recorded as made by
+// s's command
+func WithScope(ctx context.Context, s *Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// Hook runs every command under its own Scope and logs the scope's
+// correlation ID with the command. Install it with commands.WithHook.
+func Hook(ctx context.Context, doc *commands.Document, entry *commands.CommandLog) (context.Context, func()) {
+	scope := NewScope(entry.CommandType, doc)
+	entry.CorrelationID = scope.CorrelationID
+	return WithScope(ctx, scope), nil
+}
+
+var _ commands.Hook = Hook
+
+func scopeFrom(ctx context.Context) *Scope {
+	s, _ := ctx.Value(scopeKey{}).(*Scope)
+	return s
+}
+
+// AddReasoning attaches the AI's explanation to the changes made under ctx
+// from now on. It does nothing outside a Scope.
+func AddReasoning(ctx context.Context, reasoning string) {
+	reasoning = strings.TrimSpace(reasoning)
+	s := scopeFrom(ctx)
+	if s == nil || reasoning == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reasoning = append(s.reasoning, reasoning)
+}
+
+func (s *Scope) reasons() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.reasoning, "\n")
+}
+
+// remember stores the latest known state of doc
+func (s *Scope) remember(doc *outline.Document) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[doc.ID] = outline.Document{ID: doc.ID, CollectionID: doc.CollectionID, Title: doc.Title, Text: doc.Text}
+}
+
+func (s *Scope) document(id string) (outline.Document, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, ok := s.docs[id]
+	return doc, ok
+}
+
+// newCorrelationID returns 16 random hex digits
+func newCorrelationID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,263 @@
+// Package catchup recovers commands whose webhook events were lost while
+// the service was down. It remembers when the last event arrived and, on
+// boot, scans the documents updated since then, handing any that still
+// carry command markers to the command pipeline.
+package catchup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/internal/webhook"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+const (
+	defaultOverlap            = 5 * time.Minute
+	defaultCheckpointInterval = 30 * time.Second
+	defaultPageSize           = 25
+
+	// CursorName is the cursor holding the time of the last event
+	CursorName = "webhook"
+)
+
+// Sink takes documents that may carry commands. *processor.Processor
+// satisfies it.
+type Sink interface {
+	Submit(ctx context.Context, doc *outline.Document) (bool, error)
+}
+
+// Cursors persists the time of the last event. persistence.Storage
+// satisfies it.
+type Cursors interface {
+	GetCursor(ctx context.Context, name string) (time.Time, error)
+	SetCursor(ctx context.Context, name string, position time.Time) error
+}
+
+// Option configures a CatchUp
+type Option func(*CatchUp)
+
+// WithOverlap sets how far before the last recorded event the scan starts
+// (default 5 minutes). It covers events that were accepted but still
+// queued when the service went down.
+func WithOverlap(overlap time.Duration) Option {
+	return func(c *CatchUp) {
+		c.overlap = overlap
+	}
+}
+
+// WithPageSize sets how many search hits are requested at a time
+// (default 25)
+func WithPageSize(n int) Option {
+	return func(c *CatchUp) {
+		c.pageSize = n
+	}
+}
+
+// WithCheckpointInterval sets how often Run saves the last event time
+// (default 30s)
+func WithCheckpointInterval(interval time.Duration) Option {
+	return func(c *CatchUp) {
+		c.interval = interval
+	}
+}
+
+// WithSlog sets where recovery results and failed checkpoints are reported
+func WithSlog(logger *slog.Logger) Option {
+	return func(c *CatchUp) {
+		c.slog = logger
+	}
+}
+
+// WithClock replaces time.Now, for tests
+func WithClock(now func() time.Time) Option {
+	return func(c *CatchUp) {
+		c.now = now
+	}
+}
+
+// CatchUp tracks webhook events and recovers the ones missed during
+// downtime. Event times are kept in memory and saved by Checkpoint, so a
+// crash can only make the next scan start earlier, never later.
+type CatchUp struct {
+	outline  outline.Client
+	sink     Sink
+	cursors  Cursors
+	markers  []commands.CommandType
+	overlap  time.Duration
+	interval time.Duration
+	pageSize int
+	slog     *slog.Logger
+	now      func() time.Time
+
+	mu    sync.Mutex
+	last  time.Time
+	saved time.Time
+}
+
+// NewCatchUp creates a CatchUp that searches for markers, usually the
+// router's CommandTypes
+func NewCatchUp(client outline.Client, sink Sink, cursors Cursors, markers []commands.CommandType, opts ...Option) *CatchUp {
+	c := &CatchUp{
+		outline:  client,
+		sink:     sink,
+		cursors:  cursors,
+		markers:  markers,
+		overlap:  defaultOverlap,
+		interval: defaultCheckpointInterval,
+		pageSize: defaultPageSize,
+		slog:     slog.Default(),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Track returns a Queue that passes events to next and records the time
+// of each one next accepts
+func (c *CatchUp) Track(next webhook.Queue) webhook.Queue {
+	return webhook.QueueFunc(func(event *webhook.Event) error {
+		if err := next.Enqueue(event); err != nil {
+			return err
+		}
+		at := event.CreatedAt
+		if at.IsZero() {
+			at = c.now()
+		}
+		c.record(at)
+		return nil
+	})
+}
+
+func (c *CatchUp) record(at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if at.After(c.last) {
+		c.last = at
+	}
+}
+
+// Checkpoint saves the time of the last tracked event if it has moved on
+// since the previous save
+func (c *CatchUp) Checkpoint(ctx context.Context) error {
+	c.mu.Lock()
+	last, saved := c.last, c.saved
+	c.mu.Unlock()
+
+	if !last.After(saved) {
+		return nil
+	}
+	if err := c.cursors.SetCursor(ctx, CursorName, last); err != nil {
+		return fmt.Errorf("catchup: failed to save last event time: %w", err)
+	}
+
+	c.mu.Lock()
+	if last.After(c.saved) {
+		c.saved = last
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// Run checkpoints every interval until ctx is done, then once more
+func (c *CatchUp) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Checkpoint(ctx); err != nil {
+				c.slog.Error("catchup: checkpoint failed", "error", err)
+			}
+		case <-ctx.Done():
+			final, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+			if err := c.Checkpoint(final); err != nil {
+				c.slog.Error("catchup: final checkpoint failed", "error", err)
+			}
+			return
+		}
+	}
+}
+
+// Recover queues the documents updated since the last recorded event that
+// still carry command markers, and returns how many were queued. On the
+// first boot there is nothing to compare against, so it only records the
+// current time.
+func (c *CatchUp) Recover(ctx context.Context) (int, error) {
+	started := c.now()
+
+	last, err := c.cursors.GetCursor(ctx, CursorName)
+	if errors.Is(err, persistence.ErrNotFound) {
+		c.slog.Info("catchup: no previous event recorded, nothing to recover")
+		c.record(started)
+		return 0, c.Checkpoint(ctx)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("catchup: failed to read last event time: %w", err)
+	}
+
+	docs, err := c.changedSince(ctx, last.Add(-c.overlap))
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	for _, doc := range docs {
+		ok, err := c.sink.Submit(ctx, doc)
+		if err != nil {
+			return recovered, fmt.Errorf("catchup: failed to queue document %s: %w", doc.ID, err)
+		}
+		if ok {
+			recovered++
+		}
+	}
+
+	c.slog.Info("catchup: recovered missed documents",
+		"count", recovered,
+		"scanned", len(docs),
+		"since", last,
+		"downtime", started.Sub(last).Round(time.Second))
+
+	c.record(started)
+	return recovered, c.Checkpoint(ctx)
+}
+
+// changedSince returns the documents with text updated after since,
+// oldest first. Like the poller it only searches for the markers, so the
+// cost of a boot follows the number of marker documents rather than the
+// size of the workspace.
+func (c *CatchUp) changedSince(ctx context.Context, since time.Time) ([]*outline.Document, error) {
+	found := make(map[string]*outline.Document)
+	err := outline.SearchEach(ctx, c.outline, c.markers, c.pageSize, func(doc *outline.Document) error {
+		if doc.Text != "" && doc.UpdatedAt.After(since) {
+			found[doc.ID] = doc
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]*outline.Document, 0, len(found))
+	for _, doc := range found {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if !docs[i].UpdatedAt.Equal(docs[j].UpdatedAt) {
+			return docs[i].UpdatedAt.Before(docs[j].UpdatedAt)
+		}
+		return docs[i].ID < docs[j].ID
+	})
+	return docs, nil
+}
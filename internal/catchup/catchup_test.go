@@ -0,0 +1,162 @@
+package catchup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/catchup"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/webhook"
+	"github.com/yourusername/outline-ai/pkg/commands"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+// markerSink queues documents that carry a built-in command, like the
+// processor does
+type markerSink struct {
+	ids []string
+}
+
+func (s *markerSink) Submit(ctx context.Context, doc *outline.Document) (bool, error) {
+	if len(commands.Detect(doc.Text)) == 0 {
+		return false, nil
+	}
+	s.ids = append(s.ids, doc.ID)
+	return true, nil
+}
+
+var (
+	markers = []commands.CommandType{commands.CommandAI, commands.CommandSummarize}
+	now     = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func TestCatchUp_Recover(t *testing.T) {
+	ctx := context.Background()
+	client := mocks.NewOutlineMock()
+	storage := mocks.NewStorageMock()
+	sink := &markerSink{}
+	c := catchup.NewCatchUp(client, sink, storage, markers,
+		catchup.WithOverlap(time.Minute),
+		catchup.WithPageSize(1),
+		catchup.WithClock(func() time.Time { return now }))
+
+	lastEvent := now.Add(-2 * time.Hour)
+	if err := storage.SetCursor(ctx, catchup.CursorName, lastEvent); err != nil {
+		t.Fatalf("Failed to seed cursor: %v", err)
+	}
+
+	client.AddCollection("col-1", "Engineering", "")
+	add := func(id, collectionID, text string, updated time.Time) {
+		client.AddDocument(id, collectionID, "Doc "+id, text).UpdatedAt = updated
+	}
+	add("old", "col-1", "/ai handled before the restart?", lastEvent.Add(-time.Hour))
+	add("overlap", "col-1", "/summarize", lastEvent.Add(-30*time.Second))
+	add("missed", "col-1", "/ai what did we miss?", lastEvent.Add(time.Hour))
+	add("plain", "col-1", "Edited while down, no commands", lastEvent.Add(time.Hour))
+	add("drafted", "", "/summarize", lastEvent.Add(30*time.Minute))
+
+	recovered, err := c.Recover(ctx)
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if recovered != 3 {
+		t.Errorf("Expected 3 recovered documents, got %d: %v", recovered, sink.ids)
+	}
+	want := []string{"overlap", "drafted", "missed"}
+	for i, id := range want {
+		if i >= len(sink.ids) || sink.ids[i] != id {
+			t.Fatalf("Expected %v queued oldest first, got %v", want, sink.ids)
+		}
+	}
+
+	for _, method := range []string{"ListCollections", "ListDocuments"} {
+		if calls := client.GetCallCount(method); calls != 0 {
+			t.Errorf("Expected the scan to search rather than walk the workspace, got %d %s calls", calls, method)
+		}
+	}
+
+	position, err := storage.GetCursor(ctx, catchup.CursorName)
+	if err != nil || !position.Equal(now) {
+		t.Errorf("Expected the cursor to move to the recovery time, got %v (%v)", position, err)
+	}
+}
+
+func TestCatchUp_RecoverFirstBoot(t *testing.T) {
+	ctx := context.Background()
+	client := mocks.NewOutlineMock()
+	storage := mocks.NewStorageMock()
+	c := catchup.NewCatchUp(client, &markerSink{}, storage, markers,
+		catchup.WithClock(func() time.Time { return now }))
+
+	client.AddDocument("doc-1", "", "Notes", "/ai question?")
+
+	recovered, err := c.Recover(ctx)
+	if err != nil || recovered != 0 {
+		t.Fatalf("Expected nothing to recover on first boot, got %d (%v)", recovered, err)
+	}
+	if client.GetCallCount("SearchDocuments") != 0 {
+		t.Error("Expected no scan without a recorded event")
+	}
+	if position, err := storage.GetCursor(ctx, catchup.CursorName); err != nil || !position.Equal(now) {
+		t.Errorf("Expected the boot time to be recorded, got %v (%v)", position, err)
+	}
+}
+
+func TestCatchUp_RecoverFailure(t *testing.T) {
+	ctx := context.Background()
+	client := mocks.NewOutlineMock()
+	storage := mocks.NewStorageMock()
+	c := catchup.NewCatchUp(client, &markerSink{}, storage, markers)
+
+	lastEvent := now.Add(-time.Hour)
+	storage.SetCursor(ctx, catchup.CursorName, lastEvent)
+	client.SetFailureMode(true)
+
+	if _, err := c.Recover(ctx); err == nil {
+		t.Fatal("Expected the scan failure to be returned")
+	}
+	if position, _ := storage.GetCursor(ctx, catchup.CursorName); !position.Equal(lastEvent) {
+		t.Errorf("Expected the cursor to stay at %v after a failed scan, got %v", lastEvent, position)
+	}
+}
+
+func TestCatchUp_Track(t *testing.T) {
+	ctx := context.Background()
+	storage := mocks.NewStorageMock()
+	c := catchup.NewCatchUp(mocks.NewOutlineMock(), &markerSink{}, storage, markers)
+
+	errFull := errors.New("full")
+	var fail bool
+	queue := c.Track(webhook.QueueFunc(func(event *webhook.Event) error {
+		if fail {
+			return errFull
+		}
+		return nil
+	}))
+
+	first := now.Add(-time.Minute)
+	for _, at := range []time.Time{first, first.Add(-time.Hour)} {
+		if err := queue.Enqueue(&webhook.Event{ID: "evt", CreatedAt: at}); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+
+	// A rejected event was not accepted, so it must not move the time on
+	fail = true
+	if err := queue.Enqueue(&webhook.Event{ID: "evt", CreatedAt: now}); !errors.Is(err, errFull) {
+		t.Fatalf("Expected the queue error, got %v", err)
+	}
+
+	if err := c.Checkpoint(ctx); err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	if position, err := storage.GetCursor(ctx, catchup.CursorName); err != nil || !position.Equal(first) {
+		t.Errorf("Expected the newest accepted event time %v, got %v (%v)", first, position, err)
+	}
+
+	if err := c.Checkpoint(ctx); err != nil || storage.GetCallCount("SetCursor") != 1 {
+		t.Errorf("Expected an unchanged time not to be saved again, got %d saves (%v)", storage.GetCallCount("SetCursor"), err)
+	}
+}
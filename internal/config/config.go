@@ -0,0 +1,196 @@
+// Package config loads the service configuration. Settings are layered:
+// built-in defaults, then a YAML file, then OUTLINE_AI_* environment
+// variables, then command-line flags, each overriding the one before.
+// Secrets can also be read from files named by *_FILE variables, as used
+// by Docker secrets.
+package config
+
+import "time"
+
+// Config is the complete service configuration. The yaml tags name each
+// setting; the same dotted path names its flag and, upper-cased with
+// underscores and the OUTLINE_AI_ prefix, its environment variable.
+type Config struct {
+	Service     ServiceConfig     `yaml:"service"`
+	Outline     OutlineConfig     `yaml:"outline"`
+	Webhooks    WebhookConfig     `yaml:"webhooks"`
+	AI          AIConfig          `yaml:"ai"`
+	Processing  ProcessingConfig  `yaml:"processing"`
+	Taxonomy    TaxonomyConfig    `yaml:"taxonomy"`
+	Persistence PersistenceConfig `yaml:"persistence"`
+	Logging     LoggingConfig     `yaml:"logging"`
+}
+
+// ServiceConfig covers the process itself
+type ServiceConfig struct {
+	MaxConcurrentWorkers int           `yaml:"max_concurrent_workers"`
+	QueueSize            int           `yaml:"queue_size"`
+	HealthCheckPort      int           `yaml:"health_check_port"`
+	WebhookPort          int           `yaml:"webhook_port"`
+	ShutdownTimeout      time.Duration `yaml:"shutdown_timeout"`
+	Health               HealthConfig  `yaml:"health"`
+	// AdminToken is the bearer token required by the usage reports and
+	// audit trails on the health port. They are not served while it is
+	// empty.
+	AdminToken string `yaml:"admin_token" secret:"true"`
+}
+
+// HealthConfig covers the dependency checks reported by the probes
+type HealthConfig struct {
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	Timeout  time.Duration `yaml:"timeout"`
+	// NonCritical names dependencies (storage, outline, ai) whose failure
+	// degrades the service instead of making it unready
+	NonCritical []string `yaml:"non_critical"`
+}
+
+// OutlineConfig covers the Outline API
+type OutlineConfig struct {
+	APIEndpoint             string        `yaml:"api_endpoint"`
+	APIKey                  string        `yaml:"api_key" secret:"true"`
+	WebhookSecret           string        `yaml:"webhook_secret" secret:"true"`
+	PreviousWebhookSecret   string        `yaml:"previous_webhook_secret" secret:"true"`
+	RequestTimeout          time.Duration `yaml:"request_timeout"`
+	RateLimitPerMinute      int           `yaml:"rate_limit_per_minute"`
+	WriteRateLimitPerMinute int           `yaml:"write_rate_limit_per_minute"`
+}
+
+// WebhookConfig covers how document changes are discovered
+type WebhookConfig struct {
+	Enabled         bool                  `yaml:"enabled"`
+	Events          []string              `yaml:"events"`
+	ReplayWindow    time.Duration         `yaml:"replay_window"`
+	FallbackPolling FallbackPollingConfig `yaml:"fallback_polling"`
+}
+
+// FallbackPollingConfig covers the poller used where webhooks cannot reach
+// the service
+type FallbackPollingConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// AIConfig covers the OpenAI-compatible endpoint
+type AIConfig struct {
+	Endpoint                 string        `yaml:"endpoint"`
+	APIKey                   string        `yaml:"api_key" secret:"true"`
+	Model                    string        `yaml:"model"`
+	RequestTimeout           time.Duration `yaml:"request_timeout"`
+	MaxTokens                int           `yaml:"max_tokens"`
+	RateLimitPerMinute       int           `yaml:"rate_limit_per_minute"`
+	ConfidenceThreshold      float64       `yaml:"confidence_threshold"`
+	TitleConfidenceThreshold float64       `yaml:"title_confidence_threshold"`
+
+	// Prices in USD per million tokens, used to cost each command. Zero
+	// leaves costs at zero.
+	PromptCostPerMillion     float64 `yaml:"prompt_cost_per_million"`
+	CompletionCostPerMillion float64 `yaml:"completion_cost_per_million"`
+
+	// Monthly budgets; once one is used up, commands are refused until the
+	// next month (UTC). Zero means no limit.
+	MonthlyTokenBudget int     `yaml:"monthly_token_budget"`
+	MonthlyCostBudget  float64 `yaml:"monthly_cost_budget"`
+}
+
+// ProcessingConfig covers retries in the worker pool
+type ProcessingConfig struct {
+	MaxRetries       int           `yaml:"max_retries"`
+	RetryBackoffBase time.Duration `yaml:"retry_backoff_base"`
+	RetryBackoffMax  time.Duration `yaml:"retry_backoff_max"`
+	TaskTimeout      time.Duration `yaml:"task_timeout"`
+}
+
+// TaxonomyConfig covers the collection overview used for filing
+type TaxonomyConfig struct {
+	CacheTTL                time.Duration `yaml:"cache_ttl"`
+	MaxSamplesPerCollection int           `yaml:"max_samples_per_collection"`
+	PromptBudget            int           `yaml:"prompt_budget"`
+}
+
+// PersistenceConfig covers the SQLite state database
+type PersistenceConfig struct {
+	DatabasePath string `yaml:"database_path"`
+}
+
+// LoggingConfig covers log output
+type LoggingConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// Default returns the built-in defaults. Outline's endpoint and API key
+// have none and must be configured.
+func Default() *Config {
+	return &Config{
+		Service: ServiceConfig{
+			MaxConcurrentWorkers: 3,
+			QueueSize:            100,
+			HealthCheckPort:      8080,
+			WebhookPort:          8081,
+			ShutdownTimeout:      30 * time.Second,
+			Health: HealthConfig{
+				CacheTTL: 30 * time.Second,
+				Timeout:  5 * time.Second,
+			},
+		},
+		Outline: OutlineConfig{
+			RequestTimeout:          30 * time.Second,
+			RateLimitPerMinute:      60,
+			WriteRateLimitPerMinute: 30,
+		},
+		Webhooks: WebhookConfig{
+			Enabled: true,
+			Events: []string{
+				"documents.create",
+				"documents.update",
+				"collections.create",
+				"collections.update",
+				"collections.delete",
+			},
+			ReplayWindow: 5 * time.Minute,
+			FallbackPolling: FallbackPollingConfig{
+				Interval: 60 * time.Second,
+			},
+		},
+		AI: AIConfig{
+			Endpoint:                 "https://api.openai.com/v1",
+			Model:                    "gpt-4o-mini",
+			RequestTimeout:           30 * time.Second,
+			MaxTokens:                1024,
+			RateLimitPerMinute:       20,
+			ConfidenceThreshold:      0.7,
+			TitleConfidenceThreshold: 0.7,
+		},
+		Processing: ProcessingConfig{
+			MaxRetries:       3,
+			RetryBackoffBase: time.Second,
+			RetryBackoffMax:  time.Minute,
+			TaskTimeout:      5 * time.Minute,
+		},
+		Taxonomy: TaxonomyConfig{
+			CacheTTL:                time.Hour,
+			MaxSamplesPerCollection: 5,
+			PromptBudget:            3000,
+		},
+		Persistence: PersistenceConfig{
+			DatabasePath: "/data/state.db",
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "json",
+		},
+	}
+}
+
+// Redacted returns a copy safe to log, with every secret masked
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.Webhooks.Events = append([]string(nil), c.Webhooks.Events...)
+	redacted.Service.Health.NonCritical = append([]string(nil), c.Service.Health.NonCritical...)
+	for _, f := range fields(&redacted) {
+		if f.secret && f.value.String() != "" {
+			f.value.SetString("****")
+		}
+	}
+	return &redacted
+}
@@ -0,0 +1,238 @@
+package config_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/config"
+)
+
+// env builds a lookup over a fixed set of variables
+func env(vars map[string]string) config.Option {
+	return config.WithLookupEnv(func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	})
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+// required holds the settings that have no default
+var required = map[string]string{
+	"OUTLINE_AI_OUTLINE_API_ENDPOINT":   "https://outline.example.com/api",
+	"OUTLINE_AI_OUTLINE_API_KEY":        "ol_api_key",
+	"OUTLINE_AI_OUTLINE_WEBHOOK_SECRET": "whsec",
+}
+
+func withRequired(extra map[string]string) map[string]string {
+	vars := make(map[string]string, len(required)+len(extra))
+	for k, v := range required {
+		vars[k] = v
+	}
+	for k, v := range extra {
+		vars[k] = v
+	}
+	return vars
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := config.Load(nil, env(required))
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	want := config.Default()
+	if cfg.Service.MaxConcurrentWorkers != want.Service.MaxConcurrentWorkers || cfg.AI.Model != want.AI.Model ||
+		cfg.Webhooks.FallbackPolling.Interval != time.Minute || cfg.Persistence.DatabasePath != "/data/state.db" {
+		t.Errorf("Expected defaults, got %+v", cfg)
+	}
+	if cfg.Outline.APIKey != "ol_api_key" {
+		t.Errorf("Expected the API key from the environment, got %q", cfg.Outline.APIKey)
+	}
+}
+
+func TestLoad_Layers(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+service:
+  max_concurrent_workers: 5
+ai:
+  model: file-model
+  confidence_threshold: 0.8
+webhooks:
+  events: [documents.update]
+  fallback_polling:
+    enabled: true
+    interval: 2m
+`)
+	vars := withRequired(map[string]string{
+		"OUTLINE_AI_CONFIG":                         path,
+		"OUTLINE_AI_AI_MODEL":                       "env-model",
+		"OUTLINE_AI_SERVICE_MAX_CONCURRENT_WORKERS": "7",
+	})
+
+	cfg, err := config.Load([]string{"-service.max_concurrent_workers=9"}, env(vars))
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	if cfg.AI.ConfidenceThreshold != 0.8 {
+		t.Errorf("Expected the file to override the default, got %g", cfg.AI.ConfidenceThreshold)
+	}
+	if cfg.AI.Model != "env-model" {
+		t.Errorf("Expected the environment to override the file, got %q", cfg.AI.Model)
+	}
+	if cfg.Service.MaxConcurrentWorkers != 9 {
+		t.Errorf("Expected the flag to override the environment, got %d", cfg.Service.MaxConcurrentWorkers)
+	}
+	if !cfg.Webhooks.FallbackPolling.Enabled || cfg.Webhooks.FallbackPolling.Interval != 2*time.Minute {
+		t.Errorf("Expected nested settings from the file, got %+v", cfg.Webhooks.FallbackPolling)
+	}
+	if len(cfg.Webhooks.Events) != 1 || cfg.Webhooks.Events[0] != "documents.update" {
+		t.Errorf("Expected the file's event list to replace the default, got %v", cfg.Webhooks.Events)
+	}
+}
+
+func TestLoad_ConfigFlag(t *testing.T) {
+	path := writeFile(t, "config.yaml", "ai:\n  model: flag-file-model\n")
+
+	cfg, err := config.Load([]string{"-config", path, "-webhooks.events", "documents.create, documents.update"}, env(required))
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if cfg.AI.Model != "flag-file-model" {
+		t.Errorf("Expected the file named by -config, got %q", cfg.AI.Model)
+	}
+	if strings.Join(cfg.Webhooks.Events, "|") != "documents.create|documents.update" {
+		t.Errorf("Expected a comma-separated list, got %v", cfg.Webhooks.Events)
+	}
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	secret := writeFile(t, "ai_key", "sk-from-file\n")
+	vars := withRequired(map[string]string{"OUTLINE_AI_AI_API_KEY_FILE": secret})
+
+	cfg, err := config.Load(nil, env(vars))
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if cfg.AI.APIKey != "sk-from-file" {
+		t.Errorf("Expected the key from the file without its newline, got %q", cfg.AI.APIKey)
+	}
+
+	vars["OUTLINE_AI_AI_API_KEY"] = "sk-direct"
+	delete(vars, "OUTLINE_AI_OUTLINE_API_KEY")
+	vars["OUTLINE_AI_OUTLINE_API_KEY_FILE"] = filepath.Join(t.TempDir(), "missing")
+	_, err = config.Load(nil, env(vars))
+
+	var errs config.Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("Expected two errors, got %v", err)
+	}
+	if errs[0].Field != "outline.api_key" || errs[0].Source != "OUTLINE_AI_OUTLINE_API_KEY_FILE" {
+		t.Errorf("Expected the unreadable file to be reported, got %+v", errs[0])
+	}
+	if errs[1].Field != "ai.api_key" || !strings.Contains(errs[1].Message, "not both") {
+		t.Errorf("Expected the conflicting sources to be reported, got %+v", errs[1])
+	}
+
+	// Only secrets can come from files
+	vars = withRequired(map[string]string{"OUTLINE_AI_AI_MODEL_FILE": secret})
+	if cfg, err := config.Load(nil, env(vars)); err != nil || cfg.AI.Model == "sk-from-file" {
+		t.Errorf("Expected _FILE to be ignored for non-secrets, got %q (%v)", cfg.AI.Model, err)
+	}
+}
+
+func TestLoad_AggregatedErrors(t *testing.T) {
+	path := writeFile(t, "config.yaml", "ai:\n  confidence_threshold: 1.5\n")
+	vars := map[string]string{
+		"OUTLINE_AI_CONFIG":           path,
+		"OUTLINE_AI_AI_MAX_TOKENS":    "lots",
+		"OUTLINE_AI_OUTLINE_API_KEY":  "key",
+		"OUTLINE_AI_WEBHOOKS_ENABLED": "false",
+		"OUTLINE_AI_LOGGING_LEVEL":    "verbose",
+
+		"OUTLINE_AI_SERVICE_HEALTH_NON_CRITICAL": "ai, llm",
+		"OUTLINE_AI_AI_MONTHLY_COST_BUDGET":      "50",
+	}
+
+	_, err := config.Load([]string{"-processing.retry_backoff_max=10ms"}, env(vars))
+	if !errors.Is(err, config.ErrInvalid) {
+		t.Fatalf("Expected ErrInvalid, got %v", err)
+	}
+
+	var errs config.Errors
+	errors.As(err, &errs)
+	got := make(map[string]string)
+	for _, e := range errs {
+		got[e.Field] = e.Source
+	}
+
+	want := map[string]string{
+		"ai.max_tokens":                "OUTLINE_AI_AI_MAX_TOKENS",
+		"outline.api_endpoint":         "",
+		"webhooks.enabled":             "OUTLINE_AI_WEBHOOKS_ENABLED",
+		"ai.confidence_threshold":      path,
+		"processing.retry_backoff_max": "-processing.retry_backoff_max",
+		"logging.level":                "OUTLINE_AI_LOGGING_LEVEL",
+		"service.health.non_critical":  "OUTLINE_AI_SERVICE_HEALTH_NON_CRITICAL",
+		"ai.monthly_cost_budget":       "OUTLINE_AI_AI_MONTHLY_COST_BUDGET",
+	}
+	for field, source := range want {
+		if s, ok := got[field]; !ok || s != source {
+			t.Errorf("Expected an error for %s from %q, got %q (present: %v)", field, source, s, ok)
+		}
+	}
+	if len(errs) != len(want) {
+		t.Errorf("Expected %d errors, got %d:\n%v", len(want), len(errs), err)
+	}
+	if !strings.Contains(err.Error(), "ai.confidence_threshold: must be between 0 and 1, got 1.5 (from "+path+")") {
+		t.Errorf("Expected a field-precise message, got:\n%v", err)
+	}
+}
+
+func TestLoad_FileErrors(t *testing.T) {
+	path := writeFile(t, "config.yaml", "ai:\n  modle: typo\n")
+	_, err := config.Load([]string{"-config", path}, env(required))
+	if !errors.Is(err, config.ErrInvalid) || !strings.Contains(err.Error(), "modle") {
+		t.Errorf("Expected the unknown key to be reported, got %v", err)
+	}
+
+	_, err = config.Load([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, env(required))
+	if !errors.Is(err, config.ErrInvalid) || !strings.Contains(err.Error(), "cannot read config file") {
+		t.Errorf("Expected the missing file to be reported, got %v", err)
+	}
+
+	if _, err := config.Load([]string{"-no-such-flag"}, env(required), config.WithFlagOutput(io.Discard)); err == nil {
+		t.Error("Expected an unknown flag to fail")
+	}
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg, err := config.Load(nil, env(required))
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	redacted := cfg.Redacted()
+	if redacted.Outline.APIKey != "****" || redacted.Outline.WebhookSecret != "****" {
+		t.Errorf("Expected secrets to be masked, got %+v", redacted.Outline)
+	}
+	if redacted.AI.APIKey != "" {
+		t.Errorf("Expected an unset secret to stay empty, got %q", redacted.AI.APIKey)
+	}
+	if cfg.Outline.APIKey != "ol_api_key" || redacted.Outline.APIEndpoint != cfg.Outline.APIEndpoint {
+		t.Error("Expected the original to be untouched and other settings kept")
+	}
+}
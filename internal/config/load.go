@@ -0,0 +1,291 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvPrefix starts every environment variable the loader reads
+	EnvPrefix = "OUTLINE_AI_"
+
+	// FileSuffix names the variable holding the path of a secret's file,
+	// e.g. OUTLINE_AI_OUTLINE_API_KEY_FILE
+	FileSuffix = "_FILE"
+
+	configFlag = "config"
+	configEnv  = EnvPrefix + "CONFIG"
+)
+
+// Option configures Load
+type Option func(*loader)
+
+// WithLookupEnv replaces os.LookupEnv, for tests
+func WithLookupEnv(lookup func(key string) (string, bool)) Option {
+	return func(l *loader) {
+		l.lookupEnv = lookup
+	}
+}
+
+// WithFlagOutput sets where flag usage and parse errors are written
+// (default os.Stderr)
+func WithFlagOutput(w io.Writer) Option {
+	return func(l *loader) {
+		l.output = w
+	}
+}
+
+type loader struct {
+	lookupEnv func(key string) (string, bool)
+	output    io.Writer
+}
+
+// field is one setting, addressed by its dotted YAML path
+type field struct {
+	path   string
+	value  reflect.Value
+	secret bool
+}
+
+// envName returns the environment variable for the setting
+func (f field) envName() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(f.path, ".", "_"))
+}
+
+// fields lists the settings of cfg in declaration order
+func fields(cfg *Config) []field {
+	var list []field
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+			path := prefix + name
+			if sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeFor[time.Duration]() {
+				walk(v.Field(i), path+".")
+				continue
+			}
+			list = append(list, field{path: path, value: v.Field(i), secret: sf.Tag.Get("secret") == "true"})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return list
+}
+
+// set parses raw into the setting
+func (f field) set(raw string) error {
+	v := f.value
+	switch {
+	case v.Type() == reflect.TypeFor[time.Duration]():
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		x, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(x)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		// Lists are comma-separated outside YAML
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// Load builds the configuration from args, usually os.Args[1:]. The YAML
+// file is named by the -config flag or OUTLINE_AI_CONFIG; without either,
+// only defaults, environment and flags apply. Every problem found is
+// reported at once in an Errors value.
+func Load(args []string, opts ...Option) (*Config, error) {
+	l := &loader{lookupEnv: os.LookupEnv, output: os.Stderr}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	cfg := Default()
+	list := fields(cfg)
+	sources := make(map[string]string, len(list))
+	var errs Errors
+
+	// Flags are parsed first to find the config file, but applied last
+	fs := flag.NewFlagSet("outline-ai", flag.ContinueOnError)
+	fs.SetOutput(l.output)
+	configPath := fs.String(configFlag, "", "path to the YAML config file (env "+configEnv+")")
+	flagValues := make(map[string]*string, len(list))
+	for _, f := range list {
+		usage := fmt.Sprintf("%s (env %s)", f.path, f.envName())
+		flagValues[f.path] = fs.String(f.path, "", usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("config: unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	// YAML file
+	path := *configPath
+	if path == "" {
+		path, _ = l.lookupEnv(configEnv)
+	}
+	if path != "" {
+		errs = append(errs, loadFile(cfg, path, list, sources)...)
+	}
+
+	// Environment
+	for _, f := range list {
+		name := f.envName()
+		raw, ok := l.lookupEnv(name)
+		if f.secret {
+			if file, fromFile := l.lookupEnv(name + FileSuffix); fromFile {
+				if ok {
+					errs = append(errs, &FieldError{Field: f.path, Source: name + FileSuffix,
+						Message: fmt.Sprintf("set either %s or %s, not both", name, name+FileSuffix)})
+					continue
+				}
+				secret, err := readSecret(file)
+				if err != nil {
+					errs = append(errs, &FieldError{Field: f.path, Source: name + FileSuffix, Message: err.Error()})
+					continue
+				}
+				raw, ok, name = secret, true, name+FileSuffix
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := f.set(raw); err != nil {
+			errs = append(errs, &FieldError{Field: f.path, Source: name, Message: err.Error()})
+			continue
+		}
+		sources[f.path] = name
+	}
+
+	// Flags
+	set := make(map[string]bool)
+	fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+	for _, f := range list {
+		if !set[f.path] {
+			continue
+		}
+		source := "-" + f.path
+		if err := f.set(*flagValues[f.path]); err != nil {
+			errs = append(errs, &FieldError{Field: f.path, Source: source, Message: err.Error()})
+			continue
+		}
+		sources[f.path] = source
+	}
+
+	// A value that could not be loaded would only fail validation again
+	failed := make(map[string]bool, len(errs))
+	for _, e := range errs {
+		failed[e.Field] = true
+	}
+	for _, e := range cfg.validate() {
+		if !failed[e.Field] {
+			errs = append(errs, e)
+		}
+	}
+	if len(errs) > 0 {
+		for _, e := range errs {
+			if e.Source == "" {
+				e.Source = sources[e.Field]
+			}
+		}
+		return nil, errs
+	}
+	return cfg, nil
+}
+
+// loadFile decodes the YAML file over cfg. Unknown keys are errors, so a
+// misspelt setting is not silently ignored.
+func loadFile(cfg *Config, path string, list []field, sources map[string]string) Errors {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Errors{{Source: path, Message: fmt.Sprintf("cannot read config file: %v", err)}}
+	}
+
+	// Decode generically first to learn which keys the file sets
+	var present map[string]any
+	if err := yaml.Unmarshal(data, &present); err != nil {
+		return Errors{{Source: path, Message: err.Error()}}
+	}
+	for _, f := range list {
+		if hasPath(present, f.path) {
+			sources[f.path] = path
+		}
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	err = dec.Decode(cfg)
+	var typeErr *yaml.TypeError
+	switch {
+	case err == nil, errors.Is(err, io.EOF):
+		return nil
+	case errors.As(err, &typeErr):
+		errs := make(Errors, 0, len(typeErr.Errors))
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, &FieldError{Source: path, Message: msg})
+		}
+		return errs
+	default:
+		return Errors{{Source: path, Message: err.Error()}}
+	}
+}
+
+func hasPath(m map[string]any, path string) bool {
+	head, rest, nested := strings.Cut(path, ".")
+	v, ok := m[head]
+	if !ok || !nested {
+		return ok
+	}
+	child, ok := v.(map[string]any)
+	return ok && hasPath(child, rest)
+}
+
+// readSecret returns the contents of a secret file without its trailing
+// line break
+func readSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read secret file: %v", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
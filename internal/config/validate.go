@@ -0,0 +1,187 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrInvalid matches every Errors value returned by Load
+var ErrInvalid = errors.New("config: invalid configuration")
+
+// FieldError is a problem with one setting
+type FieldError struct {
+	// Field is the dotted path of the setting, empty for file-level
+	// problems
+	Field string
+
+	// Source is where the offending value came from: the config file,
+	// an environment variable or a flag. It is empty for defaults.
+	Source string
+
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	var sb strings.Builder
+	if e.Field != "" {
+		sb.WriteString(e.Field)
+		sb.WriteString(": ")
+	}
+	sb.WriteString(e.Message)
+	if e.Source != "" {
+		fmt.Fprintf(&sb, " (from %s)", e.Source)
+	}
+	return sb.String()
+}
+
+// Errors collects every problem found while loading, so they can all be
+// fixed in one go
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "config: %d invalid setting", len(e))
+	if len(e) != 1 {
+		sb.WriteString("s")
+	}
+	for _, fe := range e {
+		sb.WriteString("\n  - ")
+		sb.WriteString(fe.Error())
+	}
+	return sb.String()
+}
+
+// Is makes errors.Is(err, ErrInvalid) true
+func (e Errors) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// Validate checks every setting and returns an Errors value listing all
+// problems, or nil
+func (c *Config) Validate() error {
+	if errs := c.validate(); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c *Config) validate() Errors {
+	var errs Errors
+	check := func(ok bool, field, format string, args ...any) {
+		if !ok {
+			errs = append(errs, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+	positive := func(d time.Duration, field string) {
+		check(d > 0, field, "must be a positive duration, got %s", d)
+	}
+
+	// Service
+	s := c.Service
+	check(s.MaxConcurrentWorkers >= 1 && s.MaxConcurrentWorkers <= 64, "service.max_concurrent_workers", "must be between 1 and 64, got %d", s.MaxConcurrentWorkers)
+	check(s.QueueSize >= 1, "service.queue_size", "must be at least 1, got %d", s.QueueSize)
+	check(validPort(s.HealthCheckPort), "service.health_check_port", "must be between 1 and 65535, got %d", s.HealthCheckPort)
+	check(validPort(s.WebhookPort), "service.webhook_port", "must be between 1 and 65535, got %d", s.WebhookPort)
+	check(s.HealthCheckPort != s.WebhookPort || !c.Webhooks.Enabled, "service.webhook_port", "must differ from service.health_check_port")
+	positive(s.ShutdownTimeout, "service.shutdown_timeout")
+	positive(s.Health.CacheTTL, "service.health.cache_ttl")
+	positive(s.Health.Timeout, "service.health.timeout")
+	for _, name := range s.Health.NonCritical {
+		switch name {
+		case "storage", "outline", "ai":
+		default:
+			check(false, "service.health.non_critical", "must list only storage, outline or ai, got %q", name)
+		}
+	}
+
+	// Outline
+	o := c.Outline
+	if msg := checkURL(o.APIEndpoint); msg != "" {
+		check(false, "outline.api_endpoint", "%s", msg)
+	}
+	check(o.APIKey != "", "outline.api_key", "is required")
+	positive(o.RequestTimeout, "outline.request_timeout")
+	check(o.RateLimitPerMinute >= 1, "outline.rate_limit_per_minute", "must be at least 1, got %d", o.RateLimitPerMinute)
+	check(o.WriteRateLimitPerMinute >= 1, "outline.write_rate_limit_per_minute", "must be at least 1, got %d", o.WriteRateLimitPerMinute)
+
+	// Webhooks and polling
+	w := c.Webhooks
+	check(w.Enabled || w.FallbackPolling.Enabled, "webhooks.enabled", "webhooks or webhooks.fallback_polling must be enabled, or no command is ever seen")
+	if w.Enabled {
+		check(o.WebhookSecret != "", "outline.webhook_secret", "is required when webhooks are enabled")
+		check(len(w.Events) > 0, "webhooks.events", "must list at least one event")
+		positive(w.ReplayWindow, "webhooks.replay_window")
+	}
+	if w.FallbackPolling.Enabled {
+		check(w.FallbackPolling.Interval >= 5*time.Second, "webhooks.fallback_polling.interval", "must be at least 5s, got %s", w.FallbackPolling.Interval)
+	}
+
+	// AI
+	a := c.AI
+	if msg := checkURL(a.Endpoint); msg != "" {
+		check(false, "ai.endpoint", "%s", msg)
+	}
+	check(strings.TrimSpace(a.Model) != "", "ai.model", "is required")
+	positive(a.RequestTimeout, "ai.request_timeout")
+	check(a.MaxTokens >= 100, "ai.max_tokens", "must be at least 100, got %d", a.MaxTokens)
+	check(a.RateLimitPerMinute >= 1, "ai.rate_limit_per_minute", "must be at least 1, got %d", a.RateLimitPerMinute)
+	check(a.ConfidenceThreshold >= 0 && a.ConfidenceThreshold <= 1, "ai.confidence_threshold", "must be between 0 and 1, got %g", a.ConfidenceThreshold)
+	check(a.TitleConfidenceThreshold >= 0 && a.TitleConfidenceThreshold <= 1, "ai.title_confidence_threshold", "must be between 0 and 1, got %g", a.TitleConfidenceThreshold)
+	check(a.PromptCostPerMillion >= 0, "ai.prompt_cost_per_million", "must not be negative, got %g", a.PromptCostPerMillion)
+	check(a.CompletionCostPerMillion >= 0, "ai.completion_cost_per_million", "must not be negative, got %g", a.CompletionCostPerMillion)
+	check(a.MonthlyTokenBudget >= 0, "ai.monthly_token_budget", "must not be negative, got %d", a.MonthlyTokenBudget)
+	check(a.MonthlyCostBudget >= 0, "ai.monthly_cost_budget", "must not be negative, got %g", a.MonthlyCostBudget)
+	if a.MonthlyCostBudget > 0 {
+		check(a.PromptCostPerMillion > 0 || a.CompletionCostPerMillion > 0, "ai.monthly_cost_budget",
+			"needs ai.prompt_cost_per_million or ai.completion_cost_per_million, or every command costs nothing")
+	}
+
+	// Processing
+	p := c.Processing
+	check(p.MaxRetries >= 0, "processing.max_retries", "must not be negative, got %d", p.MaxRetries)
+	positive(p.RetryBackoffBase, "processing.retry_backoff_base")
+	check(p.RetryBackoffMax >= p.RetryBackoffBase, "processing.retry_backoff_max", "must not be below processing.retry_backoff_base (%s), got %s", p.RetryBackoffBase, p.RetryBackoffMax)
+	positive(p.TaskTimeout, "processing.task_timeout")
+
+	// Taxonomy
+	t := c.Taxonomy
+	positive(t.CacheTTL, "taxonomy.cache_ttl")
+	check(t.MaxSamplesPerCollection >= 0, "taxonomy.max_samples_per_collection", "must not be negative, got %d", t.MaxSamplesPerCollection)
+	check(t.PromptBudget >= 100, "taxonomy.prompt_budget", "must be at least 100 tokens, got %d", t.PromptBudget)
+
+	// Persistence and logging
+	check(strings.TrimSpace(c.Persistence.DatabasePath) != "", "persistence.database_path", "is required")
+	switch c.Logging.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "logging.level", "must be one of debug, info, warn, error, got %q", c.Logging.Level)
+	}
+	check(c.Logging.Format == "json" || c.Logging.Format == "text", "logging.format", "must be json or text, got %q", c.Logging.Format)
+
+	return errs
+}
+
+func validPort(port int) bool {
+	return port >= 1 && port <= 65535
+}
+
+// checkURL returns why raw is not a usable http(s) endpoint, or ""
+func checkURL(raw string) string {
+	if raw == "" {
+		return "is required"
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Sprintf("is not a valid URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Sprintf("must be an http or https URL, got %q", raw)
+	}
+	if u.Host == "" {
+		return fmt.Sprintf("has no host: %q", raw)
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+// markerBlock is a region of a document delimited by hidden HTML comment
+// markers. While the markers are present the region belongs to the
+// assistant and is replaced wholesale; once a user removes them it is theirs.
+type markerBlock struct {
+	start string
+	end   string
+}
+
+func newMarkerBlock(name string) markerBlock {
+	return markerBlock{
+		start: "<!-- " + name + "-START -->",
+		end:   "<!-- " + name + "-END -->",
+	}
+}
+
+// render wraps body in the block's markers
+func (b markerBlock) render(body, newline string) string {
+	return b.start + newline + body + newline + b.end
+}
+
+// find locates the first complete block outside code fences. start is the
+// offset of the start marker line and end the offset just past the end
+// marker. A start marker without a matching end marker is not a block.
+func (b markerBlock) find(text string) (start, end int, ok bool) {
+	start = -1
+	for _, ln := range scanLines(text) {
+		if ln.fenced {
+			continue
+		}
+		switch strings.TrimSpace(text[ln.start:ln.end]) {
+		case b.start:
+			if start < 0 {
+				start = ln.start
+			}
+		case b.end:
+			if start >= 0 {
+				return start, ln.end, true
+			}
+		}
+	}
+	return -1, -1, false
+}
+
+// replace swaps the block found in text for body, reporting false when
+// there is no block
+func (b markerBlock) replace(text, body string) (string, bool) {
+	start, end, ok := b.find(text)
+	if !ok {
+		return text, false
+	}
+	return text[:start] + b.render(body, newline(text)) + text[end:], true
+}
+
+// remove deletes the block found in text with the blank lines before it,
+// reporting false when there is no block
+func (b markerBlock) remove(text string) (string, bool) {
+	start, end, ok := b.find(text)
+	if !ok {
+		return text, false
+	}
+	before := strings.TrimRightFunc(text[:start], unicode.IsSpace)
+	after := text[end:]
+	if before == "" {
+		after = strings.TrimLeftFunc(after, unicode.IsSpace)
+	}
+	return before + after, true
+}
+
+// scannedLine is a line of text; end excludes the line terminator
+type scannedLine struct {
+	start  int
+	end    int
+	fenced bool
+}
+
+// scanLines splits text into lines, flagging those inside ``` or ~~~
+// fences, fence lines included, as the command detector sees them
+func scanLines(text string) []scannedLine {
+	var (
+		lines []scannedLine
+		fence commands.Fence
+	)
+	for start := 0; start <= len(text); {
+		end := strings.IndexByte(text[start:], '\n')
+		next := len(text) + 1
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += start
+			next = end + 1
+		}
+		content := end
+		if content > start && text[content-1] == '\r' {
+			content--
+		}
+
+		lines = append(lines, scannedLine{start: start, end: content, fenced: fence.Line(text[start:content])})
+		start = next
+	}
+	return lines
+}
+
+// newline returns the line terminator text already uses
+func newline(text string) string {
+	if strings.Contains(text, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import "testing"
+
+func TestMarkerBlockReplace(t *testing.T) {
+	block := newMarkerBlock("AI-TEST")
+	tests := []struct {
+		name  string
+		text  string
+		want  string
+		found bool
+	}{
+		{
+			name:  "replaces between markers",
+			text:  "intro\n<!-- AI-TEST-START -->\nold\nlines\n<!-- AI-TEST-END -->\noutro",
+			want:  "intro\n<!-- AI-TEST-START -->\nnew\n<!-- AI-TEST-END -->\noutro",
+			found: true,
+		},
+		{
+			name:  "keeps CRLF line endings",
+			text:  "<!-- AI-TEST-START -->\r\nold\r\n<!-- AI-TEST-END -->\r\n",
+			want:  "<!-- AI-TEST-START -->\r\nnew\r\n<!-- AI-TEST-END -->\r\n",
+			found: true,
+		},
+		{
+			name: "ignores markers in code fences",
+			text: "```html\n<!-- AI-TEST-START -->\nexample\n<!-- AI-TEST-END -->\n```",
+			want: "```html\n<!-- AI-TEST-START -->\nexample\n<!-- AI-TEST-END -->\n```",
+		},
+		{
+			name: "shorter fence inside a longer one",
+			text: "````md\n```\n<!-- AI-TEST-START -->\nexample\n<!-- AI-TEST-END -->\n```\n````",
+			want: "````md\n```\n<!-- AI-TEST-START -->\nexample\n<!-- AI-TEST-END -->\n```\n````",
+		},
+		{
+			name:  "indented code is not a fence",
+			text:  "    ```\n<!-- AI-TEST-START -->\nold\n<!-- AI-TEST-END -->",
+			want:  "    ```\n<!-- AI-TEST-START -->\nnew\n<!-- AI-TEST-END -->",
+			found: true,
+		},
+		{
+			name: "start marker without end",
+			text: "<!-- AI-TEST-START -->\nold",
+			want: "<!-- AI-TEST-START -->\nold",
+		},
+		{
+			name: "end marker before start",
+			text: "<!-- AI-TEST-END -->\nold\n<!-- AI-TEST-START -->",
+			want: "<!-- AI-TEST-END -->\nold\n<!-- AI-TEST-START -->",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := block.replace(tt.text, "new")
+			if found != tt.found {
+				t.Errorf("Expected found %v, got %v", tt.found, found)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
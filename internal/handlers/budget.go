@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/usage"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+// Budget tells whether AI work may start. usage.Budget satisfies it.
+type Budget interface {
+	// Check returns an error wrapping usage.ErrBudgetExhausted once the
+	// budget is used up
+	Check(ctx context.Context) error
+	// ResetsAt returns when the budget is renewed
+	ResetsAt() time.Time
+}
+
+// BudgetGuard refuses commands once the AI budget is exhausted. The marker
+// is removed and a comment explains when commands work again, so the
+// document is not retried on every edit.
+type BudgetGuard struct {
+	budget  Budget
+	outline outline.Client
+}
+
+// NewBudgetGuard creates a BudgetGuard
+func NewBudgetGuard(budget Budget, outlineClient outline.Client) *BudgetGuard {
+	return &BudgetGuard{
+		budget:  budget,
+		outline: outlineClient,
+	}
+}
+
+// Wrap returns a handler that checks the budget before running next
+func (g *BudgetGuard) Wrap(next commands.Handler) commands.Handler {
+	return commands.HandlerFunc(func(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+		err := g.budget.Check(ctx)
+		switch {
+		case err == nil:
+			return next.Handle(ctx, doc, cmd)
+		case errors.Is(err, usage.ErrBudgetExhausted):
+			return g.refuse(ctx, doc, cmd)
+		default:
+			return commands.Result{}, fmt.Errorf("handlers: failed to check AI budget: %w", err)
+		}
+	})
+}
+
+func (g *BudgetGuard) refuse(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+	text, err := removeCommands(doc.Text, cmd)
+	if err != nil {
+		return commands.Result{}, err
+	}
+	if _, err := g.outline.UpdateDocument(ctx, doc.ID, &outline.UpdateDocumentRequest{Text: &text, Done: true}); err != nil {
+		return commands.Result{}, fmt.Errorf("handlers: failed to remove refused command: %w", err)
+	}
+	doc.Text = text
+
+	if err := comment(ctx, g.outline, doc.ID,
+		fmt.Sprintf("⚠️ AI budget exhausted - %s was not run", cmd.Type),
+		fmt.Sprintf("The monthly AI budget is used up and resets on %s. Add %s again after that.",
+			g.budget.ResetsAt().UTC().Format("January 2, 2006"), cmd.Type),
+	); err != nil {
+		return commands.Result{}, err
+	}
+	return commands.Result{Message: "refused: monthly AI budget exhausted"}, nil
+}
@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/handlers"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/internal/usage"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+type fakeBudget struct {
+	err error
+}
+
+func (b *fakeBudget) Check(ctx context.Context) error { return b.err }
+
+func (b *fakeBudget) ResetsAt() time.Time {
+	return time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func newBudgetEnv(t *testing.T, budget *fakeBudget) (*env, *int) {
+	t.Helper()
+	e := newEnv(t)
+	calls := new(int)
+	guard := handlers.NewBudgetGuard(budget, e.outline)
+	e.router.Register(commands.CommandSummarize, guard.Wrap(commands.HandlerFunc(
+		func(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+			*calls++
+			return commands.Result{}, nil
+		})))
+	return e, calls
+}
+
+func TestBudgetGuard_RunsWithinBudget(t *testing.T) {
+	e, calls := newBudgetEnv(t, &fakeBudget{})
+	e.outline.AddDocument("doc-1", "col-engineering-001", "Notes", "# Notes\n\n/summarize")
+
+	if errs := e.run(t, "doc-1"); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	if *calls != 1 {
+		t.Errorf("Expected the handler to run once, got %d", *calls)
+	}
+}
+
+func TestBudgetGuard_RefusesWhenExhausted(t *testing.T) {
+	e, calls := newBudgetEnv(t, &fakeBudget{err: usage.ErrBudgetExhausted})
+	doc := e.outline.AddDocument("doc-1", "col-engineering-001", "Notes", "# Notes\n\n/summarize")
+
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Expected the refusal not to fail the command, got %v", errs)
+	}
+	if *calls != 0 {
+		t.Errorf("Expected the handler not to run, got %d calls", *calls)
+	}
+	if doc.Text != "# Notes\n" {
+		t.Errorf("Expected the marker removed, got %q", doc.Text)
+	}
+	comments := e.comments(t, doc.ID)
+	if len(comments) != 1 || !strings.Contains(comments[0], "AI budget exhausted") || !strings.Contains(comments[0], "April 1, 2026") {
+		t.Errorf("Expected a comment with the reset date, got %v", comments)
+	}
+
+	history, _ := e.storage.GetCommandHistory(context.Background(), doc.ID, 0)
+	if len(history) != 1 || history[0].Status != persistence.CommandStatusSuccess {
+		t.Errorf("Expected the refusal logged as handled, got %+v", history)
+	}
+}
+
+func TestBudgetGuard_RefusesMarkerOnlyDocument(t *testing.T) {
+	e, _ := newBudgetEnv(t, &fakeBudget{err: usage.ErrBudgetExhausted})
+	doc := e.outline.AddDocument("doc-1", "col-engineering-001", "Notes", "/summarize")
+
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Expected the refusal not to fail the command, got %v", errs)
+	}
+	if doc.Text != "" {
+		t.Errorf("Expected the marker removed, got %q", doc.Text)
+	}
+
+	// With the marker gone, a later edit finds nothing to refuse
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	if comments := e.comments(t, doc.ID); len(comments) != 1 {
+		t.Errorf("Expected a single refusal comment, got %v", comments)
+	}
+}
+
+func TestBudgetGuard_CheckFails(t *testing.T) {
+	e, calls := newBudgetEnv(t, &fakeBudget{err: errors.New("database locked")})
+	e.outline.AddDocument("doc-1", "col-engineering-001", "Notes", "# Notes\n\n/summarize")
+
+	errs := e.run(t, "doc-1")
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "failed to check AI budget") {
+		t.Errorf("Expected the check error, got %v", errs)
+	}
+	if *calls != 0 {
+		t.Errorf("Expected the handler not to run, got %d calls", *calls)
+	}
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/audit"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+const (
+	defaultFilingThreshold = 0.7
+	defaultMaxAlternatives = 3
+)
+
+var _ commands.Handler = (*FilingHandler)(nil)
+
+// TaxonomySource provides the collections a document can be filed into
+type TaxonomySource interface {
+	Taxonomy(ctx context.Context) (*ai.TaxonomyContext, error)
+}
+
+// FilingOption configures a FilingHandler
+type FilingOption func(*FilingHandler)
+
+// WithFilingThreshold sets the confidence needed to move a document
+// (default 0.7)
+func WithFilingThreshold(threshold float64) FilingOption {
+	return func(h *FilingHandler) {
+		h.threshold = threshold
+	}
+}
+
+// WithMaxAlternatives limits the alternatives listed in a low-confidence
+// comment (default 3)
+func WithMaxAlternatives(n int) FilingOption {
+	return func(h *FilingHandler) {
+		h.maxAlternatives = n
+	}
+}
+
+// WithTaxonomySource replaces the default taxonomy, which is rebuilt from
+// ListCollections on every command
+func WithTaxonomySource(source TaxonomySource) FilingOption {
+	return func(h *FilingHandler) {
+		h.taxonomy = source
+	}
+}
+
+// WithSearchTerms refreshes the search terms block of every document that
+// is filed, using the terms returned with the classification
+func WithSearchTerms(terms *SearchTerms) FilingOption {
+	return func(h *FilingHandler) {
+		h.searchTerms = terms
+	}
+}
+
+// FilingHandler handles /ai-file. Above the confidence threshold it moves
+// the document and removes the /ai-file and any ?ai-file markers; below it
+// the marker becomes ?ai-file and a comment asks the user for guidance.
+type FilingHandler struct {
+	ai              ai.Client
+	outline         outline.Client
+	taxonomy        TaxonomySource
+	searchTerms     *SearchTerms
+	threshold       float64
+	maxAlternatives int
+}
+
+// NewFilingHandler creates a FilingHandler
+func NewFilingHandler(aiClient ai.Client, outlineClient outline.Client, opts ...FilingOption) *FilingHandler {
+	h := &FilingHandler{
+		ai:              aiClient,
+		outline:         outlineClient,
+		taxonomy:        &collectionTaxonomy{client: outlineClient},
+		threshold:       defaultFilingThreshold,
+		maxAlternatives: defaultMaxAlternatives,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Handle classifies the document and files it or asks for guidance
+func (h *FilingHandler) Handle(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+	markers := append([]commands.Command{cmd}, cmd.Supersedes...)
+	content, err := removeCommands(doc.Text, markers...)
+	if err != nil {
+		return commands.Result{}, err
+	}
+
+	taxonomy, err := h.taxonomy.Taxonomy(ctx)
+	if err != nil {
+		return commands.Result{}, fmt.Errorf("handlers: failed to build taxonomy: %w", err)
+	}
+	if len(taxonomy.Collections) == 0 {
+		return commands.Result{}, ErrNoCollections
+	}
+
+	resp, err := h.ai.ClassifyDocument(ctx, &ai.ClassificationRequest{
+		DocumentTitle:   doc.Title,
+		DocumentContent: content,
+		UserGuidance:    cmd.Arguments,
+		Taxonomy:        taxonomy,
+	})
+	if err != nil {
+		return commands.Result{}, fmt.Errorf("handlers: classification failed: %w", err)
+	}
+	audit.AddReasoning(ctx, resp.Reasoning)
+
+	names := collectionNames(taxonomy)
+	target, known := names[resp.CollectionID]
+
+	// A collection the model made up cannot be filed into, however confident
+	if !known || resp.Confidence < h.threshold {
+		return h.askForGuidance(ctx, doc, cmd, resp, names)
+	}
+
+	// A retry after the markers failed to clear finds the document filed
+	if doc.CollectionID != resp.CollectionID {
+		if err := h.outline.MoveDocument(ctx, doc.ID, resp.CollectionID); err != nil {
+			return commands.Result{}, fmt.Errorf("handlers: failed to move document: %w", err)
+		}
+		doc.CollectionID = resp.CollectionID
+	}
+
+	// The document is filed; search terms are a bonus that must not fail it
+	message := "filed to " + target
+	if h.searchTerms != nil {
+		var err error
+		if content, err = h.withSearchTerms(ctx, doc, content, resp.SearchTerms); err != nil {
+			message += ", search terms not updated: " + err.Error()
+		}
+	}
+
+	if _, err := h.outline.UpdateDocument(ctx, doc.ID, &outline.UpdateDocumentRequest{Text: &content, Done: true}); err != nil {
+		return commands.Result{}, fmt.Errorf("handlers: failed to remove filing markers: %w", err)
+	}
+	doc.Text = content
+
+	summary := fmt.Sprintf("✓ Filed to %s (confidence: %s)", target, percent(resp.Confidence))
+	if cmd.Arguments != "" {
+		summary += " - Thank you for the guidance!"
+	}
+	if err := comment(ctx, h.outline, doc.ID, summary, "Reasoning: "+resp.Reasoning); err != nil {
+		return commands.Result{}, err
+	}
+
+	return commands.Result{Message: message}, nil
+}
+
+// withSearchTerms refreshes the search terms block in content, generating
+// terms when the classification returned none
+func (h *FilingHandler) withSearchTerms(ctx context.Context, doc *commands.Document, content string, terms []string) (string, error) {
+	if len(cleanTerms(terms)) == 0 {
+		var err error
+		if terms, err = h.searchTerms.Generate(ctx, doc.Title, content); err != nil {
+			return content, err
+		}
+	}
+	updated, _, _ := h.searchTerms.Apply(content, terms)
+	return updated, nil
+}
+
+// askForGuidance turns /ai-file into ?ai-file and explains the alternatives
+func (h *FilingHandler) askForGuidance(ctx context.Context, doc *commands.Document, cmd commands.Command, resp *ai.ClassificationResponse, names map[string]string) (commands.Result, error) {
+	line := strings.Replace(cmd.RawText, string(commands.CommandAIFile), string(commands.CommandAIFileUncertain), 1)
+	if _, own, _ := strings.Cut(cmd.RawText, string(commands.CommandAIFile)); strings.TrimSpace(own) == "" && cmd.Arguments != "" {
+		// Keep the guidance inherited from a superseded ?ai-file line
+		line = strings.TrimRight(line, " \t") + " " + cmd.Arguments
+	}
+
+	// Edit from the bottom up so earlier offsets stay valid
+	markers := append([]commands.Command{cmd}, cmd.Supersedes...)
+	sort.Slice(markers, func(i, j int) bool { return markers[i].Start > markers[j].Start })
+
+	text := doc.Text
+	for _, marker := range markers {
+		var err error
+		if marker.Type == commands.CommandAIFile {
+			text, err = replaceCommand(text, marker, line)
+		} else {
+			text, err = removeCommands(text, marker)
+		}
+		if err != nil {
+			return commands.Result{}, err
+		}
+	}
+
+	if _, err := h.outline.UpdateDocument(ctx, doc.ID, &outline.UpdateDocumentRequest{Text: &text, Done: true}); err != nil {
+		return commands.Result{}, fmt.Errorf("handlers: failed to mark filing as uncertain: %w", err)
+	}
+	doc.Text = text
+
+	paragraphs := []string{
+		fmt.Sprintf("⚠️ Unable to file with confidence (%s). Uncertain between:", percent(resp.Confidence)),
+		alternativeLine(names, resp.CollectionID, resp.Confidence, resp.Reasoning),
+	}
+	for i, alt := range resp.Alternatives {
+		if i == h.maxAlternatives {
+			break
+		}
+		paragraphs = append(paragraphs, alternativeLine(names, alt.CollectionID, alt.Confidence, alt.Reasoning))
+	}
+	paragraphs = append(paragraphs,
+		"To help me decide, change ?ai-file to /ai-file followed by guidance, for example: /ai-file engineering focus",
+	)
+
+	if err := comment(ctx, h.outline, doc.ID, paragraphs...); err != nil {
+		return commands.Result{}, err
+	}
+
+	return commands.Result{Message: "asked for filing guidance at " + percent(resp.Confidence)}, nil
+}
+
+func alternativeLine(names map[string]string, collectionID string, confidence float64, reasoning string) string {
+	name, ok := names[collectionID]
+	if !ok {
+		name = collectionID + " (unknown collection)"
+	}
+	return fmt.Sprintf("- %s (%s): %s", name, percent(confidence), reasoning)
+}
+
+func collectionNames(taxonomy *ai.TaxonomyContext) map[string]string {
+	names := make(map[string]string, len(taxonomy.Collections))
+	for _, c := range taxonomy.Collections {
+		names[c.ID] = c.Name
+	}
+	return names
+}
+
+// collectionTaxonomy builds the taxonomy from collection names and
+// descriptions alone
+type collectionTaxonomy struct {
+	client outline.Client
+}
+
+func (t *collectionTaxonomy) Taxonomy(ctx context.Context) (*ai.TaxonomyContext, error) {
+	collections, err := t.client.ListCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	taxonomy := &ai.TaxonomyContext{Collections: make([]ai.TaxonomyCollection, 0, len(collections))}
+	for _, c := range collections {
+		taxonomy.Collections = append(taxonomy.Collections, ai.TaxonomyCollection{
+			ID:          c.ID,
+			Name:        c.Name,
+			Description: c.Description,
+		})
+	}
+	return taxonomy, nil
+}
@@ -0,0 +1,241 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/audit"
+	"github.com/yourusername/outline-ai/internal/handlers"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+func newFilingEnv(t *testing.T, fixture string) *env {
+	t.Helper()
+	e := newEnv(t)
+
+	var resp ai.ClassificationResponse
+	readFixture(t, "ai_responses/"+fixture, &resp)
+	e.ai.SetClassificationResponse(&resp)
+
+	e.router.Register(commands.CommandAIFile, handlers.NewFilingHandler(e.ai, e.outline))
+	return e
+}
+
+func TestFilingHandler_HighConfidence(t *testing.T) {
+	e := newFilingEnv(t, "filing_high_confidence.json")
+	doc := e.addDocument(t, "technical_doc.json", "/ai-file backend API", "?ai-file engineering")
+	original := doc.Text
+
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+
+	if doc.CollectionID != "col-engineering-001" {
+		t.Errorf("Expected document in col-engineering-001, got %s", doc.CollectionID)
+	}
+	if strings.Contains(doc.Text, "ai-file") {
+		t.Errorf("Expected both markers removed, got:\n%s", doc.Text[:80])
+	}
+	if !strings.HasSuffix(original, doc.Text) {
+		t.Error("Expected the rest of the document to be untouched")
+	}
+
+	req := e.ai.GetLastCall("ClassifyDocument").(*ai.ClassificationRequest)
+	if req.UserGuidance != "backend API" {
+		t.Errorf("Expected guidance from the command, got %q", req.UserGuidance)
+	}
+	if len(req.Taxonomy.Collections) != 14 {
+		t.Errorf("Expected taxonomy from ListCollections, got %d collections", len(req.Taxonomy.Collections))
+	}
+	if strings.Contains(req.DocumentContent, "ai-file") {
+		t.Error("Expected markers to be stripped from the content sent to the model")
+	}
+
+	comments := e.comments(t, doc.ID)
+	if len(comments) != 1 || !strings.Contains(comments[0], "Filed to Engineering (confidence: 95%)") {
+		t.Errorf("Expected a filing comment, got %v", comments)
+	}
+
+	history, _ := e.storage.GetCommandHistory(context.Background(), doc.ID, 0)
+	if len(history) != 1 || history[0].Status != persistence.CommandStatusSuccess {
+		t.Errorf("Expected one successful command log entry, got %+v", history)
+	}
+}
+
+func TestFilingHandler_LowConfidence(t *testing.T) {
+	e := newFilingEnv(t, "filing_low_confidence.json")
+	doc := e.addDocument(t, "ambiguous_doc.json", "/ai-file")
+
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+
+	if doc.CollectionID != "col-inbox-001" {
+		t.Errorf("Expected document to stay in its collection, got %s", doc.CollectionID)
+	}
+	if got := e.outline.GetCallCount("MoveDocument"); got != 0 {
+		t.Errorf("Expected no move, got %d", got)
+	}
+	if !strings.HasPrefix(doc.Text, "?ai-file\n") {
+		t.Errorf("Expected /ai-file rewritten to ?ai-file, got %q", doc.Text[:20])
+	}
+
+	comments := e.comments(t, doc.ID)
+	if len(comments) != 1 {
+		t.Fatalf("Expected one comment, got %v", comments)
+	}
+	for _, want := range []string{"55%", "Engineering", "Product (42%)", "Customer Success (15%)", "SDK examples", "/ai-file"} {
+		if !strings.Contains(comments[0], want) {
+			t.Errorf("Expected comment to mention %q, got %s", want, comments[0])
+		}
+	}
+
+	// ?ai-file on its own waits for the user
+	if errs := e.run(t, doc.ID); len(errs) > 0 || e.ai.GetCallCount("ClassifyDocument") != 1 {
+		t.Errorf("Expected ?ai-file alone not to be processed, got %v", errs)
+	}
+}
+
+func TestFilingHandler_GuidanceLoop(t *testing.T) {
+	e := newFilingEnv(t, "filing_low_confidence.json")
+	doc := e.addDocument(t, "ambiguous_doc.json", "/ai-file mobile")
+
+	e.run(t, doc.ID)
+	if !strings.HasPrefix(doc.Text, "?ai-file mobile\n") {
+		t.Fatalf("Expected guidance kept on the ?ai-file line, got %q", doc.Text[:20])
+	}
+
+	// The user answers by adding a new /ai-file line below the old marker
+	answered := strings.Replace(doc.Text, "\n\n", "\n/ai-file product focus\n\n", 1)
+	e.outline.UpdateDocument(context.Background(), doc.ID, &outline.UpdateDocumentRequest{Text: &answered})
+
+	var high ai.ClassificationResponse
+	readFixture(t, "ai_responses/filing_high_confidence.json", &high)
+	high.CollectionID = "col-product-001"
+	e.ai.SetClassificationResponse(&high)
+
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	if doc.CollectionID != "col-product-001" {
+		t.Errorf("Expected document filed to Product, got %s", doc.CollectionID)
+	}
+	if strings.Contains(doc.Text, "ai-file") {
+		t.Errorf("Expected both markers removed, got %q", doc.Text[:40])
+	}
+	req := e.ai.GetLastCall("ClassifyDocument").(*ai.ClassificationRequest)
+	if req.UserGuidance != "product focus" {
+		t.Errorf("Expected the new guidance, got %q", req.UserGuidance)
+	}
+	if comments := e.comments(t, doc.ID); !strings.Contains(comments[len(comments)-1], "Thank you for the guidance") {
+		t.Errorf("Expected guidance acknowledged, got %v", comments)
+	}
+}
+
+func TestFilingHandler_UnknownCollection(t *testing.T) {
+	e := newFilingEnv(t, "filing_high_confidence.json")
+	e.ai.SetClassificationResponse(&ai.ClassificationResponse{CollectionID: "col-imaginary", Confidence: 0.99})
+	doc := e.addDocument(t, "technical_doc.json", "/ai-file")
+
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	if got := e.outline.GetCallCount("MoveDocument"); got != 0 {
+		t.Errorf("Expected no move into a made-up collection, got %d", got)
+	}
+	if !strings.HasPrefix(doc.Text, "?ai-file") {
+		t.Errorf("Expected the user to be asked for guidance, got %q", doc.Text[:20])
+	}
+}
+
+func TestFilingHandler_Failures(t *testing.T) {
+	t.Run("classification error leaves the marker", func(t *testing.T) {
+		e := newFilingEnv(t, "filing_high_confidence.json")
+		doc := e.addDocument(t, "technical_doc.json", "/ai-file")
+		e.ai.SetRateLimited(true)
+
+		errs := e.run(t, doc.ID)
+		if len(errs) != 1 || !errors.Is(errs[0], ai.ErrRateLimited) {
+			t.Fatalf("Expected ErrRateLimited, got %v", errs)
+		}
+		if !strings.HasPrefix(doc.Text, "/ai-file") {
+			t.Error("Expected /ai-file left for a retry")
+		}
+		history, _ := e.storage.GetCommandHistory(context.Background(), doc.ID, 1)
+		if len(history) != 1 || history[0].Status != persistence.CommandStatusRetrying {
+			t.Errorf("Expected a retrying log entry, got %+v", history)
+		}
+	})
+
+	t.Run("retry after the markers failed to clear", func(t *testing.T) {
+		e := newFilingEnv(t, "filing_high_confidence.json")
+		doc := e.addDocument(t, "technical_doc.json", "/ai-file")
+		doc.CollectionID = "col-inbox-001"
+		e.outline.SetUpdateDocumentError(outline.ErrServerError)
+
+		errs := e.run(t, doc.ID)
+		if len(errs) != 1 || !errors.Is(errs[0], outline.ErrServerError) {
+			t.Fatalf("Expected ErrServerError, got %v", errs)
+		}
+		if doc.CollectionID != "col-engineering-001" || !strings.HasPrefix(doc.Text, "/ai-file") {
+			t.Fatalf("Expected the document moved with /ai-file left, got %s %q", doc.CollectionID, doc.Text[:20])
+		}
+
+		e.outline.SetUpdateDocumentError(nil)
+		if errs := e.run(t, doc.ID); len(errs) > 0 {
+			t.Fatalf("Unexpected errors on retry: %v", errs)
+		}
+		if calls := e.outline.GetCallCount("MoveDocument"); calls != 1 {
+			t.Errorf("Expected the document to be moved once, got %d moves", calls)
+		}
+		if strings.Contains(doc.Text, "ai-file") {
+			t.Errorf("Expected the marker removed on retry, got %q", doc.Text[:20])
+		}
+		if comments := e.comments(t, doc.ID); len(comments) != 1 {
+			t.Errorf("Expected one filing comment, got %v", comments)
+		}
+	})
+
+	t.Run("no collections", func(t *testing.T) {
+		e := newFilingEnv(t, "filing_high_confidence.json")
+		e.outline.Reset()
+		doc := e.outline.AddDocument("doc-1", "", "Notes", "/ai-file")
+
+		errs := e.run(t, doc.ID)
+		if len(errs) != 1 || !errors.Is(errs[0], handlers.ErrNoCollections) {
+			t.Errorf("Expected ErrNoCollections, got %v", errs)
+		}
+	})
+}
+
+func TestFilingHandler_RecordsReasoning(t *testing.T) {
+	e := newEnv(t)
+	var resp ai.ClassificationResponse
+	readFixture(t, "ai_responses/filing_high_confidence.json", &resp)
+	e.ai.SetClassificationResponse(&resp)
+	e.router.Register(commands.CommandAIFile, handlers.NewFilingHandler(e.ai, audit.NewClient(e.outline, e.storage)))
+	doc := e.addDocument(t, "technical_doc.json", "/ai-file")
+	doc.CollectionID = "col-inbox-001"
+
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+
+	trail, _ := e.storage.GetAuditTrail(context.Background(), doc.ID, 0)
+	actions := make([]string, 0, len(trail))
+	for _, entry := range trail {
+		actions = append(actions, entry.Action)
+		if entry.Reasoning != resp.Reasoning {
+			t.Errorf("Expected the classification's reasoning on %s, got %q", entry.Action, entry.Reasoning)
+		}
+	}
+	want := []string{persistence.AuditActionComment, persistence.AuditActionUpdate, persistence.AuditActionMove}
+	if strings.Join(actions, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, actions)
+	}
+}
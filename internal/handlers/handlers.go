@@ -0,0 +1,93 @@
+// Package handlers implements the built-in commands: filing, question
+// answering, summaries, title enhancement, related documents and search
+// terms. Each handler satisfies commands.Handler and is registered on a
+// commands.Router.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+// Package-level errors for command handlers
+var (
+	ErrStaleCommand  = errors.New("handlers: command does not match document text")
+	ErrNoCollections = errors.New("handlers: no collections to file into")
+	ErrEmptyQuestion = errors.New("handlers: question is empty")
+	ErrEmptySummary  = errors.New("handlers: model returned an empty summary")
+)
+
+// removeCommands deletes the marker lines of cmds, together with their line
+// terminators, from text. The commands must have been detected in text.
+func removeCommands(text string, cmds ...commands.Command) (string, error) {
+	if err := checkOffsets(text, cmds...); err != nil {
+		return "", err
+	}
+
+	sorted := append([]commands.Command(nil), cmds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start > sorted[j].Start })
+
+	for _, cmd := range sorted {
+		start, end := cmd.Start, cmd.End
+		switch {
+		case end < len(text) && text[end] == '\r' && end+1 < len(text) && text[end+1] == '\n':
+			end += 2
+		case end < len(text) && text[end] == '\n':
+			end++
+		case start > 0 && text[start-1] == '\n':
+			// Last line: drop the terminator of the line before instead
+			start--
+			if start > 0 && text[start-1] == '\r' {
+				start--
+			}
+		}
+		text = text[:start] + text[end:]
+	}
+	return text, nil
+}
+
+// replaceCommand swaps the marker line of cmd for line
+func replaceCommand(text string, cmd commands.Command, line string) (string, error) {
+	if err := checkOffsets(text, cmd); err != nil {
+		return "", err
+	}
+	return text[:cmd.Start] + line + text[cmd.End:], nil
+}
+
+// checkOffsets guards against editing a document that changed after its
+// commands were detected
+func checkOffsets(text string, cmds ...commands.Command) error {
+	for _, cmd := range cmds {
+		if cmd.Start < 0 || cmd.End > len(text) || cmd.Start > cmd.End || text[cmd.Start:cmd.End] != cmd.RawText {
+			return fmt.Errorf("%w: %s on line %d", ErrStaleCommand, cmd.Type, cmd.Line)
+		}
+	}
+	return nil
+}
+
+// comment posts paragraphs as a comment on the document
+func comment(ctx context.Context, client outline.Client, documentID string, paragraphs ...string) error {
+	_, err := postComment(ctx, client, documentID, outline.NewCommentParagraphs(paragraphs...))
+	return err
+}
+
+func postComment(ctx context.Context, client outline.Client, documentID string, content outline.CommentContent) (*outline.Comment, error) {
+	created, err := client.CreateComment(ctx, &outline.CreateCommentRequest{
+		DocumentID: documentID,
+		Data:       content,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("handlers: failed to comment: %w", err)
+	}
+	return created, nil
+}
+
+// percent formats a 0-1 confidence for comments
+func percent(confidence float64) string {
+	return fmt.Sprintf("%.0f%%", confidence*100)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+func TestRemoveCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"first line", "/summarize\nBody", "Body"},
+		{"middle line", "Intro\n/summarize\nBody", "Intro\nBody"},
+		{"last line", "Body\n/summarize", "Body"},
+		{"only line", "/summarize", ""},
+		{"CRLF", "Intro\r\n/summarize\r\nBody\r\n/related", "Intro\r\nBody"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := removeCommands(tt.text, commands.Detect(tt.text)...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRemoveCommands_Stale(t *testing.T) {
+	cmds := commands.Detect("/summarize\nBody")
+	if _, err := removeCommands("Edited\n/summarize\nBody", cmds...); !errors.Is(err, ErrStaleCommand) {
+		t.Errorf("Expected ErrStaleCommand, got %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+const (
+	defaultContextDocs   = 5
+	defaultContextBudget = 2000
+	defaultExcerptTokens = 500
+	defaultMaxRetries    = 3
+	defaultLinkBase      = "outline://doc/"
+)
+
+var _ commands.Handler = (*QuestionHandler)(nil)
+
+// QuestionOption configures a QuestionHandler
+type QuestionOption func(*QuestionHandler)
+
+// WithContextDocs sets how many workspace documents are searched for
+// context (default 5)
+func WithContextDocs(n int) QuestionOption {
+	return func(h *QuestionHandler) {
+		h.contextDocs = n
+	}
+}
+
+// WithContextBudget sets the estimated token budget for all excerpts
+// together (default 2000) and for a single excerpt (default 500)
+func WithContextBudget(total, perDoc int) QuestionOption {
+	return func(h *QuestionHandler) {
+		h.budget = total
+		h.excerptTokens = perDoc
+	}
+}
+
+// WithMaxRetries sets how many failed attempts a question gets before it
+// is left alone (default 3)
+func WithMaxRetries(n int) QuestionOption {
+	return func(h *QuestionHandler) {
+		h.maxRetries = n
+	}
+}
+
+// WithQuestionLinkBase sets the prefix that turns a document ID into the
+// link used in citations (default "outline://doc/")
+func WithQuestionLinkBase(base string) QuestionOption {
+	return func(h *QuestionHandler) {
+		h.linkBase = base
+	}
+}
+
+// QuestionHandler handles /ai by answering from workspace documents in a
+// comment. The marker stays in the document; answered questions are
+// tracked in storage so they are not answered twice.
+type QuestionHandler struct {
+	ai      ai.Client
+	outline outline.Client
+	storage persistence.Storage
+
+	contextDocs   int
+	budget        int
+	excerptTokens int
+	maxRetries    int
+	linkBase      string
+}
+
+// NewQuestionHandler creates a QuestionHandler
+func NewQuestionHandler(aiClient ai.Client, outlineClient outline.Client, storage persistence.Storage, opts ...QuestionOption) *QuestionHandler {
+	h := &QuestionHandler{
+		ai:            aiClient,
+		outline:       outlineClient,
+		storage:       storage,
+		contextDocs:   defaultContextDocs,
+		budget:        defaultContextBudget,
+		excerptTokens: defaultExcerptTokens,
+		maxRetries:    defaultMaxRetries,
+		linkBase:      defaultLinkBase,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Handle answers the question unless it was already answered or has used
+// up its retries
+func (h *QuestionHandler) Handle(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+	question := cmd.Arguments
+	if NormalizeQuestion(question) == "" {
+		return commands.Result{}, ErrEmptyQuestion
+	}
+	hash := QuestionHash(doc.ID, question)
+
+	answered, err := h.storage.HasAnsweredQuestion(ctx, hash)
+	if err != nil {
+		return commands.Result{}, fmt.Errorf("handlers: failed to check question state: %w", err)
+	}
+	if answered {
+		return commands.Result{Message: "already answered"}, nil
+	}
+
+	state, err := h.storage.GetQuestionState(ctx, hash)
+	if err != nil && !errors.Is(err, persistence.ErrQuestionNotFound) {
+		return commands.Result{}, fmt.Errorf("handlers: failed to load question state: %w", err)
+	}
+	if state != nil && state.RetryCount >= h.maxRetries {
+		return commands.Result{Message: fmt.Sprintf("gave up after %d attempts", state.RetryCount)}, nil
+	}
+
+	commentID, err := h.answer(ctx, doc, question)
+	if err != nil {
+		if recordErr := h.recordFailure(ctx, hash, doc.ID, question, err); recordErr != nil {
+			return commands.Result{}, errors.Join(err, recordErr)
+		}
+		return commands.Result{}, err
+	}
+
+	if err := h.recordAnswer(ctx, hash, doc.ID, question, state, commentID); err != nil {
+		// The answer is already posted, so failing here would only repeat it
+		return commands.Result{Message: "answered, but not recorded: " + err.Error()}, nil
+	}
+	return commands.Result{Message: "answered"}, nil
+}
+
+// answer retrieves context, asks the model and posts the answer, returning
+// the comment ID
+func (h *QuestionHandler) answer(ctx context.Context, doc *commands.Document, question string) (string, error) {
+	keywords := Keywords(question)
+	if len(keywords) == 0 {
+		keywords = []string{NormalizeQuestion(question)}
+	}
+
+	docs, err := searchRelevant(ctx, h.outline, keywords, doc.ID, h.contextDocs)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := h.ai.AnswerQuestion(ctx, &ai.QuestionRequest{
+		Question:    question,
+		ContextDocs: buildContext(docs, keywords, h.budget, h.excerptTokens, h.linkBase),
+	})
+	if err != nil {
+		return "", fmt.Errorf("handlers: failed to answer question: %w", err)
+	}
+
+	created, err := postComment(ctx, h.outline, doc.ID, answerComment(question, resp))
+	if err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+func (h *QuestionHandler) recordAnswer(ctx context.Context, hash, documentID, question string, state *persistence.QuestionState, commentID string) error {
+	ctx = context.WithoutCancel(ctx)
+	if state == nil {
+		err := h.storage.MarkQuestionAnswered(ctx, &persistence.QuestionState{
+			QuestionHash: hash,
+			DocumentID:   documentID,
+			QuestionText: question,
+			CommentID:    &commentID,
+		})
+		if errors.Is(err, persistence.ErrDuplicateEntry) {
+			return nil
+		}
+		return err
+	}
+
+	state.AnswerDelivered = true
+	state.CommentID = &commentID
+	state.LastError = nil
+	state.ProcessedAt = time.Time{}
+	return h.storage.UpdateQuestionState(ctx, state)
+}
+
+// recordFailure stores the error and bumps the retry count
+func (h *QuestionHandler) recordFailure(ctx context.Context, hash, documentID, question string, cause error) error {
+	msg := cause.Error()
+	return h.storage.RecordQuestionFailure(context.WithoutCancel(ctx), &persistence.QuestionState{
+		QuestionHash: hash,
+		DocumentID:   documentID,
+		QuestionText: question,
+		LastError:    &msg,
+	})
+}
+
+// answerComment renders the answer with its citations as links
+func answerComment(question string, resp *ai.QuestionResponse) outline.CommentContent {
+	content := outline.CommentContent{Type: "doc"}
+	add := func(inline ...outline.ContentNode) {
+		content.Content = append(content.Content, outline.ParagraphNode(inline...))
+	}
+
+	add(outline.TextNode("Q: " + question))
+	for _, line := range strings.Split(resp.Answer, "\n") {
+		if line = strings.TrimRight(line, " \t"); line != "" {
+			add(outline.TextNode(line))
+		}
+	}
+
+	if len(resp.Citations) > 0 {
+		add(outline.TextNode("Sources:"))
+		for _, c := range resp.Citations {
+			title := c.DocumentTitle
+			if title == "" {
+				title = c.DocumentURL
+			}
+			if c.DocumentURL == "" {
+				add(outline.TextNode("- " + title))
+				continue
+			}
+			add(outline.TextNode("- "), outline.LinkNode(title, c.DocumentURL))
+		}
+	}
+
+	add(outline.TextNode("Confidence: " + percent(resp.Confidence)))
+	return content
+}
@@ -0,0 +1,163 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/handlers"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+const fixtureQuestion = "What is our current API rate limiting policy?"
+
+func newQuestionEnv(t *testing.T, opts ...handlers.QuestionOption) *env {
+	t.Helper()
+	e := newEnv(t)
+
+	var fixture struct {
+		Answer     string  `json:"answer"`
+		Confidence float64 `json:"confidence"`
+		Sources    []struct {
+			Title string `json:"title"`
+			URL   string `json:"url"`
+		} `json:"sources"`
+	}
+	readFixture(t, "ai_responses/qna_answer.json", &fixture)
+
+	resp := &ai.QuestionResponse{Answer: fixture.Answer, Confidence: fixture.Confidence}
+	for _, s := range fixture.Sources {
+		resp.Citations = append(resp.Citations, ai.CitationInfo{DocumentTitle: s.Title, DocumentURL: s.URL})
+	}
+	e.ai.SetQuestionResponse(resp)
+
+	e.addDocument(t, "technical_doc.json")
+	e.addDocument(t, "marketing_doc.json")
+	e.router.Register(commands.CommandAI, handlers.NewQuestionHandler(e.ai, e.outline, e.storage, opts...))
+	return e
+}
+
+func questionState(t *testing.T, e *env, documentID string) *persistence.QuestionState {
+	t.Helper()
+	state, err := e.storage.GetQuestionState(context.Background(), handlers.QuestionHash(documentID, fixtureQuestion))
+	if err != nil {
+		t.Fatalf("Expected question state, got %v", err)
+	}
+	return state
+}
+
+func TestQuestionHandler_Answers(t *testing.T) {
+	e := newQuestionEnv(t, handlers.WithContextBudget(400, 250))
+	doc := e.addDocument(t, "with_commands.json")
+
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+
+	req := e.ai.GetLastCall("AnswerQuestion").(*ai.QuestionRequest)
+	if req.Question != fixtureQuestion {
+		t.Errorf("Expected the question from the command, got %q", req.Question)
+	}
+	if len(req.ContextDocs) == 0 || req.ContextDocs[0].Title != "API Authentication & Rate Limiting" {
+		t.Fatalf("Expected the rate limiting document first in context, got %+v", req.ContextDocs)
+	}
+	tokens := 0
+	for _, c := range req.ContextDocs {
+		if c.Title == doc.Title {
+			t.Error("Expected the asking document to be excluded from its own context")
+		}
+		if !strings.HasPrefix(c.URL, "outline://doc/doc-") {
+			t.Errorf("Expected a document link, got %q", c.URL)
+		}
+		tokens += (utf8.RuneCountInString(c.Excerpt) + 3) / 4
+	}
+	if tokens > 400 {
+		t.Errorf("Expected excerpts within the 400 token budget, got about %d", tokens)
+	}
+
+	comments := e.comments(t, doc.ID)
+	if len(comments) != 1 {
+		t.Fatalf("Expected one answer comment, got %v", comments)
+	}
+	for _, want := range []string{"tiered rate limiting", "Sources:", "API Authentication & Rate Limiting", "Confidence: 92%"} {
+		if !strings.Contains(comments[0], want) {
+			t.Errorf("Expected answer comment to contain %q", want)
+		}
+	}
+
+	state := questionState(t, e, doc.ID)
+	if !state.AnswerDelivered || state.CommentID == nil || *state.CommentID == "" {
+		t.Errorf("Expected delivered answer with its comment ID, got %+v", state)
+	}
+	if !strings.Contains(doc.Text, "/ai "+fixtureQuestion) {
+		t.Error("Expected the question to stay in the document")
+	}
+
+	// The marker is still there, but the question is not answered twice
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	if got := e.ai.GetCallCount("AnswerQuestion"); got != 1 {
+		t.Errorf("Expected one AnswerQuestion call, got %d", got)
+	}
+}
+
+func TestQuestionHandler_RetriesFailures(t *testing.T) {
+	e := newQuestionEnv(t, handlers.WithMaxRetries(2))
+	doc := e.addDocument(t, "with_commands.json")
+
+	e.ai.SetTimeoutError(true)
+	for attempt := 1; attempt <= 2; attempt++ {
+		errs := e.run(t, doc.ID)
+		if len(errs) != 1 || !errors.Is(errs[0], ai.ErrTimeout) {
+			t.Fatalf("Attempt %d: expected ErrTimeout, got %v", attempt, errs)
+		}
+
+		state := questionState(t, e, doc.ID)
+		if state.AnswerDelivered || state.RetryCount != attempt || state.LastError == nil {
+			t.Errorf("Attempt %d: expected undelivered state with retry count %d, got %+v", attempt, attempt, state)
+		}
+	}
+
+	// Retries are used up, so the question is left alone
+	e.ai.SetTimeoutError(false)
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	if got := e.ai.GetCallCount("AnswerQuestion"); got != 2 {
+		t.Errorf("Expected no further attempts, got %d calls", got)
+	}
+}
+
+func TestQuestionHandler_SucceedsAfterFailure(t *testing.T) {
+	e := newQuestionEnv(t)
+	doc := e.addDocument(t, "with_commands.json")
+
+	e.outline.SetCreateCommentError(errors.New("outline: server error"))
+	if errs := e.run(t, doc.ID); len(errs) != 1 {
+		t.Fatalf("Expected the comment failure, got %v", errs)
+	}
+	e.outline.SetCreateCommentError(nil)
+
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	state := questionState(t, e, doc.ID)
+	if !state.AnswerDelivered || state.LastError != nil || state.RetryCount != 1 {
+		t.Errorf("Expected delivered answer with the earlier failure counted, got %+v", state)
+	}
+}
+
+func TestQuestionHandler_EmptyQuestion(t *testing.T) {
+	e := newQuestionEnv(t)
+	doc := e.outline.AddDocument("doc-empty", "col-engineering-001", "Notes", "/ai ?")
+
+	errs := e.run(t, doc.ID)
+	if len(errs) != 1 || !errors.Is(errs[0], handlers.ErrEmptyQuestion) {
+		t.Errorf("Expected ErrEmptyQuestion, got %v", errs)
+	}
+}
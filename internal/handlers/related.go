@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+const (
+	defaultRelatedCandidates = 30
+	defaultMaxRelated        = 5
+)
+
+var relatedBlock = newMarkerBlock("AI-RELATED")
+
+var _ commands.Handler = (*RelatedHandler)(nil)
+
+// RelatedOption configures a RelatedHandler
+type RelatedOption func(*RelatedHandler)
+
+// WithRelatedCandidates sets how many documents the model chooses from
+// (default 30)
+func WithRelatedCandidates(n int) RelatedOption {
+	return func(h *RelatedHandler) {
+		h.candidates = n
+	}
+}
+
+// WithMaxRelated sets how many related documents are linked (default 5)
+func WithMaxRelated(n int) RelatedOption {
+	return func(h *RelatedHandler) {
+		h.maxRelated = n
+	}
+}
+
+// WithRelatedLinkBase sets the prefix that turns a document ID into a link
+// (default "outline://doc/")
+func WithRelatedLinkBase(base string) RelatedOption {
+	return func(h *RelatedHandler) {
+		h.linkBase = base
+	}
+}
+
+// RelatedHandler handles /related by linking similar documents in an
+// AI-RELATED block at the end of the document, above any search terms.
+// Candidates come from a search on the document's keywords and from its
+// own collection; the model only picks among them, and picks that match no
+// candidate are dropped.
+type RelatedHandler struct {
+	ai      ai.Client
+	outline outline.Client
+
+	candidates int
+	maxRelated int
+	linkBase   string
+}
+
+// NewRelatedHandler creates a RelatedHandler
+func NewRelatedHandler(aiClient ai.Client, outlineClient outline.Client, opts ...RelatedOption) *RelatedHandler {
+	h := &RelatedHandler{
+		ai:         aiClient,
+		outline:    outlineClient,
+		candidates: defaultRelatedCandidates,
+		maxRelated: defaultMaxRelated,
+		linkBase:   defaultLinkBase,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Handle finds related documents and writes them into the related block
+func (h *RelatedHandler) Handle(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+	text, err := removeCommands(doc.Text, cmd)
+	if err != nil {
+		return commands.Result{}, err
+	}
+	content, hadBlock := relatedBlock.remove(text)
+
+	candidates, err := h.findCandidates(ctx, doc, content)
+	if err != nil {
+		return commands.Result{}, err
+	}
+
+	var related []relatedLink
+	dropped := 0
+	if len(candidates) > 0 {
+		titles := make([]string, len(candidates))
+		for i, c := range candidates {
+			titles[i] = c.Title
+		}
+		resp, err := h.ai.FindRelatedDocuments(ctx, &ai.RelatedDocsRequest{
+			DocumentTitle:   doc.Title,
+			DocumentContent: content,
+			AvailableDocs:   titles,
+		})
+		if err != nil {
+			return commands.Result{}, fmt.Errorf("handlers: failed to find related documents: %w", err)
+		}
+		related, dropped = resolveRelated(resp.RelatedDocuments, candidates, h.maxRelated)
+	}
+
+	if len(related) == 0 {
+		// Links from an earlier run would contradict the comment
+		if _, err := h.outline.UpdateDocument(ctx, doc.ID, &outline.UpdateDocumentRequest{Text: &content, Done: true}); err != nil {
+			return commands.Result{}, fmt.Errorf("handlers: failed to remove related marker: %w", err)
+		}
+		doc.Text = content
+		message := "ℹ️ No related documents found"
+		if hadBlock {
+			message += ", so the previous links were removed"
+		}
+		if err := comment(ctx, h.outline, doc.ID, message); err != nil {
+			return commands.Result{}, err
+		}
+		return commands.Result{Message: relatedMessage(0, dropped)}, nil
+	}
+
+	nl := newline(text)
+	body := h.relatedBody(related, nl)
+	updated, found := relatedBlock.replace(text, body)
+	if !found {
+		updated = appendSection(text, relatedBlock.render(body, nl), nl)
+	}
+
+	if _, err := h.outline.UpdateDocument(ctx, doc.ID, &outline.UpdateDocumentRequest{Text: &updated, Done: true}); err != nil {
+		return commands.Result{}, fmt.Errorf("handlers: failed to update related documents: %w", err)
+	}
+	doc.Text = updated
+	return commands.Result{Message: relatedMessage(len(related), dropped)}, nil
+}
+
+// findCandidates gathers up to h.candidates other documents, search matches
+// first and then the rest of the collection, with distinct titles so each
+// can be resolved from the model's answer
+func (h *RelatedHandler) findCandidates(ctx context.Context, doc *commands.Document, content string) ([]*outline.Document, error) {
+	keywords := Keywords(doc.Title)
+	if len(keywords) == 0 {
+		keywords = Keywords(content)
+	}
+	keywords = keywords[:min(len(keywords), maxSearchKeywords)]
+
+	var found []*outline.Document
+	if len(keywords) > 0 {
+		matches, err := searchRelevant(ctx, h.outline, keywords, doc.ID, h.candidates)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, matches...)
+	}
+
+	if doc.CollectionID != "" {
+		siblings, err := h.outline.ListDocuments(ctx, doc.CollectionID)
+		if err != nil && !errors.Is(err, outline.ErrNotFound) {
+			return nil, fmt.Errorf("handlers: failed to list collection documents: %w", err)
+		}
+		sort.Slice(siblings, func(i, j int) bool {
+			if !siblings[i].UpdatedAt.Equal(siblings[j].UpdatedAt) {
+				return siblings[i].UpdatedAt.After(siblings[j].UpdatedAt)
+			}
+			return siblings[i].Title < siblings[j].Title
+		})
+		found = append(found, siblings...)
+	}
+
+	seen := map[string]bool{titleKey(doc.Title): true}
+	candidates := make([]*outline.Document, 0, min(len(found), h.candidates))
+	for _, c := range found {
+		key := titleKey(c.Title)
+		if c.ID == doc.ID || key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		candidates = append(candidates, c)
+		if len(candidates) == h.candidates {
+			break
+		}
+	}
+	return candidates, nil
+}
+
+// relatedLink is a related document resolved to a real one
+type relatedLink struct {
+	doc       *outline.Document
+	relevance float64
+}
+
+// resolveRelated maps the model's titles back to candidates, most relevant
+// first, counting the titles that match none
+func resolveRelated(picks []ai.RelatedDocument, candidates []*outline.Document, limit int) (links []relatedLink, dropped int) {
+	byTitle := make(map[string]*outline.Document, len(candidates))
+	for _, c := range candidates {
+		byTitle[titleKey(c.Title)] = c
+	}
+
+	linked := make(map[string]bool)
+	for _, pick := range picks {
+		doc, ok := byTitle[titleKey(pick.Title)]
+		if !ok {
+			dropped++
+			continue
+		}
+		if linked[doc.ID] {
+			continue
+		}
+		linked[doc.ID] = true
+		links = append(links, relatedLink{doc: doc, relevance: pick.Relevance})
+	}
+
+	sort.SliceStable(links, func(i, j int) bool { return links[i].relevance > links[j].relevance })
+	if len(links) > limit {
+		links = links[:limit]
+	}
+	return links, dropped
+}
+
+// titleKey normalizes a title for matching the model's answer
+func titleKey(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+func (h *RelatedHandler) relatedBody(links []relatedLink, nl string) string {
+	lines := []string{"**Related Documents:**"}
+	for _, link := range links {
+		lines = append(lines, fmt.Sprintf("- [%s](%s)", escapeLinkText(link.doc.Title), h.linkBase+link.doc.ID))
+	}
+	return strings.Join(lines, nl)
+}
+
+// escapeLinkText keeps brackets in a title from ending the link text
+func escapeLinkText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(text)
+}
+
+func relatedMessage(linked, dropped int) string {
+	message := fmt.Sprintf("linked %d related documents", linked)
+	if dropped > 0 {
+		message += fmt.Sprintf(", dropped %d unknown titles", dropped)
+	}
+	return message
+}
@@ -0,0 +1,90 @@
+package handlers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/handlers"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+func newRelatedEnv(t *testing.T, related ...ai.RelatedDocument) (*env, *outline.Document) {
+	t.Helper()
+	e := newEnv(t)
+	e.ai.SetRelatedDocsResponse(&ai.RelatedDocsResponse{RelatedDocuments: related})
+	e.router.Register(commands.CommandRelated, handlers.NewRelatedHandler(e.ai, e.outline))
+
+	for _, name := range []string{"marketing_doc.json", "ambiguous_doc.json", "with_commands.json", "with_existing_summary.json"} {
+		e.addDocument(t, name)
+	}
+	return e, e.addDocument(t, "technical_doc.json")
+}
+
+func TestRelatedHandler_LinksResolvedDocuments(t *testing.T) {
+	e, doc := newRelatedEnv(t,
+		ai.RelatedDocument{Title: "Database Migration Strategy", Relevance: 0.6},
+		ai.RelatedDocument{Title: "API Design Guidelines - DRAFT", Relevance: 0.9},
+		ai.RelatedDocument{Title: "OAuth2 Implementation Guide", Relevance: 0.8},
+		ai.RelatedDocument{Title: "mobile app  API documentation", Relevance: 0.7},
+	)
+	original := doc.Text
+
+	text := e.runAppended(t, doc, "/related")
+
+	want := original + "\n\n<!-- AI-RELATED-START -->\n**Related Documents:**\n" +
+		"- [API Design Guidelines - DRAFT](outline://doc/doc-with-commands-001)\n" +
+		"- [Mobile App API Documentation](outline://doc/doc-ambiguous-mobile-api-001)\n" +
+		"- [Database Migration Strategy](outline://doc/doc-with-summary-001)\n" +
+		"<!-- AI-RELATED-END -->"
+	if text != want {
+		t.Errorf("Expected related block ordered by relevance without unknown titles, got:\n%s", text[len(original):])
+	}
+
+	req := e.ai.GetLastCall("FindRelatedDocuments").(*ai.RelatedDocsRequest)
+	available := strings.Join(req.AvailableDocs, "|")
+	for _, title := range []string{"Mobile App API Documentation", "Database Migration Strategy"} {
+		if !strings.Contains(available, title) {
+			t.Errorf("Expected %q among the available documents, got %v", title, req.AvailableDocs)
+		}
+	}
+	if strings.Contains(available, doc.Title) {
+		t.Error("Expected the document itself to be left out of the candidates")
+	}
+
+	if again := e.runAppended(t, doc, "/related"); again != text {
+		t.Errorf("Expected a second run to replace the block in place, got:\n%s", again[len(original):])
+	}
+}
+
+func TestRelatedHandler_NothingFound(t *testing.T) {
+	e, doc := newRelatedEnv(t, ai.RelatedDocument{Title: "Made Up", Relevance: 0.9})
+	original := doc.Text
+
+	if text := e.runAppended(t, doc, "/related"); text != original {
+		t.Errorf("Expected only the marker removed, got:\n%s", text)
+	}
+	comments := e.comments(t, doc.ID)
+	if len(comments) != 1 || !strings.Contains(comments[0], "No related documents found") {
+		t.Errorf("Expected a comment saying nothing was found, got %v", comments)
+	}
+}
+
+func TestRelatedHandler_NothingFoundRemovesOldLinks(t *testing.T) {
+	e, doc := newRelatedEnv(t, ai.RelatedDocument{Title: "API Design Guidelines - DRAFT", Relevance: 0.9})
+	original := doc.Text
+
+	if text := e.runAppended(t, doc, "/related"); !strings.Contains(text, "AI-RELATED-START") {
+		t.Fatalf("Expected a related block from the first run, got:\n%s", text[len(original):])
+	}
+
+	e.ai.SetRelatedDocsResponse(&ai.RelatedDocsResponse{RelatedDocuments: []ai.RelatedDocument{{Title: "Made Up", Relevance: 0.9}}})
+	if text := e.runAppended(t, doc, "/related"); text != original {
+		t.Errorf("Expected the stale block removed with the marker, got:\n%s", text[len(original):])
+	}
+	comments := e.comments(t, doc.ID)
+	if len(comments) != 1 || !strings.Contains(comments[0], "previous links were removed") {
+		t.Errorf("Expected a comment saying the old links were removed, got %v", comments)
+	}
+}
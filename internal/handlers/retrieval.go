@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/outline"
+)
+
+// maxSearchKeywords bounds the per-keyword searches made for one question
+const maxSearchKeywords = 5
+
+// minExcerptTokens is the smallest excerpt worth sending to the model
+const minExcerptTokens = 50
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "but": true, "not": true,
+	"you": true, "all": true, "any": true, "can": true, "had": true, "her": true,
+	"was": true, "one": true, "our": true, "out": true, "has": true, "have": true,
+	"how": true, "its": true, "who": true, "why": true, "what": true, "when": true,
+	"where": true, "which": true, "with": true, "this": true, "that": true,
+	"from": true, "they": true, "them": true, "their": true, "there": true,
+	"does": true, "did": true, "should": true, "would": true, "could": true,
+	"about": true, "into": true, "your": true, "some": true, "many": true,
+	"much": true, "will": true, "been": true, "were": true, "than": true,
+	"then": true, "also": true, "just": true, "current": true, "currently": true,
+}
+
+// NormalizeQuestion lowercases a question, collapses whitespace and drops
+// surrounding punctuation so trivial edits do not count as a new question
+func NormalizeQuestion(question string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(question)), " ")
+	return strings.TrimFunc(normalized, unicode.IsPunct)
+}
+
+// QuestionHash identifies a question within a document for deduplication
+func QuestionHash(documentID, question string) string {
+	sum := sha256.Sum256([]byte(documentID + ":" + NormalizeQuestion(question)))
+	return fmt.Sprintf("%x", sum)
+}
+
+// Keywords extracts the distinct search words of a question, in order,
+// without stop words or words shorter than three letters
+func Keywords(question string) []string {
+	words := strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+
+	seen := make(map[string]bool, len(words))
+	var keywords []string
+	for _, word := range words {
+		word = strings.Trim(word, "-")
+		if utf8.RuneCountInString(word) < 3 || stopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		keywords = append(keywords, word)
+	}
+	return keywords
+}
+
+// estimateTokens approximates the token count of text at four characters
+// per token, which is close enough for budgeting prompts
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// searchRelevant finds documents matching the keywords, excluding
+// excludeID, ranked by how many keywords each contains
+func searchRelevant(ctx context.Context, client outline.Client, keywords []string, excludeID string, limit int) ([]*outline.Document, error) {
+	queries := []string{strings.Join(keywords, " ")}
+	if len(keywords) > 1 {
+		// Outline matches the whole query, so also try the words on their own
+		queries = append(queries, keywords[:min(len(keywords), maxSearchKeywords)]...)
+	}
+
+	found := make(map[string]*outline.Document)
+	for _, query := range queries {
+		result, err := client.SearchDocuments(ctx, query, &outline.SearchOptions{Limit: limit + 1})
+		if err != nil {
+			return nil, fmt.Errorf("handlers: search failed: %w", err)
+		}
+		for _, doc := range result.Documents {
+			if doc.ID != excludeID {
+				found[doc.ID] = doc
+			}
+		}
+	}
+
+	type scored struct {
+		doc   *outline.Document
+		score int
+	}
+	ranked := make([]scored, 0, len(found))
+	for _, doc := range found {
+		ranked = append(ranked, scored{doc: doc, score: keywordScore(doc.Title+"\n"+doc.Text, keywords)})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].doc.Title < ranked[j].doc.Title
+	})
+
+	docs := make([]*outline.Document, 0, min(len(ranked), limit))
+	for i := 0; i < len(ranked) && i < limit; i++ {
+		docs = append(docs, ranked[i].doc)
+	}
+	return docs, nil
+}
+
+// keywordScore counts the distinct keywords that occur in text
+func keywordScore(text string, keywords []string) int {
+	lower := strings.ToLower(text)
+	score := 0
+	for _, k := range keywords {
+		if strings.Contains(lower, k) {
+			score++
+		}
+	}
+	return score
+}
+
+// buildContext turns documents into excerpts that fit within budget tokens
+// in total and perDoc tokens each, dropping documents once the budget runs out
+func buildContext(docs []*outline.Document, keywords []string, budget, perDoc int, linkBase string) []ai.ContextDocument {
+	contextDocs := make([]ai.ContextDocument, 0, len(docs))
+	for _, doc := range docs {
+		allowance := min(perDoc, budget)
+		if allowance < minExcerptTokens {
+			break
+		}
+
+		text := excerpt(doc.Text, keywords, allowance)
+		if text == "" {
+			continue
+		}
+		budget -= estimateTokens(text)
+
+		contextDocs = append(contextDocs, ai.ContextDocument{
+			Title:   doc.Title,
+			Excerpt: text,
+			URL:     linkBase + doc.ID,
+		})
+	}
+	return contextDocs
+}
+
+// excerpt picks the paragraphs of text that mention the most keywords, kept
+// in document order, until maxTokens is reached
+func excerpt(text string, keywords []string, maxTokens int) string {
+	var paragraphs []string
+	for _, p := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	if len(paragraphs) == 0 {
+		return ""
+	}
+
+	order := make([]int, len(paragraphs))
+	for i := range order {
+		order[i] = i
+	}
+	scores := make([]int, len(paragraphs))
+	for i, p := range paragraphs {
+		scores[i] = keywordScore(p, keywords)
+	}
+	// Stable, so paragraphs without keywords keep their order as filler
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	chosen := make([]bool, len(paragraphs))
+	remaining := maxTokens
+	for _, i := range order {
+		cost := estimateTokens(paragraphs[i]) + 1
+		if cost <= remaining {
+			chosen[i] = true
+			remaining -= cost
+		}
+	}
+
+	var selected []string
+	for i, p := range paragraphs {
+		if chosen[i] {
+			selected = append(selected, p)
+		}
+	}
+	if len(selected) == 0 {
+		// Even the best paragraph is too long, so cut it down
+		return truncateRunes(paragraphs[order[0]], maxTokens*4-3) + "..."
+	}
+	return strings.Join(selected, "\n\n")
+}
+
+func truncateRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+)
+
+func TestKeywords(t *testing.T) {
+	tests := []struct {
+		question string
+		want     []string
+	}{
+		{"What is our current API rate limiting policy?", []string{"api", "rate", "limiting", "policy"}},
+		{"How do we deploy to staging? Deploy steps!", []string{"deploy", "staging", "steps"}},
+		{"Où est la réunion d'équipe?", []string{"est", "réunion", "équipe"}},
+		{"Is it up?", nil},
+	}
+
+	for _, tt := range tests {
+		if got := Keywords(tt.question); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Keywords(%q): expected %v, got %v", tt.question, tt.want, got)
+		}
+	}
+}
+
+func TestQuestionHash(t *testing.T) {
+	base := QuestionHash("doc-1", "What is the policy?")
+
+	if QuestionHash("doc-1", "  what IS the   policy ") != base {
+		t.Error("Expected case, spacing and trailing punctuation to be ignored")
+	}
+	if QuestionHash("doc-2", "What is the policy?") == base {
+		t.Error("Expected the same question in another document to hash differently")
+	}
+	if QuestionHash("doc-1", "What is the budget?") == base {
+		t.Error("Expected a different question to hash differently")
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	text := strings.Join([]string{
+		"# Intro",
+		"General background that says nothing useful.",
+		"Rate limits are enforced per user with a token bucket.",
+		strings.Repeat("Filler text. ", 40),
+		"Exceeding the rate limit returns HTTP 429.",
+	}, "\n\n")
+	keywords := []string{"rate", "limit"}
+
+	got := excerpt(text, keywords, 40)
+	if !strings.Contains(got, "token bucket") || !strings.Contains(got, "HTTP 429") {
+		t.Errorf("Expected keyword paragraphs to be chosen, got %q", got)
+	}
+	if strings.Contains(got, "Filler") {
+		t.Error("Expected the long filler paragraph to be left out")
+	}
+	if strings.Index(got, "token bucket") > strings.Index(got, "HTTP 429") {
+		t.Error("Expected chosen paragraphs to keep document order")
+	}
+	if tokens := estimateTokens(got); tokens > 40 {
+		t.Errorf("Expected at most 40 tokens, got %d", tokens)
+	}
+
+	if got := excerpt(strings.Repeat("rate ", 200), keywords, 20); estimateTokens(got) > 20 || !strings.HasSuffix(got, "...") {
+		t.Errorf("Expected an oversized paragraph to be truncated to the budget, got %d tokens", estimateTokens(got))
+	}
+}
+
+func TestAnswerComment(t *testing.T) {
+	content := answerComment("What is the policy?", &ai.QuestionResponse{
+		Answer:     "Line one\n\nLine two",
+		Confidence: 0.9,
+		Citations: []ai.CitationInfo{
+			{DocumentTitle: "Rate Limits", DocumentURL: "https://wiki.example.com/doc/abc"},
+			{DocumentTitle: "Unlinked"},
+		},
+	})
+
+	var link *struct{ text, href string }
+	for _, p := range content.Content {
+		for _, node := range p.Content {
+			for _, mark := range node.Marks {
+				if mark.Type == "link" {
+					link = &struct{ text, href string }{node.Text, mark.Attrs["href"]}
+				}
+			}
+		}
+	}
+	if link == nil || link.text != "Rate Limits" || link.href != "https://wiki.example.com/doc/abc" {
+		t.Errorf("Expected citation rendered as a link, got %+v", link)
+	}
+
+	plain := content.PlainText()
+	for _, want := range []string{"Q: What is the policy?", "Line one\nLine two", "- Unlinked", "Confidence: 90%"} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("Expected %q in comment, got:\n%s", want, plain)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+const (
+	defaultMaxSearchTerms = 10
+
+	// searchTermsPrefix starts the line listing the terms
+	searchTermsPrefix = "**Search Terms**:"
+
+	// generatedPrefix starts the hidden line recording which terms the
+	// assistant wrote, so the others can be told apart as the user's
+	generatedPrefix = "<!-- generated:"
+
+	searchTermsSeparator = "---"
+)
+
+var searchTermsBlock = newMarkerBlock("AI-SEARCH-TERMS")
+
+var _ commands.Handler = (*SearchTermsHandler)(nil)
+
+// SearchTermsOption configures SearchTerms
+type SearchTermsOption func(*SearchTerms)
+
+// WithMaxSearchTerms limits how many generated terms are written; terms
+// the user added are always kept (default 10)
+func WithMaxSearchTerms(n int) SearchTermsOption {
+	return func(s *SearchTerms) {
+		s.maxTerms = n
+	}
+}
+
+// SearchTerms maintains the AI-SEARCH-TERMS block at the end of a document,
+// below a --- separator. Refreshing replaces the generated terms and keeps
+// any the user typed into the list. A "**Search Terms**:" line without
+// markers belongs to the user and is left alone.
+type SearchTerms struct {
+	ai       ai.Client
+	maxTerms int
+}
+
+// NewSearchTerms creates SearchTerms generating with aiClient
+func NewSearchTerms(aiClient ai.Client, opts ...SearchTermsOption) *SearchTerms {
+	s := &SearchTerms{
+		ai:       aiClient,
+		maxTerms: defaultMaxSearchTerms,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Generate asks the model for terms describing the document. The existing
+// block is left out of the content so old terms do not feed new ones.
+func (s *SearchTerms) Generate(ctx context.Context, title, text string) ([]string, error) {
+	content := text
+	if start, _, end, ok := findSearchTerms(text); ok {
+		content = text[:start] + text[end:]
+	}
+	resp, err := s.ai.GenerateSearchTerms(ctx, &ai.SearchTermsRequest{
+		DocumentTitle:   title,
+		DocumentContent: content,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("handlers: failed to generate search terms: %w", err)
+	}
+	return resp.SearchTerms, nil
+}
+
+// Apply returns text with the block holding terms plus the terms the user
+// added by hand, and how many of those were kept. The block is moved to
+// the end if text was added below it. ok is false, and text is returned
+// unchanged, when the user owns the search terms or there are none.
+func (s *SearchTerms) Apply(text string, terms []string) (updated string, kept int, ok bool) {
+	start, blockStart, end, found := findSearchTerms(text)
+	var handAdded []string
+	if found {
+		handAdded = userTerms(text[blockStart:end])
+	} else if hasUnmarkedSearchTerms(text) {
+		return text, 0, false
+	}
+
+	generated := cleanTerms(terms)
+	if len(generated) > s.maxTerms {
+		generated = generated[:s.maxTerms]
+	}
+	all, kept := mergeTerms(generated, handAdded)
+	if len(all) == 0 {
+		return text, 0, false
+	}
+
+	nl := newline(text)
+	body := searchTermsPrefix + " " + strings.Join(all, ", ")
+	if len(generated) > 0 {
+		body += nl + generatedPrefix + " " + strings.Join(generated, ", ") + " -->"
+	}
+
+	rest := text
+	if found {
+		rest = text[:start] + strings.TrimLeft(text[end:], "\r\n")
+	}
+	rest = strings.TrimRightFunc(rest, unicode.IsSpace)
+	if rest != "" {
+		rest += nl + nl + searchTermsSeparator + nl + nl
+	}
+	return rest + searchTermsBlock.render(body, nl), kept, true
+}
+
+// SearchTermsHandler handles /search-terms by refreshing the search terms
+// block on demand
+type SearchTermsHandler struct {
+	terms   *SearchTerms
+	outline outline.Client
+}
+
+// NewSearchTermsHandler creates a SearchTermsHandler
+func NewSearchTermsHandler(aiClient ai.Client, outlineClient outline.Client, opts ...SearchTermsOption) *SearchTermsHandler {
+	return &SearchTermsHandler{
+		terms:   NewSearchTerms(aiClient, opts...),
+		outline: outlineClient,
+	}
+}
+
+// Handle generates terms and writes them into the block
+func (h *SearchTermsHandler) Handle(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+	text, err := removeCommands(doc.Text, cmd)
+	if err != nil {
+		return commands.Result{}, err
+	}
+
+	if _, _, _, found := findSearchTerms(text); !found && hasUnmarkedSearchTerms(text) {
+		if err := h.update(ctx, doc, text); err != nil {
+			return commands.Result{}, err
+		}
+		if err := comment(ctx, h.outline, doc.ID,
+			"ℹ️ Search terms not updated - markers removed (respecting your edits)",
+			"To get AI search terms again, delete the Search Terms line and run /search-terms.",
+		); err != nil {
+			return commands.Result{}, err
+		}
+		return commands.Result{Message: "search terms not updated: markers removed"}, nil
+	}
+
+	terms, err := h.terms.Generate(ctx, doc.Title, text)
+	if err != nil {
+		return commands.Result{}, err
+	}
+	updated, kept, ok := h.terms.Apply(text, terms)
+	if err := h.update(ctx, doc, updated); err != nil {
+		return commands.Result{}, err
+	}
+	if !ok {
+		return commands.Result{Message: "no usable search terms generated"}, nil
+	}
+	return commands.Result{Message: searchTermsMessage(kept)}, nil
+}
+
+func (h *SearchTermsHandler) update(ctx context.Context, doc *commands.Document, text string) error {
+	if _, err := h.outline.UpdateDocument(ctx, doc.ID, &outline.UpdateDocumentRequest{Text: &text, Done: true}); err != nil {
+		return fmt.Errorf("handlers: failed to update search terms: %w", err)
+	}
+	doc.Text = text
+	return nil
+}
+
+func searchTermsMessage(kept int) string {
+	if kept == 0 {
+		return "search terms updated"
+	}
+	return fmt.Sprintf("search terms updated, kept %d added by hand", kept)
+}
+
+// findSearchTerms locates the block. start includes the --- separator
+// above it when there is one; blockStart is the start marker and end the
+// offset just past the end marker.
+func findSearchTerms(text string) (start, blockStart, end int, ok bool) {
+	blockStart, end, ok = searchTermsBlock.find(text)
+	if !ok {
+		return -1, -1, -1, false
+	}
+
+	start = blockStart
+	lines := scanLines(text[:blockStart])
+	for i := len(lines) - 1; i >= 0; i-- {
+		ln := lines[i]
+		content := strings.TrimSpace(text[ln.start:ln.end])
+		if content == "" {
+			continue
+		}
+		if content == searchTermsSeparator && !ln.fenced {
+			start = ln.start
+		}
+		break
+	}
+	return start, blockStart, end, true
+}
+
+// hasUnmarkedSearchTerms reports whether text lists search terms outside
+// a block, which happens when the user removed the markers
+func hasUnmarkedSearchTerms(text string) bool {
+	for _, ln := range scanLines(text) {
+		if !ln.fenced && strings.HasPrefix(lineText(text, ln), searchTermsPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// userTerms returns the terms in block that the assistant did not generate
+func userTerms(block string) []string {
+	var listed, generated []string
+	recorded := false
+	for _, line := range strings.Split(block, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, searchTermsPrefix):
+			listed = append(listed, splitTerms(strings.TrimPrefix(line, searchTermsPrefix))...)
+		case strings.HasPrefix(line, generatedPrefix):
+			recorded = true
+			generated = splitTerms(strings.TrimSuffix(strings.TrimPrefix(line, generatedPrefix), "-->"))
+		}
+	}
+	if !recorded {
+		// Without a record every term is treated as the user's
+		return cleanTerms(listed)
+	}
+
+	ours := make(map[string]bool, len(generated))
+	for _, term := range generated {
+		ours[termKey(term)] = true
+	}
+	var added []string
+	for _, term := range cleanTerms(listed) {
+		if !ours[termKey(term)] {
+			added = append(added, term)
+		}
+	}
+	return added
+}
+
+func splitTerms(list string) []string {
+	return strings.Split(list, ",")
+}
+
+// cleanTerms trims terms, drops empty and duplicate ones and removes what
+// would break the list: commas, line breaks and comment delimiters
+func cleanTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	cleaned := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.NewReplacer(",", " ", "<!--", " ", "-->", " ").Replace(term)
+		term = strings.Trim(strings.Join(strings.Fields(term), " "), ".;:")
+		key := termKey(term)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, term)
+	}
+	return cleaned
+}
+
+// mergeTerms lists generated terms first, then the user's that are not
+// already among them
+func mergeTerms(generated, handAdded []string) (all []string, kept int) {
+	seen := make(map[string]bool, len(generated))
+	all = append(all, generated...)
+	for _, term := range generated {
+		seen[termKey(term)] = true
+	}
+	for _, term := range handAdded {
+		if !seen[termKey(term)] {
+			seen[termKey(term)] = true
+			all = append(all, term)
+			kept++
+		}
+	}
+	return all, kept
+}
+
+func termKey(term string) string {
+	return strings.ToLower(strings.TrimSpace(term))
+}
+
+// appendSection adds section at the end of text, above the search terms
+// block so that stays last
+func appendSection(text, section, nl string) string {
+	if start, _, _, ok := findSearchTerms(text); ok {
+		before := strings.TrimRightFunc(text[:start], unicode.IsSpace)
+		if before != "" {
+			before += nl + nl
+		}
+		return before + section + nl + nl + text[start:]
+	}
+
+	text = strings.TrimRight(text, "\r\n")
+	if text != "" {
+		text += nl + nl
+	}
+	return text + section
+}
@@ -0,0 +1,226 @@
+package handlers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/handlers"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+func newSearchTermsEnv(t *testing.T, terms ...string) *env {
+	t.Helper()
+	e := newEnv(t)
+	e.ai.SetSearchTermsResponse(&ai.SearchTermsResponse{SearchTerms: terms})
+	e.router.Register(commands.CommandSearchTerms, handlers.NewSearchTermsHandler(e.ai, e.outline))
+	return e
+}
+
+func searchTermsBlock(terms, generated string) string {
+	return "\n\n---\n\n<!-- AI-SEARCH-TERMS-START -->\n**Search Terms**: " + terms +
+		"\n<!-- generated: " + generated + " -->\n<!-- AI-SEARCH-TERMS-END -->"
+}
+
+func TestSearchTermsHandler_AddsBlock(t *testing.T) {
+	e := newSearchTermsEnv(t, "rate limiting", " Redis ", "redis", "tokens, buckets", "")
+	doc := e.addDocument(t, "technical_doc.json")
+	original := strings.TrimRight(doc.Text, "\n")
+
+	text := e.runAppended(t, doc, "/search-terms")
+
+	want := original + searchTermsBlock("rate limiting, Redis, tokens buckets", "rate limiting, Redis, tokens buckets")
+	if text != want {
+		t.Errorf("Expected a cleaned search terms block at the end, got:\n%s", text[len(original):])
+	}
+	req := e.ai.GetLastCall("GenerateSearchTerms").(*ai.SearchTermsRequest)
+	if req.DocumentTitle != doc.Title || strings.Contains(req.DocumentContent, "/search-terms") {
+		t.Errorf("Expected the title and content without the marker, got %q", req.DocumentTitle)
+	}
+
+	if again := e.runAppended(t, doc, "/search-terms"); again != text {
+		t.Errorf("Expected a second run to leave the same block, got:\n%s", again[len(original):])
+	}
+	if req := e.ai.GetLastCall("GenerateSearchTerms").(*ai.SearchTermsRequest); strings.Contains(req.DocumentContent, "Search Terms") {
+		t.Error("Expected the existing block to be left out of the content sent to the model")
+	}
+}
+
+func TestSearchTermsHandler_MergesHandAddedTerms(t *testing.T) {
+	e := newSearchTermsEnv(t, "Redis", "throttling")
+	doc := e.outline.AddDocument("doc-1", "", "Rate Limits", "Body."+
+		searchTermsBlock("rate limiting, Redis, Kubernetes, ops runbook", "rate limiting, Redis")+
+		"\n\nAdded below the block.")
+	doc.Text += "\n/search-terms"
+
+	result, err := e.router.Route(t.Context(), doc, e.router.Detect(doc.Text)[0])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "Body.\n\nAdded below the block." + searchTermsBlock("Redis, throttling, Kubernetes, ops runbook", "Redis, throttling")
+	if doc.Text != want {
+		t.Errorf("Expected hand-added terms kept and the block moved to the end, got:\n%s", doc.Text)
+	}
+	if result.Message != "search terms updated, kept 2 added by hand" {
+		t.Errorf("Expected the kept terms in the result, got %q", result.Message)
+	}
+}
+
+func TestSearchTermsHandler_RespectsRemovedMarkers(t *testing.T) {
+	e := newSearchTermsEnv(t, "Redis")
+	text := "Body.\n\n---\n\n**Search Terms**: my own, terms"
+	doc := e.outline.AddDocument("doc-1", "", "Rate Limits", text)
+
+	if got := e.runAppended(t, doc, "/search-terms"); got != text {
+		t.Errorf("Expected only the marker removed, got:\n%s", got)
+	}
+	if e.ai.GetCallCount("GenerateSearchTerms") != 0 {
+		t.Error("Expected no terms generated for a user-owned list")
+	}
+	comments := e.comments(t, doc.ID)
+	if len(comments) != 1 || !strings.Contains(comments[0], "markers removed") {
+		t.Errorf("Expected a comment explaining why, got %v", comments)
+	}
+}
+
+func TestSearchTerms_Apply(t *testing.T) {
+	terms := handlers.NewSearchTerms(nil, handlers.WithMaxSearchTerms(2))
+
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+		kept  int
+		ok    bool
+	}{
+		{
+			name:  "limits generated terms",
+			text:  "Body.\n",
+			terms: []string{"a", "b", "c"},
+			want:  "Body." + searchTermsBlock("a, b", "a, b"),
+			ok:    true,
+		},
+		{
+			name:  "empty document has no separator",
+			text:  "",
+			terms: []string{"a"},
+			want:  strings.TrimPrefix(searchTermsBlock("a", "a"), "\n\n---\n\n"),
+			ok:    true,
+		},
+		{
+			name:  "block without a record is the user's",
+			text:  "Body.\n\n<!-- AI-SEARCH-TERMS-START -->\n**Search Terms**: mine\n<!-- AI-SEARCH-TERMS-END -->",
+			terms: []string{"a", "MINE"},
+			want:  "Body." + searchTermsBlock("a, MINE", "a, MINE"),
+			ok:    true,
+		},
+		{
+			name:  "keeps CRLF line endings",
+			text:  "Body.\r\n",
+			terms: []string{"a"},
+			want:  "Body.\r\n\r\n---\r\n\r\n<!-- AI-SEARCH-TERMS-START -->\r\n**Search Terms**: a\r\n<!-- generated: a -->\r\n<!-- AI-SEARCH-TERMS-END -->",
+			ok:    true,
+		},
+		{
+			name:  "no terms leaves the text",
+			text:  "Body.",
+			terms: []string{" ", ","},
+			want:  "Body.",
+		},
+		{
+			name:  "ignores lists in code",
+			text:  "```\n**Search Terms**: example\n```",
+			terms: []string{"a"},
+			want:  "```\n**Search Terms**: example\n```" + searchTermsBlock("a", "a"),
+			ok:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, kept, ok := terms.Apply(tt.text, tt.terms)
+			if got != tt.want || kept != tt.kept || ok != tt.ok {
+				t.Errorf("Expected %q (kept %d, ok %v), got %q (kept %d, ok %v)", tt.want, tt.kept, tt.ok, got, kept, ok)
+			}
+		})
+	}
+}
+
+func TestFilingHandler_SearchTerms(t *testing.T) {
+	setup := func(t *testing.T, fixture string) *env {
+		t.Helper()
+		e := newEnv(t)
+		var resp ai.ClassificationResponse
+		readFixture(t, "ai_responses/"+fixture, &resp)
+		e.ai.SetClassificationResponse(&resp)
+		e.ai.SetSearchTermsResponse(&ai.SearchTermsResponse{SearchTerms: []string{"generated"}})
+		e.router.Register(commands.CommandAIFile, handlers.NewFilingHandler(e.ai, e.outline,
+			handlers.WithSearchTerms(handlers.NewSearchTerms(e.ai, handlers.WithMaxSearchTerms(3)))))
+		return e
+	}
+
+	t.Run("uses the classification's terms", func(t *testing.T) {
+		e := setup(t, "filing_high_confidence.json")
+		doc := e.addDocument(t, "technical_doc.json", "/ai-file")
+
+		if errs := e.run(t, doc.ID); len(errs) > 0 {
+			t.Fatalf("Unexpected errors: %v", errs)
+		}
+		if !strings.HasSuffix(doc.Text, searchTermsBlock("API, authentication, rate limiting", "API, authentication, rate limiting")) {
+			t.Errorf("Expected the classification's terms at the end, got:\n%s", doc.Text[len(doc.Text)-200:])
+		}
+		if e.ai.GetCallCount("GenerateSearchTerms") != 0 {
+			t.Error("Expected no extra model call")
+		}
+		if e.outline.GetCallCount("UpdateDocument") != 1 {
+			t.Errorf("Expected one document update, got %d", e.outline.GetCallCount("UpdateDocument"))
+		}
+	})
+
+	t.Run("generates terms when the classification has none", func(t *testing.T) {
+		e := setup(t, "filing_high_confidence.json")
+		e.ai.SetClassificationResponse(&ai.ClassificationResponse{CollectionID: "col-engineering-001", Confidence: 0.9})
+		doc := e.addDocument(t, "technical_doc.json", "/ai-file")
+
+		if errs := e.run(t, doc.ID); len(errs) > 0 {
+			t.Fatalf("Unexpected errors: %v", errs)
+		}
+		if !strings.HasSuffix(doc.Text, searchTermsBlock("generated", "generated")) {
+			t.Errorf("Expected generated terms at the end, got:\n%s", doc.Text[len(doc.Text)-200:])
+		}
+	})
+
+	t.Run("a failed generation does not fail filing", func(t *testing.T) {
+		e := setup(t, "filing_high_confidence.json")
+		e.ai.SetClassificationResponse(&ai.ClassificationResponse{CollectionID: "col-engineering-001", Confidence: 0.9})
+		e.ai.SetMethodError("GenerateSearchTerms", ai.ErrTimeout)
+		doc := e.addDocument(t, "technical_doc.json", "/ai-file")
+
+		result, err := e.router.Route(t.Context(), doc, e.router.Detect(doc.Text)[0])
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if doc.CollectionID != "col-engineering-001" || strings.Contains(doc.Text, "ai-file") {
+			t.Error("Expected the document filed and the marker removed")
+		}
+		if !strings.HasPrefix(result.Message, "filed to Engineering, search terms not updated") {
+			t.Errorf("Expected the skipped terms in the result, got %q", result.Message)
+		}
+	})
+}
+
+func TestRelatedHandler_StaysAboveSearchTerms(t *testing.T) {
+	e, doc := newRelatedEnv(t, ai.RelatedDocument{Title: "Database Migration Strategy", Relevance: 0.6})
+	block := searchTermsBlock("API", "API")
+	doc.Text = strings.TrimRight(doc.Text, "\n") + block
+	original := strings.TrimSuffix(doc.Text, block)
+
+	text := e.runAppended(t, doc, "/related")
+
+	want := original + "\n\n<!-- AI-RELATED-START -->\n**Related Documents:**\n" +
+		"- [Database Migration Strategy](outline://doc/doc-with-summary-001)\n" +
+		"<!-- AI-RELATED-END -->" + block
+	if text != want {
+		t.Errorf("Expected the related block above the search terms, got:\n%s", text[len(original):])
+	}
+}
@@ -0,0 +1,126 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/outline-ai/internal/audit"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/usage"
+	"github.com/yourusername/outline-ai/internal/worker"
+	"github.com/yourusername/outline-ai/pkg/commands"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+const fixturesDir = "../../test/fixtures"
+
+// env wires the mocks to a router the way the service does
+type env struct {
+	outline *mocks.OutlineMock
+	ai      *mocks.AIMock
+	storage *mocks.StorageMock
+	router  *commands.Router
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	e := &env{
+		outline: mocks.NewOutlineMock(),
+		ai:      mocks.NewAIMock(),
+		storage: mocks.NewStorageMock(),
+	}
+	e.router = commands.NewRouter(e.storage,
+		commands.WithHook(usage.Hook),
+		commands.WithHook(audit.Hook),
+		commands.WithRetryClassifier(worker.IsRetryable))
+
+	var collections []outline.Collection
+	readFixture(t, "collections/sample_collections.json", &collections)
+	for _, c := range collections {
+		e.outline.AddCollection(c.ID, c.Name, c.Description)
+	}
+	return e
+}
+
+func readFixture(t *testing.T, name string, v any) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(fixturesDir, name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture %s: %v", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("Failed to parse fixture %s: %v", name, err)
+	}
+}
+
+// addDocument loads a document fixture into the Outline mock, with the
+// given lines placed before its text
+func (e *env) addDocument(t *testing.T, name string, lines ...string) *outline.Document {
+	t.Helper()
+	var doc outline.Document
+	readFixture(t, "documents/"+name, &doc)
+
+	text := doc.Text
+	if len(lines) > 0 {
+		text = strings.Join(lines, "\n") + "\n\n" + text
+	}
+	return e.outline.AddDocument(doc.ID, doc.CollectionID, doc.Title, text)
+}
+
+// run detects and routes every command in the stored document
+func (e *env) run(t *testing.T, documentID string) []error {
+	t.Helper()
+	ctx := context.Background()
+
+	doc, err := e.outline.GetDocument(ctx, documentID)
+	if err != nil {
+		t.Fatalf("Failed to fetch document: %v", err)
+	}
+
+	var errs []error
+	for _, cmd := range e.router.Detect(doc.Text) {
+		// Handlers edit the document, so route each command against a
+		// fresh copy as the service does
+		stored, _ := e.outline.GetDocument(ctx, documentID)
+		current := *stored
+		for _, c := range e.router.Detect(current.Text) {
+			if c.Type == cmd.Type && c.RawText == cmd.RawText {
+				cmd = c
+				break
+			}
+		}
+		if _, err := e.router.Route(ctx, &current, cmd); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// runAppended adds a marker line to the end of the document, runs it and
+// returns the resulting text
+func (e *env) runAppended(t *testing.T, doc *outline.Document, marker string) string {
+	t.Helper()
+	doc.Text += "\n" + marker
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	return doc.Text
+}
+
+// comments returns the plain text of the comments on a document
+func (e *env) comments(t *testing.T, documentID string) []string {
+	t.Helper()
+	list, err := e.outline.ListComments(context.Background(), documentID)
+	if err != nil {
+		t.Fatalf("Failed to list comments: %v", err)
+	}
+	texts := make([]string, 0, len(list))
+	for _, c := range list {
+		texts = append(texts, c.Data)
+	}
+	return texts
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+// summaryPrefix starts the first line of a summary block, with or without
+// markers around it
+const summaryPrefix = "> **Summary**:"
+
+var summaryBlock = newMarkerBlock("AI-SUMMARY")
+
+var _ commands.Handler = (*SummaryHandler)(nil)
+
+// SummaryHandler handles /summarize by keeping a summary block at the top
+// of the document. The block is wrapped in AI-SUMMARY markers and replaced
+// on every run, so repeated runs leave a single, current summary.
+//
+// A "> **Summary**:" block without markers is adopted when the document has
+// never been summarized, since it predates the markers. Once a summary has
+// been written, missing markers mean the user removed them and the text is
+// theirs.
+type SummaryHandler struct {
+	ai      ai.Client
+	outline outline.Client
+	storage persistence.Storage
+}
+
+// NewSummaryHandler creates a SummaryHandler. Storage provides the command
+// history used to tell legacy summaries from user-owned ones.
+func NewSummaryHandler(aiClient ai.Client, outlineClient outline.Client, storage persistence.Storage) *SummaryHandler {
+	return &SummaryHandler{
+		ai:      aiClient,
+		outline: outlineClient,
+		storage: storage,
+	}
+}
+
+// Handle generates a summary and writes it into the summary block
+func (h *SummaryHandler) Handle(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+	text, err := removeCommands(doc.Text, cmd)
+	if err != nil {
+		return commands.Result{}, err
+	}
+
+	_, _, marked := summaryBlock.find(text)
+	legacyStart, legacyEnd, legacy := findLegacySummary(text)
+	if !marked && legacy {
+		summarized, err := h.summarized(ctx, doc.ID)
+		if err != nil {
+			return commands.Result{}, err
+		}
+		if summarized {
+			return h.keepUserSummary(ctx, doc, text)
+		}
+	}
+
+	content := text
+	switch {
+	case marked:
+		content, _ = summaryBlock.replace(text, "")
+	case legacy:
+		content = text[:legacyStart] + text[legacyEnd:]
+	}
+	resp, err := h.ai.GenerateSummary(ctx, &ai.SummaryRequest{
+		DocumentTitle:   doc.Title,
+		DocumentContent: content,
+	})
+	if err != nil {
+		return commands.Result{}, fmt.Errorf("handlers: failed to generate summary: %w", err)
+	}
+	summary := strings.TrimSpace(resp.Summary)
+	if summary == "" {
+		return commands.Result{}, ErrEmptySummary
+	}
+
+	nl := newline(text)
+	body := summaryBody(summary, nl)
+	var message string
+	switch {
+	case marked:
+		text, _ = summaryBlock.replace(text, body)
+		message = "summary updated"
+	case legacy:
+		text = text[:legacyStart] + summaryBlock.render(body, nl) + text[legacyEnd:]
+		message = "legacy summary adopted"
+	default:
+		text = insertSummary(text, summaryBlock.render(body, nl), nl)
+		message = "summary added"
+	}
+
+	if err := h.update(ctx, doc, text); err != nil {
+		return commands.Result{}, err
+	}
+	return commands.Result{Message: message}, nil
+}
+
+// keepUserSummary removes the marker but leaves the summary the user took
+// over, explaining why in a comment
+func (h *SummaryHandler) keepUserSummary(ctx context.Context, doc *commands.Document, text string) (commands.Result, error) {
+	if err := h.update(ctx, doc, text); err != nil {
+		return commands.Result{}, err
+	}
+	if err := comment(ctx, h.outline, doc.ID,
+		"ℹ️ Summary not updated - markers removed (respecting your edits)",
+		"To get AI summaries again, delete the summary and run /summarize.",
+	); err != nil {
+		return commands.Result{}, err
+	}
+	return commands.Result{Message: "summary not updated: markers removed"}, nil
+}
+
+func (h *SummaryHandler) update(ctx context.Context, doc *commands.Document, text string) error {
+	if _, err := h.outline.UpdateDocument(ctx, doc.ID, &outline.UpdateDocumentRequest{Text: &text, Done: true}); err != nil {
+		return fmt.Errorf("handlers: failed to update summary: %w", err)
+	}
+	doc.Text = text
+	return nil
+}
+
+// summarized reports whether a summary was ever written to the document
+func (h *SummaryHandler) summarized(ctx context.Context, documentID string) (bool, error) {
+	history, err := h.storage.GetCommandHistory(ctx, documentID, 0)
+	if err != nil {
+		return false, fmt.Errorf("handlers: failed to load command history: %w", err)
+	}
+	for _, entry := range history {
+		if entry.CommandType == string(commands.CommandSummarize) && entry.Status == persistence.CommandStatusSuccess {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// summaryBody renders summary as the quoted lines of the block
+func summaryBody(summary, nl string) string {
+	lines := strings.Split(strings.ReplaceAll(summary, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		switch {
+		case i == 0:
+			lines[i] = summaryPrefix + " " + line
+		case line == "":
+			lines[i] = ">"
+		default:
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, nl)
+}
+
+// findLegacySummary locates an unmarked summary quote that opens the
+// document, after the title heading if there is one. end is the offset just
+// past its last quoted line.
+func findLegacySummary(text string) (start, end int, ok bool) {
+	lines := scanLines(text)
+	i := firstContentLine(text, lines)
+	if i < len(lines) && isHeading(text, lines[i]) {
+		i = firstContentLine(text, lines[i+1:]) + i + 1
+	}
+	if i >= len(lines) || lines[i].fenced || !strings.HasPrefix(lineText(text, lines[i]), summaryPrefix) {
+		return -1, -1, false
+	}
+
+	start, end = lines[i].start, lines[i].end
+	for _, ln := range lines[i+1:] {
+		if !strings.HasPrefix(lineText(text, ln), ">") {
+			break
+		}
+		end = ln.end
+	}
+	return start, end, true
+}
+
+// insertSummary places block at the top of text, below the title heading
+func insertSummary(text, block, nl string) string {
+	lines := scanLines(text)
+	i := firstContentLine(text, lines)
+	if i >= len(lines) {
+		return block + nl
+	}
+	if !isHeading(text, lines[i]) {
+		return text[:lines[i].start] + block + nl + nl + text[lines[i].start:]
+	}
+
+	heading := text[:lines[i].end]
+	rest := strings.TrimLeft(text[lines[i].end:], "\r\n")
+	if rest == "" {
+		return heading + nl + nl + block + nl
+	}
+	return heading + nl + nl + block + nl + nl + rest
+}
+
+// firstContentLine returns the index of the first non-blank line
+func firstContentLine(text string, lines []scannedLine) int {
+	for i, ln := range lines {
+		if strings.TrimSpace(text[ln.start:ln.end]) != "" {
+			return i
+		}
+	}
+	return len(lines)
+}
+
+func isHeading(text string, ln scannedLine) bool {
+	return !ln.fenced && strings.HasPrefix(lineText(text, ln), "# ")
+}
+
+func lineText(text string, ln scannedLine) string {
+	return strings.TrimLeft(text[ln.start:ln.end], " ")
+}
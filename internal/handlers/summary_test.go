@@ -0,0 +1,110 @@
+package handlers_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/handlers"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func newSummaryEnv(t *testing.T) *env {
+	t.Helper()
+	e := newEnv(t)
+
+	var fixture ai.SummaryResponse
+	readFixture(t, "ai_responses/summary.json", &fixture)
+	e.ai.SetSummaryResponse(&fixture)
+
+	e.router.Register(commands.CommandSummarize, handlers.NewSummaryHandler(e.ai, e.outline, e.storage))
+	return e
+}
+
+func TestSummaryHandler_Golden(t *testing.T) {
+	e := newSummaryEnv(t)
+	doc := e.addDocument(t, "with_existing_summary.json")
+
+	first := e.runAppended(t, doc, "/summarize")
+	second := e.runAppended(t, doc, "/summarize")
+	if first != second {
+		t.Errorf("Expected a second run to leave the document byte-identical, got:\n%s", second)
+	}
+
+	golden := filepath.Join(fixturesDir, "golden", "with_existing_summary.md")
+	if *update {
+		if err := os.WriteFile(golden, []byte(first), 0o644); err != nil {
+			t.Fatalf("Failed to write golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if first != string(want) {
+		t.Errorf("Expected document to match %s, got:\n%s", golden, first)
+	}
+}
+
+func TestSummaryHandler_AddsBelowTitle(t *testing.T) {
+	e := newSummaryEnv(t)
+	doc := e.addDocument(t, "technical_doc.json")
+	original := doc.Text
+
+	text := e.runAppended(t, doc, "/summarize")
+	if !strings.HasPrefix(text, "# API Authentication & Rate Limiting\n\n<!-- AI-SUMMARY-START -->\n> **Summary**: This document establishes") {
+		t.Errorf("Expected the summary block below the title, got:\n%.300s", text)
+	}
+	if !strings.HasSuffix(text, strings.TrimPrefix(original, "# API Authentication & Rate Limiting\n\n")) {
+		t.Error("Expected the rest of the document to be unchanged")
+	}
+	if got := len(e.comments(t, doc.ID)); got != 0 {
+		t.Errorf("Expected no comments, got %d", got)
+	}
+}
+
+func TestSummaryHandler_AdoptsLegacyBlock(t *testing.T) {
+	e := newSummaryEnv(t)
+	doc := e.outline.AddDocument("doc-legacy", "col-engineering-001", "Runbook",
+		"# Runbook\n\n> **Summary**: An old summary\n> spanning two lines.\n\n## Steps\n\n> A quote the user wrote.")
+
+	text := e.runAppended(t, doc, "/summarize")
+	if strings.Contains(text, "An old summary") || strings.Count(text, "**Summary**") != 1 {
+		t.Errorf("Expected the legacy summary to be replaced, got:\n%s", text)
+	}
+	if !strings.HasPrefix(text, "# Runbook\n\n<!-- AI-SUMMARY-START -->\n> **Summary**: ") ||
+		!strings.HasSuffix(text, "<!-- AI-SUMMARY-END -->\n\n## Steps\n\n> A quote the user wrote.") {
+		t.Errorf("Expected the legacy block wrapped in markers in place, got:\n%s", text)
+	}
+
+	req := e.ai.GetLastCall("GenerateSummary").(*ai.SummaryRequest)
+	if strings.Contains(req.DocumentContent, "An old summary") {
+		t.Error("Expected the old summary to be left out of the content sent to the model")
+	}
+}
+
+func TestSummaryHandler_RespectsRemovedMarkers(t *testing.T) {
+	e := newSummaryEnv(t)
+	doc := e.addDocument(t, "technical_doc.json")
+	e.runAppended(t, doc, "/summarize")
+
+	// The user takes the summary over by deleting the markers
+	doc.Text = strings.NewReplacer("<!-- AI-SUMMARY-START -->\n", "", "\n<!-- AI-SUMMARY-END -->", "").Replace(doc.Text)
+	owned := doc.Text
+
+	if text := e.runAppended(t, doc, "/summarize"); text != owned {
+		t.Errorf("Expected the user's summary to be left alone, got:\n%.300s", text)
+	}
+	if got := e.ai.GetCallCount("GenerateSummary"); got != 1 {
+		t.Errorf("Expected no new summary, got %d GenerateSummary calls", got)
+	}
+	comments := e.comments(t, doc.ID)
+	if len(comments) != 1 || !strings.Contains(comments[0], "markers removed") {
+		t.Errorf("Expected a comment explaining the skip, got %v", comments)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/titles"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+const defaultTitleConfidence = 0.7
+
+var _ commands.Handler = (*TitleHandler)(nil)
+
+// TitleOption configures a TitleHandler
+type TitleOption func(*TitleHandler)
+
+// WithTitleConfidence sets the confidence a suggestion needs before it
+// replaces the title (default 0.7). Weaker suggestions are only commented.
+func WithTitleConfidence(floor float64) TitleOption {
+	return func(h *TitleHandler) {
+		h.confidence = floor
+	}
+}
+
+// TitleHandler handles /enhance-title by replacing vague titles with one
+// suggested from the content. Descriptive titles are left alone. The old
+// title is logged as the command arguments so a rename can be reverted.
+type TitleHandler struct {
+	ai      ai.Client
+	outline outline.Client
+
+	confidence float64
+}
+
+// NewTitleHandler creates a TitleHandler
+func NewTitleHandler(aiClient ai.Client, outlineClient outline.Client, opts ...TitleOption) *TitleHandler {
+	h := &TitleHandler{
+		ai:         aiClient,
+		outline:    outlineClient,
+		confidence: defaultTitleConfidence,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Handle renames the document when its title is vague and the suggestion
+// is confident enough, and comments otherwise
+func (h *TitleHandler) Handle(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+	text, err := removeCommands(doc.Text, cmd)
+	if err != nil {
+		return commands.Result{}, err
+	}
+
+	if !titles.IsVague(doc.Title) {
+		return h.skip(ctx, doc, text, "title already descriptive",
+			fmt.Sprintf("ℹ️ Title not changed - %q is already descriptive", doc.Title))
+	}
+
+	resp, err := h.ai.EnhanceTitle(ctx, &ai.TitleRequest{
+		CurrentTitle:    doc.Title,
+		DocumentContent: text,
+	})
+	if err != nil {
+		return commands.Result{}, fmt.Errorf("handlers: failed to enhance title: %w", err)
+	}
+
+	suggested := strings.Join(strings.Fields(resp.SuggestedTitle), " ")
+	if suggested == "" || strings.EqualFold(suggested, doc.Title) {
+		return h.skip(ctx, doc, text, "no better title",
+			"ℹ️ Title not changed - no better title found")
+	}
+	if resp.Confidence < h.confidence {
+		return h.skip(ctx, doc, text, "suggestion below confidence floor",
+			fmt.Sprintf("💡 Suggested title: %q (confidence: %s)", suggested, percent(resp.Confidence)),
+			fmt.Sprintf("Not applied because confidence is below %s. Rename the document if you like it.", percent(h.confidence)))
+	}
+
+	oldTitle := doc.Title
+	if _, err := h.outline.UpdateDocument(ctx, doc.ID, &outline.UpdateDocumentRequest{Title: suggested, Text: &text, Done: true}); err != nil {
+		return commands.Result{}, fmt.Errorf("handlers: failed to update title: %w", err)
+	}
+	doc.Title = suggested
+	doc.Text = text
+
+	result := commands.Result{Message: "title enhanced", LogArgs: &oldTitle}
+	if err := comment(ctx, h.outline, doc.ID,
+		fmt.Sprintf("✓ Title enhanced (confidence: %s)", percent(resp.Confidence)),
+		fmt.Sprintf("Previous title: %q", oldTitle),
+	); err != nil {
+		// The rename happened, so keep the old title in the log
+		return result, err
+	}
+	return result, nil
+}
+
+// skip removes the marker and explains in a comment why the title stayed
+func (h *TitleHandler) skip(ctx context.Context, doc *commands.Document, text, message string, paragraphs ...string) (commands.Result, error) {
+	if _, err := h.outline.UpdateDocument(ctx, doc.ID, &outline.UpdateDocumentRequest{Text: &text, Done: true}); err != nil {
+		return commands.Result{}, fmt.Errorf("handlers: failed to remove title marker: %w", err)
+	}
+	doc.Text = text
+
+	if err := comment(ctx, h.outline, doc.ID, paragraphs...); err != nil {
+		return commands.Result{}, err
+	}
+	return commands.Result{Message: message}, nil
+}
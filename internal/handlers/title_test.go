@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/handlers"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+func newTitleEnv(t *testing.T, title string, resp *ai.TitleResponse, opts ...handlers.TitleOption) (*env, *outline.Document) {
+	t.Helper()
+	e := newEnv(t)
+	e.ai.SetTitleResponse(resp)
+	e.router.Register(commands.CommandEnhanceTitle, handlers.NewTitleHandler(e.ai, e.outline, opts...))
+
+	doc := e.addDocument(t, "technical_doc.json", "/enhance-title")
+	doc.Title = title
+	return e, doc
+}
+
+func lastLog(t *testing.T, e *env, documentID string) *commands.CommandLog {
+	t.Helper()
+	history, err := e.storage.GetCommandHistory(context.Background(), documentID, 0)
+	if err != nil || len(history) == 0 {
+		t.Fatalf("Expected a command log entry, got %v", err)
+	}
+	return history[0]
+}
+
+func TestTitleHandler_Renames(t *testing.T) {
+	e, doc := newTitleEnv(t, "Notes", &ai.TitleResponse{SuggestedTitle: "  API Authentication and\nRate Limiting ", Confidence: 0.88})
+
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+
+	if doc.Title != "API Authentication and Rate Limiting" {
+		t.Errorf("Expected the suggested title, got %q", doc.Title)
+	}
+	if strings.Contains(doc.Text, "/enhance-title") {
+		t.Error("Expected the marker to be removed")
+	}
+	req := e.ai.GetLastCall("EnhanceTitle").(*ai.TitleRequest)
+	if req.CurrentTitle != "Notes" || strings.Contains(req.DocumentContent, "/enhance-title") {
+		t.Errorf("Expected the current title and content without the marker, got %+v", req.CurrentTitle)
+	}
+
+	log := lastLog(t, e, doc.ID)
+	if log.CommandArgs == nil || *log.CommandArgs != "Notes" {
+		t.Errorf("Expected the old title in the command log, got %v", log.CommandArgs)
+	}
+	comments := e.comments(t, doc.ID)
+	if len(comments) != 1 || !strings.Contains(comments[0], "Title enhanced (confidence: 88%)") {
+		t.Errorf("Expected a rename comment, got %v", comments)
+	}
+}
+
+func TestTitleHandler_LowConfidenceComments(t *testing.T) {
+	e, doc := newTitleEnv(t, "Draft", &ai.TitleResponse{SuggestedTitle: "API Design", Confidence: 0.75}, handlers.WithTitleConfidence(0.8))
+
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+
+	if doc.Title != "Draft" {
+		t.Errorf("Expected the title to stay, got %q", doc.Title)
+	}
+	if strings.Contains(doc.Text, "/enhance-title") {
+		t.Error("Expected the marker to be removed")
+	}
+	comments := e.comments(t, doc.ID)
+	if len(comments) != 1 || !strings.Contains(comments[0], `Suggested title: "API Design" (confidence: 75%)`) {
+		t.Errorf("Expected the suggestion in a comment, got %v", comments)
+	}
+	if log := lastLog(t, e, doc.ID); log.CommandArgs != nil {
+		t.Errorf("Expected no old title logged without a rename, got %q", *log.CommandArgs)
+	}
+}
+
+func TestTitleHandler_KeepsDescriptiveTitle(t *testing.T) {
+	e, doc := newTitleEnv(t, "API Authentication & Rate Limiting", &ai.TitleResponse{SuggestedTitle: "Other", Confidence: 0.99})
+
+	if errs := e.run(t, doc.ID); len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+
+	if doc.Title != "API Authentication & Rate Limiting" {
+		t.Errorf("Expected the title to stay, got %q", doc.Title)
+	}
+	if got := e.ai.GetCallCount("EnhanceTitle"); got != 0 {
+		t.Errorf("Expected no EnhanceTitle calls, got %d", got)
+	}
+	comments := e.comments(t, doc.ID)
+	if len(comments) != 1 || !strings.Contains(comments[0], "already descriptive") {
+		t.Errorf("Expected a comment explaining the skip, got %v", comments)
+	}
+}
+
+func TestTitleHandler_AIFailure(t *testing.T) {
+	e, doc := newTitleEnv(t, "Untitled", &ai.TitleResponse{SuggestedTitle: "API Design", Confidence: 0.9})
+	e.ai.SetRateLimited(true)
+
+	errs := e.run(t, doc.ID)
+	if len(errs) != 1 || !errors.Is(errs[0], ai.ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", errs)
+	}
+	if doc.Title != "Untitled" || !strings.Contains(doc.Text, "/enhance-title") {
+		t.Error("Expected the document untouched so the command can be retried")
+	}
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+)
+
+var _ ai.Client = (*AIClient)(nil)
+
+// AIClient records the latency and errors of every call to the wrapped
+// client. Ping is not recorded, so health checks do not skew latency.
+type AIClient struct {
+	next    ai.Client
+	metrics *Metrics
+}
+
+// AIClient wraps next to record AI metrics. Wrap the outermost client to
+// see what handlers see, including circuit breaker rejections.
+func (m *Metrics) AIClient(next ai.Client) *AIClient {
+	return &AIClient{next: next, metrics: m}
+}
+
+func observeAI[T any](c *AIClient, method string, fn func() (T, error)) (T, error) {
+	started := time.Now()
+	resp, err := fn()
+	c.metrics.aiDuration.WithLabelValues(method).Observe(time.Since(started).Seconds())
+	if err != nil {
+		c.metrics.aiErrors.WithLabelValues(method, aiErrorLabel(err)).Inc()
+	}
+	return resp, err
+}
+
+// aiErrorLabel names the sentinel err wraps
+func aiErrorLabel(err error) string {
+	switch {
+	case errors.Is(err, ai.ErrCircuitBreakerOpen):
+		return "circuit_open"
+	case errors.Is(err, ai.ErrTimeout):
+		return "timeout"
+	case errors.Is(err, ai.ErrTokenLimitExceeded):
+		return "token_limit_exceeded"
+	case errors.Is(err, ai.ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ai.ErrServerError):
+		return "server_error"
+	case errors.Is(err, ai.ErrUnreachable):
+		return "unreachable"
+	case errors.Is(err, ai.ErrInvalidResponse):
+		return "invalid_response"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	default:
+		return "other"
+	}
+}
+
+// Interface Implementation
+
+// ClassifyDocument records the call
+func (c *AIClient) ClassifyDocument(ctx context.Context, req *ai.ClassificationRequest) (*ai.ClassificationResponse, error) {
+	return observeAI(c, "ClassifyDocument", func() (*ai.ClassificationResponse, error) {
+		return c.next.ClassifyDocument(ctx, req)
+	})
+}
+
+// AnswerQuestion records the call
+func (c *AIClient) AnswerQuestion(ctx context.Context, req *ai.QuestionRequest) (*ai.QuestionResponse, error) {
+	return observeAI(c, "AnswerQuestion", func() (*ai.QuestionResponse, error) {
+		return c.next.AnswerQuestion(ctx, req)
+	})
+}
+
+// GenerateSummary records the call
+func (c *AIClient) GenerateSummary(ctx context.Context, req *ai.SummaryRequest) (*ai.SummaryResponse, error) {
+	return observeAI(c, "GenerateSummary", func() (*ai.SummaryResponse, error) {
+		return c.next.GenerateSummary(ctx, req)
+	})
+}
+
+// EnhanceTitle records the call
+func (c *AIClient) EnhanceTitle(ctx context.Context, req *ai.TitleRequest) (*ai.TitleResponse, error) {
+	return observeAI(c, "EnhanceTitle", func() (*ai.TitleResponse, error) {
+		return c.next.EnhanceTitle(ctx, req)
+	})
+}
+
+// GenerateSearchTerms records the call
+func (c *AIClient) GenerateSearchTerms(ctx context.Context, req *ai.SearchTermsRequest) (*ai.SearchTermsResponse, error) {
+	return observeAI(c, "GenerateSearchTerms", func() (*ai.SearchTermsResponse, error) {
+		return c.next.GenerateSearchTerms(ctx, req)
+	})
+}
+
+// FindRelatedDocuments records the call
+func (c *AIClient) FindRelatedDocuments(ctx context.Context, req *ai.RelatedDocsRequest) (*ai.RelatedDocsResponse, error) {
+	return observeAI(c, "FindRelatedDocuments", func() (*ai.RelatedDocsResponse, error) {
+		return c.next.FindRelatedDocuments(ctx, req)
+	})
+}
+
+// Ping forwards without recording
+func (c *AIClient) Ping(ctx context.Context) error {
+	return c.next.Ping(ctx)
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+var _ commands.Logger = (*CommandLogger)(nil)
+
+// CommandLogger counts every command the router logs before passing the
+// entry on, so the metrics share the command log's types and statuses
+type CommandLogger struct {
+	next    commands.Logger
+	metrics *Metrics
+}
+
+// CommandLogger wraps next, usually the storage, to record command metrics
+func (m *Metrics) CommandLogger(next commands.Logger) *CommandLogger {
+	return &CommandLogger{next: next, metrics: m}
+}
+
+// LogCommand records log and forwards it
+func (l *CommandLogger) LogCommand(ctx context.Context, log *commands.CommandLog) error {
+	l.metrics.commands.WithLabelValues(log.CommandType, log.Status).Inc()
+	if log.ExecutionTimeMs != nil {
+		elapsed := time.Duration(*log.ExecutionTimeMs) * time.Millisecond
+		l.metrics.commandDuration.WithLabelValues(log.CommandType, log.Status).Observe(elapsed.Seconds())
+	}
+	return l.next.LogCommand(ctx, log)
+}
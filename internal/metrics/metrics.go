@@ -0,0 +1,160 @@
+// Package metrics exposes the service's Prometheus metrics: commands by
+// type and status, AI call latency and errors, Outline calls, and the
+// state of the worker pool and the AI circuit breakers. Metrics are
+// gathered by decorators around the components that already exist, so
+// nothing else needs to know about Prometheus.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/worker"
+)
+
+const (
+	// Path is where the metrics are served
+	Path = "/metrics"
+
+	namespace = "outline_ai"
+)
+
+// Buckets in seconds. Commands include at least one model call, so both
+// reach into minutes.
+var (
+	commandBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+	aiBuckets      = []float64{0.25, 0.5, 1, 2, 5, 10, 20, 30, 60}
+)
+
+// Metrics owns a registry and the collectors registered on it. Use one per
+// service; its registry is separate from the global default.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	commands        *prometheus.CounterVec
+	commandDuration *prometheus.HistogramVec
+	aiDuration      *prometheus.HistogramVec
+	aiErrors        *prometheus.CounterVec
+	outlineRequests *prometheus.CounterVec
+}
+
+// New creates Metrics with the Go runtime and process collectors
+// registered alongside the service's own
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		commands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "commands_total",
+			Help:      "Commands handled, by command type and logged status.",
+		}, []string{"command", "status"}),
+		commandDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "command_duration_seconds",
+			Help:      "Command execution time, by command type and logged status.",
+			Buckets:   commandBuckets,
+		}, []string{"command", "status"}),
+		aiDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "ai_request_duration_seconds",
+			Help:      "AI call latency as seen by handlers, including rate limiting, by method.",
+			Buckets:   aiBuckets,
+		}, []string{"method"}),
+		aiErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ai_errors_total",
+			Help:      "Failed AI calls, by method and error.",
+		}, []string{"method", "error"}),
+		outlineRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "outline_requests_total",
+			Help:      "Outline API calls, by method and result.",
+		}, []string{"method", "result"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.commands,
+		m.commandDuration,
+		m.aiDuration,
+		m.aiErrors,
+		m.outlineRequests,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// WatchPool reports the worker pool's queue depth, active tasks and task
+// outcomes, read from stats at every scrape
+func (m *Metrics) WatchPool(stats func() worker.Stats) {
+	m.registry.MustRegister(&poolCollector{stats: stats})
+}
+
+// WatchBreakers reports the state of each AI circuit breaker, read from
+// states at every scrape
+func (m *Metrics) WatchBreakers(states func() map[string]ai.State) {
+	m.registry.MustRegister(&breakerCollector{states: states})
+}
+
+var (
+	workersDesc = prometheus.NewDesc(namespace+"_worker_count",
+		"Workers in the pool.", nil, nil)
+	queueDepthDesc = prometheus.NewDesc(namespace+"_worker_queue_depth",
+		"Tasks waiting for a worker.", nil, nil)
+	activeDesc = prometheus.NewDesc(namespace+"_worker_active_tasks",
+		"Tasks being run.", nil, nil)
+	tasksDesc = prometheus.NewDesc(namespace+"_worker_tasks_total",
+		"Task attempts, by outcome.", []string{"outcome"}, nil)
+	breakerDesc = prometheus.NewDesc(namespace+"_ai_circuit_breaker_state",
+		"AI circuit breaker state: 0 closed, 1 half-open, 2 open.", []string{"breaker"}, nil)
+)
+
+// poolCollector turns worker.Stats into metrics at scrape time
+type poolCollector struct {
+	stats func() worker.Stats
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- workersDesc
+	ch <- queueDepthDesc
+	ch <- activeDesc
+	ch <- tasksDesc
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(workersDesc, prometheus.GaugeValue, float64(stats.Workers))
+	ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(stats.Queued))
+	ch <- prometheus.MustNewConstMetric(activeDesc, prometheus.GaugeValue, float64(stats.Active))
+	for outcome, count := range map[string]int64{
+		"completed":     stats.Completed,
+		"failed":        stats.Failed,
+		"retried":       stats.Retried,
+		"dead_lettered": stats.DeadLettered,
+	} {
+		ch <- prometheus.MustNewConstMetric(tasksDesc, prometheus.CounterValue, float64(count), outcome)
+	}
+}
+
+// breakerCollector reports one gauge per circuit breaker at scrape time
+type breakerCollector struct {
+	states func() map[string]ai.State
+}
+
+func (c *breakerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- breakerDesc
+}
+
+func (c *breakerCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, state := range c.states() {
+		ch <- prometheus.MustNewConstMetric(breakerDesc, prometheus.GaugeValue, float64(state), name)
+	}
+}
@@ -0,0 +1,152 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/metrics"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/internal/worker"
+	"github.com/yourusername/outline-ai/pkg/commands"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, metrics.Path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 from the metrics handler, got %d", rec.Code)
+	}
+	return rec.Body.String()
+}
+
+func expectLines(t *testing.T, body string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected %q in the metrics", line)
+		}
+	}
+}
+
+func TestCommandLogger(t *testing.T) {
+	m := metrics.New()
+	storage := mocks.NewStorageMock()
+	router := commands.NewRouter(m.CommandLogger(storage))
+	router.Register(commands.CommandSummarize, commands.HandlerFunc(func(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+		if doc.ID == "doc-fails" {
+			return commands.Result{}, errors.New("boom")
+		}
+		return commands.Result{}, nil
+	}))
+
+	for _, id := range []string{"doc-1", "doc-2", "doc-fails"} {
+		router.Route(t.Context(), &commands.Document{ID: id}, commands.Command{Type: commands.CommandSummarize})
+	}
+
+	expectLines(t, scrape(t, m),
+		`outline_ai_commands_total{command="/summarize",status="success"} 2`,
+		`outline_ai_commands_total{command="/summarize",status="failed"} 1`,
+		`outline_ai_command_duration_seconds_count{command="/summarize",status="success"} 2`,
+	)
+	if storage.GetCallCount("LogCommand") != 3 {
+		t.Errorf("Expected every entry forwarded to storage, got %d", storage.GetCallCount("LogCommand"))
+	}
+}
+
+func TestCommandLogger_StatusesMatchCommandLog(t *testing.T) {
+	m := metrics.New()
+	logger := m.CommandLogger(mocks.NewStorageMock())
+	for _, status := range []string{persistence.CommandStatusSuccess, persistence.CommandStatusFailed, persistence.CommandStatusRetrying} {
+		logger.LogCommand(t.Context(), &commands.CommandLog{CommandType: "/ai", Status: status})
+	}
+
+	body := scrape(t, m)
+	for _, status := range []string{"success", "failed", "retrying"} {
+		expectLines(t, body, fmt.Sprintf(`outline_ai_commands_total{command="/ai",status=%q} 1`, status))
+	}
+}
+
+func TestAIClient(t *testing.T) {
+	m := metrics.New()
+	mock := mocks.NewAIMock()
+	client := m.AIClient(mock)
+	ctx := t.Context()
+
+	client.GenerateSummary(ctx, &ai.SummaryRequest{})
+	mock.SetMethodError("GenerateSummary", fmt.Errorf("wrapped: %w", ai.ErrTokenLimitExceeded))
+	client.GenerateSummary(ctx, &ai.SummaryRequest{})
+	mock.SetMethodError("ClassifyDocument", ai.ErrCircuitBreakerOpen)
+	client.ClassifyDocument(ctx, &ai.ClassificationRequest{})
+	mock.SetMethodError("FindRelatedDocuments", fmt.Errorf("wrapped: %w", ai.ErrServerError))
+	client.FindRelatedDocuments(ctx, &ai.RelatedDocsRequest{})
+	mock.SetMethodError("EnhanceTitle", errors.New("unexpected"))
+	client.EnhanceTitle(ctx, &ai.TitleRequest{})
+	client.Ping(ctx)
+
+	body := scrape(t, m)
+	expectLines(t, body,
+		`outline_ai_ai_request_duration_seconds_count{method="GenerateSummary"} 2`,
+		`outline_ai_ai_errors_total{error="token_limit_exceeded",method="GenerateSummary"} 1`,
+		`outline_ai_ai_errors_total{error="circuit_open",method="ClassifyDocument"} 1`,
+		`outline_ai_ai_errors_total{error="server_error",method="FindRelatedDocuments"} 1`,
+		`outline_ai_ai_errors_total{error="other",method="EnhanceTitle"} 1`,
+	)
+	if strings.Contains(body, `method="Ping"`) {
+		t.Error("Expected pings to be left out")
+	}
+	if mock.GetCallCount("Ping") != 1 {
+		t.Errorf("Expected the ping forwarded, got %d", mock.GetCallCount("Ping"))
+	}
+}
+
+func TestOutlineClient(t *testing.T) {
+	m := metrics.New()
+	mock := mocks.NewOutlineMock()
+	mock.AddDocument("doc-1", "col-1", "Notes", "Text")
+	client := m.OutlineClient(mock)
+	ctx := t.Context()
+
+	client.GetDocument(ctx, "doc-1")
+	client.GetDocument(ctx, "doc-1")
+	client.UpdateDocument(ctx, "doc-1", &outline.UpdateDocumentRequest{Text: outline.String("New")})
+	mock.SetRateLimited(true)
+	client.CreateComment(ctx, &outline.CreateCommentRequest{DocumentID: "doc-1"})
+
+	expectLines(t, scrape(t, m),
+		`outline_ai_outline_requests_total{method="GetDocument",result="ok"} 2`,
+		`outline_ai_outline_requests_total{method="UpdateDocument",result="ok"} 1`,
+		`outline_ai_outline_requests_total{method="CreateComment",result="rate_limited"} 1`,
+	)
+}
+
+func TestWatchPoolAndBreakers(t *testing.T) {
+	m := metrics.New()
+	stats := worker.Stats{Workers: 3, Queued: 7, Active: 2, Completed: 10, DeadLettered: 1}
+	m.WatchPool(func() worker.Stats { return stats })
+	m.WatchBreakers(func() map[string]ai.State {
+		return map[string]ai.State{"ClassifyDocument": ai.StateOpen, "GenerateSummary": ai.StateClosed}
+	})
+
+	expectLines(t, scrape(t, m),
+		`outline_ai_worker_count 3`,
+		`outline_ai_worker_queue_depth 7`,
+		`outline_ai_worker_active_tasks 2`,
+		`outline_ai_worker_tasks_total{outcome="completed"} 10`,
+		`outline_ai_worker_tasks_total{outcome="dead_lettered"} 1`,
+		`outline_ai_ai_circuit_breaker_state{breaker="ClassifyDocument"} 2`,
+		`outline_ai_ai_circuit_breaker_state{breaker="GenerateSummary"} 0`,
+	)
+
+	// Gauges are read at every scrape
+	stats.Queued = 0
+	expectLines(t, scrape(t, m), `outline_ai_worker_queue_depth 0`)
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+)
+
+var _ outline.Client = (*OutlineClient)(nil)
+
+// OutlineClient counts every call to the wrapped client by method and
+// result. Ping is not counted.
+type OutlineClient struct {
+	next    outline.Client
+	metrics *Metrics
+}
+
+// OutlineClient wraps next to count Outline calls. Wrap the HTTP client,
+// below rate limiting, to count the requests that reach Outline.
+func (m *Metrics) OutlineClient(next outline.Client) *OutlineClient {
+	return &OutlineClient{next: next, metrics: m}
+}
+
+func (c *OutlineClient) count(method string, err error) {
+	c.metrics.outlineRequests.WithLabelValues(method, outlineResultLabel(err)).Inc()
+}
+
+// outlineResultLabel names the sentinel err wraps, or "ok"
+func outlineResultLabel(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, outline.ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, outline.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, outline.ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, outline.ErrServerError):
+		return "server_error"
+	case errors.Is(err, outline.ErrInvalidRequest):
+		return "invalid_request"
+	default:
+		return "other"
+	}
+}
+
+// Interface Implementation
+
+// ListCollections counts the call
+func (c *OutlineClient) ListCollections(ctx context.Context) ([]*outline.Collection, error) {
+	collections, err := c.next.ListCollections(ctx)
+	c.count("ListCollections", err)
+	return collections, err
+}
+
+// GetCollection counts the call
+func (c *OutlineClient) GetCollection(ctx context.Context, id string) (*outline.Collection, error) {
+	collection, err := c.next.GetCollection(ctx, id)
+	c.count("GetCollection", err)
+	return collection, err
+}
+
+// GetDocument counts the call
+func (c *OutlineClient) GetDocument(ctx context.Context, id string) (*outline.Document, error) {
+	doc, err := c.next.GetDocument(ctx, id)
+	c.count("GetDocument", err)
+	return doc, err
+}
+
+// ListDocuments counts the call
+func (c *OutlineClient) ListDocuments(ctx context.Context, collectionID string) ([]*outline.Document, error) {
+	docs, err := c.next.ListDocuments(ctx, collectionID)
+	c.count("ListDocuments", err)
+	return docs, err
+}
+
+// CreateDocument counts the call
+func (c *OutlineClient) CreateDocument(ctx context.Context, req *outline.CreateDocumentRequest) (*outline.Document, error) {
+	doc, err := c.next.CreateDocument(ctx, req)
+	c.count("CreateDocument", err)
+	return doc, err
+}
+
+// UpdateDocument counts the call
+func (c *OutlineClient) UpdateDocument(ctx context.Context, id string, req *outline.UpdateDocumentRequest) (*outline.Document, error) {
+	doc, err := c.next.UpdateDocument(ctx, id, req)
+	c.count("UpdateDocument", err)
+	return doc, err
+}
+
+// MoveDocument counts the call
+func (c *OutlineClient) MoveDocument(ctx context.Context, id string, collectionID string) error {
+	err := c.next.MoveDocument(ctx, id, collectionID)
+	c.count("MoveDocument", err)
+	return err
+}
+
+// SearchDocuments counts the call
+func (c *OutlineClient) SearchDocuments(ctx context.Context, query string, opts *outline.SearchOptions) (*outline.SearchResult, error) {
+	result, err := c.next.SearchDocuments(ctx, query, opts)
+	c.count("SearchDocuments", err)
+	return result, err
+}
+
+// CreateComment counts the call
+func (c *OutlineClient) CreateComment(ctx context.Context, req *outline.CreateCommentRequest) (*outline.Comment, error) {
+	comment, err := c.next.CreateComment(ctx, req)
+	c.count("CreateComment", err)
+	return comment, err
+}
+
+// ListComments counts the call
+func (c *OutlineClient) ListComments(ctx context.Context, documentID string) ([]*outline.Comment, error) {
+	comments, err := c.next.ListComments(ctx, documentID)
+	c.count("ListComments", err)
+	return comments, err
+}
+
+// Ping forwards without counting
+func (c *OutlineClient) Ping(ctx context.Context) error {
+	return c.next.Ping(ctx)
+}
@@ -0,0 +1,435 @@
+package outline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ratelimit"
+	public "github.com/yourusername/outline-ai/pkg/outline"
+)
+
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = time.Second
+	listPageSize        = 100
+
+	// maxRetryAfterWait caps how long call sleeps on a Retry-After hint;
+	// longer pauses are returned to the caller's rate limiter instead
+	maxRetryAfterWait = 30 * time.Second
+)
+
+// Client is the contract every Outline backend and decorator implements,
+// defined in pkg/outline
+type Client = public.Client
+
+var _ Client = (*HTTPClient)(nil)
+
+// HTTPClient talks to Outline's POST-style JSON RPC API
+type HTTPClient struct {
+	httpClient   *http.Client
+	baseURL      string
+	apiKey       string
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// Option configures an HTTPClient
+type Option func(*HTTPClient)
+
+// WithHTTPClient replaces the underlying *http.Client
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *HTTPClient) {
+		c.httpClient = client
+	}
+}
+
+// WithTimeout sets the per-request timeout of the default *http.Client
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *HTTPClient) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithRetries sets how many times transient failures are retried and the base backoff between attempts
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *HTTPClient) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// NewHTTPClient creates a client for the Outline API rooted at baseURL,
+// e.g. "https://outline.example.com/api"
+func NewHTTPClient(baseURL, apiKey string, opts ...Option) *HTTPClient {
+	c := &HTTPClient{
+		httpClient:   &http.Client{Timeout: defaultTimeout},
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		apiKey:       apiKey,
+		maxRetries:   defaultMaxRetries,
+		retryBackoff: defaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Wire formats
+
+type idRequest struct {
+	ID string `json:"id"`
+}
+
+type pageRequest struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+type listDocumentsRequest struct {
+	CollectionID string `json:"collectionId"`
+	Offset       int    `json:"offset"`
+	Limit        int    `json:"limit"`
+}
+
+type updateDocumentRequest struct {
+	ID    string  `json:"id"`
+	Title string  `json:"title,omitempty"`
+	Text  *string `json:"text,omitempty"`
+	Done  bool    `json:"done,omitempty"`
+}
+
+type moveDocumentRequest struct {
+	ID           string `json:"id"`
+	CollectionID string `json:"collectionId"`
+}
+
+type searchRequest struct {
+	Query        string `json:"query"`
+	CollectionID string `json:"collectionId,omitempty"`
+	Offset       int    `json:"offset,omitempty"`
+	Limit        int    `json:"limit,omitempty"`
+}
+
+type listCommentsRequest struct {
+	DocumentID string `json:"documentId"`
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit"`
+}
+
+type pagination struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+	Total  int `json:"total"`
+}
+
+type searchHit struct {
+	Context  string    `json:"context"`
+	Ranking  float64   `json:"ranking"`
+	Document *Document `json:"document"`
+}
+
+type searchResponse struct {
+	Data       []searchHit `json:"data"`
+	Pagination pagination  `json:"pagination"`
+}
+
+type wireComment struct {
+	ID         string         `json:"id"`
+	DocumentID string         `json:"documentId"`
+	Data       CommentContent `json:"data"`
+	CreatedAt  time.Time      `json:"createdAt"`
+}
+
+func (w *wireComment) toComment() *Comment {
+	return &Comment{
+		ID:         w.ID,
+		DocumentID: w.DocumentID,
+		Data:       w.Data.PlainText(),
+		CreatedAt:  w.CreatedAt,
+	}
+}
+
+// dataEnvelope is the {"data": ...} wrapper around every Outline response
+type dataEnvelope[T any] struct {
+	Data T `json:"data"`
+}
+
+// Collections
+
+// ListCollections returns all collections visible to the API key
+func (c *HTTPClient) ListCollections(ctx context.Context) ([]*Collection, error) {
+	collections := make([]*Collection, 0)
+	for offset := 0; ; offset += listPageSize {
+		var page dataEnvelope[[]*Collection]
+		req := pageRequest{Offset: offset, Limit: listPageSize}
+		if err := c.call(ctx, "/collections.list", req, &page, true); err != nil {
+			return nil, err
+		}
+		collections = append(collections, page.Data...)
+		if len(page.Data) < listPageSize {
+			return collections, nil
+		}
+	}
+}
+
+// GetCollection returns a collection by ID
+func (c *HTTPClient) GetCollection(ctx context.Context, id string) (*Collection, error) {
+	var resp dataEnvelope[*Collection]
+	if err := c.call(ctx, "/collections.info", idRequest{ID: id}, &resp, true); err != nil {
+		return nil, err
+	}
+	if resp.Data == nil {
+		return nil, ErrNotFound
+	}
+	return resp.Data, nil
+}
+
+// Documents
+
+// GetDocument returns a document by ID
+func (c *HTTPClient) GetDocument(ctx context.Context, id string) (*Document, error) {
+	var resp dataEnvelope[*Document]
+	if err := c.call(ctx, "/documents.info", idRequest{ID: id}, &resp, true); err != nil {
+		return nil, err
+	}
+	if resp.Data == nil {
+		return nil, ErrNotFound
+	}
+	return resp.Data, nil
+}
+
+// ListDocuments returns all documents in a collection
+func (c *HTTPClient) ListDocuments(ctx context.Context, collectionID string) ([]*Document, error) {
+	documents := make([]*Document, 0)
+	for offset := 0; ; offset += listPageSize {
+		var page dataEnvelope[[]*Document]
+		req := listDocumentsRequest{CollectionID: collectionID, Offset: offset, Limit: listPageSize}
+		if err := c.call(ctx, "/documents.list", req, &page, true); err != nil {
+			return nil, err
+		}
+		documents = append(documents, page.Data...)
+		if len(page.Data) < listPageSize {
+			return documents, nil
+		}
+	}
+}
+
+// CreateDocument creates a new document
+func (c *HTTPClient) CreateDocument(ctx context.Context, req *CreateDocumentRequest) (*Document, error) {
+	if req == nil || req.CollectionID == "" {
+		return nil, fmt.Errorf("%w: collection ID is required", ErrInvalidRequest)
+	}
+
+	var resp dataEnvelope[*Document]
+	if err := c.call(ctx, "/documents.create", req, &resp, false); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// UpdateDocument updates an existing document; an empty title or nil text is
+// left unchanged
+func (c *HTTPClient) UpdateDocument(ctx context.Context, id string, req *UpdateDocumentRequest) (*Document, error) {
+	if req == nil {
+		return nil, fmt.Errorf("%w: update request is required", ErrInvalidRequest)
+	}
+
+	payload := updateDocumentRequest{
+		ID:    id,
+		Title: req.Title,
+		Text:  req.Text,
+		Done:  req.Done,
+	}
+
+	var resp dataEnvelope[*Document]
+	if err := c.call(ctx, "/documents.update", payload, &resp, true); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// MoveDocument moves a document to a different collection
+func (c *HTTPClient) MoveDocument(ctx context.Context, id string, collectionID string) error {
+	payload := moveDocumentRequest{ID: id, CollectionID: collectionID}
+	return c.call(ctx, "/documents.move", payload, nil, true)
+}
+
+// SearchDocuments runs a full-text search. When Outline omits a total in the
+// pagination block, TotalCount counts the documents up to the end of this page.
+func (c *HTTPClient) SearchDocuments(ctx context.Context, query string, opts *SearchOptions) (*SearchResult, error) {
+	payload := searchRequest{Query: query}
+	if opts != nil {
+		payload.CollectionID = opts.CollectionID
+		payload.Offset = opts.Offset
+		payload.Limit = opts.Limit
+	}
+
+	var resp searchResponse
+	if err := c.call(ctx, "/documents.search", payload, &resp, true); err != nil {
+		return nil, err
+	}
+
+	documents := make([]*Document, 0, len(resp.Data))
+	for _, hit := range resp.Data {
+		if hit.Document != nil {
+			documents = append(documents, hit.Document)
+		}
+	}
+
+	total := resp.Pagination.Total
+	if total == 0 {
+		total = payload.Offset + len(documents)
+	}
+
+	return &SearchResult{
+		Documents:  documents,
+		TotalCount: total,
+	}, nil
+}
+
+// Comments
+
+// CreateComment creates a comment on a document
+func (c *HTTPClient) CreateComment(ctx context.Context, req *CreateCommentRequest) (*Comment, error) {
+	if req == nil || req.DocumentID == "" {
+		return nil, fmt.Errorf("%w: document ID is required", ErrInvalidRequest)
+	}
+
+	var resp dataEnvelope[*wireComment]
+	if err := c.call(ctx, "/comments.create", req, &resp, false); err != nil {
+		return nil, err
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("outline: empty comments.create response")
+	}
+	return resp.Data.toComment(), nil
+}
+
+// ListComments returns all comments for a document
+func (c *HTTPClient) ListComments(ctx context.Context, documentID string) ([]*Comment, error) {
+	comments := make([]*Comment, 0)
+	for offset := 0; ; offset += listPageSize {
+		var page dataEnvelope[[]*wireComment]
+		req := listCommentsRequest{DocumentID: documentID, Offset: offset, Limit: listPageSize}
+		if err := c.call(ctx, "/comments.list", req, &page, true); err != nil {
+			return nil, err
+		}
+		for _, wc := range page.Data {
+			comments = append(comments, wc.toComment())
+		}
+		if len(page.Data) < listPageSize {
+			return comments, nil
+		}
+	}
+}
+
+// Health
+
+// Ping verifies connectivity and that the API key is accepted
+func (c *HTTPClient) Ping(ctx context.Context) error {
+	return c.call(ctx, "/auth.info", struct{}{}, nil, true)
+}
+
+// Transport
+
+// call POSTs payload to endpoint and decodes the response into out (if non-nil).
+// Non-idempotent calls are only retried when Outline rejected them outright
+// with 429, so a create never runs twice after an ambiguous server error.
+func (c *HTTPClient) call(ctx context.Context, endpoint string, payload any, out any, idempotent bool) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("outline: failed to marshal %s request: %w", endpoint, err)
+	}
+
+	var (
+		lastErr    error
+		retryAfter time.Duration
+	)
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := max(time.Duration(attempt)*c.retryBackoff, retryAfter)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		respBody, statusCode, header, err := c.do(ctx, endpoint, body)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = fmt.Errorf("outline: %s request failed: %w", endpoint, err)
+			if !idempotent {
+				return lastErr
+			}
+			continue
+		}
+
+		if statusCode >= 200 && statusCode < 300 {
+			if out == nil {
+				return nil
+			}
+			if err := decodeJSON(respBody, out); err != nil {
+				return fmt.Errorf("outline: failed to parse %s response: %w", endpoint, err)
+			}
+			return nil
+		}
+
+		lastErr = classifyHTTPError(statusCode, respBody)
+		retryAfter = 0
+		if statusCode == http.StatusTooManyRequests {
+			retryAfter = ratelimit.ParseRetryAfter(header, time.Now())
+			lastErr = &ratelimit.RetryAfterError{Err: lastErr, After: retryAfter}
+			if retryAfter > maxRetryAfterWait {
+				return lastErr
+			}
+		}
+		if !isRetriableHTTPError(statusCode) {
+			return lastErr
+		}
+		if !idempotent && statusCode != http.StatusTooManyRequests {
+			return lastErr
+		}
+	}
+
+	return fmt.Errorf("outline: max retries exceeded for %s: %w", endpoint, lastErr)
+}
+
+func (c *HTTPClient) do(ctx context.Context, endpoint string, body []byte) ([]byte, int, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return respBody, resp.StatusCode, resp.Header, nil
+}
+
+func decodeJSON(data []byte, out any) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return errors.New("empty body")
+	}
+	return json.Unmarshal(data, out)
+}
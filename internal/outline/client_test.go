@@ -0,0 +1,643 @@
+package outline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ratelimit"
+)
+
+const fixturesDir = "../../test/fixtures"
+
+// fakeOutline replays the JSON fixtures behind Outline's RPC endpoints
+type fakeOutline struct {
+	t *testing.T
+
+	mu          sync.Mutex
+	collections []*Collection
+	documents   map[string]*Document
+	comments    map[string][]wireComment
+	calls       map[string]int
+	lastBodies  map[string][]byte
+
+	// statusOverrides forces a status for the next N calls to an endpoint
+	statusOverrides map[string][]int
+	// retryAfter is sent as the Retry-After header on forced 429 responses
+	retryAfter string
+}
+
+func loadFixture(t *testing.T, rel string, out any) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(fixturesDir, rel))
+	if err != nil {
+		t.Fatalf("Failed to load fixture %s: %v", rel, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatalf("Failed to parse fixture %s: %v", rel, err)
+	}
+}
+
+func newFakeOutline(t *testing.T) *fakeOutline {
+	f := &fakeOutline{
+		t:               t,
+		documents:       make(map[string]*Document),
+		comments:        make(map[string][]wireComment),
+		calls:           make(map[string]int),
+		lastBodies:      make(map[string][]byte),
+		statusOverrides: make(map[string][]int),
+	}
+
+	loadFixture(t, "collections/sample_collections.json", &f.collections)
+
+	docFiles, err := filepath.Glob(filepath.Join(fixturesDir, "documents", "*.json"))
+	if err != nil || len(docFiles) == 0 {
+		t.Fatalf("Failed to find document fixtures: %v", err)
+	}
+	for _, path := range docFiles {
+		var doc Document
+		loadFixture(t, filepath.Join("documents", filepath.Base(path)), &doc)
+		f.documents[doc.ID] = &doc
+	}
+
+	return f
+}
+
+func (f *fakeOutline) failNext(endpoint string, statuses ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statusOverrides[endpoint] = append(f.statusOverrides[endpoint], statuses...)
+}
+
+func (f *fakeOutline) callCount(endpoint string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[endpoint]
+}
+
+func (f *fakeOutline) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	endpoint := r.URL.Path
+	f.calls[endpoint]++
+
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, apiError{Error: "method_not_allowed"})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer test-key" {
+		writeJSON(w, http.StatusUnauthorized, apiError{Error: "authentication_required", Message: "Authentication required"})
+		return
+	}
+
+	if pending := f.statusOverrides[endpoint]; len(pending) > 0 {
+		f.statusOverrides[endpoint] = pending[1:]
+		if pending[0] == http.StatusTooManyRequests && f.retryAfter != "" {
+			w.Header().Set("Retry-After", f.retryAfter)
+		}
+		writeJSON(w, pending[0], apiError{Error: "forced", Message: http.StatusText(pending[0])})
+		return
+	}
+
+	var body struct {
+		ID           string         `json:"id"`
+		CollectionID string         `json:"collectionId"`
+		DocumentID   string         `json:"documentId"`
+		Query        string         `json:"query"`
+		Title        string         `json:"title"`
+		Text         string         `json:"text"`
+		Offset       int            `json:"offset"`
+		Limit        int            `json:"limit"`
+		Data         CommentContent `json:"data"`
+	}
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.t.Errorf("reading request body: %v", err)
+	}
+	f.lastBodies[endpoint] = raw
+	if err := json.Unmarshal(raw, &body); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "validation_error", Message: "invalid JSON"})
+		return
+	}
+
+	switch endpoint {
+	case "/api/auth.info":
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]string{"user": "test"}})
+
+	case "/api/collections.list":
+		writeJSON(w, http.StatusOK, dataEnvelope[[]*Collection]{Data: paginate(f.collections, body.Offset, body.Limit)})
+
+	case "/api/collections.info":
+		for _, col := range f.collections {
+			if col.ID == body.ID {
+				writeJSON(w, http.StatusOK, dataEnvelope[*Collection]{Data: col})
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, apiError{Error: "not_found", Message: "Collection not found"})
+
+	case "/api/documents.info":
+		doc, ok := f.documents[body.ID]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, apiError{Error: "not_found", Message: "Document not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, dataEnvelope[*Document]{Data: doc})
+
+	case "/api/documents.list":
+		var docs []*Document
+		for _, doc := range sortedDocs(f.documents) {
+			if doc.CollectionID == body.CollectionID {
+				docs = append(docs, doc)
+			}
+		}
+		writeJSON(w, http.StatusOK, dataEnvelope[[]*Document]{Data: paginate(docs, body.Offset, body.Limit)})
+
+	case "/api/documents.create":
+		if body.CollectionID == "" {
+			writeJSON(w, http.StatusBadRequest, apiError{Error: "validation_error", Message: "collectionId is required"})
+			return
+		}
+		doc := &Document{ID: "doc-created-1", CollectionID: body.CollectionID, Title: body.Title, Text: body.Text}
+		f.documents[doc.ID] = doc
+		writeJSON(w, http.StatusOK, dataEnvelope[*Document]{Data: doc})
+
+	case "/api/documents.update":
+		doc, ok := f.documents[body.ID]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, apiError{Error: "not_found", Message: "Document not found"})
+			return
+		}
+		if body.Title != "" {
+			doc.Title = body.Title
+		}
+		if body.Text != "" {
+			doc.Text = body.Text
+		}
+		writeJSON(w, http.StatusOK, dataEnvelope[*Document]{Data: doc})
+
+	case "/api/documents.move":
+		doc, ok := f.documents[body.ID]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, apiError{Error: "not_found", Message: "Document not found"})
+			return
+		}
+		doc.CollectionID = body.CollectionID
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"documents": []*Document{doc}}})
+
+	case "/api/documents.search":
+		var hits []searchHit
+		for _, doc := range sortedDocs(f.documents) {
+			if body.CollectionID != "" && doc.CollectionID != body.CollectionID {
+				continue
+			}
+			if strings.Contains(strings.ToLower(doc.Text), strings.ToLower(body.Query)) {
+				hits = append(hits, searchHit{Context: body.Query, Ranking: 1, Document: doc})
+			}
+		}
+		writeJSON(w, http.StatusOK, searchResponse{
+			Data:       paginate(hits, body.Offset, body.Limit),
+			Pagination: pagination{Offset: body.Offset, Limit: body.Limit, Total: len(hits)},
+		})
+
+	case "/api/comments.create":
+		if _, ok := f.documents[body.DocumentID]; !ok {
+			writeJSON(w, http.StatusNotFound, apiError{Error: "not_found", Message: "Document not found"})
+			return
+		}
+		comment := wireComment{ID: "comment-1", DocumentID: body.DocumentID, Data: body.Data, CreatedAt: time.Now()}
+		f.comments[body.DocumentID] = append(f.comments[body.DocumentID], comment)
+		writeJSON(w, http.StatusOK, dataEnvelope[wireComment]{Data: comment})
+
+	case "/api/comments.list":
+		writeJSON(w, http.StatusOK, dataEnvelope[[]wireComment]{Data: paginate(f.comments[body.DocumentID], body.Offset, body.Limit)})
+
+	default:
+		writeJSON(w, http.StatusNotFound, apiError{Error: "not_found", Message: "Resource not found"})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	end := len(items)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return items[offset:end]
+}
+
+func sortedDocs(docs map[string]*Document) []*Document {
+	out := make([]*Document, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, doc)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func setupClient(t *testing.T) (*fakeOutline, *HTTPClient) {
+	t.Helper()
+	fake := newFakeOutline(t)
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client := NewHTTPClient(server.URL+"/api/", "test-key", WithRetries(2, time.Millisecond))
+	return fake, client
+}
+
+func TestHTTPClient_Collections(t *testing.T) {
+	_, client := setupClient(t)
+	ctx := context.Background()
+
+	t.Run("list collections", func(t *testing.T) {
+		collections, err := client.ListCollections(ctx)
+		if err != nil {
+			t.Fatalf("ListCollections failed: %v", err)
+		}
+		if len(collections) != 14 {
+			t.Errorf("Expected 14 collections, got %d", len(collections))
+		}
+		if collections[0].Name != "Engineering" {
+			t.Errorf("Expected first collection 'Engineering', got '%s'", collections[0].Name)
+		}
+		if collections[0].CreatedAt.IsZero() {
+			t.Error("Expected CreatedAt to be parsed")
+		}
+	})
+
+	t.Run("get collection", func(t *testing.T) {
+		col, err := client.GetCollection(ctx, "col-marketing-001")
+		if err != nil {
+			t.Fatalf("GetCollection failed: %v", err)
+		}
+		if col.Name != "Marketing" {
+			t.Errorf("Expected 'Marketing', got '%s'", col.Name)
+		}
+	})
+
+	t.Run("get missing collection", func(t *testing.T) {
+		_, err := client.GetCollection(ctx, "col-missing")
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestHTTPClient_Documents(t *testing.T) {
+	fake, client := setupClient(t)
+	ctx := context.Background()
+
+	t.Run("get document from fixture", func(t *testing.T) {
+		doc, err := client.GetDocument(ctx, "doc-with-commands-001")
+		if err != nil {
+			t.Fatalf("GetDocument failed: %v", err)
+		}
+		if doc.Title != "API Design Guidelines - DRAFT" {
+			t.Errorf("Unexpected title '%s'", doc.Title)
+		}
+		if !strings.Contains(doc.Text, "/ai-file engineering related") {
+			t.Error("Expected document text to contain the /ai-file command")
+		}
+		if doc.PublishedAt != nil {
+			t.Error("Expected draft fixture to have no PublishedAt")
+		}
+	})
+
+	t.Run("list documents", func(t *testing.T) {
+		docs, err := client.ListDocuments(ctx, "col-engineering-001")
+		if err != nil {
+			t.Fatalf("ListDocuments failed: %v", err)
+		}
+		if len(docs) != 2 {
+			t.Errorf("Expected 2 engineering documents, got %d", len(docs))
+		}
+	})
+
+	t.Run("create document", func(t *testing.T) {
+		doc, err := client.CreateDocument(ctx, &CreateDocumentRequest{
+			CollectionID: "col-engineering-001",
+			Title:        "New Doc",
+			Text:         "Body",
+			Publish:      true,
+		})
+		if err != nil {
+			t.Fatalf("CreateDocument failed: %v", err)
+		}
+		if doc.ID == "" || doc.Title != "New Doc" {
+			t.Errorf("Unexpected created document: %+v", doc)
+		}
+	})
+
+	t.Run("create document without collection", func(t *testing.T) {
+		_, err := client.CreateDocument(ctx, &CreateDocumentRequest{Title: "Orphan"})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("Expected ErrInvalidRequest, got %v", err)
+		}
+	})
+
+	t.Run("update document omits empty fields", func(t *testing.T) {
+		doc, err := client.UpdateDocument(ctx, "doc-tech-api-design-001", &UpdateDocumentRequest{Title: "Renamed"})
+		if err != nil {
+			t.Fatalf("UpdateDocument failed: %v", err)
+		}
+		if doc.Title != "Renamed" {
+			t.Errorf("Expected title 'Renamed', got '%s'", doc.Title)
+		}
+		if doc.Text == "" {
+			t.Error("Expected text to be preserved")
+		}
+
+		fake.mu.Lock()
+		sent := string(fake.lastBodies["/api/documents.update"])
+		fake.mu.Unlock()
+		if strings.Contains(sent, `"text"`) {
+			t.Errorf("Expected nil text to be omitted from payload, got %s", sent)
+		}
+	})
+
+	t.Run("update document can empty the text", func(t *testing.T) {
+		if _, err := client.UpdateDocument(ctx, "doc-tech-api-design-001", &UpdateDocumentRequest{Text: String("")}); err != nil {
+			t.Fatalf("UpdateDocument failed: %v", err)
+		}
+
+		fake.mu.Lock()
+		sent := string(fake.lastBodies["/api/documents.update"])
+		fake.mu.Unlock()
+		if !strings.Contains(sent, `"text":""`) {
+			t.Errorf("Expected empty text in payload, got %s", sent)
+		}
+	})
+
+	t.Run("move document", func(t *testing.T) {
+		if err := client.MoveDocument(ctx, "doc-ambiguous-mobile-api-001", "col-product-001"); err != nil {
+			t.Fatalf("MoveDocument failed: %v", err)
+		}
+		doc, err := client.GetDocument(ctx, "doc-ambiguous-mobile-api-001")
+		if err != nil {
+			t.Fatalf("GetDocument failed: %v", err)
+		}
+		if doc.CollectionID != "col-product-001" {
+			t.Errorf("Expected document to move to col-product-001, got %s", doc.CollectionID)
+		}
+	})
+
+	t.Run("move missing document", func(t *testing.T) {
+		err := client.MoveDocument(ctx, "doc-missing", "col-product-001")
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestHTTPClient_SearchDocuments(t *testing.T) {
+	_, client := setupClient(t)
+	ctx := context.Background()
+
+	t.Run("search with pagination", func(t *testing.T) {
+		first, err := client.SearchDocuments(ctx, "API", &SearchOptions{Limit: 2})
+		if err != nil {
+			t.Fatalf("SearchDocuments failed: %v", err)
+		}
+		if len(first.Documents) != 2 {
+			t.Fatalf("Expected 2 documents on first page, got %d", len(first.Documents))
+		}
+		if first.TotalCount < 3 {
+			t.Errorf("Expected total count of at least 3, got %d", first.TotalCount)
+		}
+
+		second, err := client.SearchDocuments(ctx, "API", &SearchOptions{Limit: 2, Offset: 2})
+		if err != nil {
+			t.Fatalf("SearchDocuments failed: %v", err)
+		}
+		if len(second.Documents) == 0 {
+			t.Error("Expected documents on second page")
+		}
+		if second.Documents[0].ID == first.Documents[0].ID {
+			t.Error("Expected second page to differ from first")
+		}
+	})
+
+	t.Run("search within collection", func(t *testing.T) {
+		result, err := client.SearchDocuments(ctx, "launch", &SearchOptions{CollectionID: "col-marketing-001"})
+		if err != nil {
+			t.Fatalf("SearchDocuments failed: %v", err)
+		}
+		for _, doc := range result.Documents {
+			if doc.CollectionID != "col-marketing-001" {
+				t.Errorf("Expected only marketing documents, got %s", doc.CollectionID)
+			}
+		}
+	})
+
+	t.Run("search with nil options", func(t *testing.T) {
+		result, err := client.SearchDocuments(ctx, "no-such-term-anywhere", nil)
+		if err != nil {
+			t.Fatalf("SearchDocuments failed: %v", err)
+		}
+		if len(result.Documents) != 0 || result.TotalCount != 0 {
+			t.Errorf("Expected no results, got %d/%d", len(result.Documents), result.TotalCount)
+		}
+	})
+}
+
+func TestHTTPClient_Comments(t *testing.T) {
+	_, client := setupClient(t)
+	ctx := context.Background()
+
+	comment, err := client.CreateComment(ctx, &CreateCommentRequest{
+		DocumentID: "doc-tech-api-design-001",
+		Data:       NewCommentContent("Filed to Engineering"),
+	})
+	if err != nil {
+		t.Fatalf("CreateComment failed: %v", err)
+	}
+	if comment.Data != "Filed to Engineering" {
+		t.Errorf("Expected flattened comment text, got '%s'", comment.Data)
+	}
+
+	comments, err := client.ListComments(ctx, "doc-tech-api-design-001")
+	if err != nil {
+		t.Fatalf("ListComments failed: %v", err)
+	}
+	if len(comments) != 1 || comments[0].Data != "Filed to Engineering" {
+		t.Errorf("Unexpected comments: %+v", comments)
+	}
+
+	_, err = client.CreateComment(ctx, &CreateCommentRequest{DocumentID: "doc-missing", Data: NewCommentContent("x")})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestHTTPClient_Ping(t *testing.T) {
+	fake := newFakeOutline(t)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	if err := NewHTTPClient(server.URL+"/api", "test-key").Ping(context.Background()); err != nil {
+		t.Errorf("Ping failed: %v", err)
+	}
+
+	err := NewHTTPClient(server.URL+"/api", "wrong-key").Ping(context.Background())
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestHTTPClient_RetryLogic(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retries transient server errors", func(t *testing.T) {
+		fake, client := setupClient(t)
+		fake.failNext("/api/documents.info", http.StatusBadGateway, http.StatusServiceUnavailable)
+
+		doc, err := client.GetDocument(ctx, "doc-tech-api-design-001")
+		if err != nil {
+			t.Fatalf("Expected retry to succeed, got %v", err)
+		}
+		if doc.ID != "doc-tech-api-design-001" {
+			t.Errorf("Unexpected document %s", doc.ID)
+		}
+		if got := fake.callCount("/api/documents.info"); got != 3 {
+			t.Errorf("Expected 3 attempts, got %d", got)
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		fake, client := setupClient(t)
+		fake.failNext("/api/documents.info", 500, 500, 500, 500)
+
+		_, err := client.GetDocument(ctx, "doc-tech-api-design-001")
+		if !errors.Is(err, ErrServerError) {
+			t.Errorf("Expected ErrServerError, got %v", err)
+		}
+		if got := fake.callCount("/api/documents.info"); got != 3 {
+			t.Errorf("Expected 3 attempts, got %d", got)
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		fake, client := setupClient(t)
+		fake.failNext("/api/documents.update", http.StatusBadRequest)
+
+		_, err := client.UpdateDocument(ctx, "doc-tech-api-design-001", &UpdateDocumentRequest{Text: String("x")})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("Expected ErrInvalidRequest, got %v", err)
+		}
+		if got := fake.callCount("/api/documents.update"); got != 1 {
+			t.Errorf("Expected 1 attempt, got %d", got)
+		}
+	})
+
+	t.Run("does not retry comment creation after server error", func(t *testing.T) {
+		fake, client := setupClient(t)
+		fake.failNext("/api/comments.create", http.StatusInternalServerError)
+
+		_, err := client.CreateComment(ctx, &CreateCommentRequest{
+			DocumentID: "doc-tech-api-design-001",
+			Data:       NewCommentContent("hello"),
+		})
+		if !errors.Is(err, ErrServerError) {
+			t.Errorf("Expected ErrServerError, got %v", err)
+		}
+		if got := fake.callCount("/api/comments.create"); got != 1 {
+			t.Errorf("Expected 1 attempt, got %d", got)
+		}
+	})
+
+	t.Run("retries comment creation after rate limit", func(t *testing.T) {
+		fake, client := setupClient(t)
+		fake.failNext("/api/comments.create", http.StatusTooManyRequests)
+
+		_, err := client.CreateComment(ctx, &CreateCommentRequest{
+			DocumentID: "doc-tech-api-design-001",
+			Data:       NewCommentContent("hello"),
+		})
+		if err != nil {
+			t.Fatalf("Expected retry to succeed, got %v", err)
+		}
+		if got := fake.callCount("/api/comments.create"); got != 2 {
+			t.Errorf("Expected 2 attempts, got %d", got)
+		}
+	})
+
+	t.Run("long Retry-After is returned instead of slept on", func(t *testing.T) {
+		fake, client := setupClient(t)
+		fake.retryAfter = "3600"
+		fake.failNext("/api/documents.search", http.StatusTooManyRequests)
+
+		_, err := client.SearchDocuments(ctx, "api", nil)
+		if !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("Expected ErrRateLimited, got %v", err)
+		}
+		if pause, ok := ratelimit.RetryAfter(err); !ok || pause != time.Hour {
+			t.Errorf("Expected a 1h Retry-After hint, got %s (%v)", pause, ok)
+		}
+		if got := fake.callCount("/api/documents.search"); got != 1 {
+			t.Errorf("Expected 1 attempt, got %d", got)
+		}
+	})
+
+	t.Run("context cancellation stops retries", func(t *testing.T) {
+		fake := newFakeOutline(t)
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		fake.failNext("/api/documents.info", 503, 503, 503)
+
+		client := NewHTTPClient(server.URL+"/api", "test-key", WithRetries(3, time.Hour))
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := client.GetDocument(ctx, "doc-tech-api-design-001")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected error
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrUnauthorized},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusBadRequest, ErrInvalidRequest},
+		{http.StatusUnprocessableEntity, ErrInvalidRequest},
+		{http.StatusInternalServerError, ErrServerError},
+		{http.StatusServiceUnavailable, ErrServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			err := classifyHTTPError(tt.status, []byte(`{"ok":false,"error":"x","message":"detail"}`))
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+			if !strings.Contains(err.Error(), "detail") {
+				t.Errorf("Expected error to carry server message, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package outline
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	public "github.com/yourusername/outline-ai/pkg/outline"
+)
+
+// Package-level errors for the Outline API client, shared with pkg/outline
+var (
+	ErrUnauthorized   = public.ErrUnauthorized
+	ErrNotFound       = public.ErrNotFound
+	ErrRateLimited    = public.ErrRateLimited
+	ErrServerError    = public.ErrServerError
+	ErrInvalidRequest = public.ErrInvalidRequest
+)
+
+// apiError is the error envelope Outline returns alongside non-2xx statuses
+type apiError struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// classifyHTTPError maps an HTTP status onto the package sentinel errors
+func classifyHTTPError(statusCode int, body []byte) error {
+	detail := errorDetail(body)
+
+	var sentinel error
+	switch {
+	case statusCode == http.StatusUnauthorized, statusCode == http.StatusForbidden:
+		sentinel = ErrUnauthorized
+	case statusCode == http.StatusNotFound:
+		sentinel = ErrNotFound
+	case statusCode == http.StatusTooManyRequests:
+		sentinel = ErrRateLimited
+	case statusCode >= 500:
+		sentinel = ErrServerError
+	case statusCode >= 400:
+		sentinel = ErrInvalidRequest
+	default:
+		return fmt.Errorf("outline: unexpected HTTP status %d: %s", statusCode, detail)
+	}
+
+	if detail == "" {
+		return fmt.Errorf("%w (HTTP %d)", sentinel, statusCode)
+	}
+	return fmt.Errorf("%w (HTTP %d): %s", sentinel, statusCode, detail)
+}
+
+// isRetriableHTTPError reports whether a status is worth retrying
+func isRetriableHTTPError(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func errorDetail(body []byte) string {
+	var envelope apiError
+	if err := decodeJSON(body, &envelope); err == nil {
+		if envelope.Message != "" {
+			return envelope.Message
+		}
+		if envelope.Error != "" {
+			return envelope.Error
+		}
+	}
+
+	detail := strings.TrimSpace(string(body))
+	if len(detail) > 200 {
+		detail = detail[:200]
+	}
+	return detail
+}
@@ -0,0 +1,33 @@
+package outline
+
+import (
+	public "github.com/yourusername/outline-ai/pkg/outline"
+)
+
+// The models are defined in pkg/outline so that handlers built outside this
+// module can use them; these aliases keep the internal packages reading as
+// before
+type (
+	Collection            = public.Collection
+	Document              = public.Document
+	User                  = public.User
+	CreateDocumentRequest = public.CreateDocumentRequest
+	UpdateDocumentRequest = public.UpdateDocumentRequest
+	Comment               = public.Comment
+	CommentContent        = public.CommentContent
+	ContentNode           = public.ContentNode
+	Mark                  = public.Mark
+	CreateCommentRequest  = public.CreateCommentRequest
+	SearchOptions         = public.SearchOptions
+	SearchResult          = public.SearchResult
+)
+
+// Node and comment builders, see pkg/outline
+var (
+	TextNode             = public.TextNode
+	LinkNode             = public.LinkNode
+	ParagraphNode        = public.ParagraphNode
+	NewCommentContent    = public.NewCommentContent
+	NewCommentParagraphs = public.NewCommentParagraphs
+	String               = public.String
+)
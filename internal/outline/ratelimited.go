@@ -0,0 +1,142 @@
+package outline
+
+import (
+	"context"
+
+	"github.com/yourusername/outline-ai/internal/ratelimit"
+)
+
+var _ Client = (*RateLimitedClient)(nil)
+
+// RateLimitedClient decorates a Client with token buckets. Reads (lists,
+// lookups, search) and writes (create, update, move, comment) draw from
+// separate budgets so a burst of searches cannot starve document updates.
+// A Retry-After hint from either kind of call pauses both buckets, since
+// Outline limits per API key.
+type RateLimitedClient struct {
+	next  Client
+	read  *ratelimit.Limiter
+	write *ratelimit.Limiter
+}
+
+// NewRateLimitedClient wraps next. read and write may be the same limiter to
+// share one budget.
+func NewRateLimitedClient(next Client, read, write *ratelimit.Limiter) *RateLimitedClient {
+	return &RateLimitedClient{next: next, read: read, write: write}
+}
+
+func (c *RateLimitedClient) limit(ctx context.Context, limiter *ratelimit.Limiter, fn func() error) error {
+	if err := limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	err := fn()
+	if pause, ok := ratelimit.RetryAfter(err); ok {
+		c.read.PauseFor(pause)
+		c.write.PauseFor(pause)
+	}
+	return err
+}
+
+// Collections
+
+// ListCollections draws from the read budget
+func (c *RateLimitedClient) ListCollections(ctx context.Context) (collections []*Collection, err error) {
+	err = c.limit(ctx, c.read, func() error {
+		collections, err = c.next.ListCollections(ctx)
+		return err
+	})
+	return collections, err
+}
+
+// GetCollection draws from the read budget
+func (c *RateLimitedClient) GetCollection(ctx context.Context, id string) (collection *Collection, err error) {
+	err = c.limit(ctx, c.read, func() error {
+		collection, err = c.next.GetCollection(ctx, id)
+		return err
+	})
+	return collection, err
+}
+
+// Documents
+
+// GetDocument draws from the read budget
+func (c *RateLimitedClient) GetDocument(ctx context.Context, id string) (doc *Document, err error) {
+	err = c.limit(ctx, c.read, func() error {
+		doc, err = c.next.GetDocument(ctx, id)
+		return err
+	})
+	return doc, err
+}
+
+// ListDocuments draws from the read budget
+func (c *RateLimitedClient) ListDocuments(ctx context.Context, collectionID string) (docs []*Document, err error) {
+	err = c.limit(ctx, c.read, func() error {
+		docs, err = c.next.ListDocuments(ctx, collectionID)
+		return err
+	})
+	return docs, err
+}
+
+// CreateDocument draws from the write budget
+func (c *RateLimitedClient) CreateDocument(ctx context.Context, req *CreateDocumentRequest) (doc *Document, err error) {
+	err = c.limit(ctx, c.write, func() error {
+		doc, err = c.next.CreateDocument(ctx, req)
+		return err
+	})
+	return doc, err
+}
+
+// UpdateDocument draws from the write budget
+func (c *RateLimitedClient) UpdateDocument(ctx context.Context, id string, req *UpdateDocumentRequest) (doc *Document, err error) {
+	err = c.limit(ctx, c.write, func() error {
+		doc, err = c.next.UpdateDocument(ctx, id, req)
+		return err
+	})
+	return doc, err
+}
+
+// MoveDocument draws from the write budget
+func (c *RateLimitedClient) MoveDocument(ctx context.Context, id, collectionID string) error {
+	return c.limit(ctx, c.write, func() error {
+		return c.next.MoveDocument(ctx, id, collectionID)
+	})
+}
+
+// Search
+
+// SearchDocuments draws from the read budget
+func (c *RateLimitedClient) SearchDocuments(ctx context.Context, query string, opts *SearchOptions) (result *SearchResult, err error) {
+	err = c.limit(ctx, c.read, func() error {
+		result, err = c.next.SearchDocuments(ctx, query, opts)
+		return err
+	})
+	return result, err
+}
+
+// Comments
+
+// CreateComment draws from the write budget
+func (c *RateLimitedClient) CreateComment(ctx context.Context, req *CreateCommentRequest) (comment *Comment, err error) {
+	err = c.limit(ctx, c.write, func() error {
+		comment, err = c.next.CreateComment(ctx, req)
+		return err
+	})
+	return comment, err
+}
+
+// ListComments draws from the read budget
+func (c *RateLimitedClient) ListComments(ctx context.Context, documentID string) (comments []*Comment, err error) {
+	err = c.limit(ctx, c.read, func() error {
+		comments, err = c.next.ListComments(ctx, documentID)
+		return err
+	})
+	return comments, err
+}
+
+// Health
+
+// Ping bypasses the buckets so health checks never queue behind real work
+func (c *RateLimitedClient) Ping(ctx context.Context) error {
+	return c.next.Ping(ctx)
+}
@@ -0,0 +1,100 @@
+package outline_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/ratelimit"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+func newRateLimitedMock() (*outline.RateLimitedClient, *mocks.OutlineMock, *ratelimit.Limiter, *ratelimit.Limiter) {
+	mock := mocks.NewOutlineMock()
+	mock.AddCollection("col-1", "Engineering", "")
+	mock.AddDocument("doc-1", "col-1", "API Design", "Body")
+
+	read := ratelimit.NewLimiter(60, 1)
+	write := ratelimit.NewLimiter(60, 1)
+	return outline.NewRateLimitedClient(mock, read, write), mock, read, write
+}
+
+func TestRateLimitedClient_SeparateBudgets(t *testing.T) {
+	ctx := context.Background()
+	client, mock, _, _ := newRateLimitedMock()
+
+	if _, err := client.SearchDocuments(ctx, "api", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The read bucket is empty, but writes have their own budget
+	if _, err := client.UpdateDocument(ctx, "doc-1", &outline.UpdateDocumentRequest{Text: outline.String("New body")}); err != nil {
+		t.Fatalf("Expected write to use its own budget, got %v", err)
+	}
+
+	shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetDocument(shortCtx, "doc-1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected exhausted read budget to hit the deadline, got %v", err)
+	}
+	if got := mock.GetCallCount("GetDocument"); got != 0 {
+		t.Errorf("Expected throttled call not to reach Outline, got %d calls", got)
+	}
+}
+
+func TestRateLimitedClient_RetryAfterPausesBothBudgets(t *testing.T) {
+	ctx := context.Background()
+	client, mock, read, write := newRateLimitedMock()
+
+	mock.SetGetDocumentError(&ratelimit.RetryAfterError{Err: outline.ErrRateLimited, After: time.Minute})
+	if _, err := client.GetDocument(ctx, "doc-1"); !errors.Is(err, outline.ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+
+	for name, limiter := range map[string]*ratelimit.Limiter{"read": read, "write": write} {
+		if until := time.Until(limiter.PausedUntil()); until < 50*time.Second {
+			t.Errorf("Expected %s budget to be paused for about a minute, got %s", name, until)
+		}
+	}
+
+	shortCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := client.MoveDocument(shortCtx, "doc-1", "col-1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected paused write to fail before its deadline, got %v", err)
+	}
+}
+
+func TestRateLimitedClient_CancelledWait(t *testing.T) {
+	ctx := context.Background()
+	client, _, read, _ := newRateLimitedMock()
+	read.PauseFor(time.Hour)
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.ListCollections(cancelCtx)
+		done <- err
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected queued call to abort on cancellation")
+	}
+}
+
+func TestRateLimitedClient_PingBypassesBudget(t *testing.T) {
+	client, _, read, write := newRateLimitedMock()
+	read.PauseFor(time.Hour)
+	write.PauseFor(time.Hour)
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("Expected Ping to bypass paused budgets, got %v", err)
+	}
+}
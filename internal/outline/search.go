@@ -0,0 +1,35 @@
+package outline
+
+import (
+	"context"
+	"fmt"
+)
+
+// SearchEach runs each query in turn, paging through its results pageSize
+// hits at a time and calling visit for every document. A document matching
+// several queries is visited once per query. Errors from visit are returned
+// as they are.
+func SearchEach[Q ~string](ctx context.Context, client Client, queries []Q, pageSize int, visit func(doc *Document) error) error {
+	for _, query := range queries {
+		for offset := 0; ; {
+			result, err := client.SearchDocuments(ctx, string(query), &SearchOptions{
+				Offset: offset,
+				Limit:  pageSize,
+			})
+			if err != nil {
+				return fmt.Errorf("outline: failed to search for %s: %w", query, err)
+			}
+			for _, doc := range result.Documents {
+				if err := visit(doc); err != nil {
+					return err
+				}
+			}
+
+			offset += len(result.Documents)
+			if len(result.Documents) == 0 || offset >= result.TotalCount {
+				break
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package outline_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/pkg/commands"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+func TestSearchEach(t *testing.T) {
+	ctx := context.Background()
+	mock := mocks.NewOutlineMock()
+	for i := range 5 {
+		mock.AddDocument(fmt.Sprintf("doc-%d", i), "col-1", "Notes", "/ai question?")
+	}
+	mock.AddDocument("doc-both", "col-1", "Both", "/summarize\n\n/ai question?")
+
+	markers := []commands.CommandType{commands.CommandAI, commands.CommandSummarize}
+	visits := make(map[string]int)
+	err := outline.SearchEach(ctx, mock, markers, 2, func(doc *outline.Document) error {
+		visits[doc.ID]++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SearchEach failed: %v", err)
+	}
+	if len(visits) != 6 || visits["doc-both"] != 2 {
+		t.Errorf("Expected every page read and doc-both visited once per marker, got %v", visits)
+	}
+	if calls := mock.GetCallCount("SearchDocuments"); calls != 4 {
+		t.Errorf("Expected 3 pages for /ai and 1 for /summarize, got %d searches", calls)
+	}
+
+	errStop := errors.New("stop")
+	err = outline.SearchEach(ctx, mock, markers, 2, func(doc *outline.Document) error { return errStop })
+	if err != errStop {
+		t.Errorf("Expected the visit error unchanged, got %v", err)
+	}
+
+	mock.SetFailureMode(true)
+	err = outline.SearchEach(ctx, mock, markers, 2, func(doc *outline.Document) error { return nil })
+	if !errors.Is(err, outline.ErrServerError) {
+		t.Errorf("Expected ErrServerError, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Package-level errors for the persistence layer
+var (
+	ErrNotFound         = errors.New("persistence: record not found")
+	ErrQuestionNotFound = errors.New("persistence: question not found")
+	ErrDuplicateEntry   = errors.New("persistence: duplicate entry")
+	ErrDatabaseLocked   = errors.New("persistence: database locked")
+	ErrInvalidInput     = errors.New("persistence: invalid input")
+)
+
+// wrapError maps SQLite result codes onto the package sentinels, keeping the
+// driver error in the message
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	switch {
+	case sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique,
+		sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey:
+		return fmt.Errorf("%w: %v", ErrDuplicateEntry, err)
+	case sqliteErr.Code == sqlite3.ErrBusy, sqliteErr.Code == sqlite3.ErrLocked:
+		return fmt.Errorf("%w: %v", ErrDatabaseLocked, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,45 @@
+package persistence
+
+import (
+	"context"
+	"time"
+)
+
+// Storage is the contract for persisting Q&A state, command history, dead
+// letters and cursors
+type Storage interface {
+	// Q&A state management
+	HasAnsweredQuestion(ctx context.Context, questionHash string) (bool, error)
+	MarkQuestionAnswered(ctx context.Context, state *QuestionState) error
+	RecordQuestionFailure(ctx context.Context, state *QuestionState) error
+	GetQuestionState(ctx context.Context, questionHash string) (*QuestionState, error)
+	UpdateQuestionState(ctx context.Context, state *QuestionState) error
+	DeleteStaleQuestions(ctx context.Context, olderThan time.Time) (int64, error)
+
+	// Command logging
+	LogCommand(ctx context.Context, log *CommandLog) error
+	GetCommandHistory(ctx context.Context, documentID string, limit int) ([]*CommandLog, error)
+	GetUsage(ctx context.Context, query UsageQuery) ([]*UsageSummary, error)
+
+	// Audit log
+	RecordAudit(ctx context.Context, entry *AuditEntry) error
+	GetAuditTrail(ctx context.Context, documentID string, limit int) ([]*AuditEntry, error)
+
+	// Dead letters
+	AddDeadLetter(ctx context.Context, letter *DeadLetter) error
+	ListDeadLetters(ctx context.Context, limit int) ([]*DeadLetter, error)
+	DeleteDeadLetter(ctx context.Context, id int64) error
+
+	// Cursors
+	GetCursor(ctx context.Context, name string) (time.Time, error)
+	SetCursor(ctx context.Context, name string, position time.Time) error
+
+	// Health and maintenance
+	Ping(ctx context.Context) error
+	Close() error
+	Backup(ctx context.Context, destinationPath string) error
+
+	// RunInTransaction runs fn so that every Storage call made with the
+	// context it receives commits or rolls back together
+	RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,175 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration is one forward-only schema change. Versions must be unique and
+// increasing; never edit a migration that has shipped, add a new one instead.
+type migration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+var migrations = []migration{
+	{
+		version:     1,
+		description: "question state and command log",
+		statements: []string{
+			`CREATE TABLE question_state (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				question_hash TEXT NOT NULL UNIQUE,
+				document_id TEXT NOT NULL,
+				question_text TEXT NOT NULL,
+				processed_at TIMESTAMP NOT NULL,
+				answer_delivered BOOLEAN NOT NULL DEFAULT FALSE,
+				comment_id TEXT,
+				last_error TEXT,
+				retry_count INTEGER NOT NULL DEFAULT 0,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX idx_question_state_document ON question_state(document_id)`,
+			`CREATE INDEX idx_question_state_processed ON question_state(processed_at)`,
+			`CREATE TABLE command_log (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				document_id TEXT NOT NULL,
+				command_type TEXT NOT NULL,
+				command_args TEXT,
+				executed_at TIMESTAMP NOT NULL,
+				status TEXT NOT NULL,
+				error_message TEXT,
+				execution_time_ms INTEGER,
+				created_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX idx_command_log_document ON command_log(document_id)`,
+			`CREATE INDEX idx_command_log_executed ON command_log(executed_at)`,
+			`CREATE INDEX idx_command_log_status ON command_log(status)`,
+		},
+	},
+	{
+		version:     2,
+		description: "dead letters",
+		statements: []string{
+			`CREATE TABLE dead_letters (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				task_id TEXT NOT NULL,
+				task_type TEXT NOT NULL,
+				document_id TEXT,
+				attempts INTEGER NOT NULL,
+				last_error TEXT NOT NULL,
+				failed_at TIMESTAMP NOT NULL,
+				created_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX idx_dead_letters_failed ON dead_letters(failed_at)`,
+		},
+	},
+	{
+		version:     3,
+		description: "cursors",
+		statements: []string{
+			`CREATE TABLE cursors (
+				name TEXT PRIMARY KEY,
+				position TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+		},
+	},
+	{
+		version:     4,
+		description: "token usage in the command log",
+		statements: []string{
+			`ALTER TABLE command_log ADD COLUMN collection_id TEXT`,
+			`ALTER TABLE command_log ADD COLUMN user_id TEXT`,
+			`ALTER TABLE command_log ADD COLUMN prompt_tokens INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE command_log ADD COLUMN completion_tokens INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE command_log ADD COLUMN cost_usd REAL NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version:     5,
+		description: "audit log",
+		statements: []string{
+			`ALTER TABLE command_log ADD COLUMN correlation_id TEXT`,
+			`CREATE TABLE audit_log (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				document_id TEXT NOT NULL,
+				action TEXT NOT NULL,
+				command_type TEXT,
+				correlation_id TEXT,
+				before_hash TEXT NOT NULL,
+				after_hash TEXT NOT NULL,
+				diff TEXT NOT NULL,
+				reasoning TEXT,
+				created_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX idx_audit_log_document ON audit_log(document_id, created_at)`,
+			`CREATE INDEX idx_audit_log_correlation ON audit_log(correlation_id)`,
+			// Entries are evidence, so the table only ever grows
+			`CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+			BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END`,
+			`CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+			BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END`,
+		},
+	},
+}
+
+// migrate brings the schema up to the latest version, applying each pending
+// migration in its own transaction
+func migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("persistence: failed to create schema_migrations: %w", wrapError(err))
+	}
+
+	current, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, db, m); err != nil {
+			return fmt.Errorf("persistence: migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+	}
+	return nil
+}
+
+func schemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("persistence: failed to read schema version: %w", wrapError(err))
+	}
+	return int(version.Int64), nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapError(err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return wrapError(err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.description, time.Now().UTC(),
+	); err != nil {
+		return wrapError(err)
+	}
+	return wrapError(tx.Commit())
+}
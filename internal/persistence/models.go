@@ -0,0 +1,111 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+// QuestionState represents the state of a question
+type QuestionState struct {
+	ID              int64
+	QuestionHash    string
+	DocumentID      string
+	QuestionText    string
+	ProcessedAt     time.Time
+	AnswerDelivered bool
+	CommentID       *string
+	LastError       *string
+	RetryCount      int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// CommandLog represents a logged command execution. It is defined in
+// pkg/commands, which writes it.
+type CommandLog = commands.CommandLog
+
+// Command status constants
+const (
+	CommandStatusSuccess  = commands.StatusSuccess
+	CommandStatusFailed   = commands.StatusFailed
+	CommandStatusRetrying = commands.StatusRetrying
+)
+
+// UsageGroup is what a usage report is broken down by
+type UsageGroup string
+
+// Usage groupings
+const (
+	UsageByDay        UsageGroup = "day"
+	UsageByCommand    UsageGroup = "command"
+	UsageByCollection UsageGroup = "collection"
+	UsageByUser       UsageGroup = "user"
+)
+
+// UsageQuery selects the logged commands a usage report covers. A zero
+// Since or Until leaves that end open; Until is exclusive. An empty
+// GroupBy reports a single total.
+type UsageQuery struct {
+	Since   time.Time
+	Until   time.Time
+	GroupBy UsageGroup
+}
+
+// UsageSummary totals the usage of one group: a day as YYYY-MM-DD (UTC), a
+// command type, a collection ID or a user ID. Key is empty for the total
+// and for commands without a collection or user.
+type UsageSummary struct {
+	Key              string
+	Commands         int
+	PromptTokens     int64
+	CompletionTokens int64
+	CostUSD          float64
+}
+
+// Audit actions: the Outline calls that change a document
+const (
+	AuditActionUpdate  = "update_document"
+	AuditActionMove    = "move_document"
+	AuditActionComment = "create_comment"
+)
+
+// AuditEntry records one change made to a document. Entries are never
+// updated or deleted.
+type AuditEntry struct {
+	ID         int64
+	DocumentID string
+	Action     string
+
+	// The command that made the change and the correlation ID of its
+	// command log entry; both are empty for changes made outside a command
+	CommandType   string
+	CorrelationID string
+
+	// SHA-256 of the document text before and after, hex encoded. They are
+	// equal for moves and comments, which leave the text alone.
+	BeforeHash string
+	AfterHash  string
+
+	// Diff shows what changed: a line diff of the title and text for
+	// updates, the old and new collection for moves, the comment for
+	// comments
+	Diff string
+
+	// Reasoning is the AI's explanation for the change, when it gave one
+	Reasoning string
+
+	CreatedAt time.Time
+}
+
+// DeadLetter records a task that failed after using up its retries
+type DeadLetter struct {
+	ID         int64
+	TaskID     string
+	TaskType   string
+	DocumentID string
+	Attempts   int
+	LastError  string
+	FailedAt   time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,676 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	// defaultBusyTimeout is how long a writer waits on another connection's
+	// lock before the call fails with ErrDatabaseLocked
+	defaultBusyTimeout = 5 * time.Second
+
+	// maxOpenConns allows concurrent readers alongside the single WAL writer
+	maxOpenConns = 4
+)
+
+var _ Storage = (*SQLiteStorage)(nil)
+
+// SQLiteStorage implements Storage on a single SQLite file in WAL mode
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+type sqliteOptions struct {
+	busyTimeout time.Duration
+}
+
+// Option configures a SQLiteStorage
+type Option func(*sqliteOptions)
+
+// WithBusyTimeout sets how long a write waits for a competing lock before
+// failing with ErrDatabaseLocked
+func WithBusyTimeout(timeout time.Duration) Option {
+	return func(o *sqliteOptions) {
+		o.busyTimeout = timeout
+	}
+}
+
+// NewSQLiteStorage opens (creating if needed) the database at path and
+// applies any pending schema migrations
+func NewSQLiteStorage(path string, opts ...Option) (*SQLiteStorage, error) {
+	options := sqliteOptions{busyTimeout: defaultBusyTimeout}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("%w: database path is empty", ErrInvalidInput)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("persistence: failed to create database directory: %w", err)
+		}
+	}
+
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=%d&_journal_mode=WAL&_foreign_keys=on&_txlock=immediate",
+		path, options.busyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("persistence: failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(maxOpenConns)
+
+	ctx := context.Background()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("persistence: failed to open database: %w", wrapError(err))
+	}
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStorage{db: db}, nil
+}
+
+// querier is the subset of *sql.DB and *sql.Tx used by the storage methods
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// conn returns the transaction carried by ctx, or the pool outside one
+func (s *SQLiteStorage) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return s.db
+}
+
+// Q&A State Management
+
+// HasAnsweredQuestion reports whether an answer was delivered for the hash
+func (s *SQLiteStorage) HasAnsweredQuestion(ctx context.Context, questionHash string) (bool, error) {
+	var delivered bool
+	err := s.conn(ctx).QueryRowContext(ctx,
+		`SELECT answer_delivered FROM question_state WHERE question_hash = ?`, questionHash,
+	).Scan(&delivered)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, wrapError(err)
+	}
+	return delivered, nil
+}
+
+// MarkQuestionAnswered records a delivered answer. It returns
+// ErrDuplicateEntry if the hash is already tracked.
+func (s *SQLiteStorage) MarkQuestionAnswered(ctx context.Context, state *QuestionState) error {
+	if state == nil || state.QuestionHash == "" || state.DocumentID == "" {
+		return fmt.Errorf("%w: question hash and document ID are required", ErrInvalidInput)
+	}
+
+	now := time.Now().UTC()
+	res, err := s.conn(ctx).ExecContext(ctx,
+		`INSERT INTO question_state
+			(question_hash, document_id, question_text, processed_at, answer_delivered,
+			 comment_id, last_error, retry_count, created_at, updated_at)
+		VALUES (?, ?, ?, ?, TRUE, ?, ?, ?, ?, ?)`,
+		state.QuestionHash, state.DocumentID, state.QuestionText, now,
+		state.CommentID, state.LastError, state.RetryCount, now, now,
+	)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return wrapError(err)
+	}
+	state.ID = id
+	state.AnswerDelivered = true
+	state.ProcessedAt = now
+	state.CreatedAt = now
+	state.UpdatedAt = now
+	return nil
+}
+
+// RecordQuestionFailure records a failed attempt at answering with
+// state.LastError. A new question is inserted undelivered with one retry;
+// a known one keeps its delivery status and has its retry count bumped.
+func (s *SQLiteStorage) RecordQuestionFailure(ctx context.Context, state *QuestionState) error {
+	if state == nil || state.QuestionHash == "" || state.DocumentID == "" {
+		return fmt.Errorf("%w: question hash and document ID are required", ErrInvalidInput)
+	}
+
+	now := time.Now().UTC()
+	var commentID sql.NullString
+	err := s.conn(ctx).QueryRowContext(ctx,
+		`INSERT INTO question_state
+			(question_hash, document_id, question_text, processed_at, answer_delivered,
+			 comment_id, last_error, retry_count, created_at, updated_at)
+		VALUES (?, ?, ?, ?, FALSE, NULL, ?, 1, ?, ?)
+		ON CONFLICT (question_hash) DO UPDATE SET
+			processed_at = excluded.processed_at,
+			last_error = excluded.last_error,
+			retry_count = question_state.retry_count + 1,
+			updated_at = excluded.updated_at
+		RETURNING id, answer_delivered, comment_id, retry_count, created_at`,
+		state.QuestionHash, state.DocumentID, state.QuestionText, now,
+		state.LastError, now, now,
+	).Scan(&state.ID, &state.AnswerDelivered, &commentID, &state.RetryCount, &state.CreatedAt)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	state.CommentID = nullableString(commentID)
+	state.ProcessedAt = now
+	state.UpdatedAt = now
+	return nil
+}
+
+// GetQuestionState returns the tracked state or ErrQuestionNotFound
+func (s *SQLiteStorage) GetQuestionState(ctx context.Context, questionHash string) (*QuestionState, error) {
+	var (
+		state              QuestionState
+		commentID, lastErr sql.NullString
+	)
+	err := s.conn(ctx).QueryRowContext(ctx,
+		`SELECT id, question_hash, document_id, question_text, processed_at, answer_delivered,
+			comment_id, last_error, retry_count, created_at, updated_at
+		FROM question_state WHERE question_hash = ?`, questionHash,
+	).Scan(
+		&state.ID, &state.QuestionHash, &state.DocumentID, &state.QuestionText, &state.ProcessedAt,
+		&state.AnswerDelivered, &commentID, &lastErr, &state.RetryCount, &state.CreatedAt, &state.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrQuestionNotFound
+	}
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	state.CommentID = nullableString(commentID)
+	state.LastError = nullableString(lastErr)
+	return &state, nil
+}
+
+// UpdateQuestionState overwrites the mutable fields of an existing state,
+// matched by hash, or returns ErrQuestionNotFound
+func (s *SQLiteStorage) UpdateQuestionState(ctx context.Context, state *QuestionState) error {
+	if state == nil || state.QuestionHash == "" {
+		return fmt.Errorf("%w: question hash is required", ErrInvalidInput)
+	}
+
+	now := time.Now().UTC()
+	processedAt := state.ProcessedAt
+	if processedAt.IsZero() {
+		processedAt = now
+	}
+
+	err := s.conn(ctx).QueryRowContext(ctx,
+		`UPDATE question_state SET
+			document_id = ?, question_text = ?, processed_at = ?, answer_delivered = ?,
+			comment_id = ?, last_error = ?, retry_count = ?, updated_at = ?
+		WHERE question_hash = ?
+		RETURNING id, created_at`,
+		state.DocumentID, state.QuestionText, processedAt.UTC(), state.AnswerDelivered,
+		state.CommentID, state.LastError, state.RetryCount, now, state.QuestionHash,
+	).Scan(&state.ID, &state.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrQuestionNotFound
+	}
+	if err != nil {
+		return wrapError(err)
+	}
+
+	state.ProcessedAt = processedAt
+	state.UpdatedAt = now
+	return nil
+}
+
+// DeleteStaleQuestions removes states processed before olderThan
+func (s *SQLiteStorage) DeleteStaleQuestions(ctx context.Context, olderThan time.Time) (int64, error) {
+	res, err := s.conn(ctx).ExecContext(ctx,
+		`DELETE FROM question_state WHERE processed_at < ?`, olderThan.UTC(),
+	)
+	if err != nil {
+		return 0, wrapError(err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, wrapError(err)
+	}
+	return deleted, nil
+}
+
+// Command Logging
+
+// LogCommand appends a command execution to the log
+func (s *SQLiteStorage) LogCommand(ctx context.Context, log *CommandLog) error {
+	if log == nil || log.DocumentID == "" || log.CommandType == "" || log.Status == "" {
+		return fmt.Errorf("%w: document ID, command type and status are required", ErrInvalidInput)
+	}
+
+	now := time.Now().UTC()
+	executedAt := log.ExecutedAt
+	if executedAt.IsZero() {
+		executedAt = now
+	}
+
+	res, err := s.conn(ctx).ExecContext(ctx,
+		`INSERT INTO command_log
+			(document_id, command_type, command_args, executed_at, status,
+			 error_message, execution_time_ms, created_at,
+			 collection_id, user_id, prompt_tokens, completion_tokens, cost_usd, correlation_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		log.DocumentID, log.CommandType, log.CommandArgs, executedAt.UTC(), log.Status,
+		log.ErrorMessage, log.ExecutionTimeMs, now,
+		nullIfEmpty(log.CollectionID), nullIfEmpty(log.UserID), log.PromptTokens, log.CompletionTokens, log.CostUSD,
+		nullIfEmpty(log.CorrelationID),
+	)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return wrapError(err)
+	}
+	log.ID = id
+	log.ExecutedAt = executedAt
+	log.CreatedAt = now
+	return nil
+}
+
+// GetCommandHistory returns a document's commands, newest first. A limit of
+// zero or less returns the full history.
+func (s *SQLiteStorage) GetCommandHistory(ctx context.Context, documentID string, limit int) ([]*CommandLog, error) {
+	query := `SELECT id, document_id, command_type, command_args, executed_at, status,
+			error_message, execution_time_ms, created_at,
+			COALESCE(collection_id, ''), COALESCE(user_id, ''), prompt_tokens, completion_tokens, cost_usd,
+			COALESCE(correlation_id, '')
+		FROM command_log WHERE document_id = ?
+		ORDER BY executed_at DESC, id DESC`
+	args := []any{documentID}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	logs := make([]*CommandLog, 0)
+	for rows.Next() {
+		var (
+			log           CommandLog
+			args, errMsg  sql.NullString
+			executionTime sql.NullInt64
+		)
+		if err := rows.Scan(
+			&log.ID, &log.DocumentID, &log.CommandType, &args, &log.ExecutedAt, &log.Status,
+			&errMsg, &executionTime, &log.CreatedAt,
+			&log.CollectionID, &log.UserID, &log.PromptTokens, &log.CompletionTokens, &log.CostUSD,
+			&log.CorrelationID,
+		); err != nil {
+			return nil, wrapError(err)
+		}
+		log.CommandArgs = nullableString(args)
+		log.ErrorMessage = nullableString(errMsg)
+		if executionTime.Valid {
+			ms := int(executionTime.Int64)
+			log.ExecutionTimeMs = &ms
+		}
+		logs = append(logs, &log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+	return logs, nil
+}
+
+// usageKeys are the SQL expressions each grouping reports by. Timestamps
+// are stored in UTC with the date first, so the day is a prefix.
+var usageKeys = map[UsageGroup]string{
+	"":                `''`,
+	UsageByDay:        `substr(executed_at, 1, 10)`,
+	UsageByCommand:    `command_type`,
+	UsageByCollection: `COALESCE(collection_id, '')`,
+	UsageByUser:       `COALESCE(user_id, '')`,
+}
+
+// GetUsage totals the logged commands query selects, per group in key
+// order. The total is returned even when no command matches.
+func (s *SQLiteStorage) GetUsage(ctx context.Context, query UsageQuery) ([]*UsageSummary, error) {
+	key, ok := usageKeys[query.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown usage grouping %q", ErrInvalidInput, query.GroupBy)
+	}
+
+	stmt := `SELECT ` + key + `, COUNT(*), COALESCE(SUM(prompt_tokens), 0),
+			COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(cost_usd), 0)
+		FROM command_log WHERE 1 = 1`
+	var args []any
+	if !query.Since.IsZero() {
+		stmt += ` AND executed_at >= ?`
+		args = append(args, query.Since.UTC())
+	}
+	if !query.Until.IsZero() {
+		stmt += ` AND executed_at < ?`
+		args = append(args, query.Until.UTC())
+	}
+	if query.GroupBy != "" {
+		stmt += ` GROUP BY 1 ORDER BY 1`
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	summaries := make([]*UsageSummary, 0)
+	for rows.Next() {
+		var summary UsageSummary
+		if err := rows.Scan(&summary.Key, &summary.Commands, &summary.PromptTokens, &summary.CompletionTokens, &summary.CostUSD); err != nil {
+			return nil, wrapError(err)
+		}
+		summaries = append(summaries, &summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+	return summaries, nil
+}
+
+// Audit Log
+
+// RecordAudit appends a change to the audit log
+func (s *SQLiteStorage) RecordAudit(ctx context.Context, entry *AuditEntry) error {
+	if entry == nil || entry.DocumentID == "" || entry.Action == "" {
+		return fmt.Errorf("%w: document ID and action are required", ErrInvalidInput)
+	}
+
+	now := time.Now().UTC()
+	res, err := s.conn(ctx).ExecContext(ctx,
+		`INSERT INTO audit_log
+			(document_id, action, command_type, correlation_id, before_hash, after_hash,
+			 diff, reasoning, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.DocumentID, entry.Action, nullIfEmpty(entry.CommandType), nullIfEmpty(entry.CorrelationID),
+		entry.BeforeHash, entry.AfterHash, entry.Diff, nullIfEmpty(entry.Reasoning), now,
+	)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return wrapError(err)
+	}
+	entry.ID = id
+	entry.CreatedAt = now
+	return nil
+}
+
+// GetAuditTrail returns the changes made to a document, newest first. A
+// limit of zero or less returns all of them.
+func (s *SQLiteStorage) GetAuditTrail(ctx context.Context, documentID string, limit int) ([]*AuditEntry, error) {
+	query := `SELECT id, document_id, action, COALESCE(command_type, ''), COALESCE(correlation_id, ''),
+			before_hash, after_hash, diff, COALESCE(reasoning, ''), created_at
+		FROM audit_log WHERE document_id = ?
+		ORDER BY created_at DESC, id DESC`
+	args := []any{documentID}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	entries := make([]*AuditEntry, 0)
+	for rows.Next() {
+		var entry AuditEntry
+		if err := rows.Scan(
+			&entry.ID, &entry.DocumentID, &entry.Action, &entry.CommandType, &entry.CorrelationID,
+			&entry.BeforeHash, &entry.AfterHash, &entry.Diff, &entry.Reasoning, &entry.CreatedAt,
+		); err != nil {
+			return nil, wrapError(err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+	return entries, nil
+}
+
+// Dead Letters
+
+// AddDeadLetter records a task that used up its retries
+func (s *SQLiteStorage) AddDeadLetter(ctx context.Context, letter *DeadLetter) error {
+	if letter == nil || letter.TaskID == "" || letter.TaskType == "" {
+		return fmt.Errorf("%w: task ID and type are required", ErrInvalidInput)
+	}
+
+	now := time.Now().UTC()
+	failedAt := letter.FailedAt
+	if failedAt.IsZero() {
+		failedAt = now
+	}
+
+	res, err := s.conn(ctx).ExecContext(ctx,
+		`INSERT INTO dead_letters
+			(task_id, task_type, document_id, attempts, last_error, failed_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		letter.TaskID, letter.TaskType, letter.DocumentID, letter.Attempts, letter.LastError,
+		failedAt.UTC(), now,
+	)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return wrapError(err)
+	}
+	letter.ID = id
+	letter.FailedAt = failedAt
+	letter.CreatedAt = now
+	return nil
+}
+
+// ListDeadLetters returns dead letters, newest first. A limit of zero or
+// less returns all of them.
+func (s *SQLiteStorage) ListDeadLetters(ctx context.Context, limit int) ([]*DeadLetter, error) {
+	query := `SELECT id, task_id, task_type, document_id, attempts, last_error, failed_at, created_at
+		FROM dead_letters ORDER BY failed_at DESC, id DESC`
+	var args []any
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer rows.Close()
+
+	letters := make([]*DeadLetter, 0)
+	for rows.Next() {
+		var (
+			letter     DeadLetter
+			documentID sql.NullString
+		)
+		if err := rows.Scan(
+			&letter.ID, &letter.TaskID, &letter.TaskType, &documentID, &letter.Attempts,
+			&letter.LastError, &letter.FailedAt, &letter.CreatedAt,
+		); err != nil {
+			return nil, wrapError(err)
+		}
+		letter.DocumentID = documentID.String
+		letters = append(letters, &letter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err)
+	}
+	return letters, nil
+}
+
+// DeleteDeadLetter removes a dead letter once it has been dealt with. It
+// returns ErrNotFound for an unknown ID.
+func (s *SQLiteStorage) DeleteDeadLetter(ctx context.Context, id int64) error {
+	res, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM dead_letters WHERE id = ?`, id)
+	if err != nil {
+		return wrapError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return wrapError(err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: dead letter %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+// Cursors
+
+// GetCursor returns the position stored under name. It returns ErrNotFound
+// if the cursor was never set.
+func (s *SQLiteStorage) GetCursor(ctx context.Context, name string) (time.Time, error) {
+	var position time.Time
+	err := s.conn(ctx).QueryRowContext(ctx,
+		`SELECT position FROM cursors WHERE name = ?`, name,
+	).Scan(&position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, fmt.Errorf("%w: cursor %q", ErrNotFound, name)
+	}
+	if err != nil {
+		return time.Time{}, wrapError(err)
+	}
+	return position, nil
+}
+
+// SetCursor stores position under name, replacing any previous value
+func (s *SQLiteStorage) SetCursor(ctx context.Context, name string, position time.Time) error {
+	if name == "" {
+		return fmt.Errorf("%w: cursor name is required", ErrInvalidInput)
+	}
+
+	_, err := s.conn(ctx).ExecContext(ctx,
+		`INSERT INTO cursors (name, position, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET position = excluded.position, updated_at = excluded.updated_at`,
+		name, position.UTC(), time.Now().UTC(),
+	)
+	return wrapError(err)
+}
+
+// Health and Maintenance
+
+// Ping checks that the database file is reachable
+func (s *SQLiteStorage) Ping(ctx context.Context) error {
+	return wrapError(s.db.PingContext(ctx))
+}
+
+// Close closes the connection pool
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// Backup writes a consistent snapshot to destinationPath while the database
+// stays online. The snapshot is built next to the destination and renamed
+// into place, so a failed backup never leaves a partial file behind.
+func (s *SQLiteStorage) Backup(ctx context.Context, destinationPath string) error {
+	if strings.TrimSpace(destinationPath) == "" {
+		return fmt.Errorf("%w: backup destination is empty", ErrInvalidInput)
+	}
+	if dir := filepath.Dir(destinationPath); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("persistence: failed to create backup directory: %w", err)
+		}
+	}
+
+	tmpPath := destinationPath + ".tmp"
+	if err := os.Remove(tmpPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("persistence: failed to clear stale backup: %w", err)
+	}
+
+	// VACUUM INTO reads from a single read transaction, so concurrent writes
+	// are either fully in the snapshot or not at all
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("persistence: backup failed: %w", wrapError(err))
+	}
+	if err := os.Rename(tmpPath, destinationPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("persistence: failed to move backup into place: %w", err)
+	}
+	return nil
+}
+
+// RunInTransaction runs fn in a single SQLite transaction, rolling back if
+// fn returns an error or panics. Calls nested inside fn join the outer
+// transaction.
+func (s *SQLiteStorage) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+	return wrapError(tx.Commit())
+}
+
+// nullIfEmpty stores an empty string as NULL
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullableString(ns sql.NullString) *string {
+	if !ns.Valid {
+		return nil
+	}
+	return &ns.String
+}
@@ -0,0 +1,645 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func setupTestDB(t *testing.T) *SQLiteStorage {
+	t.Helper()
+	storage, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test DB: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func stringPtr(s string) *string { return &s }
+
+func TestSQLiteStorage_Migrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+
+	storage, err := NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	ctx := context.Background()
+	if err := storage.LogCommand(ctx, &CommandLog{DocumentID: "doc-1", CommandType: "/ai", Status: CommandStatusSuccess}); err != nil {
+		t.Fatalf("Failed to log command: %v", err)
+	}
+	storage.Close()
+
+	// Reopening must not re-run applied migrations or lose data
+	storage, err = NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer storage.Close()
+
+	version, err := schemaVersion(ctx, storage.db)
+	if err != nil {
+		t.Fatalf("Failed to read schema version: %v", err)
+	}
+	if want := migrations[len(migrations)-1].version; version != want {
+		t.Errorf("Expected schema version %d, got %d", want, version)
+	}
+
+	history, err := storage.GetCommandHistory(ctx, "doc-1", 0)
+	if err != nil || len(history) != 1 {
+		t.Errorf("Expected logged command to survive reopen, got %d (%v)", len(history), err)
+	}
+}
+
+func TestSQLiteStorage_QuestionState(t *testing.T) {
+	ctx := context.Background()
+	storage := setupTestDB(t)
+
+	answered, err := storage.HasAnsweredQuestion(ctx, "hash-1")
+	if err != nil || answered {
+		t.Fatalf("Expected unknown question to be unanswered, got %v (%v)", answered, err)
+	}
+
+	state := &QuestionState{
+		QuestionHash: "hash-1",
+		DocumentID:   "doc-1",
+		QuestionText: "What is our rate limit?",
+		CommentID:    stringPtr("comment-1"),
+	}
+	if err := storage.MarkQuestionAnswered(ctx, state); err != nil {
+		t.Fatalf("Failed to mark answered: %v", err)
+	}
+	if state.ID == 0 || !state.AnswerDelivered || state.ProcessedAt.IsZero() {
+		t.Errorf("Expected ID, AnswerDelivered and ProcessedAt to be set, got %+v", state)
+	}
+
+	answered, err = storage.HasAnsweredQuestion(ctx, "hash-1")
+	if err != nil || !answered {
+		t.Errorf("Expected question to be answered, got %v (%v)", answered, err)
+	}
+
+	err = storage.MarkQuestionAnswered(ctx, &QuestionState{QuestionHash: "hash-1", DocumentID: "doc-1"})
+	if !errors.Is(err, ErrDuplicateEntry) {
+		t.Errorf("Expected ErrDuplicateEntry, got %v", err)
+	}
+
+	got, err := storage.GetQuestionState(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("Failed to get state: %v", err)
+	}
+	if got.ID != state.ID || got.QuestionText != state.QuestionText || got.CommentID == nil || *got.CommentID != "comment-1" {
+		t.Errorf("Unexpected state: %+v", got)
+	}
+	if got.LastError != nil {
+		t.Errorf("Expected nil LastError, got %q", *got.LastError)
+	}
+	if !got.ProcessedAt.Equal(state.ProcessedAt) {
+		t.Errorf("Expected ProcessedAt %v to round-trip, got %v", state.ProcessedAt, got.ProcessedAt)
+	}
+
+	if _, err := storage.GetQuestionState(ctx, "missing"); !errors.Is(err, ErrQuestionNotFound) {
+		t.Errorf("Expected ErrQuestionNotFound, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_UpdateQuestionState(t *testing.T) {
+	ctx := context.Background()
+	storage := setupTestDB(t)
+
+	state := &QuestionState{QuestionHash: "hash-1", DocumentID: "doc-1", QuestionText: "Q?"}
+	if err := storage.MarkQuestionAnswered(ctx, state); err != nil {
+		t.Fatalf("Failed to mark answered: %v", err)
+	}
+
+	update := &QuestionState{
+		QuestionHash: "hash-1",
+		DocumentID:   "doc-1",
+		QuestionText: "Q?",
+		LastError:    stringPtr("ai: request timeout"),
+		RetryCount:   2,
+	}
+	if err := storage.UpdateQuestionState(ctx, update); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if update.ID != state.ID || !update.CreatedAt.Equal(state.CreatedAt) {
+		t.Errorf("Expected ID and CreatedAt to be preserved, got %+v", update)
+	}
+
+	got, err := storage.GetQuestionState(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("Failed to get state: %v", err)
+	}
+	if got.RetryCount != 2 || got.LastError == nil || *got.LastError != "ai: request timeout" || got.AnswerDelivered {
+		t.Errorf("Unexpected updated state: %+v", got)
+	}
+
+	err = storage.UpdateQuestionState(ctx, &QuestionState{QuestionHash: "missing"})
+	if !errors.Is(err, ErrQuestionNotFound) {
+		t.Errorf("Expected ErrQuestionNotFound, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_RecordQuestionFailure(t *testing.T) {
+	ctx := context.Background()
+	storage := setupTestDB(t)
+
+	for attempt, cause := range []string{"ai: request timeout", "ai: rate limited"} {
+		state := &QuestionState{QuestionHash: "hash-1", DocumentID: "doc-1", QuestionText: "Q?", LastError: stringPtr(cause)}
+		if err := storage.RecordQuestionFailure(ctx, state); err != nil {
+			t.Fatalf("Failed to record failure: %v", err)
+		}
+		if state.ID == 0 || state.RetryCount != attempt+1 || state.AnswerDelivered {
+			t.Errorf("Expected an undelivered state after %d failures, got %+v", attempt+1, state)
+		}
+	}
+
+	got, err := storage.GetQuestionState(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("Failed to get state: %v", err)
+	}
+	if got.RetryCount != 2 || got.LastError == nil || *got.LastError != "ai: rate limited" || got.AnswerDelivered {
+		t.Errorf("Unexpected state after failures: %+v", got)
+	}
+	if answered, err := storage.HasAnsweredQuestion(ctx, "hash-1"); err != nil || answered {
+		t.Errorf("Expected a failed question to stay unanswered, got %v (%v)", answered, err)
+	}
+
+	err = storage.RecordQuestionFailure(ctx, &QuestionState{QuestionHash: "hash-2"})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_DeleteStaleQuestions(t *testing.T) {
+	ctx := context.Background()
+	storage := setupTestDB(t)
+
+	for i, age := range []time.Duration{48 * time.Hour, 36 * time.Hour, time.Hour} {
+		state := &QuestionState{QuestionHash: fmt.Sprintf("hash-%d", i), DocumentID: "doc-1"}
+		if err := storage.MarkQuestionAnswered(ctx, state); err != nil {
+			t.Fatalf("Failed to mark answered: %v", err)
+		}
+		state.ProcessedAt = time.Now().Add(-age)
+		if err := storage.UpdateQuestionState(ctx, state); err != nil {
+			t.Fatalf("Failed to backdate: %v", err)
+		}
+	}
+
+	deleted, err := storage.DeleteStaleQuestions(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 deleted, got %d", deleted)
+	}
+	if _, err := storage.GetQuestionState(ctx, "hash-2"); err != nil {
+		t.Errorf("Expected recent question to survive, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_CommandLog(t *testing.T) {
+	ctx := context.Background()
+	storage := setupTestDB(t)
+
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		ms := i * 10
+		log := &CommandLog{
+			DocumentID:      "doc-1",
+			CommandType:     "/summarize",
+			ExecutedAt:      base.Add(time.Duration(i) * time.Minute),
+			Status:          CommandStatusSuccess,
+			ExecutionTimeMs: &ms,
+		}
+		if i == 4 {
+			log.Status = CommandStatusFailed
+			log.ErrorMessage = stringPtr("boom")
+		}
+		if err := storage.LogCommand(ctx, log); err != nil {
+			t.Fatalf("Failed to log command: %v", err)
+		}
+		if log.ID == 0 || log.CreatedAt.IsZero() {
+			t.Errorf("Expected ID and CreatedAt to be set, got %+v", log)
+		}
+	}
+	if err := storage.LogCommand(ctx, &CommandLog{DocumentID: "doc-2", CommandType: "/ai", Status: CommandStatusSuccess}); err != nil {
+		t.Fatalf("Failed to log command: %v", err)
+	}
+
+	history, err := storage.GetCommandHistory(ctx, "doc-1", 3)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(history))
+	}
+	if history[0].Status != CommandStatusFailed || history[0].ErrorMessage == nil || *history[0].ErrorMessage != "boom" {
+		t.Errorf("Expected newest failed entry first, got %+v", history[0])
+	}
+	if history[0].ExecutionTimeMs == nil || *history[0].ExecutionTimeMs != 40 {
+		t.Errorf("Expected ExecutionTimeMs 40, got %v", history[0].ExecutionTimeMs)
+	}
+	if !history[1].ExecutedAt.Before(history[0].ExecutedAt) {
+		t.Error("Expected history ordered newest first")
+	}
+
+	empty, err := storage.GetCommandHistory(ctx, "doc-none", 10)
+	if err != nil || empty == nil || len(empty) != 0 {
+		t.Errorf("Expected empty non-nil history, got %v (%v)", empty, err)
+	}
+
+	if err := storage.LogCommand(ctx, &CommandLog{DocumentID: "doc-1"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_Usage(t *testing.T) {
+	ctx := context.Background()
+	storage := setupTestDB(t)
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, log := range []*CommandLog{
+		{CommandType: "/ai", ExecutedAt: day.Add(-time.Minute), CollectionID: "col-1", UserID: "user-1", PromptTokens: 1, CompletionTokens: 1},
+		{CommandType: "/ai", ExecutedAt: day.Add(time.Hour), CollectionID: "col-1", UserID: "user-1", PromptTokens: 100, CompletionTokens: 20, CostUSD: 0.5},
+		{CommandType: "/summarize", ExecutedAt: day.Add(25 * time.Hour), CollectionID: "col-2", UserID: "user-2", PromptTokens: 300, CompletionTokens: 40, CostUSD: 1},
+		{CommandType: "/summarize", ExecutedAt: day.Add(26 * time.Hour)},
+	} {
+		log.DocumentID, log.Status = "doc-1", CommandStatusSuccess
+		if err := storage.LogCommand(ctx, log); err != nil {
+			t.Fatalf("Failed to log command: %v", err)
+		}
+	}
+
+	history, err := storage.GetCommandHistory(ctx, "doc-1", 0)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if h := history[1]; h.CollectionID != "col-2" || h.UserID != "user-2" || h.PromptTokens != 300 || h.CompletionTokens != 40 || h.CostUSD != 1 {
+		t.Errorf("Expected the usage stored with the entry, got %+v", h)
+	}
+
+	month := UsageQuery{Since: day, Until: day.AddDate(0, 1, 0)}
+	tests := []struct {
+		groupBy UsageGroup
+		want    []UsageSummary
+	}{
+		{"", []UsageSummary{{"", 3, 400, 60, 1.5}}},
+		{UsageByDay, []UsageSummary{{"2026-03-01", 1, 100, 20, 0.5}, {"2026-03-02", 2, 300, 40, 1}}},
+		{UsageByCommand, []UsageSummary{{"/ai", 1, 100, 20, 0.5}, {"/summarize", 2, 300, 40, 1}}},
+		{UsageByCollection, []UsageSummary{{"", 1, 0, 0, 0}, {"col-1", 1, 100, 20, 0.5}, {"col-2", 1, 300, 40, 1}}},
+		{UsageByUser, []UsageSummary{{"", 1, 0, 0, 0}, {"user-1", 1, 100, 20, 0.5}, {"user-2", 1, 300, 40, 1}}},
+	}
+	for _, tt := range tests {
+		query := month
+		query.GroupBy = tt.groupBy
+		got, err := storage.GetUsage(ctx, query)
+		if err != nil {
+			t.Fatalf("Failed to get usage by %q: %v", tt.groupBy, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Expected %d groups by %q, got %d", len(tt.want), tt.groupBy, len(got))
+			continue
+		}
+		for i, want := range tt.want {
+			if *got[i] != want {
+				t.Errorf("Expected %+v by %q, got %+v", want, tt.groupBy, *got[i])
+			}
+		}
+	}
+
+	empty, err := storage.GetUsage(ctx, UsageQuery{Since: day.AddDate(1, 0, 0)})
+	if err != nil || len(empty) != 1 || empty[0].Commands != 0 {
+		t.Errorf("Expected a zero total for an empty range, got %v (%v)", empty, err)
+	}
+	if _, err := storage.GetUsage(ctx, UsageQuery{GroupBy: "week"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for an unknown grouping, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_AuditLog(t *testing.T) {
+	ctx := context.Background()
+	storage := setupTestDB(t)
+
+	for _, entry := range []*AuditEntry{
+		{DocumentID: "doc-1", Action: AuditActionMove, CommandType: "/ai-file", CorrelationID: "corr-1",
+			BeforeHash: "aa", AfterHash: "aa", Diff: "-collection: col-1\n+collection: col-2", Reasoning: "Sales pipeline notes"},
+		{DocumentID: "doc-1", Action: AuditActionUpdate, BeforeHash: "aa", AfterHash: "bb", Diff: "-/ai-file"},
+		{DocumentID: "doc-2", Action: AuditActionComment, BeforeHash: "cc", AfterHash: "cc", Diff: "+Filed"},
+	} {
+		if err := storage.RecordAudit(ctx, entry); err != nil {
+			t.Fatalf("Failed to record audit entry: %v", err)
+		}
+		if entry.ID == 0 || entry.CreatedAt.IsZero() {
+			t.Errorf("Expected ID and CreatedAt to be set, got %+v", entry)
+		}
+	}
+	if err := storage.LogCommand(ctx, &CommandLog{DocumentID: "doc-1", CommandType: "/ai-file", Status: CommandStatusSuccess, CorrelationID: "corr-1"}); err != nil {
+		t.Fatalf("Failed to log command: %v", err)
+	}
+
+	trail, err := storage.GetAuditTrail(ctx, "doc-1", 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit trail: %v", err)
+	}
+	if len(trail) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(trail))
+	}
+	if trail[0].Action != AuditActionUpdate || trail[0].CommandType != "" || trail[0].Reasoning != "" {
+		t.Errorf("Expected the newest entry first, without a command, got %+v", trail[0])
+	}
+	if move := trail[1]; move.CommandType != "/ai-file" || move.CorrelationID != "corr-1" || move.Reasoning != "Sales pipeline notes" || move.Diff != "-collection: col-1\n+collection: col-2" {
+		t.Errorf("Expected the move stored in full, got %+v", move)
+	}
+	history, _ := storage.GetCommandHistory(ctx, "doc-1", 0)
+	if len(history) != 1 || history[0].CorrelationID != "corr-1" {
+		t.Errorf("Expected the correlation ID in the command log, got %+v", history)
+	}
+
+	// The table is append-only, even for direct SQL
+	if _, err := storage.db.ExecContext(ctx, `UPDATE audit_log SET reasoning = 'edited'`); err == nil {
+		t.Error("Expected updates to be rejected")
+	}
+	if _, err := storage.db.ExecContext(ctx, `DELETE FROM audit_log`); err == nil {
+		t.Error("Expected deletes to be rejected")
+	}
+	if trail, _ := storage.GetAuditTrail(ctx, "doc-1", 1); len(trail) != 1 || trail[0].Reasoning != "" {
+		t.Errorf("Expected the entries untouched, got %+v", trail)
+	}
+
+	if err := storage.RecordAudit(ctx, &AuditEntry{DocumentID: "doc-1"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	storage := setupTestDB(t)
+
+	base := time.Now().Add(-time.Hour)
+	for i, taskType := range []string{"/ai", "/related"} {
+		letter := &DeadLetter{
+			TaskID:     "task-" + taskType,
+			TaskType:   taskType,
+			DocumentID: "doc-1",
+			Attempts:   3,
+			LastError:  "outline: server error",
+			FailedAt:   base.Add(time.Duration(i) * time.Minute),
+		}
+		if err := storage.AddDeadLetter(ctx, letter); err != nil {
+			t.Fatalf("Failed to add dead letter: %v", err)
+		}
+		if letter.ID == 0 || letter.CreatedAt.IsZero() {
+			t.Errorf("Expected ID and CreatedAt to be set, got %+v", letter)
+		}
+	}
+
+	letters, err := storage.ListDeadLetters(ctx, 0)
+	if err != nil {
+		t.Fatalf("Failed to list dead letters: %v", err)
+	}
+	if len(letters) != 2 || letters[0].TaskType != "/related" || letters[0].Attempts != 3 || letters[0].DocumentID != "doc-1" {
+		t.Fatalf("Expected both dead letters, newest first, got %+v", letters)
+	}
+
+	if err := storage.DeleteDeadLetter(ctx, letters[0].ID); err != nil {
+		t.Fatalf("Failed to delete dead letter: %v", err)
+	}
+	if err := storage.DeleteDeadLetter(ctx, letters[0].ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if remaining, _ := storage.ListDeadLetters(ctx, 1); len(remaining) != 1 || remaining[0].TaskType != "/ai" {
+		t.Errorf("Expected the other dead letter to remain, got %+v", remaining)
+	}
+
+	if err := storage.AddDeadLetter(ctx, &DeadLetter{TaskID: "task"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_Cursors(t *testing.T) {
+	ctx := context.Background()
+	storage := setupTestDB(t)
+
+	if _, err := storage.GetCursor(ctx, "poller"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unset cursor, got %v", err)
+	}
+
+	first := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	for _, position := range []time.Time{first, second} {
+		if err := storage.SetCursor(ctx, "poller", position); err != nil {
+			t.Fatalf("Failed to set cursor: %v", err)
+		}
+	}
+
+	position, err := storage.GetCursor(ctx, "poller")
+	if err != nil {
+		t.Fatalf("Failed to get cursor: %v", err)
+	}
+	if !position.Equal(second) {
+		t.Errorf("Expected cursor at %v, got %v", second, position)
+	}
+
+	if err := storage.SetCursor(ctx, "", first); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_Transactions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		storage := setupTestDB(t)
+		errAbort := errors.New("abort")
+
+		err := storage.RunInTransaction(ctx, func(ctx context.Context) error {
+			if err := storage.MarkQuestionAnswered(ctx, &QuestionState{QuestionHash: "hash-1", DocumentID: "doc-1"}); err != nil {
+				return err
+			}
+			if err := storage.LogCommand(ctx, &CommandLog{DocumentID: "doc-1", CommandType: "/ai", Status: CommandStatusSuccess}); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("Expected fn error to be returned, got %v", err)
+		}
+
+		if _, err := storage.GetQuestionState(ctx, "hash-1"); !errors.Is(err, ErrQuestionNotFound) {
+			t.Errorf("Expected question insert to be rolled back, got %v", err)
+		}
+		if history, _ := storage.GetCommandHistory(ctx, "doc-1", 0); len(history) != 0 {
+			t.Errorf("Expected command log to be rolled back, got %d entries", len(history))
+		}
+	})
+
+	t.Run("rolls back on panic", func(t *testing.T) {
+		storage := setupTestDB(t)
+
+		func() {
+			defer func() { recover() }()
+			storage.RunInTransaction(ctx, func(ctx context.Context) error {
+				storage.MarkQuestionAnswered(ctx, &QuestionState{QuestionHash: "hash-1", DocumentID: "doc-1"})
+				panic("handler bug")
+			})
+		}()
+
+		if _, err := storage.GetQuestionState(ctx, "hash-1"); !errors.Is(err, ErrQuestionNotFound) {
+			t.Errorf("Expected insert to be rolled back after panic, got %v", err)
+		}
+	})
+
+	t.Run("commits and joins nested calls", func(t *testing.T) {
+		storage := setupTestDB(t)
+
+		err := storage.RunInTransaction(ctx, func(ctx context.Context) error {
+			if err := storage.MarkQuestionAnswered(ctx, &QuestionState{QuestionHash: "hash-1", DocumentID: "doc-1"}); err != nil {
+				return err
+			}
+			return storage.RunInTransaction(ctx, func(ctx context.Context) error {
+				answered, err := storage.HasAnsweredQuestion(ctx, "hash-1")
+				if err != nil || !answered {
+					return fmt.Errorf("expected uncommitted insert to be visible, got %v (%v)", answered, err)
+				}
+				return storage.LogCommand(ctx, &CommandLog{DocumentID: "doc-1", CommandType: "/ai", Status: CommandStatusSuccess})
+			})
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if history, _ := storage.GetCommandHistory(ctx, "doc-1", 0); len(history) != 1 {
+			t.Errorf("Expected committed command log, got %d entries", len(history))
+		}
+	})
+}
+
+func TestSQLiteStorage_DatabaseLocked(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "locked.db")
+
+	storage, err := NewSQLiteStorage(path, WithBusyTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer storage.Close()
+
+	// A second process holding the write lock
+	other, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=0")
+	if err != nil {
+		t.Fatalf("Failed to open second connection: %v", err)
+	}
+	defer other.Close()
+	conn, err := other.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		t.Fatalf("Failed to take write lock: %v", err)
+	}
+	defer conn.ExecContext(ctx, "ROLLBACK")
+
+	err = storage.LogCommand(ctx, &CommandLog{DocumentID: "doc-1", CommandType: "/ai", Status: CommandStatusSuccess})
+	if !errors.Is(err, ErrDatabaseLocked) {
+		t.Errorf("Expected ErrDatabaseLocked, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_Backup(t *testing.T) {
+	ctx := context.Background()
+	storage := setupTestDB(t)
+
+	if err := storage.MarkQuestionAnswered(ctx, &QuestionState{QuestionHash: "hash-1", DocumentID: "doc-1"}); err != nil {
+		t.Fatalf("Failed to mark answered: %v", err)
+	}
+
+	// Keep writing while the backup runs
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				storage.LogCommand(ctx, &CommandLog{DocumentID: "doc-1", CommandType: "/ai", Status: CommandStatusSuccess})
+			}
+		}
+	}()
+
+	dest := filepath.Join(t.TempDir(), "backups", "state-backup.db")
+	err := storage.Backup(ctx, dest)
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if _, err := os.Stat(dest + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Expected temporary backup file to be gone")
+	}
+
+	restored, err := NewSQLiteStorage(dest)
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer restored.Close()
+
+	answered, err := restored.HasAnsweredQuestion(ctx, "hash-1")
+	if err != nil || !answered {
+		t.Errorf("Expected backup to contain answered question, got %v (%v)", answered, err)
+	}
+
+	// Backing up over an existing file replaces it
+	if err := storage.Backup(ctx, dest); err != nil {
+		t.Errorf("Expected second backup to overwrite, got %v", err)
+	}
+
+	if err := storage.Backup(ctx, ""); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_ConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	storage := setupTestDB(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- storage.RunInTransaction(ctx, func(ctx context.Context) error {
+				return storage.MarkQuestionAnswered(ctx, &QuestionState{
+					QuestionHash: fmt.Sprintf("hash-%d", i),
+					DocumentID:   "doc-1",
+				})
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Concurrent write failed: %v", err)
+		}
+	}
+}
+
+func TestSQLiteStorage_Ping(t *testing.T) {
+	storage := setupTestDB(t)
+	if err := storage.Ping(context.Background()); err != nil {
+		t.Errorf("Expected ping to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+// Package poller discovers commands without webhooks. It periodically
+// searches Outline for every command marker and hands documents changed
+// since the previous poll to the command pipeline. It is meant for
+// installs that cannot receive webhooks, such as laptops and air-gapped
+// networks.
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+const (
+	defaultInterval = 60 * time.Second
+	defaultPageSize = 25
+
+	// CursorName is the cursor holding the high-water mark
+	CursorName = "poller"
+)
+
+// Sink takes documents that may carry commands. *processor.Processor
+// satisfies it.
+type Sink interface {
+	Submit(ctx context.Context, doc *outline.Document) (bool, error)
+}
+
+// Cursors persists the high-water mark. persistence.Storage satisfies it.
+type Cursors interface {
+	GetCursor(ctx context.Context, name string) (time.Time, error)
+	SetCursor(ctx context.Context, name string, position time.Time) error
+}
+
+// Option configures a Poller
+type Option func(*Poller)
+
+// WithInterval sets the time between polls (default 60s)
+func WithInterval(interval time.Duration) Option {
+	return func(p *Poller) {
+		p.interval = interval
+	}
+}
+
+// WithPageSize sets how many search hits are requested at a time
+// (default 25)
+func WithPageSize(n int) Option {
+	return func(p *Poller) {
+		p.pageSize = n
+	}
+}
+
+// WithSlog sets where failed polls are reported
+func WithSlog(logger *slog.Logger) Option {
+	return func(p *Poller) {
+		p.slog = logger
+	}
+}
+
+// Poller searches for command markers. Documents are only handed on when
+// their UpdatedAt is at or past the high-water mark, which advances to the
+// newest UpdatedAt seen once a poll has completed. The documents already
+// handed on at the mark are remembered, so an edit landing in the same
+// instant is still found without repeating the others. After a restart
+// those are handed on once more and the processor skips the ones it has
+// done.
+type Poller struct {
+	outline  outline.Client
+	sink     Sink
+	cursors  Cursors
+	markers  []commands.CommandType
+	interval time.Duration
+	pageSize int
+	slog     *slog.Logger
+
+	// atMark holds the IDs handed on whose UpdatedAt is the saved mark
+	atMark map[string]bool
+}
+
+// NewPoller creates a Poller that searches for markers, usually the
+// router's CommandTypes
+func NewPoller(client outline.Client, sink Sink, cursors Cursors, markers []commands.CommandType, opts ...Option) *Poller {
+	p := &Poller{
+		outline:  client,
+		sink:     sink,
+		cursors:  cursors,
+		markers:  markers,
+		interval: defaultInterval,
+		pageSize: defaultPageSize,
+		slog:     slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Run polls at once and then every interval until ctx is done. Failed
+// polls are logged and tried again at the next tick.
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if queued, err := p.Poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			p.slog.Error("poller: poll failed", "queued", queued, "error", err)
+		} else if queued > 0 {
+			p.slog.Info("poller: queued documents", "count", queued)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Poll runs one search pass and returns how many documents were queued.
+// The high-water mark is only saved when every search succeeded, so a
+// failed pass is repeated in full next time.
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	since, err := p.cursors.GetCursor(ctx, CursorName)
+	if err != nil && !errors.Is(err, persistence.ErrNotFound) {
+		return 0, fmt.Errorf("poller: failed to read high-water mark: %w", err)
+	}
+
+	mark := since
+	seen := make(map[string]time.Time)
+	queued := 0
+	err = outline.SearchEach(ctx, p.outline, p.markers, p.pageSize, func(doc *outline.Document) error {
+		if _, ok := seen[doc.ID]; ok || doc.UpdatedAt.Before(since) {
+			return nil
+		}
+		if doc.UpdatedAt.Equal(since) && p.atMark[doc.ID] {
+			return nil
+		}
+		seen[doc.ID] = doc.UpdatedAt
+
+		ok, err := p.sink.Submit(ctx, doc)
+		if err != nil {
+			return err
+		}
+		if ok {
+			queued++
+		}
+		if doc.UpdatedAt.After(mark) {
+			mark = doc.UpdatedAt
+		}
+		return nil
+	})
+	if err != nil {
+		return queued, err
+	}
+
+	if mark.After(since) {
+		if err := p.cursors.SetCursor(ctx, CursorName, mark); err != nil {
+			return queued, fmt.Errorf("poller: failed to save high-water mark: %w", err)
+		}
+		p.atMark = nil
+	}
+	for id, updated := range seen {
+		if updated.Equal(mark) {
+			if p.atMark == nil {
+				p.atMark = make(map[string]bool)
+			}
+			p.atMark[id] = true
+		}
+	}
+	return queued, nil
+}
@@ -0,0 +1,174 @@
+package poller_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/poller"
+	"github.com/yourusername/outline-ai/pkg/commands"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+// recordingSink remembers the IDs of submitted documents
+type recordingSink struct {
+	ids []string
+	err error
+}
+
+func (s *recordingSink) Submit(ctx context.Context, doc *outline.Document) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	s.ids = append(s.ids, doc.ID)
+	return true, nil
+}
+
+func (s *recordingSink) sorted() []string {
+	ids := append([]string(nil), s.ids...)
+	sort.Strings(ids)
+	return ids
+}
+
+var markers = []commands.CommandType{commands.CommandAI, commands.CommandSummarize}
+
+func TestPoller_Poll(t *testing.T) {
+	ctx := context.Background()
+	client := mocks.NewOutlineMock()
+	storage := mocks.NewStorageMock()
+	sink := &recordingSink{}
+	p := poller.NewPoller(client, sink, storage, markers, poller.WithPageSize(2))
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		doc := client.AddDocument(fmt.Sprintf("doc-%d", i), "col-1", "Notes", "/ai question?")
+		doc.UpdatedAt = base.Add(time.Duration(i) * time.Minute)
+	}
+	both := client.AddDocument("doc-both", "col-1", "Both", "/summarize\n\n/ai question?")
+	both.UpdatedAt = base
+	client.AddDocument("doc-plain", "col-1", "Plain", "No commands")
+
+	queued, err := p.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if queued != 6 || len(sink.ids) != 6 {
+		t.Fatalf("Expected every page to be read and each document queued once, got %d: %v", queued, sink.sorted())
+	}
+
+	mark, err := storage.GetCursor(ctx, poller.CursorName)
+	if err != nil || !mark.Equal(base.Add(4*time.Minute)) {
+		t.Errorf("Expected the high-water mark at the newest update, got %v (%v)", mark, err)
+	}
+
+	// Only documents updated since the last poll are handed on
+	sink.ids = nil
+	client.AddDocument("doc-new", "col-1", "New", "/summarize").UpdatedAt = base.Add(time.Hour)
+	if queued, err := p.Poll(ctx); err != nil || queued != 1 || sink.ids[0] != "doc-new" {
+		t.Errorf("Expected only doc-new to be queued, got %d %v (%v)", queued, sink.ids, err)
+	}
+
+	sink.ids = nil
+	if queued, err := p.Poll(ctx); err != nil || queued != 0 {
+		t.Errorf("Expected nothing to be queued without changes, got %d (%v)", queued, err)
+	}
+}
+
+func TestPoller_PollSameInstantAsMark(t *testing.T) {
+	ctx := context.Background()
+	client := mocks.NewOutlineMock()
+	storage := mocks.NewStorageMock()
+	sink := &recordingSink{}
+	p := poller.NewPoller(client, sink, storage, markers)
+
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	client.AddDocument("doc-first", "col-1", "First", "/ai question?").UpdatedAt = at
+	if queued, err := p.Poll(ctx); err != nil || queued != 1 {
+		t.Fatalf("Expected doc-first to be queued, got %d (%v)", queued, err)
+	}
+
+	// An edit sharing the mark's timestamp is found, the earlier one is not
+	// handed on again
+	sink.ids = nil
+	client.AddDocument("doc-second", "col-1", "Second", "/summarize").UpdatedAt = at
+	if queued, err := p.Poll(ctx); err != nil || queued != 1 || sink.ids[0] != "doc-second" {
+		t.Errorf("Expected only doc-second to be queued, got %d %v (%v)", queued, sink.ids, err)
+	}
+
+	sink.ids = nil
+	if queued, err := p.Poll(ctx); err != nil || queued != 0 {
+		t.Errorf("Expected nothing to be queued without changes, got %d %v (%v)", queued, sink.ids, err)
+	}
+}
+
+func TestPoller_PollFailures(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("search failure keeps the mark", func(t *testing.T) {
+		client := mocks.NewOutlineMock()
+		storage := mocks.NewStorageMock()
+		p := poller.NewPoller(client, &recordingSink{}, storage, markers)
+
+		client.AddDocument("doc-1", "col-1", "Notes", "/ai question?")
+		client.SetFailureMode(true)
+
+		if _, err := p.Poll(ctx); err == nil {
+			t.Fatal("Expected the search failure to be returned")
+		}
+		if _, err := storage.GetCursor(ctx, poller.CursorName); err == nil {
+			t.Error("Expected no high-water mark after a failed poll")
+		}
+	})
+
+	t.Run("sink failure keeps the mark", func(t *testing.T) {
+		client := mocks.NewOutlineMock()
+		storage := mocks.NewStorageMock()
+		errClosed := errors.New("closed")
+		p := poller.NewPoller(client, &recordingSink{err: errClosed}, storage, markers)
+
+		client.AddDocument("doc-1", "col-1", "Notes", "/ai question?")
+
+		if _, err := p.Poll(ctx); !errors.Is(err, errClosed) {
+			t.Fatalf("Expected the sink error, got %v", err)
+		}
+		if _, err := storage.GetCursor(ctx, poller.CursorName); err == nil {
+			t.Error("Expected no high-water mark after a failed poll")
+		}
+	})
+}
+
+func TestPoller_Run(t *testing.T) {
+	client := mocks.NewOutlineMock()
+	storage := mocks.NewStorageMock()
+	sink := &recordingSink{}
+	p := poller.NewPoller(client, sink, storage, markers, poller.WithInterval(time.Hour))
+
+	client.AddDocument("doc-1", "col-1", "Notes", "/ai question?")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(time.Second)
+	for client.GetCallCount("SearchDocuments") < len(markers) {
+		select {
+		case <-deadline:
+			t.Fatal("Expected Run to poll at once")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Run to return when the context ends")
+	}
+}
@@ -0,0 +1,221 @@
+// Package processor is the command pipeline shared by every way the service
+// learns about document changes. Webhook deliveries, the polling fallback
+// and the catch-up scan all hand documents to a Processor, which queues one
+// worker task per document; the task fetches the latest version and routes
+// each command found in it.
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/webhook"
+	"github.com/yourusername/outline-ai/internal/worker"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+// Pool runs queued tasks. *worker.Pool satisfies it.
+type Pool interface {
+	Submit(ctx context.Context, task *worker.Task) error
+	TrySubmit(task *worker.Task) error
+}
+
+// Option configures a Processor
+type Option func(*Processor)
+
+// WithSlog sets where skipped documents and command failures are reported
+func WithSlog(logger *slog.Logger) Option {
+	return func(p *Processor) {
+		p.slog = logger
+	}
+}
+
+// Processor turns changed documents into worker tasks. A document that is
+// already waiting in the pool is not queued twice; the waiting task reads
+// the latest version when it runs.
+type Processor struct {
+	outline outline.Client
+	router  *commands.Router
+	pool    Pool
+	slog    *slog.Logger
+
+	mu      sync.Mutex
+	pending map[string]bool
+}
+
+var _ webhook.Queue = (*Processor)(nil)
+
+// NewProcessor creates a Processor that routes commands through router on
+// tasks run by pool
+func NewProcessor(client outline.Client, router *commands.Router, pool Pool, opts ...Option) *Processor {
+	p := &Processor{
+		outline: client,
+		router:  router,
+		pool:    pool,
+		slog:    slog.Default(),
+		pending: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Submit queues doc, waiting while the pool is full until ctx is done. It
+// reports false without queueing when doc carries no handled command or is
+// already waiting.
+func (p *Processor) Submit(ctx context.Context, doc *outline.Document) (bool, error) {
+	task, ok := p.task(doc)
+	if !ok {
+		return false, nil
+	}
+	if !p.reserve(doc.ID) {
+		return false, nil
+	}
+	if err := p.pool.Submit(ctx, task); err != nil {
+		p.release(doc.ID)
+		return false, fmt.Errorf("processor: failed to queue document %s: %w", doc.ID, err)
+	}
+	return true, nil
+}
+
+// Enqueue queues the document of a webhook event without waiting. A full
+// pool is reported as webhook.ErrQueueFull so Outline delivers again later.
+func (p *Processor) Enqueue(event *webhook.Event) error {
+	doc := event.Payload.Model
+	if doc == nil {
+		// Without the document text the commands are only known once
+		// the task has fetched it
+		doc = &outline.Document{ID: event.DocumentID()}
+	}
+	if doc.ID == "" {
+		return fmt.Errorf("processor: event %s has no document", event.ID)
+	}
+
+	task, ok := p.task(doc)
+	if !ok || !p.reserve(doc.ID) {
+		return nil
+	}
+	if err := p.pool.TrySubmit(task); err != nil {
+		p.release(doc.ID)
+		if errors.Is(err, worker.ErrQueueFull) {
+			return fmt.Errorf("%w: %w", webhook.ErrQueueFull, err)
+		}
+		return fmt.Errorf("processor: failed to queue document %s: %w", doc.ID, err)
+	}
+	return nil
+}
+
+// task builds the task for doc. A document without text is queued at
+// normal priority; one whose text has no handled command is not queued.
+func (p *Processor) task(doc *outline.Document) (*worker.Task, bool) {
+	taskType := "document"
+	priority := worker.PriorityNormal
+	if doc.Text != "" {
+		cmds := p.router.Detect(doc.Text)
+		if len(cmds) == 0 {
+			return nil, false
+		}
+		taskType = string(cmds[0].Type)
+		priority = worker.CommandPriority(cmds[0].Type)
+		for _, cmd := range cmds[1:] {
+			if prio := worker.CommandPriority(cmd.Type); prio > priority {
+				taskType = string(cmd.Type)
+				priority = prio
+			}
+		}
+	}
+
+	id := doc.ID
+	return &worker.Task{
+		ID:         "document:" + id,
+		Type:       taskType,
+		DocumentID: id,
+		Priority:   priority,
+		Run: func(ctx context.Context) error {
+			// Edits made from here on need a new task to be seen
+			p.release(id)
+			return p.ProcessDocument(ctx, id)
+		},
+	}, true
+}
+
+func (p *Processor) reserve(documentID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending[documentID] {
+		return false
+	}
+	p.pending[documentID] = true
+	return true
+}
+
+func (p *Processor) release(documentID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, documentID)
+}
+
+// ProcessDocument fetches the document and routes every command in it. A
+// document deleted in the meantime is skipped. Command failures are joined,
+// so the result is retryable if any of them is.
+func (p *Processor) ProcessDocument(ctx context.Context, documentID string) error {
+	doc, err := p.fetch(ctx, documentID)
+	if err != nil || doc == nil {
+		return err
+	}
+
+	var errs []error
+	for i, cmd := range p.router.Detect(doc.Text) {
+		// Handlers edit the document, so every command after the first
+		// runs against a fresh copy
+		if i > 0 {
+			if doc, err = p.fetch(ctx, documentID); err != nil || doc == nil {
+				return errors.Join(append(errs, err)...)
+			}
+		}
+
+		current, ok := find(p.router.Detect(doc.Text), cmd)
+		if !ok {
+			// An earlier command removed it
+			continue
+		}
+		if _, err := p.router.Route(ctx, doc, current); err != nil {
+			p.slog.Warn("processor: command failed",
+				"document_id", documentID,
+				"command", string(cmd.Type),
+				"error", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fetch returns the document, or nil if it no longer exists
+func (p *Processor) fetch(ctx context.Context, documentID string) (*outline.Document, error) {
+	doc, err := p.outline.GetDocument(ctx, documentID)
+	if errors.Is(err, outline.ErrNotFound) {
+		p.slog.Debug("processor: document gone, skipping", "document_id", documentID)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("processor: failed to fetch document %s: %w", documentID, err)
+	}
+	return doc, nil
+}
+
+// find returns the command in cmds matching cmd, whose position may have
+// shifted since it was detected
+func find(cmds []commands.Command, cmd commands.Command) (commands.Command, bool) {
+	for _, c := range cmds {
+		if c.Type == cmd.Type && c.RawText == cmd.RawText {
+			return c, true
+		}
+	}
+	return commands.Command{}, false
+}
@@ -0,0 +1,185 @@
+package processor_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/processor"
+	"github.com/yourusername/outline-ai/internal/webhook"
+	"github.com/yourusername/outline-ai/internal/worker"
+	"github.com/yourusername/outline-ai/pkg/commands"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+// fakePool keeps submitted tasks so tests can run them by hand
+type fakePool struct {
+	tasks []*worker.Task
+	err   error
+}
+
+func (p *fakePool) Submit(ctx context.Context, task *worker.Task) error {
+	return p.TrySubmit(task)
+}
+
+func (p *fakePool) TrySubmit(task *worker.Task) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.tasks = append(p.tasks, task)
+	return nil
+}
+
+type env struct {
+	outline *mocks.OutlineMock
+	router  *commands.Router
+	pool    *fakePool
+	proc    *processor.Processor
+	handled []commands.CommandType
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	e := &env{
+		outline: mocks.NewOutlineMock(),
+		router:  commands.NewRouter(mocks.NewStorageMock()),
+		pool:    &fakePool{},
+	}
+	for _, cmdType := range []commands.CommandType{commands.CommandAI, commands.CommandSummarize, commands.CommandRelated} {
+		err := e.router.Register(cmdType, commands.HandlerFunc(func(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+			e.handled = append(e.handled, cmd.Type)
+			return commands.Result{}, nil
+		}))
+		if err != nil {
+			t.Fatalf("Failed to register handler: %v", err)
+		}
+	}
+	e.proc = processor.NewProcessor(e.outline, e.router, e.pool)
+	return e
+}
+
+func TestProcessor_Submit(t *testing.T) {
+	ctx := context.Background()
+	e := newEnv(t)
+
+	doc := e.outline.AddDocument("doc-1", "col-1", "Notes", "/related\n\n/ai what changed?")
+	queued, err := e.proc.Submit(ctx, doc)
+	if err != nil || !queued {
+		t.Fatalf("Expected document to be queued, got %v (%v)", queued, err)
+	}
+
+	task := e.pool.tasks[0]
+	if task.Priority != worker.PriorityHigh || task.Type != "/ai" || task.DocumentID != "doc-1" {
+		t.Errorf("Expected a high priority /ai task for doc-1, got %+v", task)
+	}
+
+	if queued, _ := e.proc.Submit(ctx, doc); queued {
+		t.Error("Expected a waiting document not to be queued again")
+	}
+
+	plain := e.outline.AddDocument("doc-2", "col-1", "Plain", "No commands here")
+	if queued, _ := e.proc.Submit(ctx, plain); queued {
+		t.Error("Expected a document without commands not to be queued")
+	}
+
+	if err := task.Run(ctx); err != nil {
+		t.Fatalf("Task failed: %v", err)
+	}
+	if len(e.handled) != 2 {
+		t.Errorf("Expected both commands to be handled, got %v", e.handled)
+	}
+	if queued, _ := e.proc.Submit(ctx, doc); !queued {
+		t.Error("Expected the document to be queued again once its task started")
+	}
+}
+
+func TestProcessor_Enqueue(t *testing.T) {
+	e := newEnv(t)
+
+	event := &webhook.Event{ID: "evt-1", Payload: webhook.Payload{ID: "doc-1"}}
+	if err := e.proc.Enqueue(event); err != nil {
+		t.Fatalf("Failed to enqueue event: %v", err)
+	}
+	if len(e.pool.tasks) != 1 || e.pool.tasks[0].Priority != worker.PriorityNormal {
+		t.Fatalf("Expected a normal priority task for an event without text, got %+v", e.pool.tasks)
+	}
+
+	// The task skips documents deleted before it ran
+	if err := e.pool.tasks[0].Run(context.Background()); err != nil {
+		t.Errorf("Expected a missing document to be skipped, got %v", err)
+	}
+
+	e.pool.err = worker.ErrQueueFull
+	model := &outline.Document{ID: "doc-2", Text: "/summarize"}
+	event = &webhook.Event{ID: "evt-2", Payload: webhook.Payload{ID: "doc-2", Model: model}}
+	if err := e.proc.Enqueue(event); !errors.Is(err, webhook.ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+
+	e.pool.err = nil
+	if err := e.proc.Enqueue(event); err != nil || len(e.pool.tasks) != 2 {
+		t.Errorf("Expected the event to be queued once there is room, got %v", err)
+	}
+}
+
+func TestProcessor_ProcessDocument(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("skips commands removed by earlier ones", func(t *testing.T) {
+		e := newEnv(t)
+		e.router = commands.NewRouter(nil)
+		e.proc = processor.NewProcessor(e.outline, e.router, e.pool)
+
+		var handled []string
+		e.router.Register(commands.CommandAI, commands.HandlerFunc(func(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+			handled = append(handled, cmd.RawText)
+			// Answering the first question also removes the second
+			_, err := e.outline.UpdateDocument(ctx, doc.ID, &outline.UpdateDocumentRequest{
+				Text: outline.String(strings.ReplaceAll(doc.Text, "/ai second?", "answered")),
+			})
+			return commands.Result{}, err
+		}))
+
+		e.outline.AddDocument("doc-1", "col-1", "Notes", "/ai first?\n\n/ai second?")
+		if err := e.proc.ProcessDocument(ctx, "doc-1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(handled) != 1 || handled[0] != "/ai first?" {
+			t.Errorf("Expected only the first command to run, got %v", handled)
+		}
+	})
+
+	t.Run("joins command failures", func(t *testing.T) {
+		e := newEnv(t)
+		e.router = commands.NewRouter(nil)
+		e.proc = processor.NewProcessor(e.outline, e.router, e.pool)
+
+		e.router.Register(commands.CommandAI, commands.HandlerFunc(func(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+			return commands.Result{}, outline.ErrRateLimited
+		}))
+		e.router.Register(commands.CommandSummarize, commands.HandlerFunc(func(ctx context.Context, doc *commands.Document, cmd commands.Command) (commands.Result, error) {
+			return commands.Result{}, errors.New("boom")
+		}))
+
+		e.outline.AddDocument("doc-1", "col-1", "Notes", "/summarize\n\n/ai why?")
+		err := e.proc.ProcessDocument(ctx, "doc-1")
+		if !errors.Is(err, outline.ErrRateLimited) || !strings.Contains(err.Error(), "boom") {
+			t.Fatalf("Expected both failures, got %v", err)
+		}
+		if !commands.IsRetryable(err) {
+			t.Error("Expected the joined error to be retryable")
+		}
+	})
+
+	t.Run("reports fetch failures", func(t *testing.T) {
+		e := newEnv(t)
+		e.outline.AddDocument("doc-1", "col-1", "Notes", "/ai why?")
+		e.outline.SetGetDocumentError(outline.ErrServerError)
+
+		if err := e.proc.ProcessDocument(ctx, "doc-1"); !errors.Is(err, outline.ErrServerError) {
+			t.Errorf("Expected ErrServerError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+// Package ratelimit provides the token buckets that keep outline-ai under
+// its upstream API budgets, plus the Retry-After plumbing that lets clients
+// report a server-requested pause.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter is a token bucket that can additionally be paused, e.g. after an
+// upstream 429 with a Retry-After hint
+type Limiter struct {
+	limiter *rate.Limiter
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+	now         func() time.Time
+}
+
+// NewLimiter creates a bucket refilled at requestsPerMinute. Burst defaults
+// to a tenth of the per-minute budget when burst is less than one.
+func NewLimiter(requestsPerMinute, burst int) *Limiter {
+	if requestsPerMinute < 1 {
+		requestsPerMinute = 1
+	}
+	if burst < 1 {
+		burst = max(1, requestsPerMinute/10)
+	}
+	return &Limiter{
+		limiter: rate.NewLimiter(rate.Limit(float64(requestsPerMinute)/60.0), burst),
+		now:     time.Now,
+	}
+}
+
+// Wait blocks until a token is available and any pause has ended. It returns
+// the context's error if ctx ends first, or a wrapped
+// context.DeadlineExceeded when the wait could not finish before the deadline.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		pause := l.pauseRemaining()
+		if pause <= 0 {
+			break
+		}
+		if deadline, ok := ctx.Deadline(); ok && l.now().Add(pause).After(deadline) {
+			return fmt.Errorf("ratelimit: pause of %s exceeds deadline: %w", pause, context.DeadlineExceeded)
+		}
+
+		timer := time.NewTimer(pause)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		// Loop in case the pause was extended while we slept
+	}
+
+	if err := l.limiter.Wait(ctx); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("ratelimit: %v: %w", err, context.DeadlineExceeded)
+	}
+	return nil
+}
+
+// Allow consumes a token if one is available right now
+func (l *Limiter) Allow() bool {
+	if l.pauseRemaining() > 0 {
+		return false
+	}
+	return l.limiter.Allow()
+}
+
+// PauseFor stops handing out tokens for d. Overlapping pauses keep the later
+// end time.
+func (l *Limiter) PauseFor(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := l.now().Add(d)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// PausedUntil returns the end of the current pause, or the zero time
+func (l *Limiter) PausedUntil() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.pausedUntil.After(l.now()) {
+		return time.Time{}
+	}
+	return l.pausedUntil
+}
+
+func (l *Limiter) pauseRemaining() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pausedUntil.Sub(l.now())
+}
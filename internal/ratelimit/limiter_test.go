@@ -0,0 +1,192 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Burst(t *testing.T) {
+	limiter := NewLimiter(60, 3)
+
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow() {
+			t.Fatalf("Expected request %d within burst to be allowed", i+1)
+		}
+	}
+	if limiter.Allow() {
+		t.Error("Expected request beyond burst to be rejected")
+	}
+}
+
+func TestLimiter_DefaultBurst(t *testing.T) {
+	limiter := NewLimiter(60, 0)
+
+	allowed := 0
+	for limiter.Allow() {
+		allowed++
+	}
+	if allowed != 6 {
+		t.Errorf("Expected default burst of a tenth of 60 rpm (6), got %d", allowed)
+	}
+}
+
+func TestLimiter_Wait(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("waits for refill", func(t *testing.T) {
+		limiter := NewLimiter(1200, 1) // one token every 50ms
+		limiter.Allow()
+
+		start := time.Now()
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+			t.Errorf("Expected Wait to block for a refill, returned after %s", elapsed)
+		}
+	})
+
+	t.Run("cancellation aborts the wait", func(t *testing.T) {
+		limiter := NewLimiter(1, 1)
+		limiter.Allow()
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+
+		if err := limiter.Wait(cancelCtx); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("deadline too soon fails fast", func(t *testing.T) {
+		limiter := NewLimiter(1, 1)
+		limiter.Allow()
+
+		deadlineCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := limiter.Wait(deadlineCtx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+			t.Errorf("Expected an impossible wait to fail immediately, took %s", elapsed)
+		}
+	})
+}
+
+func TestLimiter_PauseFor(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("blocks Allow and Wait", func(t *testing.T) {
+		limiter := NewLimiter(6000, 10)
+		limiter.PauseFor(50 * time.Millisecond)
+
+		if limiter.Allow() {
+			t.Error("Expected Allow to fail while paused")
+		}
+		if limiter.PausedUntil().IsZero() {
+			t.Error("Expected PausedUntil to report the pause")
+		}
+
+		start := time.Now()
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+			t.Errorf("Expected Wait to honor the pause, returned after %s", elapsed)
+		}
+		if !limiter.PausedUntil().IsZero() {
+			t.Error("Expected pause to have ended")
+		}
+	})
+
+	t.Run("keeps the later end", func(t *testing.T) {
+		limiter := NewLimiter(60, 1)
+		limiter.PauseFor(time.Hour)
+		limiter.PauseFor(time.Second)
+
+		if until := time.Until(limiter.PausedUntil()); until < 59*time.Minute {
+			t.Errorf("Expected shorter pause not to shorten the longer one, %s left", until)
+		}
+	})
+
+	t.Run("cancellation aborts a pause", func(t *testing.T) {
+		limiter := NewLimiter(60, 1)
+		limiter.PauseFor(time.Hour)
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := limiter.Wait(cancelCtx); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("pause past deadline fails fast", func(t *testing.T) {
+		limiter := NewLimiter(60, 1)
+		limiter.PauseFor(time.Hour)
+
+		deadlineCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		if err := limiter.Wait(deadlineCtx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"missing", "", 0},
+		{"seconds", "30", 30 * time.Second},
+		{"zero seconds", "0", 0},
+		{"negative seconds", "-5", 0},
+		{"http date", now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{"past http date", now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"garbage", "soon", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.value != "" {
+				header.Set("Retry-After", tt.value)
+			}
+			if got := ParseRetryAfter(header, now); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	errUpstream := errors.New("upstream: rate limited")
+
+	wrapped := fmt.Errorf("max retries exceeded: %w", &RetryAfterError{Err: errUpstream, After: 2 * time.Second})
+	if d, ok := RetryAfter(wrapped); !ok || d != 2*time.Second {
+		t.Errorf("Expected 2s hint through wrapping, got %s (%v)", d, ok)
+	}
+	if !errors.Is(wrapped, errUpstream) {
+		t.Error("Expected RetryAfterError to unwrap to the upstream error")
+	}
+
+	if _, ok := RetryAfter(&RetryAfterError{Err: errUpstream}); ok {
+		t.Error("Expected no hint when After is zero")
+	}
+	if _, ok := RetryAfter(errUpstream); ok {
+		t.Error("Expected no hint on a plain error")
+	}
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryAfterError wraps an upstream rate-limit error with the pause the
+// server asked for. After is zero when the response carried no usable hint.
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	if e.After <= 0 {
+		return e.Err.Error()
+	}
+	return e.Err.Error() + " (retry after " + e.After.String() + ")"
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter extracts the server-requested pause from err, if any
+func RetryAfter(err error) (time.Duration, bool) {
+	var retryErr *RetryAfterError
+	if errors.As(err, &retryErr) && retryErr.After > 0 {
+		return retryErr.After, true
+	}
+	return 0, false
+}
+
+// ParseRetryAfter reads a Retry-After header in either delta-seconds or
+// HTTP-date form. It returns zero for a missing, malformed or past value.
+func ParseRetryAfter(header http.Header, now time.Time) time.Duration {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
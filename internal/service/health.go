@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/worker"
+)
+
+const (
+	defaultHealthCacheTTL = 30 * time.Second
+	defaultHealthTimeout  = 5 * time.Second
+)
+
+// Status is the health of one dependency or of the whole service
+type Status string
+
+const (
+	// StatusOK means everything checked is available
+	StatusOK Status = "ok"
+	// StatusDegraded means a non-critical dependency failed or an AI
+	// circuit breaker is rejecting calls; the service still takes work
+	StatusDegraded Status = "degraded"
+	// StatusFailed means a critical dependency failed
+	StatusFailed Status = "failed"
+)
+
+// Dependency is something the service needs, checked with Ping
+type Dependency struct {
+	Name string
+	Ping func(ctx context.Context) error
+
+	// Critical dependencies make the service unready when they fail;
+	// others only degrade it
+	Critical bool
+
+	// Breaker, when set, reports the circuit breaker in front of the
+	// dependency. An open breaker degrades it even if Ping succeeds.
+	Breaker func() ai.State
+}
+
+// DependencyReport is the last check of one dependency
+type DependencyReport struct {
+	Status      Status     `json:"status"`
+	Critical    bool       `json:"critical"`
+	LatencyMs   int64      `json:"latency_ms"`
+	CheckedAt   time.Time  `json:"checked_at"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Breaker     string     `json:"breaker,omitempty"`
+
+	err error
+}
+
+// WorkerReport describes how busy the worker pool is
+type WorkerReport struct {
+	Workers int `json:"workers"`
+	Active  int `json:"active"`
+	Queued  int `json:"queued"`
+	// Utilization is the share of workers running a task, from 0 to 1
+	Utilization float64 `json:"utilization"`
+}
+
+// Report is the body of both probes
+type Report struct {
+	Status       Status                      `json:"status"`
+	Phase        string                      `json:"phase"`
+	Dependencies map[string]DependencyReport `json:"dependencies,omitempty"`
+	Workers      *WorkerReport               `json:"workers,omitempty"`
+	LastWebhook  *time.Time                  `json:"last_webhook,omitempty"`
+}
+
+// Ready reports whether the service should take traffic: it has started,
+// is not draining and no critical dependency has failed
+func (r *Report) Ready() bool {
+	return r.Phase == PhaseReady.String() && r.Status != StatusFailed
+}
+
+// HealthOption configures Health
+type HealthOption func(*Health)
+
+// WithCacheTTL sets how long a ping result is reused, so frequent probes
+// do not reach the dependencies, the AI provider in particular (default
+// 30s)
+func WithCacheTTL(ttl time.Duration) HealthOption {
+	return func(h *Health) {
+		h.ttl = ttl
+	}
+}
+
+// WithCheckTimeout sets how long each ping may take (default 5s)
+func WithCheckTimeout(timeout time.Duration) HealthOption {
+	return func(h *Health) {
+		h.timeout = timeout
+	}
+}
+
+// WithWorkerStats adds the worker pool's utilization to the report
+func WithWorkerStats(stats func() worker.Stats) HealthOption {
+	return func(h *Health) {
+		h.workers = stats
+	}
+}
+
+// WithLastWebhook adds when the last webhook delivery arrived to the report
+func WithLastWebhook(last func() time.Time) HealthOption {
+	return func(h *Health) {
+		h.lastWebhook = last
+	}
+}
+
+// WithHealthClock overrides the time source, mainly for tests
+func WithHealthClock(now func() time.Time) HealthOption {
+	return func(h *Health) {
+		h.now = now
+	}
+}
+
+// Health checks the dependencies and caches the results. Checks run in
+// parallel; callers arriving while a dependency is being pinged wait for
+// that ping instead of starting another. Cached never waits.
+type Health struct {
+	checks      []*check
+	ttl         time.Duration
+	timeout     time.Duration
+	workers     func() worker.Stats
+	lastWebhook func() time.Time
+	now         func() time.Time
+}
+
+// check holds the cached result for one dependency. mu is held during a
+// ping, which is what makes concurrent callers share it; last can be read
+// without it.
+type check struct {
+	Dependency
+
+	mu   sync.Mutex
+	last atomic.Pointer[pingResult]
+}
+
+// pingResult is the outcome of one ping
+type pingResult struct {
+	checkedAt   time.Time
+	latency     time.Duration
+	lastSuccess time.Time
+	err         error
+}
+
+// NewHealth creates Health for deps, reported under their names
+func NewHealth(deps []Dependency, opts ...HealthOption) *Health {
+	h := &Health{
+		ttl:     defaultHealthCacheTTL,
+		timeout: defaultHealthTimeout,
+		now:     time.Now,
+	}
+	for _, dep := range deps {
+		h.checks = append(h.checks, &check{Dependency: dep})
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Check reports every dependency, pinging those whose cached result is
+// older than the TTL
+func (h *Health) Check(ctx context.Context) *Report {
+	return h.report(ctx, false)
+}
+
+// Refresh pings every dependency, ignoring the cache, and reports them
+func (h *Health) Refresh(ctx context.Context) *Report {
+	return h.report(ctx, true)
+}
+
+// Cached reports the last check of every dependency, however old, without
+// pinging or waiting for a ping in progress. Dependencies not checked yet
+// are left out.
+func (h *Health) Cached() *Report {
+	report := &Report{
+		Status:       StatusOK,
+		Dependencies: make(map[string]DependencyReport, len(h.checks)),
+	}
+	for _, c := range h.checks {
+		if last := c.last.Load(); last != nil {
+			result := c.result(last)
+			report.Dependencies[c.Name] = result
+			report.Status = worse(report.Status, result.Status)
+		}
+	}
+	h.addActivity(report)
+	return report
+}
+
+func (h *Health) report(ctx context.Context, force bool) *Report {
+	report := &Report{
+		Status:       StatusOK,
+		Dependencies: make(map[string]DependencyReport, len(h.checks)),
+	}
+
+	results := make([]DependencyReport, len(h.checks))
+	var wg sync.WaitGroup
+	for i, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = h.run(ctx, c, force)
+		}()
+	}
+	wg.Wait()
+
+	for i, c := range h.checks {
+		result := results[i]
+		report.Dependencies[c.Name] = result
+		report.Status = worse(report.Status, result.Status)
+	}
+	h.addActivity(report)
+	return report
+}
+
+// addActivity adds the worker pool and webhook state to report
+func (h *Health) addActivity(report *Report) {
+	if h.workers != nil {
+		stats := h.workers()
+		workers := &WorkerReport{Workers: stats.Workers, Active: stats.Active, Queued: stats.Queued}
+		if stats.Workers > 0 {
+			workers.Utilization = float64(stats.Active) / float64(stats.Workers)
+		}
+		report.Workers = workers
+	}
+	if h.lastWebhook != nil {
+		if last := h.lastWebhook(); !last.IsZero() {
+			report.LastWebhook = &last
+		}
+	}
+}
+
+// run pings c unless its cached result is fresh, and reports it
+func (h *Health) run(ctx context.Context, c *check, force bool) DependencyReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last := c.last.Load()
+	if force || last == nil || h.now().Sub(last.checkedAt) >= h.ttl {
+		pingCtx, cancel := context.WithTimeout(ctx, h.timeout)
+		started := h.now()
+		err := c.Ping(pingCtx)
+		cancel()
+
+		next := &pingResult{checkedAt: h.now(), err: err}
+		next.latency = next.checkedAt.Sub(started)
+		if last != nil {
+			next.lastSuccess = last.lastSuccess
+		}
+		if err == nil {
+			next.lastSuccess = next.checkedAt
+		}
+		c.last.Store(next)
+		last = next
+	}
+	return c.result(last)
+}
+
+// result reports last together with the current breaker state
+func (c *check) result(last *pingResult) DependencyReport {
+	result := DependencyReport{
+		Status:    StatusOK,
+		Critical:  c.Critical,
+		LatencyMs: last.latency.Milliseconds(),
+		CheckedAt: last.checkedAt,
+	}
+	if !last.lastSuccess.IsZero() {
+		lastSuccess := last.lastSuccess
+		result.LastSuccess = &lastSuccess
+	}
+	if last.err != nil {
+		result.Error = last.err.Error()
+		result.err = last.err
+		result.Status = StatusDegraded
+		if c.Critical {
+			result.Status = StatusFailed
+		}
+	}
+	if c.Breaker != nil {
+		state := c.Breaker()
+		result.Breaker = state.String()
+		if state == ai.StateOpen {
+			result.Status = worse(result.Status, StatusDegraded)
+		}
+	}
+	return result
+}
+
+// worse returns the more severe of a and b
+func worse(a, b Status) Status {
+	rank := map[Status]int{StatusOK: 0, StatusDegraded: 1, StatusFailed: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
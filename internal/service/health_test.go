@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/worker"
+)
+
+type fakePing struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (p *fakePing) ping(ctx context.Context) error {
+	p.calls.Add(1)
+	return p.err
+}
+
+func TestHealth_CachesPings(t *testing.T) {
+	now := time.Date(2026, 1, 19, 12, 0, 0, 0, time.UTC)
+	outline, llm := &fakePing{}, &fakePing{}
+	health := NewHealth([]Dependency{
+		{Name: "outline", Ping: outline.ping, Critical: true},
+		{Name: "ai", Ping: llm.ping, Critical: true},
+	}, WithCacheTTL(time.Minute), WithHealthClock(func() time.Time { return now }))
+	ctx := context.Background()
+
+	first := health.Check(ctx)
+	now = now.Add(30 * time.Second)
+	second := health.Check(ctx)
+	if llm.calls.Load() != 1 || outline.calls.Load() != 1 {
+		t.Errorf("Expected one ping each within the TTL, got %d and %d", outline.calls.Load(), llm.calls.Load())
+	}
+	if !second.Dependencies["ai"].CheckedAt.Equal(first.Dependencies["ai"].CheckedAt) {
+		t.Error("Expected the cached result to be reported")
+	}
+
+	now = now.Add(30 * time.Second)
+	llm.err = errors.New("provider down")
+	report := health.Check(ctx)
+	if llm.calls.Load() != 2 {
+		t.Errorf("Expected a ping once the TTL passed, got %d", llm.calls.Load())
+	}
+	dep := report.Dependencies["ai"]
+	if report.Status != StatusFailed || dep.Status != StatusFailed || dep.Error != "provider down" {
+		t.Errorf("Expected the critical failure reported, got %s %+v", report.Status, dep)
+	}
+	if dep.LastSuccess == nil || !dep.LastSuccess.Equal(first.Dependencies["ai"].CheckedAt) {
+		t.Errorf("Expected the last success kept, got %v", dep.LastSuccess)
+	}
+
+	health.Refresh(ctx)
+	if llm.calls.Load() != 3 {
+		t.Errorf("Expected Refresh to bypass the cache, got %d pings", llm.calls.Load())
+	}
+}
+
+func TestHealth_Degraded(t *testing.T) {
+	state := ai.StateClosed
+	health := NewHealth([]Dependency{
+		{Name: "storage", Ping: (&fakePing{}).ping, Critical: true},
+		{Name: "ai", Ping: (&fakePing{}).ping, Breaker: func() ai.State { return state }},
+	}, WithCacheTTL(time.Hour), WithWorkerStats(func() worker.Stats {
+		return worker.Stats{Workers: 4, Active: 1, Queued: 3}
+	}))
+	ctx := context.Background()
+
+	report := health.Check(ctx)
+	if report.Status != StatusOK || report.Dependencies["ai"].Breaker != "closed" {
+		t.Errorf("Expected ok with a closed breaker, got %s %+v", report.Status, report.Dependencies["ai"])
+	}
+	if report.Workers.Utilization != 0.25 || report.Workers.Queued != 3 {
+		t.Errorf("Expected a quarter of the workers busy, got %+v", report.Workers)
+	}
+
+	// The breaker is read at every check, even while the ping is cached
+	state = ai.StateOpen
+	report = health.Check(ctx)
+	report.Phase = PhaseReady.String()
+	if report.Status != StatusDegraded || report.Dependencies["ai"].Breaker != "open" {
+		t.Errorf("Expected an open breaker to degrade the service, got %s %+v", report.Status, report.Dependencies["ai"])
+	}
+	if !report.Ready() {
+		t.Error("Expected a degraded service to stay ready")
+	}
+}
+
+func TestHealth_SharesPingsInFlight(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	health := NewHealth([]Dependency{{Name: "ai", Ping: func(ctx context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	}}})
+
+	done := make(chan struct{})
+	for range 5 {
+		go func() {
+			health.Check(context.Background())
+			done <- struct{}{}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	for range 5 {
+		<-done
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected concurrent checks to share one ping, got %d", calls.Load())
+	}
+}
+
+func TestHealth_CachedNeverPings(t *testing.T) {
+	storage := &fakePing{}
+	release := make(chan struct{})
+	var slow atomic.Bool
+	health := NewHealth([]Dependency{
+		{Name: "storage", Ping: storage.ping, Critical: true},
+		{Name: "ai", Ping: func(ctx context.Context) error {
+			if slow.Load() {
+				<-release
+			}
+			return errors.New("provider down")
+		}},
+	}, WithCacheTTL(0))
+
+	if report := health.Cached(); len(report.Dependencies) != 0 || report.Status != StatusOK {
+		t.Errorf("Expected nothing to report before the first check, got %+v", report)
+	}
+
+	health.Check(context.Background())
+	slow.Store(true)
+	go health.Check(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	defer close(release)
+
+	done := make(chan *Report)
+	go func() { done <- health.Cached() }()
+	select {
+	case report := <-done:
+		if report.Status != StatusDegraded || report.Dependencies["ai"].Error != "provider down" {
+			t.Errorf("Expected the last check reported, got %s %+v", report.Status, report.Dependencies["ai"])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Cached not to wait for the ping in progress")
+	}
+	if storage.calls.Load() != 2 {
+		t.Errorf("Expected only the two checks to ping, got %d pings", storage.calls.Load())
+	}
+}
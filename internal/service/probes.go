@@ -0,0 +1,118 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+)
+
+// Probe paths, as configured in the Kubernetes liveness and readiness
+// probes
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// Phase is where the service is in its lifecycle
+type Phase int32
+
+const (
+	// PhaseStarting covers dependency checks and catch-up
+	PhaseStarting Phase = iota
+	// PhaseReady means the service accepts events
+	PhaseReady
+	// PhaseDraining means shutdown has begun and queued work is finishing
+	PhaseDraining
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseStarting:
+		return "starting"
+	case PhaseReady:
+		return "ready"
+	case PhaseDraining:
+		return "draining"
+	default:
+		return "unknown"
+	}
+}
+
+// Probes answers liveness and readiness separately, both with a JSON
+// Report. Liveness only says the process is responsive: it reports the
+// cached checks without pinging anything, and holds while starting and
+// draining, and while dependencies fail, so a supervisor does not restart
+// a service that is finishing its work or waiting out an outage. Readiness
+// is true only between a successful start and the beginning of shutdown,
+// and while no critical dependency has failed.
+type Probes struct {
+	phase  atomic.Int32
+	health *Health
+}
+
+// NewProbes creates Probes in PhaseStarting that report health, which may
+// be nil to report the phase alone
+func NewProbes(health *Health) *Probes {
+	return &Probes{health: health}
+}
+
+// SetReady moves to PhaseReady, unless shutdown has already begun
+func (p *Probes) SetReady() {
+	p.phase.CompareAndSwap(int32(PhaseStarting), int32(PhaseReady))
+}
+
+// SetDraining moves to PhaseDraining
+func (p *Probes) SetDraining() {
+	p.phase.Store(int32(PhaseDraining))
+}
+
+// Phase returns the current phase
+func (p *Probes) Phase() Phase {
+	return Phase(p.phase.Load())
+}
+
+// Report returns the phase and the health of the dependencies, from
+// cached pings where they are fresh
+func (p *Probes) Report(r *http.Request) *Report {
+	report := &Report{Status: StatusOK}
+	if p.health != nil {
+		report = p.health.Check(r.Context())
+	}
+	report.Phase = p.Phase().String()
+	return report
+}
+
+// Liveness returns the phase and the last known health of the
+// dependencies, without checking them
+func (p *Probes) Liveness() *Report {
+	report := &Report{Status: StatusOK}
+	if p.health != nil {
+		report = p.health.Cached()
+	}
+	report.Phase = p.Phase().String()
+	return report
+}
+
+// Handler serves LivenessPath and ReadinessPath
+func (p *Probes) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(LivenessPath, func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, http.StatusOK, p.Liveness())
+	})
+	mux.HandleFunc(ReadinessPath, func(w http.ResponseWriter, r *http.Request) {
+		report := p.Report(r)
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		writeProbe(w, status, report)
+	})
+	return mux
+}
+
+func writeProbe(w http.ResponseWriter, status int, report *Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,513 @@
+// Package service assembles the components into the running service and
+// manages its lifecycle: dependency checks at startup, the webhook and
+// probe listeners, and a graceful shutdown that stops intake before
+// draining queued work and closing storage.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/audit"
+	"github.com/yourusername/outline-ai/internal/catchup"
+	"github.com/yourusername/outline-ai/internal/config"
+	"github.com/yourusername/outline-ai/internal/handlers"
+	"github.com/yourusername/outline-ai/internal/metrics"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/internal/poller"
+	"github.com/yourusername/outline-ai/internal/processor"
+	"github.com/yourusername/outline-ai/internal/ratelimit"
+	"github.com/yourusername/outline-ai/internal/taxonomy"
+	"github.com/yourusername/outline-ai/internal/usage"
+	"github.com/yourusername/outline-ai/internal/webhook"
+	"github.com/yourusername/outline-ai/internal/worker"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+// WebhookPath is where Outline delivers webhook events
+const WebhookPath = "/webhooks"
+
+// The filing handler classifies against the cached taxonomy
+var _ handlers.TaxonomySource = (*taxonomy.Builder)(nil)
+
+// Option configures a Service
+type Option func(*Service)
+
+// WithStorage uses storage instead of opening the configured SQLite
+// database. The caller keeps ownership: the Service never closes it.
+func WithStorage(storage persistence.Storage) Option {
+	return func(s *Service) {
+		s.storage = storage
+	}
+}
+
+// WithOutlineClient replaces the Outline HTTP client. Rate limiting is
+// still applied on top.
+func WithOutlineClient(client outline.Client) Option {
+	return func(s *Service) {
+		s.outline = client
+	}
+}
+
+// WithAIClient replaces the OpenAI client. Rate limiting and circuit
+// breaking are still applied on top.
+func WithAIClient(client ai.Client) Option {
+	return func(s *Service) {
+		s.ai = client
+	}
+}
+
+// WithHandler registers a handler for cmdType next to the built-in ones,
+// making cmdType a detectable marker. build receives the Outline client the
+// built-in handlers use, with rate limiting, metrics and audit applied.
+// Registering a built-in command type makes New fail.
+func WithHandler(cmdType commands.CommandType, build func(client outline.Client) commands.Handler) Option {
+	return func(s *Service) {
+		s.custom = append(s.custom, customHandler{cmdType: cmdType, build: build})
+	}
+}
+
+// WithSlog sets the logger handed to every component
+func WithSlog(logger *slog.Logger) Option {
+	return func(s *Service) {
+		s.slog = logger
+	}
+}
+
+// customHandler is a handler added with WithHandler
+type customHandler struct {
+	cmdType commands.CommandType
+	build   func(client outline.Client) commands.Handler
+}
+
+// Service is the assembled service. Create it with New, then call Run, or
+// Start and Shutdown.
+type Service struct {
+	cfg     *config.Config
+	slog    *slog.Logger
+	metrics *metrics.Metrics
+
+	storage   persistence.Storage
+	ownsStore bool
+	outline   outline.Client
+	ai        ai.Client
+	breaker   *ai.BreakerClient
+	taxonomy  *taxonomy.Builder
+	router    *commands.Router
+	custom    []customHandler
+	pool      *worker.Pool
+	processor *processor.Processor
+	catchUp   *catchup.CatchUp
+	poller    *poller.Poller
+	receiver  *webhook.Receiver
+
+	health        *Health
+	probes        *Probes
+	healthServer  *http.Server
+	healthLn      net.Listener
+	webhookServer *http.Server
+	webhookLn     net.Listener
+
+	// cancelSources stops the poller and the catch-up checkpoints; sources
+	// waits for them to return
+	cancelSources context.CancelFunc
+	sources       sync.WaitGroup
+	stopWatchdog  context.CancelFunc
+
+	// errs receives listener failures, which end Run
+	errs     chan error
+	stopOnce sync.Once
+	stopErr  error
+}
+
+// New wires the components described by cfg. Nothing is started and no
+// connection is made until Start, except opening the SQLite database,
+// which is closed again if New fails.
+func New(cfg *config.Config, opts ...Option) (*Service, error) {
+	s := &Service{
+		cfg:     cfg,
+		slog:    slog.Default(),
+		metrics: metrics.New(),
+		errs:    make(chan error, 2),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.storage == nil {
+		storage, err := persistence.NewSQLiteStorage(cfg.Persistence.DatabasePath)
+		if err != nil {
+			return nil, fmt.Errorf("service: failed to open storage: %w", err)
+		}
+		s.storage, s.ownsStore = storage, true
+	}
+	if err := s.wire(); err != nil {
+		if s.ownsStore {
+			if closeErr := s.storage.Close(); closeErr != nil {
+				err = errors.Join(err, fmt.Errorf("service: failed to close storage: %w", closeErr))
+			}
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// wire creates the components on top of storage
+func (s *Service) wire() error {
+	cfg := s.cfg
+
+	// Outline
+	if s.outline == nil {
+		s.outline = outline.NewHTTPClient(cfg.Outline.APIEndpoint, cfg.Outline.APIKey,
+			outline.WithTimeout(cfg.Outline.RequestTimeout))
+	}
+	s.outline = outline.NewRateLimitedClient(s.metrics.OutlineClient(s.outline),
+		ratelimit.NewLimiter(cfg.Outline.RateLimitPerMinute, 0),
+		ratelimit.NewLimiter(cfg.Outline.WriteRateLimitPerMinute, 0))
+	s.outline = audit.NewClient(s.outline, s.storage, audit.WithSlog(s.slog))
+
+	// AI
+	if s.ai == nil {
+		s.ai = ai.NewOpenAIClient(cfg.AI.Endpoint, cfg.AI.APIKey, cfg.AI.Model,
+			ai.WithTimeout(cfg.AI.RequestTimeout),
+			ai.WithMaxTokens(cfg.AI.MaxTokens),
+			ai.WithPricing(cfg.AI.PromptCostPerMillion, cfg.AI.CompletionCostPerMillion))
+	}
+	s.breaker = ai.NewBreakerClient(
+		ai.NewRateLimitedClient(s.ai, ratelimit.NewLimiter(cfg.AI.RateLimitPerMinute, 0)),
+		ai.WithStateChangeFunc(func(name string, from, to ai.State) {
+			s.slog.Warn("service: AI circuit breaker changed state", "breaker", name, "from", from, "to", to)
+		}))
+	s.ai = s.metrics.AIClient(s.breaker)
+	s.metrics.WatchBreakers(s.breaker.States)
+
+	// Commands
+	s.taxonomy = taxonomy.NewBuilder(s.outline,
+		taxonomy.WithTTL(cfg.Taxonomy.CacheTTL),
+		taxonomy.WithSamples(cfg.Taxonomy.MaxSamplesPerCollection),
+		taxonomy.WithBudget(cfg.Taxonomy.PromptBudget),
+		taxonomy.WithSlog(s.slog))
+	if err := s.registerHandlers(); err != nil {
+		return err
+	}
+
+	s.pool = worker.NewPool(s.storage,
+		worker.WithWorkers(cfg.Service.MaxConcurrentWorkers),
+		worker.WithQueueSize(cfg.Service.QueueSize),
+		worker.WithMaxAttempts(cfg.Processing.MaxRetries+1),
+		worker.WithBackoff(cfg.Processing.RetryBackoffBase, cfg.Processing.RetryBackoffMax),
+		worker.WithTaskTimeout(cfg.Processing.TaskTimeout),
+		worker.WithSlog(s.slog))
+	s.metrics.WatchPool(s.pool.Stats)
+	s.processor = processor.NewProcessor(s.outline, s.router, s.pool, processor.WithSlog(s.slog))
+
+	// Event sources
+	markers := s.router.CommandTypes()
+	if cfg.Webhooks.Enabled {
+		s.catchUp = catchup.NewCatchUp(s.outline, s.processor, s.storage, markers, catchup.WithSlog(s.slog))
+
+		webhookOpts := []webhook.Option{
+			webhook.WithEvents(cfg.Webhooks.Events...),
+			webhook.WithReplayWindow(cfg.Webhooks.ReplayWindow),
+			webhook.WithSlog(s.slog),
+		}
+		if cfg.Outline.PreviousWebhookSecret != "" {
+			webhookOpts = append(webhookOpts, webhook.WithPreviousSecret(cfg.Outline.PreviousWebhookSecret))
+		}
+		receiver, err := webhook.NewReceiver(s.catchUp.Track(s.taxonomy.Watch(s.processor)), cfg.Outline.WebhookSecret, webhookOpts...)
+		if err != nil {
+			return fmt.Errorf("service: failed to create webhook receiver: %w", err)
+		}
+		s.receiver = receiver
+	}
+	if cfg.Webhooks.FallbackPolling.Enabled {
+		s.poller = poller.NewPoller(s.outline, s.processor, s.storage, markers,
+			poller.WithInterval(cfg.Webhooks.FallbackPolling.Interval),
+			poller.WithSlog(s.slog))
+	}
+
+	s.health = s.newHealth()
+	s.probes = NewProbes(s.health)
+	return nil
+}
+
+// newHealth creates the dependency checks behind the probes
+func (s *Service) newHealth() *Health {
+	cfg := s.cfg.Service.Health
+	critical := func(name string) bool {
+		return !slices.Contains(cfg.NonCritical, name)
+	}
+
+	opts := []HealthOption{
+		WithCacheTTL(cfg.CacheTTL),
+		WithCheckTimeout(cfg.Timeout),
+		WithWorkerStats(s.pool.Stats),
+	}
+	if s.receiver != nil {
+		opts = append(opts, WithLastWebhook(s.receiver.LastReceived))
+	}
+	return NewHealth([]Dependency{
+		{Name: "storage", Ping: s.storage.Ping, Critical: critical("storage")},
+		{Name: "outline", Ping: s.outline.Ping, Critical: critical("outline")},
+		{Name: "ai", Ping: s.ai.Ping, Critical: critical("ai"), Breaker: s.breaker.State},
+	}, opts...)
+}
+
+// registerHandlers creates the router with one handler per command, the
+// built-in ones first
+func (s *Service) registerHandlers() error {
+	cfg := s.cfg
+	links := documentLinkBase(cfg.Outline.APIEndpoint)
+
+	budget := usage.NewBudget(s.storage,
+		usage.WithMonthlyTokens(int64(cfg.AI.MonthlyTokenBudget)),
+		usage.WithMonthlyCost(cfg.AI.MonthlyCostBudget))
+	guard := handlers.NewBudgetGuard(budget, s.outline)
+
+	s.router = commands.NewRouter(s.metrics.CommandLogger(s.storage),
+		commands.WithHook(usage.Hook),
+		commands.WithHook(audit.Hook),
+		commands.WithRetryClassifier(worker.IsRetryable),
+		commands.WithSlog(s.slog))
+	for cmdType, handler := range map[commands.CommandType]commands.Handler{
+		commands.CommandAIFile: handlers.NewFilingHandler(s.ai, s.outline,
+			handlers.WithFilingThreshold(cfg.AI.ConfidenceThreshold),
+			handlers.WithTaxonomySource(s.taxonomy),
+			handlers.WithSearchTerms(handlers.NewSearchTerms(s.ai))),
+		commands.CommandAI: handlers.NewQuestionHandler(s.ai, s.outline, s.storage,
+			handlers.WithQuestionLinkBase(links)),
+		commands.CommandSummarize: handlers.NewSummaryHandler(s.ai, s.outline, s.storage),
+		commands.CommandEnhanceTitle: handlers.NewTitleHandler(s.ai, s.outline,
+			handlers.WithTitleConfidence(cfg.AI.TitleConfidenceThreshold)),
+		commands.CommandRelated: handlers.NewRelatedHandler(s.ai, s.outline,
+			handlers.WithRelatedLinkBase(links)),
+		commands.CommandSearchTerms: handlers.NewSearchTermsHandler(s.ai, s.outline),
+	} {
+		if budget.Enabled() {
+			handler = guard.Wrap(handler)
+		}
+		if err := s.router.Register(cmdType, handler); err != nil {
+			return fmt.Errorf("service: failed to register %s: %w", cmdType, err)
+		}
+	}
+	for _, custom := range s.custom {
+		if err := s.router.Register(custom.cmdType, custom.build(s.outline)); err != nil {
+			return fmt.Errorf("service: failed to register %s: %w", custom.cmdType, err)
+		}
+	}
+	return nil
+}
+
+// documentLinkBase turns the API endpoint into the prefix of document
+// links, e.g. "https://outline.example.com/api" becomes
+// "https://outline.example.com/doc/"
+func documentLinkBase(endpoint string) string {
+	base := strings.TrimSuffix(strings.TrimRight(endpoint, "/"), "/api")
+	return base + "/doc/"
+}
+
+// Run starts the service and serves until ctx is done or a listener
+// fails, then shuts down within the configured shutdown timeout. Pass a
+// context cancelled by SIGTERM and SIGINT.
+func (s *Service) Run(ctx context.Context) error {
+	err := s.Start(ctx)
+	if err == nil {
+		select {
+		case <-ctx.Done():
+			s.slog.Info("service: shutting down")
+		case err = <-s.errs:
+			s.slog.Error("service: listener failed, shutting down", "error", err)
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.cfg.Service.ShutdownTimeout)
+	defer cancel()
+	return errors.Join(err, s.Shutdown(shutdownCtx))
+}
+
+// Start checks the dependencies, starts the workers, recovers events
+// missed while the service was down and opens the listeners. The listener
+// for probes, metrics, usage and audit trails opens first, so liveness is
+// answered while startup is still running and readiness reports
+// "starting". Usage reports and audit trails are only served when an
+// admin token is configured, and require it. Call Shutdown even when Start
+// fails, to release what was started.
+func (s *Service) Start(ctx context.Context) error {
+	watchdogCtx, stopWatchdog := context.WithCancel(context.WithoutCancel(ctx))
+	s.stopWatchdog = stopWatchdog
+	go watchdog(watchdogCtx, s.slog)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", s.probes.Handler())
+	mux.Handle(metrics.Path, s.metrics.Handler())
+	if token := s.cfg.Service.AdminToken; token != "" {
+		mux.Handle(usage.Path, requireToken(token, usage.Handler(s.storage)))
+		mux.Handle(audit.Path, requireToken(token, audit.Handler(s.storage)))
+	}
+	ln, server, err := s.listen(s.cfg.Service.HealthCheckPort, mux)
+	if err != nil {
+		return fmt.Errorf("service: failed to open health listener: %w", err)
+	}
+	s.healthLn, s.healthServer = ln, server
+
+	if err := s.checkDependencies(ctx); err != nil {
+		return err
+	}
+
+	// Work outlives ctx, which ends at SIGTERM; Shutdown drains it
+	background := context.WithoutCancel(ctx)
+	if err := s.pool.Start(background); err != nil {
+		return fmt.Errorf("service: failed to start workers: %w", err)
+	}
+
+	if s.catchUp != nil {
+		recovered, err := s.catchUp.Recover(ctx)
+		if err != nil {
+			return fmt.Errorf("service: failed to recover missed events: %w", err)
+		}
+		if recovered > 0 {
+			s.slog.Info("service: queued documents missed while down", "count", recovered)
+		}
+	}
+
+	if s.receiver != nil {
+		mux := http.NewServeMux()
+		mux.Handle(WebhookPath, s.receiver)
+		ln, server, err := s.listen(s.cfg.Service.WebhookPort, mux)
+		if err != nil {
+			return fmt.Errorf("service: failed to open webhook listener: %w", err)
+		}
+		s.webhookLn, s.webhookServer = ln, server
+	}
+
+	sources, cancel := context.WithCancel(background)
+	s.cancelSources = cancel
+	if s.catchUp != nil {
+		s.sources.Add(1)
+		go func() {
+			defer s.sources.Done()
+			s.catchUp.Run(sources)
+		}()
+	}
+	if s.poller != nil {
+		s.sources.Add(1)
+		go func() {
+			defer s.sources.Done()
+			s.poller.Run(sources)
+		}()
+	}
+
+	s.probes.SetReady()
+	notify(s.slog, "READY=1")
+	s.slog.Info("service: started",
+		"workers", s.cfg.Service.MaxConcurrentWorkers,
+		"webhooks", s.receiver != nil,
+		"polling", s.poller != nil,
+		"commands", s.router.CommandTypes())
+	return nil
+}
+
+// checkDependencies pings storage, Outline and the AI provider and reports
+// every critical one that fails. A non-critical failure is only logged.
+// The results seed the cache the probes answer from.
+func (s *Service) checkDependencies(ctx context.Context) error {
+	report := s.health.Refresh(ctx)
+
+	var errs []error
+	for _, name := range []string{"storage", "outline", "ai"} {
+		dep := report.Dependencies[name]
+		switch {
+		case dep.err == nil:
+			s.slog.Info("service: dependency available", "dependency", name, "latency_ms", dep.LatencyMs)
+		case dep.Critical:
+			errs = append(errs, fmt.Errorf("service: %s is unavailable: %w", name, dep.err))
+		default:
+			s.slog.Warn("service: non-critical dependency unavailable, starting degraded", "dependency", name, "error", dep.err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// listen opens port and serves handler on it until Shutdown
+func (s *Service) listen(port int, handler http.Handler) (net.Listener, *http.Server, error) {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, nil, err
+	}
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.errs <- fmt.Errorf("service: listener %s failed: %w", ln.Addr(), err)
+		}
+	}()
+	return ln, server, nil
+}
+
+// Shutdown stops the service in order: readiness is withdrawn and webhook
+// intake stops, the poller and catch-up stop after saving their position,
+// queued tasks drain until ctx's deadline, and storage is closed. The probe
+// listener closes last, so liveness holds while draining. Later calls
+// return the first call's result.
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		s.stopErr = s.shutdown(ctx)
+	})
+	return s.stopErr
+}
+
+func (s *Service) shutdown(ctx context.Context) error {
+	s.probes.SetDraining()
+	notify(s.slog, "STOPPING=1")
+	var errs []error
+
+	// Stop intake
+	if s.webhookServer != nil {
+		if err := s.webhookServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("service: failed to stop webhook listener: %w", err))
+		}
+	}
+	if s.cancelSources != nil {
+		s.cancelSources()
+	}
+	s.sources.Wait()
+
+	// Drain
+	stats := s.pool.Stats()
+	s.slog.Info("service: draining tasks", "queued", stats.Queued, "active", stats.Active)
+	if err := s.pool.Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("service: failed to drain tasks: %w", err))
+	}
+
+	// Flush state
+	if s.ownsStore {
+		if err := s.storage.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("service: failed to close storage: %w", err))
+		}
+	}
+
+	if s.healthServer != nil {
+		if err := s.healthServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("service: failed to stop health listener: %w", err))
+		}
+	}
+	if s.stopWatchdog != nil {
+		s.stopWatchdog()
+	}
+
+	stats = s.pool.Stats()
+	s.slog.Info("service: stopped", "completed", stats.Completed, "failed", stats.Failed, "dead_lettered", stats.DeadLettered)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,524 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/audit"
+	"github.com/yourusername/outline-ai/internal/config"
+	"github.com/yourusername/outline-ai/internal/metrics"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/internal/usage"
+	"github.com/yourusername/outline-ai/internal/webhook"
+	"github.com/yourusername/outline-ai/pkg/commands"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+const (
+	secret     = "whsec"
+	adminToken = "admin-token"
+)
+
+type fixture struct {
+	svc     *Service
+	outline *mocks.OutlineMock
+	ai      *mocks.AIMock
+	storage *mocks.StorageMock
+}
+
+func newFixture(t *testing.T, configure ...func(*config.Config)) *fixture {
+	t.Helper()
+	return newFixtureWith(t, nil, configure...)
+}
+
+// newFixtureWith creates the fixture with extra service options
+func newFixtureWith(t *testing.T, opts []Option, configure ...func(*config.Config)) *fixture {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Service.HealthCheckPort = 0
+	cfg.Service.WebhookPort = 0
+	cfg.Outline.APIEndpoint = "https://outline.example.com/api"
+	cfg.Outline.APIKey = "key"
+	cfg.Outline.WebhookSecret = secret
+	cfg.Service.AdminToken = adminToken
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	f := &fixture{
+		outline: mocks.NewOutlineMock(),
+		ai:      mocks.NewAIMock(),
+		storage: mocks.NewStorageMock(),
+	}
+	svc, err := New(cfg, append([]Option{
+		WithStorage(f.storage),
+		WithOutlineClient(f.outline),
+		WithAIClient(f.ai),
+		WithSlog(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	}, opts...)...)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	f.svc = svc
+	return f
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, strings.TrimSpace(string(body))
+}
+
+func (f *fixture) probe(t *testing.T, path string) (int, string) {
+	t.Helper()
+	return get(t, "http://"+f.svc.healthLn.Addr().String()+path)
+}
+
+// admin requests path from the health listener with token as the bearer
+// token
+func (f *fixture) admin(t *testing.T, path, token string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "http://"+f.svc.healthLn.Addr().String()+path, nil)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, strings.TrimSpace(string(body))
+}
+
+// report decodes a probe's JSON body
+func report(t *testing.T, body string) *Report {
+	t.Helper()
+	var report Report
+	if err := json.Unmarshal([]byte(body), &report); err != nil {
+		t.Fatalf("Expected a JSON report, got %q: %v", body, err)
+	}
+	return &report
+}
+
+func (f *fixture) deliver(t *testing.T, event *webhook.Event) int {
+	t.Helper()
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, "http://"+f.svc.webhookLn.Addr().String()+WebhookPath, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(secret, body, time.Now()))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestService_Lifecycle(t *testing.T) {
+	f := newFixture(t)
+	f.outline.AddDocument("doc-1", "col-1", "Notes", "Some notes.\n\n/summarize")
+
+	ctx := context.Background()
+	if err := f.svc.Start(ctx); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+	for _, dependency := range []interface{ GetCallCount(string) int }{f.storage, f.outline, f.ai} {
+		if dependency.GetCallCount("Ping") != 1 {
+			t.Errorf("Expected every dependency to be pinged once, got %d", dependency.GetCallCount("Ping"))
+		}
+	}
+	status, body := f.probe(t, ReadinessPath)
+	if status != http.StatusOK || report(t, body).Phase != "ready" {
+		t.Errorf("Expected readiness once started, got %d %s", status, body)
+	}
+	for _, dependency := range []interface{ GetCallCount(string) int }{f.storage, f.outline, f.ai} {
+		if dependency.GetCallCount("Ping") != 1 {
+			t.Errorf("Expected the probe to use the startup check, got %d pings", dependency.GetCallCount("Ping"))
+		}
+	}
+	if status, body := f.probe(t, metrics.Path); status != http.StatusOK || !strings.Contains(body, "outline_ai_worker_queue_depth") {
+		t.Errorf("Expected metrics on the probe listener, got %d", status)
+	}
+	if status, body := f.admin(t, usage.Path+"?by=command", adminToken); status != http.StatusOK || !strings.Contains(body, `"rows":[]`) {
+		t.Errorf("Expected an empty usage report on the probe listener, got %d %s", status, body)
+	}
+	if status, _ := f.probe(t, usage.Path); status != http.StatusUnauthorized {
+		t.Errorf("Expected the usage report to require the admin token, got %d", status)
+	}
+	if status, _ := f.admin(t, usage.Path, "wrong"); status != http.StatusUnauthorized {
+		t.Errorf("Expected a wrong admin token to be refused, got %d", status)
+	}
+	if status, _ := f.probe(t, audit.Path+"?document_id=doc-1"); status != http.StatusUnauthorized {
+		t.Errorf("Expected the audit trail to require the admin token, got %d", status)
+	}
+	if status, body := f.admin(t, audit.Path+"?document_id=doc-1", adminToken); status != http.StatusOK || !strings.Contains(body, `"entries":[]`) {
+		t.Errorf("Expected an empty audit trail on the probe listener, got %d %s", status, body)
+	}
+
+	status = f.deliver(t, &webhook.Event{
+		ID:        "delivery-1",
+		CreatedAt: time.Now(),
+		Event:     webhook.EventDocumentsUpdate,
+		Payload:   webhook.Payload{ID: "doc-1", Model: &outline.Document{ID: "doc-1", Text: "/summarize"}},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("Expected the delivery to be accepted, got %d", status)
+	}
+	if _, body := f.probe(t, LivenessPath); report(t, body).LastWebhook == nil {
+		t.Errorf("Expected the delivery's receipt in the report, got %s", body)
+	}
+
+	// Shutdown drains the queued task before closing storage
+	if err := f.svc.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down: %v", err)
+	}
+	if f.ai.GetCallCount("GenerateSummary") != 1 {
+		t.Errorf("Expected the queued command to finish before shutdown returned, got %d summaries", f.ai.GetCallCount("GenerateSummary"))
+	}
+	if f.storage.GetCallCount("Close") != 0 {
+		t.Errorf("Expected injected storage to be left open, got %d closes", f.storage.GetCallCount("Close"))
+	}
+	trail, _ := f.storage.GetAuditTrail(ctx, "doc-1", 0)
+	if len(trail) != 1 || trail[0].Action != persistence.AuditActionUpdate || trail[0].CommandType != "/summarize" {
+		t.Errorf("Expected the summary recorded in the audit log, got %+v", trail)
+	}
+	rec := httptest.NewRecorder()
+	f.svc.metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, metrics.Path, nil))
+	for _, want := range []string{
+		`outline_ai_commands_total{command="/summarize",status="success"} 1`,
+		`outline_ai_ai_request_duration_seconds_count{method="GenerateSummary"} 1`,
+		`outline_ai_outline_requests_total{method="UpdateDocument",result="ok"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected %q in the metrics", want)
+		}
+	}
+	if _, err := f.storage.GetCursor(ctx, "webhook"); err != nil {
+		t.Errorf("Expected the last event time to be saved, got %v", err)
+	}
+	if f.svc.probes.Phase() != PhaseDraining {
+		t.Errorf("Expected the draining phase, got %s", f.svc.probes.Phase())
+	}
+	if _, err := http.Get("http://" + f.svc.webhookLn.Addr().String() + WebhookPath); err == nil {
+		t.Error("Expected the webhook listener to be closed")
+	}
+
+	if err := f.svc.Shutdown(ctx); err != nil {
+		t.Errorf("Expected a second Shutdown to return the first result, got %v", err)
+	}
+
+}
+
+func TestService_RefusesCommandsOverBudget(t *testing.T) {
+	f := newFixture(t, func(cfg *config.Config) {
+		cfg.AI.MonthlyTokenBudget = 1000
+	})
+	ctx := context.Background()
+	f.storage.LogCommand(ctx, &persistence.CommandLog{
+		DocumentID:   "doc-0",
+		CommandType:  string(commands.CommandSummarize),
+		Status:       persistence.CommandStatusSuccess,
+		PromptTokens: 1000,
+	})
+	doc := f.outline.AddDocument("doc-1", "col-1", "Notes", "Some notes.\n\n/summarize")
+
+	cmds := f.svc.router.Detect(doc.Text)
+	if len(cmds) != 1 {
+		t.Fatalf("Expected one command, got %d", len(cmds))
+	}
+	if _, err := f.svc.router.Route(ctx, &commands.Document{ID: doc.ID, Text: doc.Text}, cmds[0]); err != nil {
+		t.Fatalf("Expected the refusal to succeed, got %v", err)
+	}
+	if f.ai.GetCallCount("GenerateSummary") != 0 {
+		t.Errorf("Expected no AI call over budget, got %d", f.ai.GetCallCount("GenerateSummary"))
+	}
+	comments, _ := f.outline.ListComments(ctx, doc.ID)
+	if len(comments) != 1 || !strings.Contains(comments[0].Data, "AI budget exhausted") {
+		t.Errorf("Expected a budget comment, got %+v", comments)
+	}
+}
+
+func TestService_CustomHandler(t *testing.T) {
+	translate := func(client outline.Client) commands.Handler {
+		return commands.HandlerFunc(func(ctx context.Context, d *commands.Document, cmd commands.Command) (commands.Result, error) {
+			text := strings.Replace(d.Text, cmd.RawText, "Bonjour", 1)
+			if _, err := client.UpdateDocument(ctx, d.ID, &outline.UpdateDocumentRequest{Text: &text}); err != nil {
+				return commands.Result{}, err
+			}
+			return commands.Result{Message: "translated to " + cmd.Arguments}, nil
+		})
+	}
+	f := newFixtureWith(t, []Option{WithHandler("/translate", translate)})
+	f.outline.AddDocument("doc-1", "col-1", "Greeting", "Hello\n/translate fr")
+
+	ctx := context.Background()
+	if err := f.svc.Start(ctx); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+	status := f.deliver(t, &webhook.Event{
+		ID:        "delivery-1",
+		CreatedAt: time.Now(),
+		Event:     webhook.EventDocumentsUpdate,
+		Payload:   webhook.Payload{ID: "doc-1", Model: &outline.Document{ID: "doc-1", Text: "Hello\n/translate fr"}},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("Expected the delivery to be accepted, got %d", status)
+	}
+	if err := f.svc.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down: %v", err)
+	}
+
+	doc, _ := f.outline.GetDocument(ctx, "doc-1")
+	if doc.Text != "Hello\nBonjour" {
+		t.Errorf("Expected the custom command to run, got %q", doc.Text)
+	}
+	history, _ := f.storage.GetCommandHistory(ctx, "doc-1", 0)
+	if len(history) != 1 || history[0].CommandType != "/translate" || *history[0].CommandArgs != "fr" {
+		t.Fatalf("Expected the custom command in the command log, got %+v", history)
+	}
+	trail, _ := f.storage.GetAuditTrail(ctx, "doc-1", 0)
+	if len(trail) != 1 || trail[0].CorrelationID != history[0].CorrelationID {
+		t.Errorf("Expected the change audited under the command's correlation ID, got %+v", trail)
+	}
+}
+
+func TestService_CustomHandlerCannotReplaceBuiltIn(t *testing.T) {
+	noop := func(outline.Client) commands.Handler {
+		return commands.HandlerFunc(func(context.Context, *commands.Document, commands.Command) (commands.Result, error) {
+			return commands.Result{}, nil
+		})
+	}
+	_, err := New(config.Default(), WithStorage(mocks.NewStorageMock()), WithHandler(commands.CommandAI, noop))
+	if !errors.Is(err, commands.ErrDuplicateHandler) {
+		t.Errorf("Expected ErrDuplicateHandler, got %v", err)
+	}
+}
+
+// walExists reports whether the SQLite database at path has a write-ahead
+// log, which SQLite removes when the last connection closes
+func walExists(t *testing.T, path string) bool {
+	t.Helper()
+	_, err := os.Stat(path + "-wal")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Failed to stat the write-ahead log: %v", err)
+	}
+	return err == nil
+}
+
+func TestService_ClosesTheStorageItOpened(t *testing.T) {
+	cfg := config.Default()
+	cfg.Service.HealthCheckPort = 0
+	cfg.Service.WebhookPort = 0
+	cfg.Outline.WebhookSecret = secret
+	cfg.Persistence.DatabasePath = filepath.Join(t.TempDir(), "state.db")
+	quiet := WithSlog(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	noop := func(outline.Client) commands.Handler {
+		return commands.HandlerFunc(func(context.Context, *commands.Document, commands.Command) (commands.Result, error) {
+			return commands.Result{}, nil
+		})
+	}
+
+	if _, err := New(cfg, quiet, WithHandler(commands.CommandAI, noop)); err == nil {
+		t.Fatal("Expected registering a built-in command to fail")
+	}
+	if walExists(t, cfg.Persistence.DatabasePath) {
+		t.Error("Expected the database to be closed when New fails")
+	}
+
+	svc, err := New(cfg, quiet, WithOutlineClient(mocks.NewOutlineMock()), WithAIClient(mocks.NewAIMock()))
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	ctx := context.Background()
+	if err := svc.Start(ctx); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+	if !walExists(t, cfg.Persistence.DatabasePath) {
+		t.Fatal("Expected the database to be open while running")
+	}
+	if err := svc.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down: %v", err)
+	}
+	if walExists(t, cfg.Persistence.DatabasePath) {
+		t.Error("Expected the database to be closed on shutdown")
+	}
+}
+
+func TestService_StartFailsOnUnavailableDependencies(t *testing.T) {
+	f := newFixture(t)
+	f.outline.SetFailureMode(true)
+	f.storage.SetMethodError("Ping", errors.New("disk full"))
+
+	err := f.svc.Start(context.Background())
+	if err == nil {
+		t.Fatal("Expected Start to fail")
+	}
+	for _, want := range []string{"storage is unavailable", "disk full", "outline is unavailable"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in the error, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "ai is unavailable") {
+		t.Errorf("Expected only failing dependencies to be reported, got %v", err)
+	}
+
+	// Liveness holds while readiness reports startup
+	if status, _ := f.probe(t, LivenessPath); status != http.StatusOK {
+		t.Errorf("Expected liveness during startup, got %d", status)
+	}
+	status, body := f.probe(t, ReadinessPath)
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("Expected not ready while starting, got %d", status)
+	}
+	if r := report(t, body); r.Phase != "starting" || r.Status != StatusFailed || r.Dependencies["outline"].Status != StatusFailed {
+		t.Errorf("Expected the failed dependencies in the report, got %s", body)
+	}
+
+	if err := f.svc.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected a clean shutdown after a failed start, got %v", err)
+	}
+	if f.storage.GetCallCount("Close") != 0 {
+		t.Errorf("Expected injected storage to be left open, got %d closes", f.storage.GetCallCount("Close"))
+	}
+}
+
+func TestService_AdminReportsOffWithoutToken(t *testing.T) {
+	f := newFixture(t, func(cfg *config.Config) {
+		cfg.Service.AdminToken = ""
+	})
+	ctx := context.Background()
+	if err := f.svc.Start(ctx); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+	defer f.svc.Shutdown(ctx)
+
+	for _, path := range []string{usage.Path, audit.Path + "?document_id=doc-1"} {
+		if status, _ := f.admin(t, path, ""); status != http.StatusNotFound {
+			t.Errorf("Expected %s not to be served without an admin token, got %d", path, status)
+		}
+	}
+}
+
+func TestService_RunStopsOnCancel(t *testing.T) {
+	f := newFixture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- f.svc.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for f.svc.probes.Phase() != PhaseReady {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the service to become ready")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected a clean stop, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for Run to return")
+	}
+	if f.storage.GetCallCount("Close") != 0 {
+		t.Errorf("Expected injected storage to be left open, got %d closes", f.storage.GetCallCount("Close"))
+	}
+}
+
+func TestService_StartsDegradedWithoutNonCriticalDependency(t *testing.T) {
+	f := newFixture(t, func(cfg *config.Config) {
+		cfg.Service.Health.NonCritical = []string{"ai"}
+	})
+	f.ai.SetMethodError("Ping", errors.New("provider down"))
+
+	if err := f.svc.Start(context.Background()); err != nil {
+		t.Fatalf("Expected a non-critical failure not to stop startup, got %v", err)
+	}
+	defer f.svc.Shutdown(context.Background())
+
+	status, body := f.probe(t, ReadinessPath)
+	r := report(t, body)
+	if status != http.StatusOK || r.Status != StatusDegraded {
+		t.Errorf("Expected ready but degraded, got %d %s", status, body)
+	}
+	if ai := r.Dependencies["ai"]; ai.Status != StatusDegraded || ai.Critical || ai.Error != "provider down" || ai.LastSuccess != nil {
+		t.Errorf("Expected the AI failure reported as degraded, got %+v", ai)
+	}
+	if r.Workers == nil || r.Workers.Workers != f.svc.cfg.Service.MaxConcurrentWorkers {
+		t.Errorf("Expected worker utilization in the report, got %+v", r.Workers)
+	}
+}
+
+func TestProbes(t *testing.T) {
+	probes := NewProbes(nil)
+	handler := probes.Handler()
+	check := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON body, got %q", rec.Header().Get("Content-Type"))
+		}
+		return rec.Code, report(t, rec.Body.String()).Phase
+	}
+
+	tests := []struct {
+		advance func()
+		ready   int
+		body    string
+	}{
+		{func() {}, http.StatusServiceUnavailable, "starting"},
+		{probes.SetReady, http.StatusOK, "ready"},
+		{probes.SetDraining, http.StatusServiceUnavailable, "draining"},
+		// A late SetReady must not undo draining
+		{probes.SetReady, http.StatusServiceUnavailable, "draining"},
+	}
+	for _, tt := range tests {
+		tt.advance()
+		if status, body := check(ReadinessPath); status != tt.ready || body != tt.body {
+			t.Errorf("Expected readiness %d %q, got %d %q", tt.ready, tt.body, status, body)
+		}
+		if status, _ := check(LivenessPath); status != http.StatusOK {
+			t.Errorf("Expected liveness in phase %s, got %d", probes.Phase(), status)
+		}
+	}
+}
+
+func TestDocumentLinkBase(t *testing.T) {
+	tests := map[string]string{
+		"https://outline.example.com/api":  "https://outline.example.com/doc/",
+		"https://outline.example.com/api/": "https://outline.example.com/doc/",
+		"http://localhost:3000":            "http://localhost:3000/doc/",
+	}
+	for endpoint, want := range tests {
+		if got := documentLinkBase(endpoint); got != want {
+			t.Errorf("Expected %q for %q, got %q", want, endpoint, got)
+		}
+	}
+}
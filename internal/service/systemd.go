@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// systemd's notification protocol, for units with Type=notify. Readiness
+// is reported with READY=1 and liveness with WATCHDOG=1 when WatchdogSec
+// is set. Outside systemd NOTIFY_SOCKET is unset and both are no-ops.
+
+// notify sends state to the service manager, if there is one
+func notify(logger *slog.Logger, state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+	if socket[0] == '@' {
+		// Abstract namespace
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		logger.Warn("service: failed to notify systemd", "state", state, "error", err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		logger.Warn("service: failed to notify systemd", "state", state, "error", err)
+	}
+}
+
+// watchdogInterval returns how often systemd expects a keep-alive, or 0
+// when the watchdog is off or meant for another process
+func watchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// watchdog sends keep-alives at half the expected interval until ctx is
+// done
+func watchdog(ctx context.Context, logger *slog.Logger) {
+	interval := watchdogInterval()
+	if interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		notify(logger, "WATCHDOG=1")
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
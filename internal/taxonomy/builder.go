@@ -0,0 +1,251 @@
+// Package taxonomy builds the collection overview the filing prompt
+// classifies documents against. Each collection is described by its name,
+// description and a few representative documents; the result is cached,
+// dropped when a collection webhook arrives, and trimmed to fit a prompt
+// budget.
+package taxonomy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/webhook"
+)
+
+const (
+	defaultTTL           = time.Hour
+	defaultSamples       = 5
+	defaultExcerptLength = 160
+	defaultBudget        = 3000
+)
+
+// Option configures a Builder
+type Option func(*Builder)
+
+// WithTTL sets how long a built taxonomy is reused (default 1h)
+func WithTTL(ttl time.Duration) Option {
+	return func(b *Builder) {
+		b.ttl = ttl
+	}
+}
+
+// WithSamples sets how many sample documents describe each collection
+// (default 5). Zero leaves samples out.
+func WithSamples(n int) Option {
+	return func(b *Builder) {
+		b.samples = n
+	}
+}
+
+// WithExcerptLength sets the most characters of document text shown
+// after each sample title (default 160). Zero shows titles only.
+func WithExcerptLength(n int) Option {
+	return func(b *Builder) {
+		b.excerptLength = n
+	}
+}
+
+// WithBudget sets the estimated token count the taxonomy may take up in a
+// prompt (default 3000). Larger taxonomies are trimmed until they fit.
+func WithBudget(tokens int) Option {
+	return func(b *Builder) {
+		b.budget = tokens
+	}
+}
+
+// WithSlog sets where builds and skipped collections are reported
+func WithSlog(logger *slog.Logger) Option {
+	return func(b *Builder) {
+		b.slog = logger
+	}
+}
+
+// WithClock replaces time.Now, for tests
+func WithClock(now func() time.Time) Option {
+	return func(b *Builder) {
+		b.now = now
+	}
+}
+
+// Stats describes the cache
+type Stats struct {
+	Hits      int64
+	Misses    int64
+	BuiltAt   time.Time
+	ExpiresAt time.Time
+
+	// Tokens is the estimated prompt size of the cached taxonomy
+	Tokens int
+}
+
+// Builder builds and caches the taxonomy the filing handler classifies
+// documents against
+type Builder struct {
+	outline       outline.Client
+	ttl           time.Duration
+	samples       int
+	excerptLength int
+	budget        int
+	slog          *slog.Logger
+	now           func() time.Time
+
+	// build serializes rebuilds so an expired cache is built only once
+	build sync.Mutex
+
+	mu         sync.Mutex
+	cached     *ai.TaxonomyContext
+	expiresAt  time.Time
+	generation int
+	stats      Stats
+}
+
+// NewBuilder creates a Builder reading collections from client
+func NewBuilder(client outline.Client, opts ...Option) *Builder {
+	b := &Builder{
+		outline:       client,
+		ttl:           defaultTTL,
+		samples:       defaultSamples,
+		excerptLength: defaultExcerptLength,
+		budget:        defaultBudget,
+		slog:          slog.Default(),
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Taxonomy returns the cached taxonomy, building it first if it has
+// expired or been invalidated
+func (b *Builder) Taxonomy(ctx context.Context) (*ai.TaxonomyContext, error) {
+	if taxonomy, ok := b.lookup(true); ok {
+		return taxonomy, nil
+	}
+
+	b.build.Lock()
+	defer b.build.Unlock()
+
+	// Another caller may have rebuilt it while we waited
+	if taxonomy, ok := b.lookup(false); ok {
+		return taxonomy, nil
+	}
+
+	b.mu.Lock()
+	generation := b.generation
+	b.mu.Unlock()
+
+	taxonomy, tokens, err := b.Build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// An invalidation during the build means the result may already be
+	// out of date, so use it once but don't keep it
+	if generation == b.generation {
+		b.cached = taxonomy
+		b.stats.BuiltAt = b.now()
+		b.expiresAt = b.stats.BuiltAt.Add(b.ttl)
+		b.stats.Tokens = tokens
+	}
+	return taxonomy, nil
+}
+
+func (b *Builder) lookup(count bool) (*ai.TaxonomyContext, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.cached != nil && b.now().Before(b.expiresAt) {
+		if count {
+			b.stats.Hits++
+		}
+		return b.cached, true
+	}
+	if count {
+		b.stats.Misses++
+	}
+	return nil, false
+}
+
+// Invalidate drops the cached taxonomy so the next call rebuilds it
+func (b *Builder) Invalidate() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.cached = nil
+	b.expiresAt = time.Time{}
+	b.generation++
+}
+
+// Watch returns a Queue that invalidates the cache on collection events
+// and passes every other event to next
+func (b *Builder) Watch(next webhook.Queue) webhook.Queue {
+	return webhook.QueueFunc(func(event *webhook.Event) error {
+		if strings.HasPrefix(event.Event, webhook.CollectionEventPrefix) {
+			b.slog.Debug("taxonomy: collection changed, invalidating cache", "event", event.Event)
+			b.Invalidate()
+			return nil
+		}
+		return next.Enqueue(event)
+	})
+}
+
+// Stats returns a snapshot of the cache counters
+func (b *Builder) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := b.stats
+	stats.ExpiresAt = b.expiresAt
+	return stats
+}
+
+// Build lists the collections and samples their documents, bypassing the
+// cache. It returns the taxonomy with its estimated token count. A
+// collection whose documents cannot be listed is kept without samples.
+func (b *Builder) Build(ctx context.Context) (*ai.TaxonomyContext, int, error) {
+	start := b.now()
+
+	collections, err := b.outline.ListCollections(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("taxonomy: failed to list collections: %w", err)
+	}
+
+	profiles := make([]profile, 0, len(collections))
+	for _, col := range collections {
+		p := profile{ID: col.ID, Name: col.Name, Description: col.Description}
+		if b.samples > 0 {
+			docs, err := b.outline.ListDocuments(ctx, col.ID)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, 0, ctx.Err()
+				}
+				b.slog.Warn("taxonomy: failed to sample collection", "collection_id", col.ID, "error", err)
+			} else {
+				p.Samples = pickSamples(docs, b.samples, b.excerptLength)
+			}
+		}
+		profiles = append(profiles, p)
+	}
+	sortProfiles(profiles)
+
+	taxonomy, tokens, trimmed := fit(profiles, b.samples, b.budget)
+	if tokens > b.budget {
+		b.slog.Warn("taxonomy: over budget even without samples or descriptions",
+			"tokens", tokens, "budget", b.budget, "collections", len(profiles))
+	}
+	b.slog.Info("taxonomy: built",
+		"collections", len(profiles),
+		"tokens", tokens,
+		"trimmed", trimmed,
+		"duration", b.now().Sub(start))
+	return taxonomy, tokens, nil
+}
@@ -0,0 +1,165 @@
+package taxonomy_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/taxonomy"
+	"github.com/yourusername/outline-ai/internal/webhook"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+func newClient() *mocks.OutlineMock {
+	client := mocks.NewOutlineMock()
+	client.AddCollection("col-eng", "Engineering", "Technical docs")
+	client.AddCollection("col-hr", "HR", "People and policies")
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	docs := []struct{ id, col, title, text string }{
+		{"d1", "col-eng", "Deploy Runbook", "# Deploy Runbook\n\nSteps to roll out a release to production."},
+		{"d2", "col-eng", "Untitled", "Scratch notes"},
+		{"d3", "col-eng", "Database Migrations", "How schema changes are reviewed and applied."},
+		{"d4", "col-hr", "Vacation Policy", "/summarize\n\nEveryone gets 25 days of paid leave per year."},
+	}
+	for i, d := range docs {
+		client.AddDocument(d.id, d.col, d.title, d.text).UpdatedAt = base.Add(time.Duration(i) * time.Minute)
+	}
+	return client
+}
+
+func TestBuilder_Taxonomy(t *testing.T) {
+	ctx := context.Background()
+	client := newClient()
+	b := taxonomy.NewBuilder(client)
+
+	tax, err := b.Taxonomy(ctx)
+	if err != nil {
+		t.Fatalf("Failed to build taxonomy: %v", err)
+	}
+	if len(tax.Collections) != 2 || tax.Collections[0].Name != "Engineering" || tax.Collections[1].Name != "HR" {
+		t.Fatalf("Expected both collections sorted by name, got %+v", tax.Collections)
+	}
+
+	eng := tax.Collections[0].SampleDocuments
+	want := []string{
+		"Database Migrations: How schema changes are reviewed and applied.",
+		"Deploy Runbook: Steps to roll out a release to production.",
+	}
+	if strings.Join(eng, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected descriptive samples, newest first, got %q", eng)
+	}
+	if hr := tax.Collections[1].SampleDocuments; len(hr) != 1 || hr[0] != "Vacation Policy: Everyone gets 25 days of paid leave per year." {
+		t.Errorf("Expected the command line to be left out of the excerpt, got %q", hr)
+	}
+}
+
+func TestBuilder_Cache(t *testing.T) {
+	ctx := context.Background()
+	client := newClient()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := taxonomy.NewBuilder(client,
+		taxonomy.WithTTL(time.Hour),
+		taxonomy.WithClock(func() time.Time { return now }))
+
+	for range 3 {
+		if _, err := b.Taxonomy(ctx); err != nil {
+			t.Fatalf("Failed to get taxonomy: %v", err)
+		}
+	}
+	if calls := client.GetCallCount("ListCollections"); calls != 1 {
+		t.Errorf("Expected one build while cached, got %d", calls)
+	}
+	if stats := b.Stats(); stats.Hits != 2 || stats.Misses != 1 || !stats.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected 2 hits and 1 miss expiring in an hour, got %+v", stats)
+	}
+
+	now = now.Add(time.Hour)
+	b.Taxonomy(ctx)
+	if calls := client.GetCallCount("ListCollections"); calls != 2 {
+		t.Errorf("Expected a rebuild after the TTL, got %d builds", calls)
+	}
+
+	// Collection events invalidate, document events pass through
+	var passed []string
+	queue := b.Watch(webhook.QueueFunc(func(event *webhook.Event) error {
+		passed = append(passed, event.Event)
+		return nil
+	}))
+	queue.Enqueue(&webhook.Event{Event: webhook.EventDocumentsUpdate})
+	b.Taxonomy(ctx)
+	if calls := client.GetCallCount("ListCollections"); calls != 2 {
+		t.Errorf("Expected a document event to keep the cache, got %d builds", calls)
+	}
+
+	client.AddCollection("col-ops", "Operations", "")
+	queue.Enqueue(&webhook.Event{Event: webhook.EventCollectionsCreate})
+	tax, _ := b.Taxonomy(ctx)
+	if len(tax.Collections) != 3 {
+		t.Errorf("Expected the new collection after invalidation, got %d", len(tax.Collections))
+	}
+	if len(passed) != 1 || passed[0] != webhook.EventDocumentsUpdate {
+		t.Errorf("Expected only the document event to be passed on, got %v", passed)
+	}
+}
+
+func TestBuilder_Budget(t *testing.T) {
+	ctx := context.Background()
+	client := mocks.NewOutlineMock()
+	for i := range 20 {
+		colID := fmt.Sprintf("col-%02d", i)
+		client.AddCollection(colID, fmt.Sprintf("Collection %02d", i), strings.Repeat("Long description. ", 20))
+		for j := range 5 {
+			client.AddDocument(fmt.Sprintf("%s-doc-%d", colID, j), colID,
+				fmt.Sprintf("Design Review %d", j), strings.Repeat("Detailed body text. ", 30))
+		}
+	}
+
+	full, fullTokens, err := taxonomy.NewBuilder(client, taxonomy.WithBudget(1_000_000)).Build(ctx)
+	if err != nil {
+		t.Fatalf("Failed to build: %v", err)
+	}
+
+	tax, tokens, err := taxonomy.NewBuilder(client, taxonomy.WithBudget(fullTokens/3)).Build(ctx)
+	if err != nil {
+		t.Fatalf("Failed to build: %v", err)
+	}
+	if tokens > fullTokens/3 {
+		t.Errorf("Expected at most %d tokens, got %d", fullTokens/3, tokens)
+	}
+	if len(tax.Collections) != len(full.Collections) {
+		t.Errorf("Expected every collection to be kept, got %d of %d", len(tax.Collections), len(full.Collections))
+	}
+	for _, col := range tax.Collections {
+		for _, s := range col.SampleDocuments {
+			if strings.Contains(s, ":") {
+				t.Fatalf("Expected excerpts to be trimmed first, got %q", s)
+			}
+		}
+	}
+}
+
+func TestBuilder_ListFailure(t *testing.T) {
+	client := newClient()
+	client.SetFailureMode(true)
+
+	if _, err := taxonomy.NewBuilder(client).Taxonomy(context.Background()); err == nil {
+		t.Fatal("Expected the collection listing failure")
+	}
+}
+
+func TestBuilder_SkipsDrafts(t *testing.T) {
+	client := mocks.NewOutlineMock()
+	client.AddCollection("col-1", "Engineering", "")
+	client.AddDocument("draft", "col-1", "Unreleased Plan", "Secret").PublishedAt = nil
+
+	tax, _, err := taxonomy.NewBuilder(client).Build(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to build: %v", err)
+	}
+	if samples := tax.Collections[0].SampleDocuments; len(samples) != 0 {
+		t.Errorf("Expected drafts to be left out, got %q", samples)
+	}
+}
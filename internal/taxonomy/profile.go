@@ -0,0 +1,213 @@
+package taxonomy
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/titles"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+const (
+	// charsPerToken is the usual rough ratio for English text
+	charsPerToken = 4
+
+	// collectionOverhead covers the labels and numbering the prompt adds
+	// around each collection
+	collectionOverhead = 50
+	sampleOverhead     = 8
+
+	descriptionLimit = 120
+)
+
+// profile is everything known about a collection before trimming
+type profile struct {
+	ID          string
+	Name        string
+	Description string
+	Samples     []sample
+}
+
+type sample struct {
+	Title   string
+	Excerpt string
+}
+
+func (s sample) String() string {
+	if s.Excerpt == "" {
+		return s.Title
+	}
+	return s.Title + ": " + s.Excerpt
+}
+
+// pickSamples chooses up to n representative documents: published ones
+// with a descriptive title, most recently updated first, one per title
+func pickSamples(docs []*outline.Document, n, excerptLength int) []sample {
+	candidates := make([]*outline.Document, 0, len(docs))
+	for _, doc := range docs {
+		if doc.PublishedAt == nil || strings.TrimSpace(doc.Title) == "" || titles.IsVague(doc.Title) {
+			continue
+		}
+		candidates = append(candidates, doc)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if !candidates[i].UpdatedAt.Equal(candidates[j].UpdatedAt) {
+			return candidates[i].UpdatedAt.After(candidates[j].UpdatedAt)
+		}
+		return candidates[i].Title < candidates[j].Title
+	})
+
+	seen := make(map[string]bool)
+	samples := make([]sample, 0, n)
+	for _, doc := range candidates {
+		if len(samples) == n {
+			break
+		}
+		key := strings.ToLower(strings.Join(strings.Fields(doc.Title), " "))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		samples = append(samples, sample{
+			Title:   strings.TrimSpace(doc.Title),
+			Excerpt: excerpt(doc.Text, excerptLength),
+		})
+	}
+	return samples
+}
+
+// excerpt returns the opening prose of text, skipping headings, quotes,
+// HTML comments, code and command lines, cut at a word boundary
+func excerpt(text string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+
+	var words []string
+	size := 0
+	var fence commands.Fence
+	for _, line := range strings.Split(text, "\n") {
+		if fence.Line(line) {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" || strings.ContainsAny(trimmed[:1], "#>/?<|") {
+			continue
+		}
+
+		for _, word := range strings.Fields(trimmed) {
+			n := utf8.RuneCountInString(word)
+			if size > 0 {
+				n++
+			}
+			if size+n > limit {
+				if size == 0 {
+					// A single overlong word is cut rather than dropped
+					return string([]rune(word)[:limit]) + "…"
+				}
+				return strings.Join(words, " ") + "…"
+			}
+			words = append(words, word)
+			size += n
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// sortProfiles orders collections by name so the prompt does not change
+// with the order the API lists them in
+func sortProfiles(profiles []profile) {
+	sort.SliceStable(profiles, func(i, j int) bool {
+		a, b := strings.ToLower(profiles[i].Name), strings.ToLower(profiles[j].Name)
+		if a != b {
+			return a < b
+		}
+		return profiles[i].ID < profiles[j].ID
+	})
+}
+
+// fit renders the profiles at the richest level of detail that fits the
+// budget. Excerpts go first, then samples one at a time, then
+// descriptions are shortened and finally dropped. Collections themselves
+// are never dropped, since a document can only be filed into a listed
+// one. It returns the taxonomy, its estimated tokens and what was trimmed.
+func fit(profiles []profile, maxSamples, budget int) (*ai.TaxonomyContext, int, string) {
+	type level struct {
+		trimmed      string
+		samples      int
+		excerpts     bool
+		descriptions int // -1 keeps them whole
+	}
+	levels := []level{{trimmed: "none", samples: maxSamples, excerpts: true, descriptions: -1}}
+	for n := maxSamples; n >= 0; n-- {
+		levels = append(levels, level{trimmed: "samples", samples: n, descriptions: -1})
+	}
+	levels[1].trimmed = "excerpts"
+	levels = append(levels,
+		level{trimmed: "descriptions", descriptions: descriptionLimit},
+		level{trimmed: "descriptions", descriptions: 0},
+	)
+
+	var (
+		taxonomy *ai.TaxonomyContext
+		tokens   int
+		trimmed  string
+	)
+	for _, l := range levels {
+		taxonomy = render(profiles, l.samples, l.excerpts, l.descriptions)
+		tokens = estimateTokens(taxonomy)
+		trimmed = l.trimmed
+		if tokens <= budget {
+			break
+		}
+	}
+	return taxonomy, tokens, trimmed
+}
+
+func render(profiles []profile, samples int, excerpts bool, descriptions int) *ai.TaxonomyContext {
+	taxonomy := &ai.TaxonomyContext{Collections: make([]ai.TaxonomyCollection, 0, len(profiles))}
+	for _, p := range profiles {
+		col := ai.TaxonomyCollection{
+			ID:          p.ID,
+			Name:        p.Name,
+			Description: shorten(p.Description, descriptions),
+		}
+		for i, s := range p.Samples {
+			if i == samples {
+				break
+			}
+			if !excerpts {
+				s.Excerpt = ""
+			}
+			col.SampleDocuments = append(col.SampleDocuments, s.String())
+		}
+		taxonomy.Collections = append(taxonomy.Collections, col)
+	}
+	return taxonomy
+}
+
+// shorten cuts s to limit runes; a negative limit keeps it whole
+func shorten(s string, limit int) string {
+	if limit < 0 || utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	if limit == 0 {
+		return ""
+	}
+	return string([]rune(s)[:limit]) + "…"
+}
+
+// estimateTokens approximates how much of a prompt the taxonomy takes up
+func estimateTokens(taxonomy *ai.TaxonomyContext) int {
+	chars := 0
+	for _, col := range taxonomy.Collections {
+		chars += collectionOverhead + len(col.ID) + len(col.Name) + len(col.Description)
+		for _, s := range col.SampleDocuments {
+			chars += sampleOverhead + len(s)
+		}
+	}
+	return (chars + charsPerToken - 1) / charsPerToken
+}
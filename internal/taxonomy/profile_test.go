@@ -0,0 +1,63 @@
+package taxonomy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{"plain", "First line.\nSecond line.", 100, "First line. Second line."},
+		{"skips headings and markers", "# Title\n\n<!-- AI-SUMMARY-START -->\n> **Summary**: x\n/ai question?\nBody.", 100, "Body."},
+		{"skips code", "```\ncode here\n```\nAfter.", 100, "After."},
+		{"tilde line inside a backtick fence", "```\n~~~\ncode here\n```\nAfter.", 100, "After."},
+		{"cuts at a word", "one two three four", 9, "one two…"},
+		{"cuts a long word", "abcdefghij", 4, "abcd…"},
+		{"disabled", "Body.", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := excerpt(tt.text, tt.limit); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFit(t *testing.T) {
+	profiles := []profile{{
+		ID:          "col-1",
+		Name:        "Engineering",
+		Description: strings.Repeat("d", 400),
+		Samples: []sample{
+			{Title: "Deploy Runbook", Excerpt: strings.Repeat("e", 200)},
+			{Title: "Database Migrations", Excerpt: strings.Repeat("e", 200)},
+		},
+	}}
+
+	tests := []struct {
+		budget      int
+		trimmed     string
+		samples     int
+		description int
+	}{
+		{1000, "none", 2, 400},
+		{200, "excerpts", 2, 400},
+		{125, "samples", 1, 400},
+		{60, "descriptions", 0, 121},
+		{10, "descriptions", 0, 0},
+	}
+	for _, tt := range tests {
+		taxonomy, tokens, trimmed := fit(profiles, 2, tt.budget)
+		col := taxonomy.Collections[0]
+		if trimmed != tt.trimmed || len(col.SampleDocuments) != tt.samples || len([]rune(col.Description)) != tt.description {
+			t.Errorf("Budget %d: expected %s trim with %d samples and a %d rune description, got %s with %d and %d (%d tokens)",
+				tt.budget, tt.trimmed, tt.samples, tt.description, trimmed, len(col.SampleDocuments), len([]rune(col.Description)), tokens)
+		}
+	}
+}
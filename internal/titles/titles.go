@@ -0,0 +1,59 @@
+// Package titles recognizes document titles that say nothing about the
+// document, which the title handler replaces and the taxonomy leaves out of
+// its samples.
+package titles
+
+import (
+	"strings"
+	"unicode"
+)
+
+// placeholderWords make up titles that say nothing about the document
+var placeholderWords = map[string]bool{
+	"untitled": true, "draft": true, "notes": true, "note": true, "new": true,
+	"document": true, "doc": true, "page": true, "temp": true, "tmp": true,
+	"test": true, "todo": true, "misc": true, "wip": true, "copy": true, "of": true,
+}
+
+// dateWords are month and weekday names, full and abbreviated
+var dateWords = map[string]bool{
+	"january": true, "february": true, "march": true, "april": true, "may": true,
+	"june": true, "july": true, "august": true, "september": true, "october": true,
+	"november": true, "december": true, "jan": true, "feb": true, "mar": true,
+	"apr": true, "jun": true, "jul": true, "aug": true, "sep": true, "sept": true,
+	"oct": true, "nov": true, "dec": true, "monday": true, "tuesday": true,
+	"wednesday": true, "thursday": true, "friday": true, "saturday": true,
+	"sunday": true, "mon": true, "tue": true, "wed": true, "thu": true, "fri": true,
+	"sat": true, "sun": true,
+}
+
+// IsVague reports whether a title is a placeholder such as "Untitled",
+// "Notes" or "Draft", a date such as "2024-01-15" or "Jan 5th", or a mix
+// of the two such as "Copy of Draft 2"
+func IsVague(title string) bool {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if !placeholderWords[word] && !dateWords[word] && !isNumbering(word) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumbering matches numbers, ordinals and versions: "2024", "5th", "v2"
+func isNumbering(word string) bool {
+	if version, ok := strings.CutPrefix(word, "v"); ok {
+		return version != "" && strings.TrimLeftFunc(version, unicode.IsDigit) == ""
+	}
+	suffix := strings.TrimLeftFunc(word, unicode.IsDigit)
+	if suffix == word {
+		return false
+	}
+	switch suffix {
+	case "", "st", "nd", "rd", "th":
+		return true
+	}
+	return false
+}
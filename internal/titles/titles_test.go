@@ -0,0 +1,36 @@
+package titles_test
+
+import (
+	"testing"
+
+	"github.com/yourusername/outline-ai/internal/titles"
+)
+
+func TestIsVague(t *testing.T) {
+	tests := []struct {
+		title string
+		want  bool
+	}{
+		{"Untitled", true},
+		{"notes", true},
+		{"Draft v2", true},
+		{"Copy of Draft (1)", true},
+		{"New Document", true},
+		{"2024-01-15", true},
+		{"15/01/2024", true},
+		{"Jan 5th, 2024", true},
+		{"Monday Notes", true},
+		{"", true},
+		{"Notes on API Rate Limits", false},
+		{"Test Plan", false},
+		{"Q3 Roadmap", false},
+		{"2024 Marketing Strategy", false},
+		{"Vision", false},
+	}
+
+	for _, tt := range tests {
+		if got := titles.IsVague(tt.title); got != tt.want {
+			t.Errorf("IsVague(%q): expected %v, got %v", tt.title, tt.want, got)
+		}
+	}
+}
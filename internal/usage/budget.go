@@ -0,0 +1,111 @@
+// Package usage reports the tokens and cost of AI work recorded in the
+// command log, and enforces optional monthly budgets on it.
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/persistence"
+)
+
+// ErrBudgetExhausted is returned by Budget.Check once this month's budget
+// is used up
+var ErrBudgetExhausted = errors.New("usage: monthly AI budget exhausted")
+
+// Store reads usage from the command log. persistence.Storage satisfies it.
+type Store interface {
+	GetUsage(ctx context.Context, query persistence.UsageQuery) ([]*persistence.UsageSummary, error)
+}
+
+// BudgetOption configures a Budget
+type BudgetOption func(*Budget)
+
+// WithMonthlyTokens limits prompt and completion tokens together per
+// calendar month (UTC). Zero means no limit.
+func WithMonthlyTokens(tokens int64) BudgetOption {
+	return func(b *Budget) {
+		b.tokens = tokens
+	}
+}
+
+// WithMonthlyCost limits spending in USD per calendar month (UTC), as
+// priced by the AI client. Zero means no limit.
+func WithMonthlyCost(usd float64) BudgetOption {
+	return func(b *Budget) {
+		b.cost = usd
+	}
+}
+
+// WithClock overrides the time source, mainly for tests
+func WithClock(now func() time.Time) BudgetOption {
+	return func(b *Budget) {
+		b.now = now
+	}
+}
+
+// Budget compares this month's logged usage with the configured limits.
+// Usage is only known once a command is logged, so the command that
+// crosses a limit finishes and the next one is refused.
+type Budget struct {
+	store  Store
+	tokens int64
+	cost   float64
+	now    func() time.Time
+}
+
+// NewBudget creates a Budget reading usage from store
+func NewBudget(store Store, opts ...BudgetOption) *Budget {
+	b := &Budget{
+		store: store,
+		now:   time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Enabled reports whether any limit is set
+func (b *Budget) Enabled() bool {
+	return b.tokens > 0 || b.cost > 0
+}
+
+// Check returns an error wrapping ErrBudgetExhausted when a limit has been
+// reached this month
+func (b *Budget) Check(ctx context.Context) error {
+	if !b.Enabled() {
+		return nil
+	}
+
+	start := monthStart(b.now())
+	totals, err := b.store.GetUsage(ctx, persistence.UsageQuery{Since: start})
+	if err != nil {
+		return fmt.Errorf("usage: failed to read this month's usage: %w", err)
+	}
+	var used persistence.UsageSummary
+	if len(totals) > 0 {
+		used = *totals[0]
+	}
+
+	if tokens := used.PromptTokens + used.CompletionTokens; b.tokens > 0 && tokens >= b.tokens {
+		return fmt.Errorf("%w: %d of %d tokens used", ErrBudgetExhausted, tokens, b.tokens)
+	}
+	if b.cost > 0 && used.CostUSD >= b.cost {
+		return fmt.Errorf("%w: $%.2f of $%.2f spent", ErrBudgetExhausted, used.CostUSD, b.cost)
+	}
+	return nil
+}
+
+// ResetsAt returns when the current month's budget is renewed
+func (b *Budget) ResetsAt() time.Time {
+	return monthStart(b.now()).AddDate(0, 1, 0)
+}
+
+// monthStart returns midnight UTC on the first of t's month
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
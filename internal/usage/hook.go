@@ -0,0 +1,20 @@
+package usage
+
+import (
+	"context"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+// Hook records the tokens and cost of the AI calls a command makes in its
+// command log entry. Install it with commands.WithHook.
+func Hook(ctx context.Context, _ *commands.Document, entry *commands.CommandLog) (context.Context, func()) {
+	recorder := &ai.UsageRecorder{}
+	return ai.WithUsageRecorder(ctx, recorder), func() {
+		used := recorder.Usage()
+		entry.PromptTokens, entry.CompletionTokens, entry.CostUSD = used.PromptTokens, used.CompletionTokens, used.CostUSD
+	}
+}
+
+var _ commands.Hook = Hook
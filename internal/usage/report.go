@@ -0,0 +1,128 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/persistence"
+)
+
+// Path is where usage reports are served
+const Path = "/usage"
+
+const dateLayout = "2006-01-02"
+
+// Row is one group of a usage report
+type Row struct {
+	Key              string  `json:"key"`
+	Commands         int     `json:"commands"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// Report is the body of a usage report. Since and Until are dates; Until is
+// exclusive.
+type Report struct {
+	By    persistence.UsageGroup `json:"by,omitempty"`
+	Since string                 `json:"since"`
+	Until string                 `json:"until"`
+	Rows  []Row                  `json:"rows"`
+	Total Row                    `json:"total"`
+}
+
+// Handler serves usage reports as JSON. Query parameters:
+//
+//	by     day, command, collection or user; omit for the total only
+//	since  first day included, YYYY-MM-DD (default: the first of this month)
+//	until  first day excluded, YYYY-MM-DD (default: tomorrow)
+//
+// Days are UTC.
+func Handler(store Store) http.Handler {
+	return reportHandler{store: store, now: time.Now}
+}
+
+type reportHandler struct {
+	store Store
+	now   func() time.Time
+}
+
+func (h reportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query, err := h.parse(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report := Report{
+		By:    query.GroupBy,
+		Since: query.Since.Format(dateLayout),
+		Until: query.Until.Format(dateLayout),
+		Rows:  make([]Row, 0),
+	}
+	summaries, err := h.store.GetUsage(r.Context(), query)
+	if err != nil {
+		http.Error(w, "failed to read usage", http.StatusInternalServerError)
+		return
+	}
+	for _, summary := range summaries {
+		row := Row{
+			Key:              summary.Key,
+			Commands:         summary.Commands,
+			PromptTokens:     summary.PromptTokens,
+			CompletionTokens: summary.CompletionTokens,
+			TotalTokens:      summary.PromptTokens + summary.CompletionTokens,
+			CostUSD:          summary.CostUSD,
+		}
+		report.Total.Commands += row.Commands
+		report.Total.PromptTokens += row.PromptTokens
+		report.Total.CompletionTokens += row.CompletionTokens
+		report.Total.TotalTokens += row.TotalTokens
+		report.Total.CostUSD += row.CostUSD
+		if query.GroupBy != "" {
+			report.Rows = append(report.Rows, row)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+func (h reportHandler) parse(r *http.Request) (persistence.UsageQuery, error) {
+	values := r.URL.Query()
+	today := h.now().UTC().Truncate(24 * time.Hour)
+	query := persistence.UsageQuery{
+		GroupBy: persistence.UsageGroup(values.Get("by")),
+		Since:   monthStart(today),
+		Until:   today.AddDate(0, 0, 1),
+	}
+
+	switch query.GroupBy {
+	case "", persistence.UsageByDay, persistence.UsageByCommand, persistence.UsageByCollection, persistence.UsageByUser:
+	default:
+		return query, errors.New("by must be day, command, collection or user")
+	}
+	for param, target := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		if raw := values.Get(param); raw != "" {
+			day, err := time.Parse(dateLayout, raw)
+			if err != nil {
+				return query, errors.New(param + " must be a date in YYYY-MM-DD format")
+			}
+			*target = day
+		}
+	}
+	if !query.Until.After(query.Since) {
+		return query, errors.New("until must be after since")
+	}
+	return query, nil
+}
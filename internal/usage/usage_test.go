@@ -0,0 +1,183 @@
+package usage_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/internal/usage"
+	"github.com/yourusername/outline-ai/pkg/commands"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+var now = time.Date(2026, time.March, 14, 12, 0, 0, 0, time.UTC)
+
+func logUsage(t *testing.T, storage *mocks.StorageMock, executed time.Time, command, collection, user string, prompt, completion int, cost float64) {
+	t.Helper()
+	err := storage.LogCommand(t.Context(), &persistence.CommandLog{
+		DocumentID:       "doc-1",
+		CommandType:      command,
+		Status:           persistence.CommandStatusSuccess,
+		ExecutedAt:       executed,
+		CollectionID:     collection,
+		UserID:           user,
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		CostUSD:          cost,
+	})
+	if err != nil {
+		t.Fatalf("Failed to log command: %v", err)
+	}
+}
+
+func TestBudget_Check(t *testing.T) {
+	storage := mocks.NewStorageMock()
+	// Last month does not count
+	logUsage(t, storage, now.AddDate(0, -1, 0), "/summarize", "col-1", "user-1", 5000, 5000, 9)
+	logUsage(t, storage, now.Add(-time.Hour), "/summarize", "col-1", "user-1", 600, 200, 0.40)
+
+	tests := []struct {
+		name      string
+		opts      []usage.BudgetOption
+		exhausted bool
+	}{
+		{name: "no limits"},
+		{name: "tokens left", opts: []usage.BudgetOption{usage.WithMonthlyTokens(1000)}},
+		{name: "tokens used up", opts: []usage.BudgetOption{usage.WithMonthlyTokens(800)}, exhausted: true},
+		{name: "cost left", opts: []usage.BudgetOption{usage.WithMonthlyCost(1)}},
+		{name: "cost used up", opts: []usage.BudgetOption{usage.WithMonthlyTokens(1000), usage.WithMonthlyCost(0.40)}, exhausted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := usage.NewBudget(storage, append(tt.opts, usage.WithClock(func() time.Time { return now }))...)
+			err := budget.Check(t.Context())
+			if tt.exhausted && !errors.Is(err, usage.ErrBudgetExhausted) {
+				t.Errorf("Expected ErrBudgetExhausted, got %v", err)
+			}
+			if !tt.exhausted && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBudget_DisabledSkipsStorage(t *testing.T) {
+	storage := mocks.NewStorageMock()
+	budget := usage.NewBudget(storage)
+
+	if budget.Enabled() {
+		t.Error("Expected a budget without limits to be disabled")
+	}
+	if err := budget.Check(t.Context()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if storage.GetCallCount("GetUsage") != 0 {
+		t.Errorf("Expected usage not to be read, got %d calls", storage.GetCallCount("GetUsage"))
+	}
+}
+
+func TestBudget_StorageError(t *testing.T) {
+	storage := mocks.NewStorageMock()
+	storage.SetMethodError("GetUsage", errors.New("database locked"))
+	budget := usage.NewBudget(storage, usage.WithMonthlyTokens(10))
+
+	err := budget.Check(t.Context())
+	if err == nil || errors.Is(err, usage.ErrBudgetExhausted) {
+		t.Errorf("Expected the storage error, got %v", err)
+	}
+}
+
+func TestBudget_ResetsAt(t *testing.T) {
+	budget := usage.NewBudget(nil, usage.WithClock(func() time.Time {
+		return time.Date(2026, time.December, 31, 23, 0, 0, 0, time.UTC)
+	}))
+
+	want := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if got := budget.ResetsAt(); !got.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func getReport(t *testing.T, handler http.Handler, target string) (int, usage.Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	var report usage.Report
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+			t.Fatalf("Failed to decode report: %v", err)
+		}
+	}
+	return rec.Code, report
+}
+
+func TestHandler(t *testing.T) {
+	storage := mocks.NewStorageMock()
+	logUsage(t, storage, time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC), "/summarize", "col-1", "user-1", 100, 50, 0.10)
+	logUsage(t, storage, time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC), "/ai", "col-2", "user-1", 300, 100, 0.30)
+	logUsage(t, storage, time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC), "/summarize", "col-1", "user-2", 200, 50, 0.20)
+	logUsage(t, storage, time.Date(2026, time.March, 3, 9, 0, 0, 0, time.UTC), "/ai", "col-1", "user-2", 1000, 1000, 1)
+	handler := usage.Handler(storage)
+
+	code, report := getReport(t, handler, usage.Path+"?by=user&since=2026-03-01&until=2026-03-03")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if len(report.Rows) != 2 {
+		t.Fatalf("Expected 2 users, got %+v", report.Rows)
+	}
+	if row := report.Rows[0]; row.Key != "user-1" || row.Commands != 2 || row.TotalTokens != 550 {
+		t.Errorf("Expected user-1 with 2 commands and 550 tokens, got %+v", row)
+	}
+	if report.Total.Commands != 3 || report.Total.TotalTokens != 800 {
+		t.Errorf("Expected 3 commands and 800 tokens in total, got %+v", report.Total)
+	}
+	if report.Since != "2026-03-01" || report.Until != "2026-03-03" {
+		t.Errorf("Expected the requested range, got %s to %s", report.Since, report.Until)
+	}
+
+	_, report = getReport(t, handler, usage.Path+"?since=2026-03-01&until=2026-04-01")
+	if len(report.Rows) != 0 || report.Total.Commands != 4 {
+		t.Errorf("Expected only a total of 4 commands, got %+v", report)
+	}
+}
+
+func TestHandler_RejectsBadQueries(t *testing.T) {
+	handler := usage.Handler(mocks.NewStorageMock())
+
+	for _, query := range []string{"?by=document", "?since=yesterday", "?since=2026-03-02&until=2026-03-01"} {
+		if code, _ := getReport(t, handler, usage.Path+query); code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, code)
+		}
+	}
+}
+
+func TestHook_LogsUsage(t *testing.T) {
+	storage := mocks.NewStorageMock()
+	aiClient := mocks.NewAIMock()
+	aiClient.SetUsage(ai.Usage{PromptTokens: 800, CompletionTokens: 120, CostUSD: 0.002})
+
+	router := commands.NewRouter(storage, commands.WithHook(usage.Hook))
+	router.Register(commands.CommandRelated, commands.HandlerFunc(
+		func(ctx context.Context, d *commands.Document, cmd commands.Command) (commands.Result, error) {
+			aiClient.GenerateSearchTerms(ctx, &ai.SearchTermsRequest{})
+			aiClient.FindRelatedDocuments(ctx, &ai.RelatedDocsRequest{})
+			return commands.Result{}, nil
+		}))
+	router.Route(context.Background(), &commands.Document{ID: "doc-1"}, commands.Command{Type: commands.CommandRelated})
+
+	history, err := storage.GetCommandHistory(context.Background(), "doc-1", 1)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("Expected a command log entry, got %v (%v)", history, err)
+	}
+	if entry := history[0]; entry.PromptTokens != 1600 || entry.CompletionTokens != 240 || entry.CostUSD != 0.004 {
+		t.Errorf("Expected the usage of both calls, got %d+%d tokens, $%g", entry.PromptTokens, entry.CompletionTokens, entry.CostUSD)
+	}
+}
@@ -0,0 +1,62 @@
+// Package webhook receives Outline webhook deliveries. Deliveries are
+// authenticated with HMAC-SHA256, checked against replays and handed to a
+// queue so the HTTP response never waits on command processing.
+package webhook
+
+import (
+	"errors"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+)
+
+// Event types the receiver accepts by default
+const (
+	EventDocumentsCreate = "documents.create"
+	EventDocumentsUpdate = "documents.update"
+)
+
+// Collection event types, for consumers that cache collection data
+const (
+	EventCollectionsCreate = "collections.create"
+	EventCollectionsUpdate = "collections.update"
+	EventCollectionsDelete = "collections.delete"
+
+	// CollectionEventPrefix starts every collection event type
+	CollectionEventPrefix = "collections."
+)
+
+// Package-level errors for webhook deliveries
+var (
+	ErrNoSecret         = errors.New("webhook: signing secret is required")
+	ErrMissingSignature = errors.New("webhook: missing signature")
+	ErrMalformedHeader  = errors.New("webhook: malformed signature header")
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	ErrStaleDelivery    = errors.New("webhook: timestamp outside replay window")
+	ErrQueueFull        = errors.New("webhook: queue full")
+)
+
+// Event is one webhook delivery
+type Event struct {
+	// ID identifies the delivery; Outline reuses it when retrying
+	ID                    string    `json:"id"`
+	WebhookSubscriptionID string    `json:"webhookSubscriptionId"`
+	CreatedAt             time.Time `json:"createdAt"`
+	Event                 string    `json:"event"`
+	ActorID               string    `json:"actorId"`
+	Payload               Payload   `json:"payload"`
+}
+
+// Payload carries the model the event is about
+type Payload struct {
+	ID    string            `json:"id"`
+	Model *outline.Document `json:"model,omitempty"`
+}
+
+// DocumentID returns the ID of the document the event is about
+func (e *Event) DocumentID() string {
+	if e.Payload.ID == "" && e.Payload.Model != nil {
+		return e.Payload.Model.ID
+	}
+	return e.Payload.ID
+}
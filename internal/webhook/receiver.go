@@ -0,0 +1,242 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultReplayWindow = 5 * time.Minute
+	defaultMaxBodySize  = 10 << 20
+)
+
+// Queue takes accepted events for processing. Enqueue runs while Outline
+// waits for the response, so it must not block; an error such as
+// ErrQueueFull answers 503 and Outline delivers again later.
+type Queue interface {
+	Enqueue(event *Event) error
+}
+
+// QueueFunc adapts an ordinary function to a Queue
+type QueueFunc func(event *Event) error
+
+// Enqueue calls f(event)
+func (f QueueFunc) Enqueue(event *Event) error {
+	return f(event)
+}
+
+// ChannelQueue is a bounded Queue read from a channel
+type ChannelQueue struct {
+	events chan *Event
+}
+
+// NewChannelQueue creates a ChannelQueue holding up to size events
+func NewChannelQueue(size int) *ChannelQueue {
+	return &ChannelQueue{events: make(chan *Event, size)}
+}
+
+// Enqueue adds event, or returns ErrQueueFull without waiting
+func (q *ChannelQueue) Enqueue(event *Event) error {
+	select {
+	case q.events <- event:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Events returns the channel events are read from
+func (q *ChannelQueue) Events() <-chan *Event {
+	return q.events
+}
+
+// Option configures a Receiver
+type Option func(*Receiver)
+
+// WithPreviousSecret also accepts deliveries signed with secret, so the
+// signing secret can be rotated without dropping deliveries
+func WithPreviousSecret(secret string) Option {
+	return func(r *Receiver) {
+		if secret != "" {
+			r.secrets = append(r.secrets, []byte(secret))
+		}
+	}
+}
+
+// WithReplayWindow sets how far a delivery's signed timestamp may be from
+// now, in either direction (default 5 minutes). Delivery IDs are remembered
+// until a replay would fail the timestamp check.
+func WithReplayWindow(window time.Duration) Option {
+	return func(r *Receiver) {
+		r.window = window
+	}
+}
+
+// WithEvents sets the event types that are queued (default documents.create
+// and documents.update). Other events are acknowledged and dropped.
+func WithEvents(events ...string) Option {
+	return func(r *Receiver) {
+		r.events = make(map[string]bool, len(events))
+		for _, e := range events {
+			r.events[e] = true
+		}
+	}
+}
+
+// WithMaxBodySize limits the accepted request body (default 10 MiB)
+func WithMaxBodySize(n int64) Option {
+	return func(r *Receiver) {
+		r.maxBody = n
+	}
+}
+
+// WithSlog sets where rejected deliveries are reported
+func WithSlog(logger *slog.Logger) Option {
+	return func(r *Receiver) {
+		r.slog = logger
+	}
+}
+
+// WithClock overrides the time source, mainly for tests
+func WithClock(now func() time.Time) Option {
+	return func(r *Receiver) {
+		r.now = now
+	}
+}
+
+// Receiver is the http.Handler for webhook deliveries. It verifies and
+// queues each delivery and responds without waiting for processing.
+type Receiver struct {
+	queue   Queue
+	secrets [][]byte
+	window  time.Duration
+	events  map[string]bool
+	maxBody int64
+	slog    *slog.Logger
+	now     func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+
+	// lastReceived is when the last verified delivery arrived, in Unix
+	// nanoseconds
+	lastReceived atomic.Int64
+}
+
+// NewReceiver creates a Receiver that verifies deliveries with secret and
+// hands them to queue
+func NewReceiver(queue Queue, secret string, opts ...Option) (*Receiver, error) {
+	if secret == "" {
+		return nil, ErrNoSecret
+	}
+
+	r := &Receiver{
+		queue:   queue,
+		secrets: [][]byte{[]byte(secret)},
+		window:  defaultReplayWindow,
+		maxBody: defaultMaxBodySize,
+		slog:    slog.Default(),
+		now:     time.Now,
+		seen:    make(map[string]time.Time),
+	}
+	WithEvents(EventDocumentsCreate, EventDocumentsUpdate)(r)
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+// ServeHTTP verifies, deduplicates and queues one delivery
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, r.maxBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	now := r.now()
+	sent, err := verifySignature(req.Header.Get(SignatureHeader), body, r.secrets)
+	if err == nil && (sent.Before(now.Add(-r.window)) || sent.After(now.Add(r.window))) {
+		err = ErrStaleDelivery
+	}
+	if err != nil {
+		r.slog.Warn("webhook: rejected delivery", "error", err, "remote", req.RemoteAddr)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	r.lastReceived.Store(now.UnixNano())
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+	if !r.events[event.Event] {
+		respond(w, "ignored")
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.ID != "" {
+		if _, dup := r.seen[event.ID]; dup {
+			respond(w, "duplicate")
+			return
+		}
+	}
+	if err := r.queue.Enqueue(&event); err != nil {
+		r.slog.Warn("webhook: failed to queue delivery", "error", err, "event", event.Event, "document", event.DocumentID())
+		http.Error(w, "queue unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	r.remember(event.ID, now)
+	respond(w, "accepted")
+}
+
+// LastReceived returns when the last verified delivery arrived, or the
+// zero time if none has
+func (r *Receiver) LastReceived() time.Time {
+	nanos := r.lastReceived.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// remember records a queued delivery ID and forgets those queued more than
+// twice the replay window ago, after which the timestamp check rejects them
+// anyway, whatever the sender's clock skew
+func (r *Receiver) remember(id string, now time.Time) {
+	for seenID, at := range r.seen {
+		if now.Sub(at) > 2*r.window {
+			delete(r.seen, seenID)
+		}
+	}
+	if id != "" {
+		r.seen[id] = now
+	}
+}
+
+func respond(w http.ResponseWriter, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": status})
+}
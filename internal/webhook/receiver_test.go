@@ -0,0 +1,260 @@
+package webhook_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/webhook"
+)
+
+const (
+	secret         = "current-secret"
+	previousSecret = "previous-secret"
+)
+
+var now = time.Date(2026, 1, 19, 12, 0, 0, 0, time.UTC)
+
+type recordingQueue struct {
+	mu     sync.Mutex
+	events []*webhook.Event
+	err    error
+}
+
+func (q *recordingQueue) Enqueue(event *webhook.Event) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err != nil {
+		return q.err
+	}
+	q.events = append(q.events, event)
+	return nil
+}
+
+func (q *recordingQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.events)
+}
+
+func newServer(t *testing.T, queue webhook.Queue, opts ...webhook.Option) *httptest.Server {
+	t.Helper()
+	opts = append([]webhook.Option{
+		webhook.WithClock(func() time.Time { return now }),
+		webhook.WithSlog(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	}, opts...)
+
+	receiver, err := webhook.NewReceiver(queue, secret, opts...)
+	if err != nil {
+		t.Fatalf("Failed to create receiver: %v", err)
+	}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func payload(t *testing.T, id, event string) []byte {
+	t.Helper()
+	body, err := json.Marshal(webhook.Event{
+		ID:        id,
+		CreatedAt: now,
+		Event:     event,
+		Payload: webhook.Payload{
+			ID:    "doc-123",
+			Model: &outline.Document{ID: "doc-123", Title: "Notes", Text: "/ai-file"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal payload: %v", err)
+	}
+	return body
+}
+
+func post(t *testing.T, server *httptest.Server, body []byte, signature string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	if signature != "" {
+		req.Header.Set(webhook.SignatureHeader, signature)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestReceiver_AcceptsSignedDelivery(t *testing.T) {
+	queue := &recordingQueue{}
+	server := newServer(t, queue)
+	body := payload(t, "delivery-1", webhook.EventDocumentsUpdate)
+
+	if status := post(t, server, body, webhook.Sign(secret, body, now)); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if queue.len() != 1 {
+		t.Fatalf("Expected one queued event, got %d", queue.len())
+	}
+	event := queue.events[0]
+	if event.Event != webhook.EventDocumentsUpdate || event.DocumentID() != "doc-123" || event.Payload.Model.Text != "/ai-file" {
+		t.Errorf("Expected the parsed delivery, got %+v", event)
+	}
+}
+
+func TestReceiver_LastReceived(t *testing.T) {
+	receiver, err := webhook.NewReceiver(&recordingQueue{}, secret,
+		webhook.WithClock(func() time.Time { return now }),
+		webhook.WithSlog(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err != nil {
+		t.Fatalf("Failed to create receiver: %v", err)
+	}
+	deliver := func(signature string) {
+		body := payload(t, "delivery-1", webhook.EventDocumentsUpdate)
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set(webhook.SignatureHeader, signature)
+		receiver.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if !receiver.LastReceived().IsZero() {
+		t.Errorf("Expected no receipt yet, got %v", receiver.LastReceived())
+	}
+	deliver("t=1,s=zz")
+	if !receiver.LastReceived().IsZero() {
+		t.Error("Expected a rejected delivery not to count")
+	}
+	body := payload(t, "delivery-1", webhook.EventDocumentsUpdate)
+	deliver(webhook.Sign(secret, body, now))
+	if !receiver.LastReceived().Equal(now) {
+		t.Errorf("Expected %v, got %v", now, receiver.LastReceived())
+	}
+}
+
+func TestReceiver_RejectsBadSignatures(t *testing.T) {
+	queue := &recordingQueue{}
+	server := newServer(t, queue)
+	body := payload(t, "delivery-1", webhook.EventDocumentsUpdate)
+	tampered := payload(t, "delivery-1", webhook.EventDocumentsCreate)
+
+	tests := []struct {
+		name      string
+		signature string
+	}{
+		{"missing", ""},
+		{"wrong secret", webhook.Sign("other-secret", body, now)},
+		{"tampered body", webhook.Sign(secret, tampered, now)},
+		{"malformed", "sha256=abc"},
+		{"not hex", "t=1,s=zz"},
+		{"too old", webhook.Sign(secret, body, now.Add(-6*time.Minute))},
+		{"too far ahead", webhook.Sign(secret, body, now.Add(6*time.Minute))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := post(t, server, body, tt.signature); status != http.StatusUnauthorized {
+				t.Errorf("Expected 401, got %d", status)
+			}
+		})
+	}
+	if queue.len() != 0 {
+		t.Errorf("Expected nothing queued, got %d events", queue.len())
+	}
+}
+
+func TestReceiver_SecretRotation(t *testing.T) {
+	queue := &recordingQueue{}
+	server := newServer(t, queue, webhook.WithPreviousSecret(previousSecret))
+
+	for i, key := range []string{secret, previousSecret} {
+		body := payload(t, "delivery-"+key, webhook.EventDocumentsUpdate)
+		if status := post(t, server, body, webhook.Sign(key, body, now)); status != http.StatusOK {
+			t.Errorf("Secret %d: expected 200, got %d", i, status)
+		}
+	}
+	if queue.len() != 2 {
+		t.Errorf("Expected both deliveries queued, got %d", queue.len())
+	}
+}
+
+func TestReceiver_RejectsReplay(t *testing.T) {
+	queue := &recordingQueue{}
+	server := newServer(t, queue)
+	body := payload(t, "delivery-1", webhook.EventDocumentsUpdate)
+	signature := webhook.Sign(secret, body, now.Add(-time.Minute))
+
+	for i := 0; i < 2; i++ {
+		if status := post(t, server, body, signature); status != http.StatusOK {
+			t.Fatalf("Attempt %d: expected 200, got %d", i, status)
+		}
+	}
+	if queue.len() != 1 {
+		t.Errorf("Expected the replayed delivery to be dropped, got %d events", queue.len())
+	}
+}
+
+func TestReceiver_IgnoresOtherEvents(t *testing.T) {
+	queue := &recordingQueue{}
+	server := newServer(t, queue)
+	body := payload(t, "delivery-1", "documents.archive")
+
+	if status := post(t, server, body, webhook.Sign(secret, body, now)); status != http.StatusOK {
+		t.Errorf("Expected 200 so Outline does not retry, got %d", status)
+	}
+	if queue.len() != 0 {
+		t.Errorf("Expected nothing queued, got %d events", queue.len())
+	}
+}
+
+func TestReceiver_QueueFull(t *testing.T) {
+	queue := webhook.NewChannelQueue(1)
+	server := newServer(t, queue)
+
+	first := payload(t, "delivery-1", webhook.EventDocumentsUpdate)
+	if status := post(t, server, first, webhook.Sign(secret, first, now)); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	second := payload(t, "delivery-2", webhook.EventDocumentsUpdate)
+	if status := post(t, server, second, webhook.Sign(secret, second, now)); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 so Outline retries, got %d", status)
+	}
+
+	// Once there is room again, the retried delivery is accepted
+	<-queue.Events()
+	if status := post(t, server, second, webhook.Sign(secret, second, now)); status != http.StatusOK {
+		t.Errorf("Expected the retry to be accepted, got %d", status)
+	}
+}
+
+func TestReceiver_RequestLimits(t *testing.T) {
+	server := newServer(t, &recordingQueue{}, webhook.WithMaxBodySize(64))
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", resp.StatusCode)
+	}
+
+	body := payload(t, "delivery-1", webhook.EventDocumentsUpdate)
+	if status := post(t, server, body, webhook.Sign(secret, body, now)); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d", status)
+	}
+}
+
+func TestNewReceiver_RequiresSecret(t *testing.T) {
+	if _, err := webhook.NewReceiver(&recordingQueue{}, ""); !errors.Is(err, webhook.ErrNoSecret) {
+		t.Errorf("Expected ErrNoSecret, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries the delivery signature as "t=<unix ms>,s=<hex>",
+// where the signature covers "<unix ms>.<body>"
+const SignatureHeader = "Outline-Signature"
+
+// Sign returns the signature header value for body sent at t, as Outline
+// computes it
+func Sign(secret string, body []byte, t time.Time) string {
+	ts := strconv.FormatInt(t.UnixMilli(), 10)
+	return fmt.Sprintf("t=%s,s=%s", ts, hex.EncodeToString(mac([]byte(secret), ts, body)))
+}
+
+func mac(secret []byte, ts string, body []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// parseSignature splits a header into its timestamp and signatures
+func parseSignature(header string) (ts string, sent time.Time, sigs [][]byte, err error) {
+	if strings.TrimSpace(header) == "" {
+		return "", time.Time{}, nil, ErrMissingSignature
+	}
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return "", time.Time{}, nil, ErrMalformedHeader
+		}
+		switch key {
+		case "t":
+			ts = value
+		case "s":
+			sig, err := hex.DecodeString(value)
+			if err != nil {
+				return "", time.Time{}, nil, fmt.Errorf("%w: %v", ErrMalformedHeader, err)
+			}
+			sigs = append(sigs, sig)
+		}
+	}
+	if ts == "" || len(sigs) == 0 {
+		return "", time.Time{}, nil, ErrMalformedHeader
+	}
+
+	ms, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return "", time.Time{}, nil, fmt.Errorf("%w: %v", ErrMalformedHeader, err)
+	}
+	return ts, time.UnixMilli(ms), sigs, nil
+}
+
+// verifySignature checks header against body with each secret in turn,
+// comparing in constant time, and returns when the delivery was signed
+func verifySignature(header string, body []byte, secrets [][]byte) (time.Time, error) {
+	ts, sent, sigs, err := parseSignature(header)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	valid := false
+	for _, secret := range secrets {
+		expected := mac(secret, ts, body)
+		for _, sig := range sigs {
+			if hmac.Equal(sig, expected) {
+				valid = true
+			}
+		}
+	}
+	if !valid {
+		return time.Time{}, ErrInvalidSignature
+	}
+	return sent, nil
+}
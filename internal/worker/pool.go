@@ -0,0 +1,509 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/internal/ratelimit"
+)
+
+const (
+	defaultWorkers     = 3
+	defaultQueueSize   = 100
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = time.Second
+	defaultMaxDelay    = time.Minute
+	defaultTaskTimeout = 5 * time.Minute
+)
+
+// Package-level errors for the worker pool
+var (
+	ErrPoolClosed     = errors.New("worker: pool closed")
+	ErrQueueFull      = errors.New("worker: queue full")
+	ErrInvalidTask    = errors.New("worker: invalid task")
+	ErrAlreadyStarted = errors.New("worker: pool already started")
+)
+
+// DeadLetters records tasks that used up their retries.
+// persistence.Storage satisfies it.
+type DeadLetters interface {
+	AddDeadLetter(ctx context.Context, letter *persistence.DeadLetter) error
+}
+
+// Option configures a Pool
+type Option func(*Pool)
+
+// WithWorkers sets how many tasks run at once (default 3)
+func WithWorkers(n int) Option {
+	return func(p *Pool) {
+		p.workers = n
+	}
+}
+
+// WithQueueSize sets how many tasks each priority lane holds before Submit
+// blocks (default 100)
+func WithQueueSize(n int) Option {
+	return func(p *Pool) {
+		p.queueSize = n
+	}
+}
+
+// WithMaxAttempts sets how often a task is run before it becomes a dead
+// letter, first attempt included (default 3)
+func WithMaxAttempts(n int) Option {
+	return func(p *Pool) {
+		p.maxAttempts = n
+	}
+}
+
+// WithBackoff sets the delay before the first retry, doubled for each
+// further one up to max (default 1s up to 1m). A longer pause requested by
+// a rate-limited server takes precedence.
+func WithBackoff(base, max time.Duration) Option {
+	return func(p *Pool) {
+		p.baseDelay = base
+		p.maxDelay = max
+	}
+}
+
+// WithTaskTimeout bounds a single attempt (default 5m)
+func WithTaskTimeout(timeout time.Duration) Option {
+	return func(p *Pool) {
+		p.taskTimeout = timeout
+	}
+}
+
+// WithRetryClassifier decides which errors are worth retrying. The default
+// is IsRetryable.
+func WithRetryClassifier(retryable func(error) bool) Option {
+	return func(p *Pool) {
+		p.retryable = retryable
+	}
+}
+
+// WithSlog sets where task failures are reported
+func WithSlog(logger *slog.Logger) Option {
+	return func(p *Pool) {
+		p.slog = logger
+	}
+}
+
+// Stats is a snapshot of the pool's counters
+type Stats struct {
+	Workers      int
+	Queued       int
+	Active       int
+	Completed    int64
+	Failed       int64
+	Retried      int64
+	DeadLettered int64
+}
+
+// Pool runs tasks on a fixed number of goroutines. Each priority lane is a
+// bounded FIFO; workers always take from the highest non-empty lane. A task
+// waiting to be retried holds no worker; it rejoins its lane when its
+// backoff ends.
+type Pool struct {
+	workers     int
+	queueSize   int
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	taskTimeout time.Duration
+	retryable   func(error) bool
+	deadLetters DeadLetters
+	slog        *slog.Logger
+
+	mu       sync.Mutex
+	lanes    [numPriorities][]*Task
+	slots    [numPriorities]chan struct{}
+	ready    chan struct{}
+	closing  chan struct{}
+	started  bool
+	closed   bool
+	retrying map[*Task]retry
+
+	// pending counts submitted tasks that have not finished for good:
+	// queued, running or waiting to be retried
+	pending sync.WaitGroup
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	abandon atomic.Bool
+	wg      sync.WaitGroup
+
+	active       atomic.Int64
+	completed    atomic.Int64
+	failed       atomic.Int64
+	retried      atomic.Int64
+	deadLettered atomic.Int64
+}
+
+// NewPool creates a Pool that records exhausted tasks in deadLetters. Call
+// Start before submitting.
+func NewPool(deadLetters DeadLetters, opts ...Option) *Pool {
+	p := &Pool{
+		workers:     defaultWorkers,
+		queueSize:   defaultQueueSize,
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   defaultBaseDelay,
+		maxDelay:    defaultMaxDelay,
+		taskTimeout: defaultTaskTimeout,
+		retryable:   IsRetryable,
+		deadLetters: deadLetters,
+		slog:        slog.Default(),
+		closing:     make(chan struct{}),
+		retrying:    make(map[*Task]retry),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	for i := range p.slots {
+		p.slots[i] = make(chan struct{}, p.queueSize)
+	}
+	// One token per queued task, so sends never block
+	p.ready = make(chan struct{}, p.queueSize*numPriorities)
+	return p
+}
+
+// Start launches the workers. Tasks run with contexts derived from ctx.
+func (p *Pool) Start(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+	if p.started {
+		return ErrAlreadyStarted
+	}
+	p.started = true
+	p.ctx, p.cancel = context.WithCancel(ctx)
+
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return nil
+}
+
+// Submit queues task, waiting while its lane is full until ctx is done
+func (p *Pool) Submit(ctx context.Context, task *Task) error {
+	if err := validate(task); err != nil {
+		return err
+	}
+
+	select {
+	case p.slots[task.Priority] <- struct{}{}:
+	case <-p.closing:
+		return ErrPoolClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.push(task)
+}
+
+// TrySubmit queues task, or returns ErrQueueFull at once if its lane is full
+func (p *Pool) TrySubmit(task *Task) error {
+	if err := validate(task); err != nil {
+		return err
+	}
+
+	select {
+	case p.slots[task.Priority] <- struct{}{}:
+	default:
+		return ErrQueueFull
+	}
+	return p.push(task)
+}
+
+func validate(task *Task) error {
+	switch {
+	case task == nil || task.Run == nil:
+		return fmt.Errorf("%w: task has nothing to run", ErrInvalidTask)
+	case task.Priority < PriorityLow || task.Priority > PriorityHigh:
+		return fmt.Errorf("%w: unknown priority %d", ErrInvalidTask, task.Priority)
+	}
+	return nil
+}
+
+// push adds a task whose lane slot is already taken
+func (p *Pool) push(task *Task) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		<-p.slots[task.Priority]
+		return ErrPoolClosed
+	}
+	task.attempts = 0
+	p.pending.Add(1)
+	p.enqueue(task)
+	return nil
+}
+
+// enqueue appends a task whose lane slot is already taken. The caller
+// holds mu.
+func (p *Pool) enqueue(task *Task) {
+	p.lanes[task.Priority] = append(p.lanes[task.Priority], task)
+	p.ready <- struct{}{}
+}
+
+// pop removes the oldest task of the highest non-empty lane
+func (p *Pool) pop() *Task {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for prio := numPriorities - 1; prio >= 0; prio-- {
+		if lane := p.lanes[prio]; len(lane) > 0 {
+			task := lane[0]
+			lane[0] = nil
+			p.lanes[prio] = lane[1:]
+			<-p.slots[prio]
+			return task
+		}
+	}
+	return nil
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for range p.ready {
+		task := p.pop()
+		if task == nil {
+			continue
+		}
+		if p.abandon.Load() {
+			p.bury(task, task.attempts, errors.New("worker: pool stopped before the task ran"))
+			p.pending.Done()
+			continue
+		}
+
+		p.active.Add(1)
+		if p.run(task) {
+			p.pending.Done()
+		}
+		p.active.Add(-1)
+	}
+}
+
+// run executes one attempt of task and reports whether the task is settled.
+// A transient failure is scheduled for a retry instead.
+func (p *Pool) run(task *Task) bool {
+	task.attempts++
+	attempt := task.attempts
+	err := p.attempt(task)
+	if err == nil {
+		p.completed.Add(1)
+		return true
+	}
+
+	if p.ctx.Err() != nil {
+		// Stopped mid-task, so keep it for a later run
+		p.failed.Add(1)
+		p.bury(task, attempt, err)
+		return true
+	}
+	if !p.retryable(err) {
+		p.failed.Add(1)
+		p.slog.Warn("worker: task failed", "task", task.ID, "type", task.Type, "attempt", attempt, "error", err)
+		return true
+	}
+	if attempt >= p.maxAttempts {
+		p.failed.Add(1)
+		p.bury(task, attempt, err)
+		return true
+	}
+
+	delay := p.backoff(attempt, err)
+	p.slog.Info("worker: retrying task", "task", task.ID, "type", task.Type, "attempt", attempt, "delay", delay, "error", err)
+	p.retried.Add(1)
+	if p.retryAfter(task, delay, err) {
+		return false
+	}
+	p.failed.Add(1)
+	p.bury(task, attempt, err)
+	return true
+}
+
+// retry is a task waiting out its backoff
+type retry struct {
+	timer *time.Timer
+	cause error
+}
+
+// retryAfter puts task back in its lane once delay has passed. It reports
+// false without scheduling anything when the pool is being abandoned.
+func (p *Pool) retryAfter(task *Task, delay time.Duration, cause error) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.abandon.Load() {
+		return false
+	}
+	p.retrying[task] = retry{
+		timer: time.AfterFunc(delay, func() { p.requeue(task) }),
+		cause: cause,
+	}
+	return true
+}
+
+// requeue moves a task whose backoff has ended back into its lane. When
+// the lane is full the task waits another base delay rather than blocking.
+func (p *Pool) requeue(task *Task) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r, ok := p.retrying[task]
+	if !ok {
+		// Abandoned by Stop
+		return
+	}
+	select {
+	case p.slots[task.Priority] <- struct{}{}:
+		delete(p.retrying, task)
+		p.enqueue(task)
+	default:
+		r.timer = time.AfterFunc(p.baseDelay, func() { p.requeue(task) })
+		p.retrying[task] = r
+	}
+}
+
+func (p *Pool) attempt(task *Task) (err error) {
+	ctx, cancel := context.WithTimeout(p.ctx, p.taskTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("worker: task panicked: %v", r)
+		}
+	}()
+	return task.Run(ctx)
+}
+
+// backoff returns the delay before the retry after attempt
+func (p *Pool) backoff(attempt int, err error) time.Duration {
+	delay := p.baseDelay
+	for i := 1; i < attempt && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.maxDelay)
+
+	if after, ok := ratelimit.RetryAfter(err); ok && after > delay {
+		delay = after
+	}
+	return delay
+}
+
+// bury records task as a dead letter
+func (p *Pool) bury(task *Task, attempts int, cause error) {
+	p.deadLettered.Add(1)
+	p.slog.Error("worker: task dead-lettered", "task", task.ID, "type", task.Type, "attempts", attempts, "error", cause)
+
+	if p.deadLetters == nil {
+		return
+	}
+	letter := &persistence.DeadLetter{
+		TaskID:     task.ID,
+		TaskType:   task.Type,
+		DocumentID: task.DocumentID,
+		Attempts:   attempts,
+		LastError:  cause.Error(),
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(p.ctx), 5*time.Second)
+	defer cancel()
+	if err := p.deadLetters.AddDeadLetter(ctx, letter); err != nil {
+		p.slog.Error("worker: failed to record dead letter", "task", task.ID, "error", err)
+	}
+}
+
+// Stop stops accepting tasks and waits for the queued ones to finish,
+// including their retries. If ctx ends first, running tasks are cancelled,
+// tasks still queued or waiting to retry become dead letters, and ctx's
+// error is returned once the workers have exited.
+func (p *Pool) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPoolClosed
+	}
+	p.closed = true
+	close(p.closing)
+	started := p.started
+	p.mu.Unlock()
+
+	if !started {
+		p.mu.Lock()
+		close(p.ready)
+		p.mu.Unlock()
+		return nil
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		p.pending.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		p.abandon.Store(true)
+		p.cancel()
+		p.abandonRetries()
+		<-drained
+		err = fmt.Errorf("worker: drain interrupted: %w", ctx.Err())
+	}
+
+	// Nothing is queued or waiting any more, so the workers can exit
+	p.mu.Lock()
+	close(p.ready)
+	p.mu.Unlock()
+	p.wg.Wait()
+	p.cancel()
+	return err
+}
+
+// abandonRetries dead-letters the tasks waiting to be retried
+func (p *Pool) abandonRetries() {
+	p.mu.Lock()
+	retrying := p.retrying
+	p.retrying = make(map[*Task]retry)
+	p.mu.Unlock()
+
+	for task, r := range retrying {
+		r.timer.Stop()
+		p.failed.Add(1)
+		p.bury(task, task.attempts, r.cause)
+		p.pending.Done()
+	}
+}
+
+// Stats returns a snapshot of the pool's counters
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	queued := 0
+	for _, lane := range p.lanes {
+		queued += len(lane)
+	}
+	p.mu.Unlock()
+
+	return Stats{
+		Workers:      p.workers,
+		Queued:       queued,
+		Active:       int(p.active.Load()),
+		Completed:    p.completed.Load(),
+		Failed:       p.failed.Load(),
+		Retried:      p.retried.Load(),
+		DeadLettered: p.deadLettered.Load(),
+	}
+}
@@ -0,0 +1,308 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/outline"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/internal/worker"
+	"github.com/yourusername/outline-ai/pkg/commands"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+func newPool(t *testing.T, storage *mocks.StorageMock, opts ...worker.Option) *worker.Pool {
+	t.Helper()
+	opts = append([]worker.Option{
+		worker.WithBackoff(time.Millisecond, 4*time.Millisecond),
+		worker.WithSlog(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	}, opts...)
+
+	pool := worker.NewPool(storage, opts...)
+	if err := pool.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start pool: %v", err)
+	}
+	return pool
+}
+
+func stop(t *testing.T, pool *worker.Pool) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pool.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop pool: %v", err)
+	}
+}
+
+// gate returns a task that blocks its worker until release is called
+func gate() (*worker.Task, func()) {
+	ch := make(chan struct{})
+	started := make(chan struct{})
+	task := &worker.Task{ID: "gate", Type: "gate", Priority: worker.PriorityHigh, Run: func(ctx context.Context) error {
+		close(started)
+		select {
+		case <-ch:
+		case <-ctx.Done():
+		}
+		return ctx.Err()
+	}}
+	var once sync.Once
+	return task, func() {
+		<-started
+		once.Do(func() { close(ch) })
+	}
+}
+
+func TestPool_PriorityLanes(t *testing.T) {
+	pool := newPool(t, mocks.NewStorageMock(), worker.WithWorkers(1))
+	blocker, release := gate()
+	if err := pool.Submit(context.Background(), blocker); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	for _, cmd := range []commands.CommandType{commands.CommandRelated, commands.CommandSummarize, commands.CommandRelated, commands.CommandAI} {
+		cmd := cmd
+		task := &worker.Task{ID: string(cmd), Type: string(cmd), Priority: worker.CommandPriority(cmd), Run: func(context.Context) error {
+			mu.Lock()
+			order = append(order, string(cmd))
+			mu.Unlock()
+			return nil
+		}}
+		if err := pool.Submit(context.Background(), task); err != nil {
+			t.Fatalf("Failed to submit: %v", err)
+		}
+	}
+
+	release()
+	stop(t, pool)
+
+	if got := strings.Join(order, " "); got != "/ai /summarize /related /related" {
+		t.Errorf("Expected questions first and /related last, got %s", got)
+	}
+}
+
+func TestPool_RetriesTransientErrors(t *testing.T) {
+	storage := mocks.NewStorageMock()
+	pool := newPool(t, storage, worker.WithMaxAttempts(3))
+
+	var attempts atomic.Int32
+	pool.Submit(context.Background(), &worker.Task{ID: "t1", Type: "/summarize", Run: func(context.Context) error {
+		if attempts.Add(1) < 3 {
+			return outline.ErrServerError
+		}
+		return nil
+	}})
+	stop(t, pool)
+
+	if attempts.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts.Load())
+	}
+	stats := pool.Stats()
+	if stats.Completed != 1 || stats.Retried != 2 || stats.DeadLettered != 0 {
+		t.Errorf("Expected one completion after two retries, got %+v", stats)
+	}
+}
+
+func TestPool_RetryBackoffFreesTheWorker(t *testing.T) {
+	pool := newPool(t, mocks.NewStorageMock(), worker.WithWorkers(1), worker.WithBackoff(100*time.Millisecond, 100*time.Millisecond))
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}
+	failed := make(chan struct{})
+	var attempts atomic.Int32
+	pool.Submit(context.Background(), &worker.Task{ID: "related", Type: "/related", Priority: worker.PriorityLow, Run: func(context.Context) error {
+		record("/related")
+		if attempts.Add(1) == 1 {
+			close(failed)
+			return outline.ErrRateLimited
+		}
+		return nil
+	}})
+
+	<-failed
+	pool.Submit(context.Background(), &worker.Task{ID: "ai", Type: "/ai", Priority: worker.PriorityHigh, Run: func(context.Context) error {
+		record("/ai")
+		return nil
+	}})
+	stop(t, pool)
+
+	if got := strings.Join(order, " "); got != "/related /ai /related" {
+		t.Errorf("Expected the question to run during the backoff, got %s", got)
+	}
+}
+
+func TestPool_DoesNotRetryPermanentErrors(t *testing.T) {
+	storage := mocks.NewStorageMock()
+	pool := newPool(t, storage)
+
+	for i, cause := range []error{outline.ErrUnauthorized, outline.ErrInvalidRequest} {
+		var attempts atomic.Int32
+		pool.Submit(context.Background(), &worker.Task{ID: "t", Type: "/ai", Run: func(context.Context) error {
+			attempts.Add(1)
+			return cause
+		}})
+		deadline := time.Now().Add(time.Second)
+		for pool.Stats().Failed <= int64(i) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if attempts.Load() != 1 {
+			t.Errorf("%v: expected a single attempt, got %d", cause, attempts.Load())
+		}
+	}
+	stop(t, pool)
+
+	if letters, _ := storage.ListDeadLetters(context.Background(), 0); len(letters) != 0 {
+		t.Errorf("Expected no dead letters for permanent errors, got %d", len(letters))
+	}
+}
+
+func TestPool_DeadLettersExhaustedTasks(t *testing.T) {
+	storage := mocks.NewStorageMock()
+	pool := newPool(t, storage, worker.WithMaxAttempts(2))
+
+	pool.Submit(context.Background(), &worker.Task{ID: "task-1", Type: "/ai-file", DocumentID: "doc-1", Run: func(context.Context) error {
+		return outline.ErrRateLimited
+	}})
+	stop(t, pool)
+
+	letters, err := storage.ListDeadLetters(context.Background(), 0)
+	if err != nil || len(letters) != 1 {
+		t.Fatalf("Expected one dead letter, got %v (%v)", letters, err)
+	}
+	letter := letters[0]
+	if letter.TaskID != "task-1" || letter.TaskType != "/ai-file" || letter.DocumentID != "doc-1" || letter.Attempts != 2 ||
+		!strings.Contains(letter.LastError, "rate limited") {
+		t.Errorf("Expected the exhausted task recorded, got %+v", letter)
+	}
+}
+
+func TestPool_BackPressure(t *testing.T) {
+	pool := newPool(t, mocks.NewStorageMock(), worker.WithWorkers(1), worker.WithQueueSize(1))
+	blocker, release := gate()
+	pool.Submit(context.Background(), blocker)
+
+	noop := func(context.Context) error { return nil }
+	if err := pool.TrySubmit(&worker.Task{ID: "a", Run: noop}); err != nil {
+		t.Fatalf("Expected room for one task, got %v", err)
+	}
+	if err := pool.TrySubmit(&worker.Task{ID: "b", Run: noop}); !errors.Is(err, worker.ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Submit(ctx, &worker.Task{ID: "c", Run: noop}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Submit to wait until its context ended, got %v", err)
+	}
+
+	// Other lanes have their own room
+	if err := pool.TrySubmit(&worker.Task{ID: "d", Priority: worker.PriorityHigh, Run: noop}); err != nil {
+		t.Errorf("Expected room in the high lane, got %v", err)
+	}
+
+	release()
+	stop(t, pool)
+	if err := pool.Submit(context.Background(), &worker.Task{ID: "e", Run: noop}); !errors.Is(err, worker.ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed after Stop, got %v", err)
+	}
+}
+
+func TestPool_DrainFinishesQueuedTasks(t *testing.T) {
+	pool := newPool(t, mocks.NewStorageMock(), worker.WithWorkers(2))
+
+	var done atomic.Int32
+	for i := 0; i < 10; i++ {
+		pool.Submit(context.Background(), &worker.Task{ID: "t", Run: func(context.Context) error {
+			time.Sleep(time.Millisecond)
+			done.Add(1)
+			return nil
+		}})
+	}
+	stop(t, pool)
+
+	if done.Load() != 10 {
+		t.Errorf("Expected all 10 queued tasks to finish, got %d", done.Load())
+	}
+}
+
+func TestPool_DrainInterrupted(t *testing.T) {
+	storage := mocks.NewStorageMock()
+	pool := newPool(t, storage, worker.WithWorkers(1))
+	blocker, _ := gate()
+	pool.Submit(context.Background(), blocker)
+	pool.Submit(context.Background(), &worker.Task{ID: "queued", Type: "/related", Run: func(context.Context) error { return nil }})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the drain deadline error, got %v", err)
+	}
+
+	letters, _ := storage.ListDeadLetters(context.Background(), 0)
+	ids := make([]string, 0, len(letters))
+	for _, l := range letters {
+		ids = append(ids, l.TaskID)
+	}
+	if got := strings.Join(ids, " "); got != "queued gate" {
+		t.Errorf("Expected the running and queued tasks kept as dead letters, got %q", got)
+	}
+}
+
+func TestPool_DrainInterruptedDuringBackoff(t *testing.T) {
+	storage := mocks.NewStorageMock()
+	pool := newPool(t, storage, worker.WithBackoff(time.Hour, time.Hour))
+	failed := make(chan struct{})
+	pool.Submit(context.Background(), &worker.Task{ID: "waiting", Type: "/summarize", Run: func(context.Context) error {
+		close(failed)
+		return outline.ErrServerError
+	}})
+	<-failed
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the drain deadline error, got %v", err)
+	}
+
+	letters, _ := storage.ListDeadLetters(context.Background(), 0)
+	if len(letters) != 1 || letters[0].TaskID != "waiting" || letters[0].Attempts != 1 {
+		t.Errorf("Expected the waiting task kept as a dead letter after one attempt, got %+v", letters)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, err := range []error{
+		fmt.Errorf("wrapped: %w", persistence.ErrDatabaseLocked),
+		ai.ErrCircuitBreakerOpen,
+		fmt.Errorf("wrapped: %w", ai.ErrServerError),
+		ai.ErrUnreachable,
+		outline.ErrRateLimited,
+	} {
+		if !worker.IsRetryable(err) {
+			t.Errorf("Expected %v to be retryable", err)
+		}
+	}
+	if worker.IsRetryable(ai.ErrTokenLimitExceeded) {
+		t.Error("Expected a token limit error not to be retryable")
+	}
+}
@@ -0,0 +1,94 @@
+// Package worker runs command processing on a bounded pool of goroutines.
+// Tasks wait in priority lanes, failed tasks are retried with exponential
+// backoff when their error is transient, and tasks that use up their
+// retries are recorded as dead letters.
+package worker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yourusername/outline-ai/internal/ai"
+	"github.com/yourusername/outline-ai/internal/persistence"
+	"github.com/yourusername/outline-ai/pkg/commands"
+)
+
+// Priority selects the lane a task waits in. Higher lanes are always
+// served first.
+type Priority int
+
+// Task priorities, lowest first
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+
+	numPriorities = int(PriorityHigh) + 1
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// Task is one unit of work. Run may be called more than once when it fails
+// with a retryable error, so it must be safe to repeat.
+type Task struct {
+	// ID identifies the task in logs and dead letters
+	ID string
+
+	// Type is the kind of work, usually the command type
+	Type string
+
+	// DocumentID is the document the task works on, if any
+	DocumentID string
+
+	Priority Priority
+	Run      func(ctx context.Context) error
+
+	// attempts counts the runs so far, across retries
+	attempts int
+}
+
+// CommandPriority returns the lane for a command: questions are answered
+// first, bulk /related runs wait behind everything else
+func CommandPriority(t commands.CommandType) Priority {
+	switch t {
+	case commands.CommandAI:
+		return PriorityHigh
+	case commands.CommandRelated:
+		return PriorityLow
+	default:
+		return PriorityNormal
+	}
+}
+
+// IsRetryable extends commands.IsRetryable with the transient errors of the
+// AI client and storage: rate limiting, timeouts, server and connection
+// errors, an open circuit breaker and a locked database
+func IsRetryable(err error) bool {
+	if commands.IsRetryable(err) {
+		return true
+	}
+	for _, transient := range []error{
+		ai.ErrRateLimited,
+		ai.ErrTimeout,
+		ai.ErrServerError,
+		ai.ErrUnreachable,
+		ai.ErrCircuitBreakerOpen,
+		persistence.ErrDatabaseLocked,
+	} {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+// Package commands detects the slash-command markers users type into Outline
+// documents and routes them to handlers. It lives outside internal/ so that
+// other binaries can register their own commands.
+package commands
+
+// CommandType is the marker that starts a command line
+type CommandType string
+
+// Built-in command markers
+const (
+	CommandAI              CommandType = "/ai"            // Question answering
+	CommandAIFile          CommandType = "/ai-file"       // Document filing
+	CommandAIFileUncertain CommandType = "?ai-file"       // Uncertain filing marker left after low confidence
+	CommandSummarize       CommandType = "/summarize"     // Generate summary
+	CommandEnhanceTitle    CommandType = "/enhance-title" // Improve title
+	CommandRelated         CommandType = "/related"       // Find related docs
+	CommandSearchTerms     CommandType = "/search-terms"  // Refresh search terms
+)
+
+// Command is one marker found in a document
+type Command struct {
+	Type CommandType
+
+	// Arguments is the trimmed text after the marker on the same line
+	Arguments string
+
+	// RawText is the whole marker line without its line terminator
+	RawText string
+
+	// Line is the 1-based line number of the marker
+	Line int
+
+	// Start and End are the byte offsets of RawText in the document text,
+	// so text[Start:End] == RawText
+	Start int
+	End   int
+
+	// Supersedes holds the ?ai-file markers made obsolete by this /ai-file
+	// command. Handlers remove them together with the command itself.
+	Supersedes []Command
+}
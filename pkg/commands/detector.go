@@ -0,0 +1,346 @@
+package commands
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// builtinMarkers is ordered so that no marker is checked before a longer
+// marker it prefixes (/ai-file before /ai)
+var builtinMarkers = []CommandType{
+	CommandEnhanceTitle,
+	CommandSummarize,
+	CommandRelated,
+	CommandSearchTerms,
+	CommandAIFile,
+	CommandAIFileUncertain,
+	CommandAI,
+}
+
+// line is one line of the document; end excludes the "\n" or "\r\n"
+type line struct {
+	number int
+	start  int
+	end    int
+}
+
+// Detect scans markdown text and returns its commands in document order.
+//
+// A command is a marker at the start of a line (after at most three spaces
+// of indentation), followed by whitespace or the end of the line. Markers in
+// fenced code blocks, indented code blocks or inline code spans are ignored.
+// When both /ai-file and ?ai-file are present, the ?ai-file markers are
+// attached to the first /ai-file as Supersedes instead of being returned, and
+// their guidance is inherited if the /ai-file line has none.
+func Detect(text string) []Command {
+	return detect(text, builtinMarkers)
+}
+
+// detect finds markers, which must be sorted longest first
+func detect(text string, markers []CommandType) []Command {
+	var (
+		cmds      []Command
+		paragraph []line
+	)
+
+	flush := func() {
+		cmds = append(cmds, detectInParagraph(text, paragraph, markers)...)
+		paragraph = paragraph[:0]
+	}
+
+	var fence Fence
+	for _, ln := range splitLines(text) {
+		content := text[ln.start:ln.end]
+
+		if fence.Line(content) {
+			flush()
+			continue
+		}
+		if strings.TrimFunc(content, isBlank) == "" {
+			flush()
+			continue
+		}
+		paragraph = append(paragraph, ln)
+	}
+	flush()
+
+	return mergeFilingMarkers(cmds)
+}
+
+// Has reports whether text contains a command of the given type
+func Has(text string, cmdType CommandType) bool {
+	for _, cmd := range Detect(text) {
+		if cmd.Type == cmdType {
+			return true
+		}
+		for _, superseded := range cmd.Supersedes {
+			if superseded.Type == cmdType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func splitLines(text string) []line {
+	var lines []line
+	for start, number := 0, 1; start <= len(text); number++ {
+		next := strings.IndexByte(text[start:], '\n')
+		end, following := len(text), len(text)+1
+		if next >= 0 {
+			end, following = start+next, start+next+1
+		}
+
+		content := end
+		if content > start && text[content-1] == '\r' {
+			content--
+		}
+		lines = append(lines, line{number: number, start: start, end: content})
+		start = following
+	}
+	return lines
+}
+
+func detectInParagraph(text string, paragraph []line, markers []CommandType) []Command {
+	if len(paragraph) == 0 {
+		return nil
+	}
+	spans := inlineCodeSpans(text, paragraph[0].start, paragraph[len(paragraph)-1].end)
+
+	var cmds []Command
+	for _, ln := range paragraph {
+		cmd, markerAt, ok := parseLine(text, ln, markers)
+		if !ok || insideSpan(spans, markerAt) {
+			continue
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+// parseLine matches a marker at the start of ln, returning the command and
+// the byte offset where the marker begins
+func parseLine(text string, ln line, markers []CommandType) (Command, int, bool) {
+	content := text[ln.start:ln.end]
+
+	i, indent := 0, 0
+	for i < len(content) {
+		r, size := utf8.DecodeRuneInString(content[i:])
+		switch {
+		case r == ' ':
+			indent++
+		case r == '\t':
+			indent += 4
+		case isBlank(r):
+			// Non-breaking spaces, BOMs and zero-width characters that editors
+			// leave in front of text do not count as indentation
+		default:
+			goto marker
+		}
+		if indent >= 4 {
+			return Command{}, 0, false // indented code block
+		}
+		i += size
+	}
+
+marker:
+	rest := content[i:]
+	for _, m := range markers {
+		if !strings.HasPrefix(rest, string(m)) {
+			continue
+		}
+		after := rest[len(m):]
+		if next, _ := utf8.DecodeRuneInString(after); after != "" && !isBlank(next) {
+			continue // e.g. /ai-files or /aisle
+		}
+		return Command{
+			Type:      m,
+			Arguments: strings.TrimFunc(after, isBlank),
+			RawText:   content,
+			Line:      ln.number,
+			Start:     ln.start,
+			End:       ln.end,
+		}, ln.start + i, true
+	}
+	return Command{}, 0, false
+}
+
+// isBlank reports whitespace plus the invisible format characters that rich
+// editors sprinkle around text
+func isBlank(r rune) bool {
+	switch r {
+	case '\uFEFF', '\u200B', '\u200C', '\u200D', '\u2060':
+		return true
+	}
+	return unicode.IsSpace(r)
+}
+
+// Fence tracks ``` and ~~~ code fences line by line, by the rules Detect
+// uses: an opening fence is indented at most three spaces, and only a run
+// of the same character at least as long closes it. The zero value is
+// outside any fence.
+type Fence struct {
+	char   byte
+	length int
+}
+
+// Line reports whether the line content belongs to a fence, opening and
+// closing fence lines included, and moves past it
+func (f *Fence) Line(content string) bool {
+	if f.open() {
+		f.close(content)
+		return true
+	}
+	return f.start(content)
+}
+
+func (f *Fence) open() bool {
+	return f.length > 0
+}
+
+// start opens a fence if content is an opening fence line
+func (f *Fence) start(content string) bool {
+	char, length, info, ok := fenceRun(content)
+	if !ok || (char == '`' && strings.ContainsRune(info, '`')) {
+		return false
+	}
+	f.char, f.length = char, length
+	return true
+}
+
+// close ends the fence if content is a matching closing fence line
+func (f *Fence) close(content string) {
+	char, length, info, ok := fenceRun(content)
+	if ok && char == f.char && length >= f.length && strings.TrimSpace(info) == "" {
+		f.char, f.length = 0, 0
+	}
+}
+
+// fenceRun parses up to three spaces of indentation followed by three or
+// more backticks or tildes
+func fenceRun(content string) (char byte, length int, info string, ok bool) {
+	i := 0
+	for i < len(content) && i < 3 && content[i] == ' ' {
+		i++
+	}
+	if i >= len(content) || (content[i] != '`' && content[i] != '~') {
+		return 0, 0, "", false
+	}
+
+	char = content[i]
+	j := i
+	for j < len(content) && content[j] == char {
+		j++
+	}
+	if j-i < 3 {
+		return 0, 0, "", false
+	}
+	return char, j - i, content[j:], true
+}
+
+// span is a half-open byte range
+type span struct {
+	start, end int
+}
+
+// inlineCodeSpans finds backtick code spans in text[start:end]. A run of N
+// backticks opens a span that the next run of exactly N backticks closes;
+// an unmatched run is literal text.
+func inlineCodeSpans(text string, start, end int) []span {
+	var spans []span
+	for i := start; i < end; {
+		switch text[i] {
+		case '\\':
+			i += 2
+			continue
+		case '`':
+		default:
+			i++
+			continue
+		}
+
+		open := backtickRun(text, i, end)
+		closeAt := -1
+		for j := i + open; j < end; {
+			if text[j] != '`' {
+				j++
+				continue
+			}
+			run := backtickRun(text, j, end)
+			if run == open {
+				closeAt = j + run
+				break
+			}
+			j += run
+		}
+
+		if closeAt < 0 {
+			i += open
+			continue
+		}
+		spans = append(spans, span{start: i, end: closeAt})
+		i = closeAt
+	}
+	return spans
+}
+
+func backtickRun(text string, i, end int) int {
+	n := 0
+	for i+n < end && text[i+n] == '`' {
+		n++
+	}
+	return n
+}
+
+func insideSpan(spans []span, offset int) bool {
+	for _, s := range spans {
+		if offset > s.start && offset < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeFilingMarkers folds ?ai-file markers into the first /ai-file command
+func mergeFilingMarkers(cmds []Command) []Command {
+	fileIdx := -1
+	for i, cmd := range cmds {
+		if cmd.Type == CommandAIFile {
+			fileIdx = i
+			break
+		}
+	}
+	if fileIdx < 0 {
+		return cmds
+	}
+
+	merged := make([]Command, 0, len(cmds))
+	var superseded []Command
+	for _, cmd := range cmds {
+		if cmd.Type == CommandAIFileUncertain {
+			superseded = append(superseded, cmd)
+			continue
+		}
+		merged = append(merged, cmd)
+	}
+	if len(superseded) == 0 {
+		return cmds
+	}
+
+	for i := range merged {
+		if merged[i].Type != CommandAIFile {
+			continue
+		}
+		merged[i].Supersedes = superseded
+		if merged[i].Arguments == "" {
+			for _, old := range superseded {
+				if old.Arguments != "" {
+					merged[i].Arguments = old.Arguments
+				}
+			}
+		}
+		break
+	}
+	return merged
+}
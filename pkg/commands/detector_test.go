@@ -0,0 +1,227 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func loadDocumentText(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("../../test/fixtures/documents/" + name)
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	var doc struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	return doc.Text
+}
+
+func TestDetect_Fixture(t *testing.T) {
+	text := loadDocumentText(t, "with_commands.json")
+	cmds := Detect(text)
+
+	want := []struct {
+		cmdType CommandType
+		args    string
+		line    int
+	}{
+		{CommandAIFile, "engineering related", 3},
+		{CommandAI, "What is our current API rate limiting policy?", 9},
+		{CommandSummarize, "", 280},
+	}
+
+	if len(cmds) != len(want) {
+		t.Fatalf("Expected %d commands, got %d: %+v", len(want), len(cmds), cmds)
+	}
+	for i, w := range want {
+		cmd := cmds[i]
+		if cmd.Type != w.cmdType || cmd.Arguments != w.args || cmd.Line != w.line {
+			t.Errorf("Command %d: expected %s %q on line %d, got %s %q on line %d",
+				i, w.cmdType, w.args, w.line, cmd.Type, cmd.Arguments, cmd.Line)
+		}
+		if text[cmd.Start:cmd.End] != cmd.RawText {
+			t.Errorf("Command %d: offsets %d-%d do not match raw text %q", i, cmd.Start, cmd.End, cmd.RawText)
+		}
+	}
+}
+
+func TestDetect_Markers(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []CommandType
+	}{
+		{"all built-ins", "/ai q\n/ai-file\n/summarize\n/enhance-title\n/related", []CommandType{CommandAI, CommandAIFile, CommandSummarize, CommandEnhanceTitle, CommandRelated}},
+		{"lone uncertain marker", "Body\n?ai-file engineering", []CommandType{CommandAIFileUncertain}},
+		{"marker must start the line", "Type /ai to ask", nil},
+		{"marker must end at whitespace", "/ai-files\n/aisle\n/summarized", nil},
+		{"three spaces of indent", "   /summarize", []CommandType{CommandSummarize}},
+		{"indented code block", "    /summarize\n\t/related", nil},
+		{"case sensitive", "/AI question", nil},
+		{"empty question still detected", "/ai", []CommandType{CommandAI}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds := Detect(tt.text)
+			if len(cmds) != len(tt.want) {
+				t.Fatalf("Expected %d commands, got %d: %+v", len(tt.want), len(cmds), cmds)
+			}
+			for i, w := range tt.want {
+				if cmds[i].Type != w {
+					t.Errorf("Command %d: expected %s, got %s", i, w, cmds[i].Type)
+				}
+			}
+		})
+	}
+}
+
+func TestDetect_IgnoresCode(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"backtick fence", "```\n/ai inside\n```"},
+		{"tilde fence", "~~~\n/summarize\n~~~"},
+		{"longer closing fence", "````md\n```\n/related\n```\n````"},
+		{"unclosed fence", "```\n/ai never closed"},
+		{"inline code", "`/ai not a command`"},
+		{"inline code spanning lines", "Use ``the\n/summarize marker`` like this"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cmds := Detect(tt.text); len(cmds) != 0 {
+				t.Errorf("Expected no commands, got %+v", cmds)
+			}
+		})
+	}
+
+	t.Run("unmatched backtick is literal", func(t *testing.T) {
+		if cmds := Detect("A stray ` here\n/summarize"); len(cmds) != 1 {
+			t.Errorf("Expected 1 command, got %+v", cmds)
+		}
+	})
+
+	t.Run("commands after a closed fence", func(t *testing.T) {
+		cmds := Detect("```\n/ai inside\n```\n/ai outside")
+		if len(cmds) != 1 || cmds[0].Arguments != "outside" || cmds[0].Line != 4 {
+			t.Errorf("Expected only the command after the fence, got %+v", cmds)
+		}
+	})
+
+	t.Run("code span does not cross paragraphs", func(t *testing.T) {
+		if cmds := Detect("An open `\n\n/related\n\nand a close `"); len(cmds) != 1 {
+			t.Errorf("Expected 1 command, got %+v", cmds)
+		}
+	})
+}
+
+func TestFence_Line(t *testing.T) {
+	lines := []struct {
+		content string
+		fenced  bool
+	}{
+		{"prose", false},
+		{"````md", true},
+		{"~~~", true},
+		{"```", true},
+		{"    ````", true},
+		{"````", true},
+		{"   ~~~", true},
+		{"~~~~", true},
+		{"after", false},
+	}
+
+	var fence Fence
+	for i, ln := range lines {
+		if got := fence.Line(ln.content); got != ln.fenced {
+			t.Errorf("Line %d %q: expected fenced %v, got %v", i+1, ln.content, ln.fenced, got)
+		}
+	}
+}
+
+func TestDetect_CRLF(t *testing.T) {
+	text := "Intro\r\n/ai What is the policy?\r\n```\r\n/summarize\r\n```\r\n/related\r\n"
+	cmds := Detect(text)
+
+	if len(cmds) != 2 {
+		t.Fatalf("Expected 2 commands, got %+v", cmds)
+	}
+	if cmds[0].Arguments != "What is the policy?" {
+		t.Errorf("Expected arguments without the carriage return, got %q", cmds[0].Arguments)
+	}
+	if cmds[1].Type != CommandRelated || cmds[1].Line != 6 {
+		t.Errorf("Expected /related on line 6, got %s on line %d", cmds[1].Type, cmds[1].Line)
+	}
+	for _, cmd := range cmds {
+		if text[cmd.Start:cmd.End] != cmd.RawText {
+			t.Errorf("Offsets %d-%d do not match raw text %q", cmd.Start, cmd.End, cmd.RawText)
+		}
+		if text[cmd.End:cmd.End+2] != "\r\n" {
+			t.Errorf("Expected End to stop before the line terminator, got %q", text[cmd.End:])
+		}
+	}
+}
+
+func TestDetect_Unicode(t *testing.T) {
+	text := "Café résumé 日本語\n\uFEFF\u200B/ai Qu'est-ce que la «politique» ? \n/ai-filé"
+	cmds := Detect(text)
+
+	if len(cmds) != 1 {
+		t.Fatalf("Expected 1 command, got %+v", cmds)
+	}
+	cmd := cmds[0]
+	if cmd.Arguments != "Qu'est-ce que la «politique» ?" {
+		t.Errorf("Expected trimmed unicode arguments, got %q", cmd.Arguments)
+	}
+	if cmd.Line != 2 {
+		t.Errorf("Expected line 2, got %d", cmd.Line)
+	}
+	if text[cmd.Start:cmd.End] != cmd.RawText {
+		t.Errorf("Offsets %d-%d do not match raw text %q", cmd.Start, cmd.End, cmd.RawText)
+	}
+}
+
+func TestDetect_DualFilingMarkers(t *testing.T) {
+	t.Run("/ai-file supersedes ?ai-file", func(t *testing.T) {
+		text := "?ai-file engineering\nBody\n/ai-file\n/summarize"
+		cmds := Detect(text)
+
+		if len(cmds) != 2 {
+			t.Fatalf("Expected 2 commands, got %+v", cmds)
+		}
+		file := cmds[0]
+		if file.Type != CommandAIFile || file.Line != 3 {
+			t.Fatalf("Expected /ai-file on line 3 first, got %s on line %d", file.Type, file.Line)
+		}
+		if len(file.Supersedes) != 1 || file.Supersedes[0].Line != 1 {
+			t.Errorf("Expected ?ai-file on line 1 to be superseded, got %+v", file.Supersedes)
+		}
+		if file.Arguments != "engineering" {
+			t.Errorf("Expected /ai-file to inherit guidance, got %q", file.Arguments)
+		}
+	})
+
+	t.Run("explicit guidance wins", func(t *testing.T) {
+		cmds := Detect("/ai-file marketing\n?ai-file engineering")
+		if len(cmds) != 1 || cmds[0].Arguments != "marketing" {
+			t.Errorf("Expected /ai-file to keep its own guidance, got %+v", cmds)
+		}
+	})
+
+	t.Run("Has sees superseded markers", func(t *testing.T) {
+		text := "/ai-file\n?ai-file"
+		if !Has(text, CommandAIFileUncertain) || !Has(text, CommandAIFile) {
+			t.Error("Expected both markers to be reported")
+		}
+		if Has(text, CommandRelated) {
+			t.Error("Expected /related not to be reported")
+		}
+	})
+}
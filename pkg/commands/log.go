@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"context"
+	"time"
+)
+
+// Command status constants
+const (
+	StatusSuccess  = "success"
+	StatusFailed   = "failed"
+	StatusRetrying = "retrying"
+)
+
+// CommandLog is the record the router writes for every handled command
+type CommandLog struct {
+	ID              int64
+	DocumentID      string
+	CommandType     string
+	CommandArgs     *string
+	ExecutedAt      time.Time
+	Status          string
+	ErrorMessage    *string
+	ExecutionTimeMs *int
+	CreatedAt       time.Time
+
+	// Where the command ran and who ran it, for usage reports. Both are
+	// empty when unknown.
+	CollectionID string
+	UserID       string
+
+	// Tokens billed for the command's AI calls and their cost
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+
+	// CorrelationID links the entry to the audit entries of the changes
+	// the command made
+	CorrelationID string
+}
+
+// Logger records command executions. The service's storage satisfies it.
+type Logger interface {
+	LogCommand(ctx context.Context, log *CommandLog) error
+}
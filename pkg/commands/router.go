@@ -0,0 +1,277 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/yourusername/outline-ai/pkg/outline"
+)
+
+// Package-level errors for command routing
+var (
+	ErrNoHandler          = errors.New("commands: no handler registered")
+	ErrDuplicateHandler   = errors.New("commands: handler already registered")
+	ErrInvalidCommandType = errors.New("commands: invalid command type")
+)
+
+// Document is the Outline document a command was found in
+type Document = outline.Document
+
+// Result describes what a handler did
+type Result struct {
+	// Message is a short description of the outcome, e.g. "moved to Engineering"
+	Message string
+
+	// LogArgs replaces the command arguments in the command log when set,
+	// so a handler can record what it needs to trace or revert its change
+	LogArgs *string
+}
+
+// Handler executes one command type
+type Handler interface {
+	Handle(ctx context.Context, doc *Document, cmd Command) (Result, error)
+}
+
+// HandlerFunc adapts an ordinary function to a Handler
+type HandlerFunc func(ctx context.Context, doc *Document, cmd Command) (Result, error)
+
+// Handle calls f(ctx, doc, cmd)
+func (f HandlerFunc) Handle(ctx context.Context, doc *Document, cmd Command) (Result, error) {
+	return f(ctx, doc, cmd)
+}
+
+// Hook runs around every handler. It may fill in entry and returns the
+// context the handler runs with, plus a function the router calls once the
+// handler returns and before entry is logged. finish may be nil.
+type Hook func(ctx context.Context, doc *Document, entry *CommandLog) (handlerCtx context.Context, finish func())
+
+// Option configures a Router
+type Option func(*Router)
+
+// WithRetryClassifier decides which handler errors are logged as retrying
+// instead of failed. The default is IsRetryable.
+func WithRetryClassifier(retryable func(error) bool) Option {
+	return func(r *Router) {
+		r.retryable = retryable
+	}
+}
+
+// WithHook adds a hook that runs around every handler. Hooks run in the
+// order they were added and finish in reverse.
+func WithHook(hook Hook) Option {
+	return func(r *Router) {
+		r.hooks = append(r.hooks, hook)
+	}
+}
+
+// WithSlog sets where the router reports errors it cannot return, such as a
+// failed write to the command log
+func WithSlog(logger *slog.Logger) Option {
+	return func(r *Router) {
+		r.slog = logger
+	}
+}
+
+// WithClock overrides the time source, mainly for tests
+func WithClock(now func() time.Time) Option {
+	return func(r *Router) {
+		r.now = now
+	}
+}
+
+// Router maps command types to handlers and logs every execution
+type Router struct {
+	mu       sync.RWMutex
+	handlers map[CommandType]Handler
+	markers  []CommandType
+
+	logger    Logger
+	hooks     []Hook
+	retryable func(error) bool
+	slog      *slog.Logger
+	now       func() time.Time
+}
+
+// NewRouter creates an empty router that records executions through logger
+func NewRouter(logger Logger, opts ...Option) *Router {
+	r := &Router{
+		handlers:  make(map[CommandType]Handler),
+		markers:   append([]CommandType(nil), builtinMarkers...),
+		logger:    logger,
+		retryable: IsRetryable,
+		slog:      slog.Default(),
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Register adds the handler for cmdType. Types other than the built-in ones
+// become detectable too; they must start with "/" or "?" and contain no
+// whitespace.
+func (r *Router) Register(cmdType CommandType, handler Handler) error {
+	if err := validateCommandType(cmdType); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.handlers[cmdType]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateHandler, cmdType)
+	}
+	r.handlers[cmdType] = handler
+
+	for _, m := range r.markers {
+		if m == cmdType {
+			return nil
+		}
+	}
+
+	// Detect reads markers without the lock, so replace rather than modify
+	markers := append(append([]CommandType(nil), r.markers...), cmdType)
+	sort.SliceStable(markers, func(i, j int) bool {
+		return len(markers[i]) > len(markers[j])
+	})
+	r.markers = markers
+	return nil
+}
+
+// CommandTypes returns the registered command types in sorted order
+func (r *Router) CommandTypes() []CommandType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]CommandType, 0, len(r.handlers))
+	for t := range r.handlers {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// Detect returns the commands in text that have a registered handler
+func (r *Router) Detect(text string) []Command {
+	r.mu.RLock()
+	markers := r.markers
+	r.mu.RUnlock()
+
+	var handled []Command
+	for _, cmd := range detect(text, markers) {
+		if r.handler(cmd.Type) != nil {
+			handled = append(handled, cmd)
+		}
+	}
+	return handled
+}
+
+// Route runs the handler for cmd and logs the outcome with its execution
+// time and whatever the hooks add to the entry. The handler's error is
+// returned unchanged.
+func (r *Router) Route(ctx context.Context, doc *Document, cmd Command) (Result, error) {
+	handler := r.handler(cmd.Type)
+	if handler == nil {
+		return Result{}, fmt.Errorf("%w: %s", ErrNoHandler, cmd.Type)
+	}
+
+	// Handlers may move the document, so note where the command ran first
+	entry := &CommandLog{
+		DocumentID:   doc.ID,
+		CommandType:  string(cmd.Type),
+		Status:       StatusSuccess,
+		CollectionID: doc.CollectionID,
+	}
+	if doc.UpdatedBy != nil {
+		entry.UserID = doc.UpdatedBy.ID
+	}
+
+	handlerCtx := ctx
+	finishers := make([]func(), 0, len(r.hooks))
+	for _, hook := range r.hooks {
+		var finish func()
+		handlerCtx, finish = hook(handlerCtx, doc, entry)
+		if finish != nil {
+			finishers = append(finishers, finish)
+		}
+	}
+
+	start := r.now()
+	result, err := handler.Handle(handlerCtx, doc, cmd)
+	elapsed := int(r.now().Sub(start).Milliseconds())
+
+	for i := len(finishers) - 1; i >= 0; i-- {
+		finishers[i]()
+	}
+
+	entry.CommandArgs = result.LogArgs
+	entry.ExecutedAt = start
+	entry.ExecutionTimeMs = &elapsed
+	if entry.CommandArgs == nil && cmd.Arguments != "" {
+		entry.CommandArgs = &cmd.Arguments
+	}
+	if err != nil {
+		msg := err.Error()
+		entry.ErrorMessage = &msg
+		entry.Status = StatusFailed
+		if r.retryable(err) {
+			entry.Status = StatusRetrying
+		}
+	}
+
+	// Logging must not turn a finished command into a failure that the
+	// caller would retry, so a failed write is only reported
+	if r.logger != nil {
+		if logErr := r.logger.LogCommand(context.WithoutCancel(ctx), entry); logErr != nil {
+			r.slog.Error("failed to log command",
+				"document_id", doc.ID,
+				"command", string(cmd.Type),
+				"error", logErr)
+		}
+	}
+
+	return result, err
+}
+
+func (r *Router) handler(cmdType CommandType) Handler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.handlers[cmdType]
+}
+
+func validateCommandType(cmdType CommandType) error {
+	s := string(cmdType)
+	if len(s) < 2 || (s[0] != '/' && s[0] != '?') {
+		return fmt.Errorf("%w: %q must start with / or ?", ErrInvalidCommandType, s)
+	}
+	if strings.IndexFunc(s, func(r rune) bool { return unicode.IsSpace(r) || r == '`' }) >= 0 {
+		return fmt.Errorf("%w: %q contains whitespace or backticks", ErrInvalidCommandType, s)
+	}
+	return nil
+}
+
+// IsRetryable reports whether err is transient as far as this package can
+// tell: Outline rate limiting or server errors, or a context that ended
+// before the command finished. The service extends it with the errors of
+// its AI client and storage.
+func IsRetryable(err error) bool {
+	for _, transient := range []error{
+		context.Canceled,
+		context.DeadlineExceeded,
+		outline.ErrRateLimited,
+		outline.ErrServerError,
+	} {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,234 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/yourusername/outline-ai/pkg/commands"
+	"github.com/yourusername/outline-ai/pkg/outline"
+	"github.com/yourusername/outline-ai/test/mocks"
+)
+
+var doc = &commands.Document{ID: "doc-1", Title: "API Design"}
+
+func newRouter(t *testing.T) (*commands.Router, *mocks.StorageMock) {
+	t.Helper()
+	storage := mocks.NewStorageMock()
+
+	// Each call to the clock advances it, so every handler takes 25ms
+	now := time.Date(2026, 1, 19, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		now = now.Add(25 * time.Millisecond)
+		return now
+	}
+
+	quiet := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return commands.NewRouter(storage, commands.WithClock(clock), commands.WithSlog(quiet)), storage
+}
+
+func lastLog(t *testing.T, storage *mocks.StorageMock) *commands.CommandLog {
+	t.Helper()
+	history, err := storage.GetCommandHistory(context.Background(), doc.ID, 1)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("Expected a command log entry, got %v (%v)", history, err)
+	}
+	return history[0]
+}
+
+func TestRouter_RouteLogsOutcome(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		handlerErr error
+		wantStatus string
+	}{
+		{"success", nil, commands.StatusSuccess},
+		{"permanent failure", outline.ErrInvalidRequest, commands.StatusFailed},
+		{"transient failure", fmt.Errorf("move: %w", outline.ErrRateLimited), commands.StatusRetrying},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, storage := newRouter(t)
+			router.Register(commands.CommandSummarize, commands.HandlerFunc(
+				func(ctx context.Context, d *commands.Document, cmd commands.Command) (commands.Result, error) {
+					return commands.Result{Message: "summarized"}, tt.handlerErr
+				}))
+
+			cmd := commands.Detect("/summarize")[0]
+			result, err := router.Route(ctx, doc, cmd)
+			if !errors.Is(err, tt.handlerErr) {
+				t.Errorf("Expected handler error %v, got %v", tt.handlerErr, err)
+			}
+			if result.Message != "summarized" {
+				t.Errorf("Expected handler result, got %+v", result)
+			}
+
+			entry := lastLog(t, storage)
+			if entry.Status != tt.wantStatus {
+				t.Errorf("Expected status %s, got %s", tt.wantStatus, entry.Status)
+			}
+			if entry.CommandType != "/summarize" {
+				t.Errorf("Expected command type /summarize, got %s", entry.CommandType)
+			}
+			if entry.ExecutionTimeMs == nil || *entry.ExecutionTimeMs != 25 {
+				t.Errorf("Expected execution time of 25ms, got %v", entry.ExecutionTimeMs)
+			}
+			if (entry.ErrorMessage != nil) != (tt.handlerErr != nil) {
+				t.Errorf("Expected error message only on failure, got %v", entry.ErrorMessage)
+			}
+		})
+	}
+}
+
+func TestRouter_CommandArgs(t *testing.T) {
+	ctx := context.Background()
+	router, storage := newRouter(t)
+
+	router.Register(commands.CommandAI, commands.HandlerFunc(
+		func(ctx context.Context, d *commands.Document, cmd commands.Command) (commands.Result, error) {
+			return commands.Result{}, nil
+		}))
+	router.Register(commands.CommandEnhanceTitle, commands.HandlerFunc(
+		func(ctx context.Context, d *commands.Document, cmd commands.Command) (commands.Result, error) {
+			oldTitle := d.Title
+			return commands.Result{LogArgs: &oldTitle}, nil
+		}))
+
+	router.Route(ctx, doc, commands.Command{Type: commands.CommandAI, Arguments: "What is the policy?"})
+	if entry := lastLog(t, storage); entry.CommandArgs == nil || *entry.CommandArgs != "What is the policy?" {
+		t.Errorf("Expected command arguments to be logged, got %v", entry.CommandArgs)
+	}
+
+	router.Route(ctx, doc, commands.Command{Type: commands.CommandEnhanceTitle})
+	if entry := lastLog(t, storage); entry.CommandArgs == nil || *entry.CommandArgs != "API Design" {
+		t.Errorf("Expected handler-provided log arguments, got %v", entry.CommandArgs)
+	}
+}
+
+func TestRouter_Hooks(t *testing.T) {
+	type key struct{}
+	var order []string
+	hook := func(name string) commands.Hook {
+		return func(ctx context.Context, d *commands.Document, entry *commands.CommandLog) (context.Context, func()) {
+			order = append(order, "start "+name)
+			return context.WithValue(ctx, key{}, name), func() {
+				order = append(order, "finish "+name)
+				entry.PromptTokens += 100
+				entry.CorrelationID = name
+			}
+		}
+	}
+
+	storage := mocks.NewStorageMock()
+	router := commands.NewRouter(storage, commands.WithHook(hook("outer")), commands.WithHook(hook("inner")))
+	router.Register(commands.CommandRelated, commands.HandlerFunc(
+		func(ctx context.Context, d *commands.Document, cmd commands.Command) (commands.Result, error) {
+			order = append(order, "handle under "+ctx.Value(key{}).(string))
+			d.CollectionID = "col-moved"
+			return commands.Result{}, nil
+		}))
+
+	filed := &commands.Document{ID: doc.ID, CollectionID: "col-1", UpdatedBy: &outline.User{ID: "user-1", Name: "Ada"}}
+	router.Route(context.Background(), filed, commands.Command{Type: commands.CommandRelated})
+
+	want := []string{"start outer", "start inner", "handle under inner", "finish inner", "finish outer"}
+	if fmt.Sprint(order) != fmt.Sprint(want) {
+		t.Errorf("Expected hooks to wrap the handler as %v, got %v", want, order)
+	}
+	entry := lastLog(t, storage)
+	if entry.PromptTokens != 200 || entry.CorrelationID != "outer" {
+		t.Errorf("Expected both hooks to fill in the entry, got %d tokens and %q", entry.PromptTokens, entry.CorrelationID)
+	}
+	if entry.CollectionID != "col-1" || entry.UserID != "user-1" {
+		t.Errorf("Expected the collection the command ran in and its user, got %q and %q", entry.CollectionID, entry.UserID)
+	}
+}
+
+func TestRouter_Registration(t *testing.T) {
+	router, storage := newRouter(t)
+	noop := commands.HandlerFunc(func(ctx context.Context, d *commands.Document, cmd commands.Command) (commands.Result, error) {
+		return commands.Result{}, nil
+	})
+
+	if err := router.Register(commands.CommandRelated, noop); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := router.Register(commands.CommandRelated, noop); !errors.Is(err, commands.ErrDuplicateHandler) {
+		t.Errorf("Expected ErrDuplicateHandler, got %v", err)
+	}
+	for _, invalid := range []commands.CommandType{"", "/", "translate", "/two words", "/`code`"} {
+		if err := router.Register(invalid, noop); !errors.Is(err, commands.ErrInvalidCommandType) {
+			t.Errorf("Expected ErrInvalidCommandType for %q, got %v", invalid, err)
+		}
+	}
+
+	if _, err := router.Route(context.Background(), doc, commands.Command{Type: commands.CommandAI}); !errors.Is(err, commands.ErrNoHandler) {
+		t.Errorf("Expected ErrNoHandler, got %v", err)
+	}
+	if got := storage.GetCallCount("LogCommand"); got != 0 {
+		t.Errorf("Expected unrouted command not to be logged, got %d calls", got)
+	}
+}
+
+func TestRouter_CustomCommands(t *testing.T) {
+	router, _ := newRouter(t)
+	noop := commands.HandlerFunc(func(ctx context.Context, d *commands.Document, cmd commands.Command) (commands.Result, error) {
+		return commands.Result{}, nil
+	})
+	router.Register("/translate", noop)
+	router.Register("/translate-all", noop)
+	router.Register(commands.CommandAI, noop)
+
+	text := "/translate fr\n/translate-all\n```\n/translate de\n```\n/summarize\n/ai question"
+	cmds := router.Detect(text)
+
+	want := []commands.CommandType{"/translate", "/translate-all", commands.CommandAI}
+	if len(cmds) != len(want) {
+		t.Fatalf("Expected %d commands, got %+v", len(want), cmds)
+	}
+	for i, w := range want {
+		if cmds[i].Type != w {
+			t.Errorf("Command %d: expected %s, got %s", i, w, cmds[i].Type)
+		}
+	}
+	if cmds[0].Arguments != "fr" {
+		t.Errorf("Expected custom command arguments, got %q", cmds[0].Arguments)
+	}
+
+	types := router.CommandTypes()
+	if len(types) != 3 || types[0] != "/ai" {
+		t.Errorf("Expected sorted registered types, got %v", types)
+	}
+}
+
+func TestRouter_LogFailureDoesNotFailCommand(t *testing.T) {
+	router, storage := newRouter(t)
+	router.Register(commands.CommandSummarize, commands.HandlerFunc(
+		func(ctx context.Context, d *commands.Document, cmd commands.Command) (commands.Result, error) {
+			return commands.Result{}, nil
+		}))
+
+	storage.SetMethodError("LogCommand", errors.New("database is locked"))
+	if _, err := router.Route(context.Background(), doc, commands.Command{Type: commands.CommandSummarize}); err != nil {
+		t.Errorf("Expected completed command to succeed despite logging failure, got %v", err)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	if !commands.IsRetryable(fmt.Errorf("wrapped: %w", outline.ErrServerError)) {
+		t.Error("Expected an Outline server error to be retryable")
+	}
+	if !commands.IsRetryable(context.DeadlineExceeded) {
+		t.Error("Expected an expired context to be retryable")
+	}
+	if commands.IsRetryable(outline.ErrNotFound) {
+		t.Error("Expected a missing document not to be retryable")
+	}
+}
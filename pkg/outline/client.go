@@ -0,0 +1,37 @@
+package outline
+
+import (
+	"context"
+	"errors"
+)
+
+// Package-level errors returned by every Client
+var (
+	ErrUnauthorized   = errors.New("outline: unauthorized")
+	ErrNotFound       = errors.New("outline: not found")
+	ErrRateLimited    = errors.New("outline: rate limited")
+	ErrServerError    = errors.New("outline: server error")
+	ErrInvalidRequest = errors.New("outline: invalid request")
+)
+
+// Client is the contract every Outline backend and decorator implements
+type Client interface {
+	// Collections
+	ListCollections(ctx context.Context) ([]*Collection, error)
+	GetCollection(ctx context.Context, id string) (*Collection, error)
+
+	// Documents
+	GetDocument(ctx context.Context, id string) (*Document, error)
+	ListDocuments(ctx context.Context, collectionID string) ([]*Document, error)
+	CreateDocument(ctx context.Context, req *CreateDocumentRequest) (*Document, error)
+	UpdateDocument(ctx context.Context, id string, req *UpdateDocumentRequest) (*Document, error)
+	MoveDocument(ctx context.Context, id string, collectionID string) error
+	SearchDocuments(ctx context.Context, query string, opts *SearchOptions) (*SearchResult, error)
+
+	// Comments
+	CreateComment(ctx context.Context, req *CreateCommentRequest) (*Comment, error)
+	ListComments(ctx context.Context, documentID string) ([]*Comment, error)
+
+	// Health
+	Ping(ctx context.Context) error
+}
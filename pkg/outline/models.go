@@ -0,0 +1,179 @@
+// Package outline defines the Outline models and the client contract that
+// command handlers act on documents through. Handlers outside this module
+// receive a Client from the service, already rate limited, counted and
+// audited; the HTTP implementation stays internal.
+package outline
+
+import (
+	"strings"
+	"time"
+)
+
+// Collection represents an Outline collection
+type Collection struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Document represents an Outline document
+type Document struct {
+	ID           string     `json:"id"`
+	CollectionID string     `json:"collectionId"`
+	Title        string     `json:"title"`
+	Text         string     `json:"text"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	PublishedAt  *time.Time `json:"publishedAt,omitempty"`
+	// UpdatedBy is who saved the latest version, i.e. who typed a command
+	UpdatedBy *User `json:"updatedBy,omitempty"`
+}
+
+// User is the summary of an Outline user embedded in other models
+type User struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// CreateDocumentRequest is the request to create a document
+type CreateDocumentRequest struct {
+	CollectionID     string  `json:"collectionId"`
+	Title            string  `json:"title"`
+	Text             string  `json:"text"`
+	Publish          bool    `json:"publish"`
+	ParentDocumentID *string `json:"parentDocumentId,omitempty"`
+}
+
+// UpdateDocumentRequest is the request to update a document. An empty
+// Title or a nil Text leaves that field unchanged; a Text pointing to ""
+// empties the document.
+type UpdateDocumentRequest struct {
+	Title string  `json:"title,omitempty"`
+	Text  *string `json:"text,omitempty"`
+	Done  bool    `json:"done,omitempty"`
+}
+
+// String returns a pointer to s, for UpdateDocumentRequest.Text
+func String(s string) *string {
+	return &s
+}
+
+// Comment represents an Outline comment with its body flattened to plain text
+type Comment struct {
+	ID         string    `json:"id"`
+	DocumentID string    `json:"documentId"`
+	Data       string    `json:"data"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// CommentContent represents comment content structure
+type CommentContent struct {
+	Type    string        `json:"type"`
+	Content []ContentNode `json:"content,omitempty"`
+}
+
+// ContentNode represents a node in comment content
+type ContentNode struct {
+	Type    string        `json:"type"`
+	Text    string        `json:"text,omitempty"`
+	Marks   []Mark        `json:"marks,omitempty"`
+	Content []ContentNode `json:"content,omitempty"`
+}
+
+// Mark is inline formatting on a text node, such as a link or bold text
+type Mark struct {
+	Type  string            `json:"type"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
+
+// TextNode builds a plain text node
+func TextNode(text string) ContentNode {
+	return ContentNode{Type: "text", Text: text}
+}
+
+// LinkNode builds a text node linking to href
+func LinkNode(text, href string) ContentNode {
+	return ContentNode{
+		Type:  "text",
+		Text:  text,
+		Marks: []Mark{{Type: "link", Attrs: map[string]string{"href": href}}},
+	}
+}
+
+// ParagraphNode builds a paragraph from inline nodes
+func ParagraphNode(inline ...ContentNode) ContentNode {
+	return ContentNode{Type: "paragraph", Content: inline}
+}
+
+// CreateCommentRequest is the request to create a comment
+type CreateCommentRequest struct {
+	DocumentID string         `json:"documentId"`
+	Data       CommentContent `json:"data"`
+}
+
+// SearchOptions contains options for document search
+type SearchOptions struct {
+	Limit        int    `json:"limit,omitempty"`
+	Offset       int    `json:"offset,omitempty"`
+	CollectionID string `json:"collectionId,omitempty"`
+}
+
+// SearchResult contains search results
+type SearchResult struct {
+	Documents  []*Document
+	TotalCount int
+}
+
+// NewCommentContent builds a single-paragraph comment body from plain text
+func NewCommentContent(text string) CommentContent {
+	return CommentContent{
+		Type: "doc",
+		Content: []ContentNode{
+			{
+				Type: "paragraph",
+				Content: []ContentNode{
+					{
+						Type: "text",
+						Text: text,
+					},
+				},
+			},
+		},
+	}
+}
+
+// NewCommentParagraphs builds a comment body with one paragraph per entry
+func NewCommentParagraphs(paragraphs ...string) CommentContent {
+	content := CommentContent{Type: "doc", Content: make([]ContentNode, 0, len(paragraphs))}
+	for _, text := range paragraphs {
+		paragraph := ParagraphNode()
+		if text != "" {
+			// Outline rejects empty text nodes, so a blank paragraph has none
+			paragraph.Content = []ContentNode{TextNode(text)}
+		}
+		content.Content = append(content.Content, paragraph)
+	}
+	return content
+}
+
+// PlainText flattens the comment content into its text, one line per block node
+func (c CommentContent) PlainText() string {
+	lines := make([]string, 0, len(c.Content))
+	for _, node := range c.Content {
+		lines = append(lines, node.plainText())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (n ContentNode) plainText() string {
+	if n.Text != "" {
+		return n.Text
+	}
+	var builder strings.Builder
+	for _, child := range n.Content {
+		builder.WriteString(child.plainText())
+	}
+	return builder.String()
+}
@@ -0,0 +1,104 @@
+// Package outlineai runs the Outline AI assistant from a main package, so
+// another module can build its own binary with extra commands next to the
+// built-in ones:
+//
+//	func main() {
+//		os.Exit(outlineai.Main(os.Args[1:],
+//			outlineai.WithHandler("/translate", newTranslateHandler)))
+//	}
+//
+// Handlers act on documents through the pkg/outline client they are given,
+// which is rate limited, counted in the metrics and recorded in the audit
+// log like the built-in handlers' client.
+package outlineai
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/yourusername/outline-ai/internal/config"
+	"github.com/yourusername/outline-ai/internal/service"
+	"github.com/yourusername/outline-ai/pkg/commands"
+	"github.com/yourusername/outline-ai/pkg/outline"
+)
+
+// Option configures Main
+type Option func(*options)
+
+type options struct {
+	version  string
+	handlers []service.Option
+}
+
+// WithVersion sets the version reported at startup (default "dev")
+func WithVersion(version string) Option {
+	return func(o *options) {
+		o.version = version
+	}
+}
+
+// WithHandler registers a handler for cmdType, which must start with "/"
+// or "?" and must not be a built-in command. build is called once at
+// startup with the service's Outline client.
+func WithHandler(cmdType commands.CommandType, build func(client outline.Client) commands.Handler) Option {
+	return func(o *options) {
+		o.handlers = append(o.handlers, service.WithHandler(cmdType, build))
+	}
+}
+
+// Main loads the configuration from defaults, a YAML file, OUTLINE_AI_*
+// environment variables and the flags in args, then runs the service until
+// SIGTERM or SIGINT. It returns the process exit code: 0 after a clean
+// stop or -h, 1 when the service fails and 2 for invalid configuration.
+func Main(args []string, opts ...Option) int {
+	o := options{version: "dev"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cfg, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	logger := newLogger(cfg.Logging)
+	slog.SetDefault(logger)
+	logger.Info("outline-ai: starting", "version", o.version, "config", cfg.Redacted())
+
+	svc, err := service.New(cfg, append([]service.Option{service.WithSlog(logger)}, o.handlers...)...)
+	if err != nil {
+		logger.Error("outline-ai: failed to create service", "error", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	if err := svc.Run(ctx); err != nil {
+		logger.Error("outline-ai: stopped with errors", "error", err)
+		return 1
+	}
+	logger.Info("outline-ai: stopped")
+	return 0
+}
+
+// newLogger builds the logger described by cfg, which Load has validated
+func newLogger(cfg config.LoggingConfig) *slog.Logger {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(cfg.Level))
+
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.Format == "text" {
+		return slog.New(slog.NewTextHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+}
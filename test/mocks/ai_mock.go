@@ -2,149 +2,28 @@ package mocks
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"sync"
-)
 
-// Package-level errors matching ai package
-var (
-	ErrCircuitBreakerOpen = errors.New("ai: circuit breaker open")
-	ErrInvalidResponse    = errors.New("ai: invalid response")
-	ErrTimeout            = errors.New("ai: request timeout")
-	ErrTokenLimitExceeded = errors.New("ai: token limit exceeded")
-	ErrAIRateLimited      = errors.New("ai: rate limited by provider")
+	"github.com/yourusername/outline-ai/internal/ai"
 )
 
-// AI Domain Models
-
-// TaxonomyCollection represents a collection in taxonomy context
-type TaxonomyCollection struct {
-	ID              string   `json:"id"`
-	Name            string   `json:"name"`
-	Description     string   `json:"description"`
-	SampleDocuments []string `json:"sample_documents,omitempty"`
-}
-
-// TaxonomyContext wraps taxonomy information
-type TaxonomyContext struct {
-	Collections []TaxonomyCollection `json:"collections"`
-}
-
-// ClassificationRequest is the request for document classification
-type ClassificationRequest struct {
-	DocumentTitle   string           `json:"document_title"`
-	DocumentContent string           `json:"document_content"`
-	UserGuidance    string           `json:"user_guidance,omitempty"`
-	Taxonomy        *TaxonomyContext `json:"taxonomy"`
-}
-
-// ClassificationResponse is the response from classification
-type ClassificationResponse struct {
-	CollectionID string                      `json:"collection_id"`
-	Confidence   float64                     `json:"confidence"`
-	Reasoning    string                      `json:"reasoning"`
-	Alternatives []AlternativeClassification `json:"alternatives,omitempty"`
-	SearchTerms  []string                    `json:"search_terms"`
-}
-
-// AlternativeClassification represents an alternative classification
-type AlternativeClassification struct {
-	CollectionID string  `json:"collection_id"`
-	Confidence   float64 `json:"confidence"`
-	Reasoning    string  `json:"reasoning"`
-}
-
-// ContextDocument represents a document for context
-type ContextDocument struct {
-	Title   string `json:"title"`
-	Excerpt string `json:"excerpt"`
-	URL     string `json:"url"`
-}
-
-// QuestionRequest is the request for question answering
-type QuestionRequest struct {
-	Question    string            `json:"question"`
-	ContextDocs []ContextDocument `json:"context_documents"`
-}
-
-// QuestionResponse is the response from question answering
-type QuestionResponse struct {
-	Answer     string         `json:"answer"`
-	Citations  []CitationInfo `json:"citations"`
-	Confidence float64        `json:"confidence"`
-}
-
-// CitationInfo represents a citation
-type CitationInfo struct {
-	DocumentTitle string `json:"document_title"`
-	DocumentURL   string `json:"document_url"`
-}
-
-// SummaryRequest is the request for summary generation
-type SummaryRequest struct {
-	DocumentTitle   string `json:"document_title"`
-	DocumentContent string `json:"document_content"`
-}
-
-// SummaryResponse is the response from summary generation
-type SummaryResponse struct {
-	Summary string `json:"summary"`
-}
-
-// TitleRequest is the request for title enhancement
-type TitleRequest struct {
-	CurrentTitle    string `json:"current_title"`
-	DocumentContent string `json:"document_content"`
-}
-
-// TitleResponse is the response from title enhancement
-type TitleResponse struct {
-	SuggestedTitle string  `json:"suggested_title"`
-	Confidence     float64 `json:"confidence"`
-}
-
-// SearchTermsRequest is the request for search terms generation
-type SearchTermsRequest struct {
-	DocumentTitle   string `json:"document_title"`
-	DocumentContent string `json:"document_content"`
-}
-
-// SearchTermsResponse is the response from search terms generation
-type SearchTermsResponse struct {
-	SearchTerms []string `json:"search_terms"`
-}
-
-// RelatedDocsRequest is the request for finding related documents
-type RelatedDocsRequest struct {
-	DocumentTitle   string   `json:"document_title"`
-	DocumentContent string   `json:"document_content"`
-	AvailableDocs   []string `json:"available_documents"`
-}
-
-// RelatedDocsResponse is the response from finding related documents
-type RelatedDocsResponse struct {
-	RelatedDocuments []RelatedDocument `json:"related_documents"`
-}
-
-// RelatedDocument represents a related document
-type RelatedDocument struct {
-	Title     string  `json:"title"`
-	Relevance float64 `json:"relevance"`
-	Reason    string  `json:"reason"`
-}
+var _ ai.Client = (*AIMock)(nil)
 
 // AIMock is a mock implementation of the ai.Client interface
 type AIMock struct {
 	mu sync.RWMutex
 
 	// Configured responses
-	classificationResponse *ClassificationResponse
-	questionResponse       *QuestionResponse
-	summaryResponse        *SummaryResponse
-	titleResponse          *TitleResponse
-	searchTermsResponse    *SearchTermsResponse
-	relatedDocsResponse    *RelatedDocsResponse
+	classificationResponse *ai.ClassificationResponse
+	questionResponse       *ai.QuestionResponse
+	summaryResponse        *ai.SummaryResponse
+	titleResponse          *ai.TitleResponse
+	searchTermsResponse    *ai.SearchTermsResponse
+	relatedDocsResponse    *ai.RelatedDocsResponse
+
+	// Token usage recorded for every successful call
+	usage ai.Usage
 
 	// Error configuration
 	circuitBreakerOpen bool
@@ -169,34 +48,34 @@ func NewAIMock() *AIMock {
 		lastCalls:      make(map[string]any),
 
 		// Default responses
-		classificationResponse: &ClassificationResponse{
+		classificationResponse: &ai.ClassificationResponse{
 			CollectionID: "default-collection",
 			Confidence:   0.85,
 			Reasoning:    "Default mock classification",
 			SearchTerms:  []string{"mock", "test", "default"},
 		},
-		questionResponse: &QuestionResponse{
+		questionResponse: &ai.QuestionResponse{
 			Answer:     "This is a mock answer based on the provided context.",
 			Confidence: 0.9,
-			Citations: []CitationInfo{
+			Citations: []ai.CitationInfo{
 				{
 					DocumentTitle: "Mock Document",
 					DocumentURL:   "https://example.com/mock",
 				},
 			},
 		},
-		summaryResponse: &SummaryResponse{
+		summaryResponse: &ai.SummaryResponse{
 			Summary: "This is a mock summary of the document content.",
 		},
-		titleResponse: &TitleResponse{
+		titleResponse: &ai.TitleResponse{
 			SuggestedTitle: "Enhanced Mock Title",
 			Confidence:     0.88,
 		},
-		searchTermsResponse: &SearchTermsResponse{
+		searchTermsResponse: &ai.SearchTermsResponse{
 			SearchTerms: []string{"mock", "test", "document", "example"},
 		},
-		relatedDocsResponse: &RelatedDocsResponse{
-			RelatedDocuments: []RelatedDocument{
+		relatedDocsResponse: &ai.RelatedDocsResponse{
+			RelatedDocuments: []ai.RelatedDocument{
 				{
 					Title:     "Related Mock Document",
 					Relevance: 0.75,
@@ -210,47 +89,55 @@ func NewAIMock() *AIMock {
 // Configuration Methods
 
 // SetClassificationResponse sets a custom classification response
-func (m *AIMock) SetClassificationResponse(resp *ClassificationResponse) {
+func (m *AIMock) SetClassificationResponse(resp *ai.ClassificationResponse) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.classificationResponse = resp
 }
 
 // SetQuestionResponse sets a custom question response
-func (m *AIMock) SetQuestionResponse(resp *QuestionResponse) {
+func (m *AIMock) SetQuestionResponse(resp *ai.QuestionResponse) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.questionResponse = resp
 }
 
 // SetSummaryResponse sets a custom summary response
-func (m *AIMock) SetSummaryResponse(resp *SummaryResponse) {
+func (m *AIMock) SetSummaryResponse(resp *ai.SummaryResponse) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.summaryResponse = resp
 }
 
 // SetTitleResponse sets a custom title response
-func (m *AIMock) SetTitleResponse(resp *TitleResponse) {
+func (m *AIMock) SetTitleResponse(resp *ai.TitleResponse) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.titleResponse = resp
 }
 
 // SetSearchTermsResponse sets a custom search terms response
-func (m *AIMock) SetSearchTermsResponse(resp *SearchTermsResponse) {
+func (m *AIMock) SetSearchTermsResponse(resp *ai.SearchTermsResponse) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.searchTermsResponse = resp
 }
 
 // SetRelatedDocsResponse sets a custom related docs response
-func (m *AIMock) SetRelatedDocsResponse(resp *RelatedDocsResponse) {
+func (m *AIMock) SetRelatedDocsResponse(resp *ai.RelatedDocsResponse) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.relatedDocsResponse = resp
 }
 
+// SetUsage sets the token usage every successful call records with
+// ai.RecordUsage
+func (m *AIMock) SetUsage(usage ai.Usage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage = usage
+}
+
 // SetCircuitBreakerOpen simulates circuit breaker state
 func (m *AIMock) SetCircuitBreakerOpen(open bool) {
 	m.mu.Lock()
@@ -317,39 +204,40 @@ func (m *AIMock) Reset() {
 	m.rateLimited = false
 	m.timeoutError = false
 	m.deterministicMode = false
+	m.usage = ai.Usage{}
 	m.specificErrors = make(map[string]error)
 	m.callCounts = make(map[string]int)
 	m.lastCalls = make(map[string]any)
 
 	// Reset to default responses
-	m.classificationResponse = &ClassificationResponse{
+	m.classificationResponse = &ai.ClassificationResponse{
 		CollectionID: "default-collection",
 		Confidence:   0.85,
 		Reasoning:    "Default mock classification",
 		SearchTerms:  []string{"mock", "test", "default"},
 	}
-	m.questionResponse = &QuestionResponse{
+	m.questionResponse = &ai.QuestionResponse{
 		Answer:     "This is a mock answer based on the provided context.",
 		Confidence: 0.9,
-		Citations: []CitationInfo{
+		Citations: []ai.CitationInfo{
 			{
 				DocumentTitle: "Mock Document",
 				DocumentURL:   "https://example.com/mock",
 			},
 		},
 	}
-	m.summaryResponse = &SummaryResponse{
+	m.summaryResponse = &ai.SummaryResponse{
 		Summary: "This is a mock summary of the document content.",
 	}
-	m.titleResponse = &TitleResponse{
+	m.titleResponse = &ai.TitleResponse{
 		SuggestedTitle: "Enhanced Mock Title",
 		Confidence:     0.88,
 	}
-	m.searchTermsResponse = &SearchTermsResponse{
+	m.searchTermsResponse = &ai.SearchTermsResponse{
 		SearchTerms: []string{"mock", "test", "document", "example"},
 	}
-	m.relatedDocsResponse = &RelatedDocsResponse{
-		RelatedDocuments: []RelatedDocument{
+	m.relatedDocsResponse = &ai.RelatedDocsResponse{
+		RelatedDocuments: []ai.RelatedDocument{
 			{
 				Title:     "Related Mock Document",
 				Relevance: 0.75,
@@ -368,24 +256,31 @@ func (m *AIMock) recordCall(method string, request any) {
 	m.lastCalls[method] = request
 }
 
+func (m *AIMock) recordUsage(ctx context.Context) {
+	m.mu.RLock()
+	usage := m.usage
+	m.mu.RUnlock()
+	ai.RecordUsage(ctx, usage)
+}
+
 func (m *AIMock) checkError(method string) error {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	if m.circuitBreakerOpen {
-		return ErrCircuitBreakerOpen
+		return ai.ErrCircuitBreakerOpen
 	}
 
 	if m.tokenLimitExceeded {
-		return ErrTokenLimitExceeded
+		return ai.ErrTokenLimitExceeded
 	}
 
 	if m.rateLimited {
-		return ErrAIRateLimited
+		return ai.ErrRateLimited
 	}
 
 	if m.timeoutError {
-		return ErrTimeout
+		return ai.ErrTimeout
 	}
 
 	if err, ok := m.specificErrors[method]; ok {
@@ -398,12 +293,13 @@ func (m *AIMock) checkError(method string) error {
 // Interface Implementation
 
 // ClassifyDocument classifies a document into a collection
-func (m *AIMock) ClassifyDocument(ctx context.Context, req *ClassificationRequest) (*ClassificationResponse, error) {
+func (m *AIMock) ClassifyDocument(ctx context.Context, req *ai.ClassificationRequest) (*ai.ClassificationResponse, error) {
 	m.recordCall("ClassifyDocument", req)
 
 	if err := m.checkError("ClassifyDocument"); err != nil {
 		return nil, err
 	}
+	m.recordUsage(ctx)
 
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -412,7 +308,7 @@ func (m *AIMock) ClassifyDocument(ctx context.Context, req *ClassificationReques
 	if m.deterministicMode && req.Taxonomy != nil && len(req.Taxonomy.Collections) > 0 {
 		// Pick first collection and generate deterministic response
 		firstCol := req.Taxonomy.Collections[0]
-		return &ClassificationResponse{
+		return &ai.ClassificationResponse{
 			CollectionID: firstCol.ID,
 			Confidence:   0.85,
 			Reasoning:    fmt.Sprintf("Document matches %s based on content", firstCol.Name),
@@ -425,27 +321,28 @@ func (m *AIMock) ClassifyDocument(ctx context.Context, req *ClassificationReques
 }
 
 // AnswerQuestion answers a question based on context documents
-func (m *AIMock) AnswerQuestion(ctx context.Context, req *QuestionRequest) (*QuestionResponse, error) {
+func (m *AIMock) AnswerQuestion(ctx context.Context, req *ai.QuestionRequest) (*ai.QuestionResponse, error) {
 	m.recordCall("AnswerQuestion", req)
 
 	if err := m.checkError("AnswerQuestion"); err != nil {
 		return nil, err
 	}
+	m.recordUsage(ctx)
 
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	// In deterministic mode, generate response based on input
 	if m.deterministicMode {
-		citations := make([]CitationInfo, 0, len(req.ContextDocs))
+		citations := make([]ai.CitationInfo, 0, len(req.ContextDocs))
 		for _, doc := range req.ContextDocs {
-			citations = append(citations, CitationInfo{
+			citations = append(citations, ai.CitationInfo{
 				DocumentTitle: doc.Title,
 				DocumentURL:   doc.URL,
 			})
 		}
 
-		return &QuestionResponse{
+		return &ai.QuestionResponse{
 			Answer:     fmt.Sprintf("Answer to: %s", req.Question),
 			Confidence: 0.9,
 			Citations:  citations,
@@ -457,19 +354,20 @@ func (m *AIMock) AnswerQuestion(ctx context.Context, req *QuestionRequest) (*Que
 }
 
 // GenerateSummary generates a summary of document content
-func (m *AIMock) GenerateSummary(ctx context.Context, req *SummaryRequest) (*SummaryResponse, error) {
+func (m *AIMock) GenerateSummary(ctx context.Context, req *ai.SummaryRequest) (*ai.SummaryResponse, error) {
 	m.recordCall("GenerateSummary", req)
 
 	if err := m.checkError("GenerateSummary"); err != nil {
 		return nil, err
 	}
+	m.recordUsage(ctx)
 
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	// In deterministic mode, generate response based on input
 	if m.deterministicMode {
-		return &SummaryResponse{
+		return &ai.SummaryResponse{
 			Summary: fmt.Sprintf("Summary of '%s'", req.DocumentTitle),
 		}, nil
 	}
@@ -479,19 +377,20 @@ func (m *AIMock) GenerateSummary(ctx context.Context, req *SummaryRequest) (*Sum
 }
 
 // EnhanceTitle suggests an improved title for a document
-func (m *AIMock) EnhanceTitle(ctx context.Context, req *TitleRequest) (*TitleResponse, error) {
+func (m *AIMock) EnhanceTitle(ctx context.Context, req *ai.TitleRequest) (*ai.TitleResponse, error) {
 	m.recordCall("EnhanceTitle", req)
 
 	if err := m.checkError("EnhanceTitle"); err != nil {
 		return nil, err
 	}
+	m.recordUsage(ctx)
 
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	// In deterministic mode, generate response based on input
 	if m.deterministicMode {
-		return &TitleResponse{
+		return &ai.TitleResponse{
 			SuggestedTitle: fmt.Sprintf("Enhanced: %s", req.CurrentTitle),
 			Confidence:     0.85,
 		}, nil
@@ -502,19 +401,20 @@ func (m *AIMock) EnhanceTitle(ctx context.Context, req *TitleRequest) (*TitleRes
 }
 
 // GenerateSearchTerms generates search terms for a document
-func (m *AIMock) GenerateSearchTerms(ctx context.Context, req *SearchTermsRequest) (*SearchTermsResponse, error) {
+func (m *AIMock) GenerateSearchTerms(ctx context.Context, req *ai.SearchTermsRequest) (*ai.SearchTermsResponse, error) {
 	m.recordCall("GenerateSearchTerms", req)
 
 	if err := m.checkError("GenerateSearchTerms"); err != nil {
 		return nil, err
 	}
+	m.recordUsage(ctx)
 
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	// In deterministic mode, generate response based on input
 	if m.deterministicMode {
-		return &SearchTermsResponse{
+		return &ai.SearchTermsResponse{
 			SearchTerms: []string{"term1", "term2", "term3"},
 		}, nil
 	}
@@ -524,31 +424,32 @@ func (m *AIMock) GenerateSearchTerms(ctx context.Context, req *SearchTermsReques
 }
 
 // FindRelatedDocuments finds documents related to the given document
-func (m *AIMock) FindRelatedDocuments(ctx context.Context, req *RelatedDocsRequest) (*RelatedDocsResponse, error) {
+func (m *AIMock) FindRelatedDocuments(ctx context.Context, req *ai.RelatedDocsRequest) (*ai.RelatedDocsResponse, error) {
 	m.recordCall("FindRelatedDocuments", req)
 
 	if err := m.checkError("FindRelatedDocuments"); err != nil {
 		return nil, err
 	}
+	m.recordUsage(ctx)
 
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	// In deterministic mode, generate response based on input
 	if m.deterministicMode && len(req.AvailableDocs) > 0 {
-		related := make([]RelatedDocument, 0, len(req.AvailableDocs))
+		related := make([]ai.RelatedDocument, 0, len(req.AvailableDocs))
 		for i, doc := range req.AvailableDocs {
 			if i >= 3 { // Limit to 3 related docs
 				break
 			}
-			related = append(related, RelatedDocument{
+			related = append(related, ai.RelatedDocument{
 				Title:     doc,
 				Relevance: 0.8 - float64(i)*0.1,
 				Reason:    "Related content",
 			})
 		}
 
-		return &RelatedDocsResponse{
+		return &ai.RelatedDocsResponse{
 			RelatedDocuments: related,
 		}, nil
 	}
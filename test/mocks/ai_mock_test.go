@@ -3,6 +3,8 @@ package mocks
 import (
 	"context"
 	"testing"
+
+	"github.com/yourusername/outline-ai/internal/ai"
 )
 
 // Example test showing usage of AIMock
@@ -14,11 +16,11 @@ func TestAIMock_BasicOperations(t *testing.T) {
 
 	// Test: Classification with default response
 	t.Run("classify document with defaults", func(t *testing.T) {
-		req := &ClassificationRequest{
+		req := &ai.ClassificationRequest{
 			DocumentTitle:   "API Design Guide",
 			DocumentContent: "This guide covers REST API best practices",
-			Taxonomy: &TaxonomyContext{
-				Collections: []TaxonomyCollection{
+			Taxonomy: &ai.TaxonomyContext{
+				Collections: []ai.TaxonomyCollection{
 					{
 						ID:          "engineering-docs",
 						Name:        "Engineering",
@@ -48,12 +50,12 @@ func TestAIMock_BasicOperations(t *testing.T) {
 
 	// Test: Custom classification response
 	t.Run("classify document with custom response", func(t *testing.T) {
-		customResp := &ClassificationResponse{
+		customResp := &ai.ClassificationResponse{
 			CollectionID: "custom-collection",
 			Confidence:   0.95,
 			Reasoning:    "Custom reasoning",
 			SearchTerms:  []string{"custom", "terms"},
-			Alternatives: []AlternativeClassification{
+			Alternatives: []ai.AlternativeClassification{
 				{
 					CollectionID: "alt-collection",
 					Confidence:   0.75,
@@ -64,11 +66,11 @@ func TestAIMock_BasicOperations(t *testing.T) {
 
 		mock.SetClassificationResponse(customResp)
 
-		req := &ClassificationRequest{
+		req := &ai.ClassificationRequest{
 			DocumentTitle:   "Test Document",
 			DocumentContent: "Test content",
-			Taxonomy: &TaxonomyContext{
-				Collections: []TaxonomyCollection{
+			Taxonomy: &ai.TaxonomyContext{
+				Collections: []ai.TaxonomyCollection{
 					{ID: "custom-collection", Name: "Custom", Description: "Custom collection"},
 				},
 			},
@@ -94,9 +96,9 @@ func TestAIMock_BasicOperations(t *testing.T) {
 
 	// Test: Question answering
 	t.Run("answer question", func(t *testing.T) {
-		req := &QuestionRequest{
+		req := &ai.QuestionRequest{
 			Question: "What is REST?",
-			ContextDocs: []ContextDocument{
+			ContextDocs: []ai.ContextDocument{
 				{
 					Title:   "REST API Guide",
 					Excerpt: "REST stands for Representational State Transfer...",
@@ -125,7 +127,7 @@ func TestAIMock_BasicOperations(t *testing.T) {
 
 	// Test: Summary generation
 	t.Run("generate summary", func(t *testing.T) {
-		req := &SummaryRequest{
+		req := &ai.SummaryRequest{
 			DocumentTitle:   "Long Document",
 			DocumentContent: "This is a very long document with lots of content...",
 		}
@@ -142,7 +144,7 @@ func TestAIMock_BasicOperations(t *testing.T) {
 
 	// Test: Title enhancement
 	t.Run("enhance title", func(t *testing.T) {
-		req := &TitleRequest{
+		req := &ai.TitleRequest{
 			CurrentTitle:    "doc1",
 			DocumentContent: "This document explains REST APIs in detail...",
 		}
@@ -163,7 +165,7 @@ func TestAIMock_BasicOperations(t *testing.T) {
 
 	// Test: Search terms generation
 	t.Run("generate search terms", func(t *testing.T) {
-		req := &SearchTermsRequest{
+		req := &ai.SearchTermsRequest{
 			DocumentTitle:   "API Documentation",
 			DocumentContent: "Guide to building REST APIs with authentication",
 		}
@@ -180,7 +182,7 @@ func TestAIMock_BasicOperations(t *testing.T) {
 
 	// Test: Find related documents
 	t.Run("find related documents", func(t *testing.T) {
-		req := &RelatedDocsRequest{
+		req := &ai.RelatedDocsRequest{
 			DocumentTitle:   "API Design",
 			DocumentContent: "REST API design patterns",
 			AvailableDocs:   []string{"API Guide", "Database Guide", "Frontend Guide"},
@@ -214,14 +216,14 @@ func TestAIMock_ErrorScenarios(t *testing.T) {
 	t.Run("circuit breaker open", func(t *testing.T) {
 		mock.SetCircuitBreakerOpen(true)
 
-		req := &ClassificationRequest{
+		req := &ai.ClassificationRequest{
 			DocumentTitle:   "Test",
 			DocumentContent: "Test",
-			Taxonomy:        &TaxonomyContext{Collections: []TaxonomyCollection{}},
+			Taxonomy:        &ai.TaxonomyContext{Collections: []ai.TaxonomyCollection{}},
 		}
 
 		_, err := mock.ClassifyDocument(ctx, req)
-		if err != ErrCircuitBreakerOpen {
+		if err != ai.ErrCircuitBreakerOpen {
 			t.Errorf("Expected ErrCircuitBreakerOpen, got %v", err)
 		}
 
@@ -232,13 +234,13 @@ func TestAIMock_ErrorScenarios(t *testing.T) {
 	t.Run("token limit exceeded", func(t *testing.T) {
 		mock.SetTokenLimitExceeded(true)
 
-		req := &SummaryRequest{
+		req := &ai.SummaryRequest{
 			DocumentTitle:   "Very Long Document",
 			DocumentContent: "Lots of content...",
 		}
 
 		_, err := mock.GenerateSummary(ctx, req)
-		if err != ErrTokenLimitExceeded {
+		if err != ai.ErrTokenLimitExceeded {
 			t.Errorf("Expected ErrTokenLimitExceeded, got %v", err)
 		}
 
@@ -249,13 +251,13 @@ func TestAIMock_ErrorScenarios(t *testing.T) {
 	t.Run("rate limited", func(t *testing.T) {
 		mock.SetRateLimited(true)
 
-		req := &QuestionRequest{
+		req := &ai.QuestionRequest{
 			Question:    "Test question?",
-			ContextDocs: []ContextDocument{},
+			ContextDocs: []ai.ContextDocument{},
 		}
 
 		_, err := mock.AnswerQuestion(ctx, req)
-		if err != ErrAIRateLimited {
+		if err != ai.ErrRateLimited {
 			t.Errorf("Expected ErrAIRateLimited, got %v", err)
 		}
 
@@ -266,13 +268,13 @@ func TestAIMock_ErrorScenarios(t *testing.T) {
 	t.Run("timeout error", func(t *testing.T) {
 		mock.SetTimeoutError(true)
 
-		req := &TitleRequest{
+		req := &ai.TitleRequest{
 			CurrentTitle:    "Test",
 			DocumentContent: "Content",
 		}
 
 		_, err := mock.EnhanceTitle(ctx, req)
-		if err != ErrTimeout {
+		if err != ai.ErrTimeout {
 			t.Errorf("Expected ErrTimeout, got %v", err)
 		}
 
@@ -281,10 +283,10 @@ func TestAIMock_ErrorScenarios(t *testing.T) {
 
 	// Test: Method-specific error
 	t.Run("method specific error", func(t *testing.T) {
-		customErr := ErrInvalidResponse
+		customErr := ai.ErrInvalidResponse
 		mock.SetMethodError("GenerateSearchTerms", customErr)
 
-		req := &SearchTermsRequest{
+		req := &ai.SearchTermsRequest{
 			DocumentTitle:   "Test",
 			DocumentContent: "Content",
 		}
@@ -313,8 +315,8 @@ func TestAIMock_DeterministicMode(t *testing.T) {
 
 	// Test: Deterministic classification
 	t.Run("deterministic classification", func(t *testing.T) {
-		taxonomy := &TaxonomyContext{
-			Collections: []TaxonomyCollection{
+		taxonomy := &ai.TaxonomyContext{
+			Collections: []ai.TaxonomyCollection{
 				{
 					ID:          "col-1",
 					Name:        "Collection 1",
@@ -328,7 +330,7 @@ func TestAIMock_DeterministicMode(t *testing.T) {
 			},
 		}
 
-		req := &ClassificationRequest{
+		req := &ai.ClassificationRequest{
 			DocumentTitle:   "Test Document",
 			DocumentContent: "Test content",
 			Taxonomy:        taxonomy,
@@ -347,9 +349,9 @@ func TestAIMock_DeterministicMode(t *testing.T) {
 
 	// Test: Deterministic question answering
 	t.Run("deterministic question answering", func(t *testing.T) {
-		req := &QuestionRequest{
+		req := &ai.QuestionRequest{
 			Question: "What is the answer?",
-			ContextDocs: []ContextDocument{
+			ContextDocs: []ai.ContextDocument{
 				{Title: "Doc 1", Excerpt: "Content 1", URL: "url1"},
 				{Title: "Doc 2", Excerpt: "Content 2", URL: "url2"},
 			},
@@ -372,7 +374,7 @@ func TestAIMock_DeterministicMode(t *testing.T) {
 
 	// Test: Deterministic related documents
 	t.Run("deterministic related documents", func(t *testing.T) {
-		req := &RelatedDocsRequest{
+		req := &ai.RelatedDocsRequest{
 			DocumentTitle:   "Main Doc",
 			DocumentContent: "Content",
 			AvailableDocs:   []string{"Doc A", "Doc B", "Doc C", "Doc D"},
@@ -405,18 +407,18 @@ func TestAIMock_CallTracking(t *testing.T) {
 	ctx := context.Background()
 
 	// Make several calls
-	req := &ClassificationRequest{
+	req := &ai.ClassificationRequest{
 		DocumentTitle:   "Test",
 		DocumentContent: "Content",
-		Taxonomy:        &TaxonomyContext{Collections: []TaxonomyCollection{}},
+		Taxonomy:        &ai.TaxonomyContext{Collections: []ai.TaxonomyCollection{}},
 	}
 
 	_, _ = mock.ClassifyDocument(ctx, req)
 	_, _ = mock.ClassifyDocument(ctx, req)
 
-	questionReq := &QuestionRequest{
+	questionReq := &ai.QuestionRequest{
 		Question:    "Test?",
-		ContextDocs: []ContextDocument{},
+		ContextDocs: []ai.ContextDocument{},
 	}
 	_, _ = mock.AnswerQuestion(ctx, questionReq)
 
@@ -437,7 +439,7 @@ func TestAIMock_CallTracking(t *testing.T) {
 		t.Error("Expected last call to be recorded")
 	}
 
-	lastReq, ok := lastCall.(*ClassificationRequest)
+	lastReq, ok := lastCall.(*ai.ClassificationRequest)
 	if !ok {
 		t.Error("Expected last call to be ClassificationRequest")
 	}
@@ -457,8 +459,8 @@ func TestAIMock_IntegrationScenario(t *testing.T) {
 	// Scenario: Document classification and enhancement workflow
 	t.Run("document processing workflow", func(t *testing.T) {
 		// 1. Classify the document
-		taxonomy := &TaxonomyContext{
-			Collections: []TaxonomyCollection{
+		taxonomy := &ai.TaxonomyContext{
+			Collections: []ai.TaxonomyCollection{
 				{
 					ID:              "engineering",
 					Name:            "Engineering",
@@ -468,7 +470,7 @@ func TestAIMock_IntegrationScenario(t *testing.T) {
 			},
 		}
 
-		classifyReq := &ClassificationRequest{
+		classifyReq := &ai.ClassificationRequest{
 			DocumentTitle:   "REST API Design",
 			DocumentContent: "This document covers REST API best practices...",
 			UserGuidance:    "This is a technical document",
@@ -476,7 +478,7 @@ func TestAIMock_IntegrationScenario(t *testing.T) {
 		}
 
 		// Configure realistic classification response
-		mock.SetClassificationResponse(&ClassificationResponse{
+		mock.SetClassificationResponse(&ai.ClassificationResponse{
 			CollectionID: "engineering",
 			Confidence:   0.92,
 			Reasoning:    "Technical content about APIs matches engineering collection",
@@ -493,12 +495,12 @@ func TestAIMock_IntegrationScenario(t *testing.T) {
 		}
 
 		// 2. Enhance the title
-		titleReq := &TitleRequest{
+		titleReq := &ai.TitleRequest{
 			CurrentTitle:    "doc1",
 			DocumentContent: classifyReq.DocumentContent,
 		}
 
-		mock.SetTitleResponse(&TitleResponse{
+		mock.SetTitleResponse(&ai.TitleResponse{
 			SuggestedTitle: "REST API Design Best Practices",
 			Confidence:     0.88,
 		})
@@ -513,12 +515,12 @@ func TestAIMock_IntegrationScenario(t *testing.T) {
 		}
 
 		// 3. Generate summary
-		summaryReq := &SummaryRequest{
+		summaryReq := &ai.SummaryRequest{
 			DocumentTitle:   titleResp.SuggestedTitle,
 			DocumentContent: classifyReq.DocumentContent,
 		}
 
-		mock.SetSummaryResponse(&SummaryResponse{
+		mock.SetSummaryResponse(&ai.SummaryResponse{
 			Summary: "A comprehensive guide covering REST API design patterns and best practices for building scalable web services.",
 		})
 
@@ -532,14 +534,14 @@ func TestAIMock_IntegrationScenario(t *testing.T) {
 		}
 
 		// 4. Find related documents
-		relatedReq := &RelatedDocsRequest{
+		relatedReq := &ai.RelatedDocsRequest{
 			DocumentTitle:   titleResp.SuggestedTitle,
 			DocumentContent: classifyReq.DocumentContent,
 			AvailableDocs:   []string{"GraphQL API Guide", "Database Design", "Authentication Guide"},
 		}
 
-		mock.SetRelatedDocsResponse(&RelatedDocsResponse{
-			RelatedDocuments: []RelatedDocument{
+		mock.SetRelatedDocsResponse(&ai.RelatedDocsResponse{
+			RelatedDocuments: []ai.RelatedDocument{
 				{
 					Title:     "GraphQL API Guide",
 					Relevance: 0.85,
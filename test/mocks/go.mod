@@ -1,3 +0,0 @@
-module github.com/yourusername/outline-ai/test/mocks
-
-go 1.25.5
@@ -2,106 +2,25 @@ package mocks
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
-)
 
-// Package-level errors matching outline package
-var (
-	ErrUnauthorized   = errors.New("outline: unauthorized")
-	ErrNotFound       = errors.New("outline: not found")
-	ErrRateLimited    = errors.New("outline: rate limited")
-	ErrServerError    = errors.New("outline: server error")
-	ErrInvalidRequest = errors.New("outline: invalid request")
+	"github.com/yourusername/outline-ai/internal/outline"
 )
 
-// Collection represents an Outline collection
-type Collection struct {
-	ID          string
-	Name        string
-	Description string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-}
-
-// Document represents an Outline document
-type Document struct {
-	ID           string
-	CollectionID string
-	Title        string
-	Text         string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	PublishedAt  *time.Time
-}
-
-// CreateDocumentRequest is the request to create a document
-type CreateDocumentRequest struct {
-	CollectionID     string
-	Title            string
-	Text             string
-	Publish          bool
-	ParentDocumentID *string
-}
-
-// UpdateDocumentRequest is the request to update a document
-type UpdateDocumentRequest struct {
-	Title string
-	Text  string
-	Done  bool
-}
-
-// Comment represents an Outline comment
-type Comment struct {
-	ID         string
-	DocumentID string
-	Data       string
-	CreatedAt  time.Time
-}
-
-// CommentContent represents comment content structure
-type CommentContent struct {
-	Type    string        `json:"type"`
-	Content []ContentNode `json:"content,omitempty"`
-}
-
-// ContentNode represents a node in comment content
-type ContentNode struct {
-	Type    string        `json:"type"`
-	Text    string        `json:"text,omitempty"`
-	Content []ContentNode `json:"content,omitempty"`
-}
-
-// CreateCommentRequest is the request to create a comment
-type CreateCommentRequest struct {
-	DocumentID string
-	Data       CommentContent
-}
-
-// SearchOptions contains options for document search
-type SearchOptions struct {
-	Limit        int
-	Offset       int
-	CollectionID string
-}
-
-// SearchResult contains search results
-type SearchResult struct {
-	Documents  []*Document
-	TotalCount int
-}
+var _ outline.Client = (*OutlineMock)(nil)
 
 // OutlineMock is a mock implementation of the outline.Client interface
 type OutlineMock struct {
 	mu sync.RWMutex
 
 	// In-memory storage
-	collections map[string]*Collection
-	documents   map[string]*Document
-	comments    map[string][]*Comment
+	collections map[string]*outline.Collection
+	documents   map[string]*outline.Document
+	comments    map[string][]*outline.Comment
 
 	// Configuration
 	failureMode       bool
@@ -118,9 +37,9 @@ type OutlineMock struct {
 // NewOutlineMock creates a new mock Outline client
 func NewOutlineMock() *OutlineMock {
 	return &OutlineMock{
-		collections:    make(map[string]*Collection),
-		documents:      make(map[string]*Document),
-		comments:       make(map[string][]*Comment),
+		collections:    make(map[string]*outline.Collection),
+		documents:      make(map[string]*outline.Document),
+		comments:       make(map[string][]*outline.Comment),
 		specificErrors: make(map[string]error),
 		callCounts:     make(map[string]int),
 	}
@@ -129,11 +48,11 @@ func NewOutlineMock() *OutlineMock {
 // Helper Methods for Test Setup
 
 // AddCollection adds a collection to the mock storage
-func (m *OutlineMock) AddCollection(id, name, description string) *Collection {
+func (m *OutlineMock) AddCollection(id, name, description string) *outline.Collection {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	col := &Collection{
+	col := &outline.Collection{
 		ID:          id,
 		Name:        name,
 		Description: description,
@@ -145,12 +64,12 @@ func (m *OutlineMock) AddCollection(id, name, description string) *Collection {
 }
 
 // AddDocument adds a document to the mock storage
-func (m *OutlineMock) AddDocument(id, collectionID, title, text string) *Document {
+func (m *OutlineMock) AddDocument(id, collectionID, title, text string) *outline.Document {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	now := time.Now()
-	doc := &Document{
+	doc := &outline.Document{
 		ID:           id,
 		CollectionID: collectionID,
 		Title:        title,
@@ -164,11 +83,11 @@ func (m *OutlineMock) AddDocument(id, collectionID, title, text string) *Documen
 }
 
 // AddComment adds a comment to the mock storage
-func (m *OutlineMock) AddComment(id, documentID, data string) *Comment {
+func (m *OutlineMock) AddComment(id, documentID, data string) *outline.Comment {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	comment := &Comment{
+	comment := &outline.Comment{
 		ID:         id,
 		DocumentID: documentID,
 		Data:       data,
@@ -176,7 +95,7 @@ func (m *OutlineMock) AddComment(id, documentID, data string) *Comment {
 	}
 
 	if m.comments[documentID] == nil {
-		m.comments[documentID] = make([]*Comment, 0)
+		m.comments[documentID] = make([]*outline.Comment, 0)
 	}
 	m.comments[documentID] = append(m.comments[documentID], comment)
 	return comment
@@ -205,6 +124,13 @@ func (m *OutlineMock) SetGetDocumentError(err error) {
 	m.specificErrors["GetDocument"] = err
 }
 
+// SetUpdateDocumentError sets a specific error for UpdateDocument
+func (m *OutlineMock) SetUpdateDocumentError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.specificErrors["UpdateDocument"] = err
+}
+
 // SetCreateCommentError sets a specific error for CreateComment
 func (m *OutlineMock) SetCreateCommentError(err error) {
 	m.mu.Lock()
@@ -231,9 +157,9 @@ func (m *OutlineMock) Reset() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.collections = make(map[string]*Collection)
-	m.documents = make(map[string]*Document)
-	m.comments = make(map[string][]*Comment)
+	m.collections = make(map[string]*outline.Collection)
+	m.documents = make(map[string]*outline.Document)
+	m.comments = make(map[string][]*outline.Comment)
 	m.specificErrors = make(map[string]error)
 	m.callCounts = make(map[string]int)
 	m.failureMode = false
@@ -260,11 +186,11 @@ func (m *OutlineMock) checkError(method string) error {
 	}
 
 	if m.failureMode {
-		return ErrServerError
+		return outline.ErrServerError
 	}
 
 	if m.rateLimited {
-		return ErrRateLimited
+		return outline.ErrRateLimited
 	}
 
 	if err, ok := m.specificErrors[method]; ok {
@@ -275,7 +201,7 @@ func (m *OutlineMock) checkError(method string) error {
 }
 
 // ListCollections returns all collections
-func (m *OutlineMock) ListCollections(ctx context.Context) ([]*Collection, error) {
+func (m *OutlineMock) ListCollections(ctx context.Context) ([]*outline.Collection, error) {
 	m.recordCall("ListCollections")
 
 	if err := m.checkError("ListCollections"); err != nil {
@@ -285,7 +211,7 @@ func (m *OutlineMock) ListCollections(ctx context.Context) ([]*Collection, error
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	collections := make([]*Collection, 0, len(m.collections))
+	collections := make([]*outline.Collection, 0, len(m.collections))
 	for _, col := range m.collections {
 		collections = append(collections, col)
 	}
@@ -294,7 +220,7 @@ func (m *OutlineMock) ListCollections(ctx context.Context) ([]*Collection, error
 }
 
 // GetCollection returns a collection by ID
-func (m *OutlineMock) GetCollection(ctx context.Context, id string) (*Collection, error) {
+func (m *OutlineMock) GetCollection(ctx context.Context, id string) (*outline.Collection, error) {
 	m.recordCall("GetCollection")
 
 	if err := m.checkError("GetCollection"); err != nil {
@@ -306,14 +232,14 @@ func (m *OutlineMock) GetCollection(ctx context.Context, id string) (*Collection
 
 	col, ok := m.collections[id]
 	if !ok {
-		return nil, ErrNotFound
+		return nil, outline.ErrNotFound
 	}
 
 	return col, nil
 }
 
 // GetDocument returns a document by ID
-func (m *OutlineMock) GetDocument(ctx context.Context, id string) (*Document, error) {
+func (m *OutlineMock) GetDocument(ctx context.Context, id string) (*outline.Document, error) {
 	m.recordCall("GetDocument")
 
 	if err := m.checkError("GetDocument"); err != nil {
@@ -325,14 +251,14 @@ func (m *OutlineMock) GetDocument(ctx context.Context, id string) (*Document, er
 
 	doc, ok := m.documents[id]
 	if !ok {
-		return nil, ErrNotFound
+		return nil, outline.ErrNotFound
 	}
 
 	return doc, nil
 }
 
 // ListDocuments returns all documents in a collection
-func (m *OutlineMock) ListDocuments(ctx context.Context, collectionID string) ([]*Document, error) {
+func (m *OutlineMock) ListDocuments(ctx context.Context, collectionID string) ([]*outline.Document, error) {
 	m.recordCall("ListDocuments")
 
 	if err := m.checkError("ListDocuments"); err != nil {
@@ -344,10 +270,10 @@ func (m *OutlineMock) ListDocuments(ctx context.Context, collectionID string) ([
 
 	// Check if collection exists
 	if _, ok := m.collections[collectionID]; !ok {
-		return nil, ErrNotFound
+		return nil, outline.ErrNotFound
 	}
 
-	documents := make([]*Document, 0)
+	documents := make([]*outline.Document, 0)
 	for _, doc := range m.documents {
 		if doc.CollectionID == collectionID {
 			documents = append(documents, doc)
@@ -358,7 +284,7 @@ func (m *OutlineMock) ListDocuments(ctx context.Context, collectionID string) ([
 }
 
 // CreateDocument creates a new document
-func (m *OutlineMock) CreateDocument(ctx context.Context, req *CreateDocumentRequest) (*Document, error) {
+func (m *OutlineMock) CreateDocument(ctx context.Context, req *outline.CreateDocumentRequest) (*outline.Document, error) {
 	m.recordCall("CreateDocument")
 
 	if err := m.checkError("CreateDocument"); err != nil {
@@ -370,7 +296,7 @@ func (m *OutlineMock) CreateDocument(ctx context.Context, req *CreateDocumentReq
 
 	// Check if collection exists
 	if _, ok := m.collections[req.CollectionID]; !ok {
-		return nil, ErrNotFound
+		return nil, outline.ErrNotFound
 	}
 
 	// Generate ID
@@ -378,7 +304,7 @@ func (m *OutlineMock) CreateDocument(ctx context.Context, req *CreateDocumentReq
 	id := fmt.Sprintf("doc-%d", m.docCounter)
 
 	now := time.Now()
-	doc := &Document{
+	doc := &outline.Document{
 		ID:           id,
 		CollectionID: req.CollectionID,
 		Title:        req.Title,
@@ -396,7 +322,7 @@ func (m *OutlineMock) CreateDocument(ctx context.Context, req *CreateDocumentReq
 }
 
 // UpdateDocument updates an existing document
-func (m *OutlineMock) UpdateDocument(ctx context.Context, id string, req *UpdateDocumentRequest) (*Document, error) {
+func (m *OutlineMock) UpdateDocument(ctx context.Context, id string, req *outline.UpdateDocumentRequest) (*outline.Document, error) {
 	m.recordCall("UpdateDocument")
 
 	if err := m.checkError("UpdateDocument"); err != nil {
@@ -408,14 +334,14 @@ func (m *OutlineMock) UpdateDocument(ctx context.Context, id string, req *Update
 
 	doc, ok := m.documents[id]
 	if !ok {
-		return nil, ErrNotFound
+		return nil, outline.ErrNotFound
 	}
 
 	if req.Title != "" {
 		doc.Title = req.Title
 	}
-	if req.Text != "" {
-		doc.Text = req.Text
+	if req.Text != nil {
+		doc.Text = *req.Text
 	}
 
 	doc.UpdatedAt = time.Now()
@@ -436,11 +362,11 @@ func (m *OutlineMock) MoveDocument(ctx context.Context, id string, collectionID
 
 	doc, ok := m.documents[id]
 	if !ok {
-		return ErrNotFound
+		return outline.ErrNotFound
 	}
 
 	if _, ok := m.collections[collectionID]; !ok {
-		return ErrNotFound
+		return outline.ErrNotFound
 	}
 
 	doc.CollectionID = collectionID
@@ -450,7 +376,7 @@ func (m *OutlineMock) MoveDocument(ctx context.Context, id string, collectionID
 }
 
 // SearchDocuments searches for documents
-func (m *OutlineMock) SearchDocuments(ctx context.Context, query string, opts *SearchOptions) (*SearchResult, error) {
+func (m *OutlineMock) SearchDocuments(ctx context.Context, query string, opts *outline.SearchOptions) (*outline.SearchResult, error) {
 	m.recordCall("SearchDocuments")
 
 	if err := m.checkError("SearchDocuments"); err != nil {
@@ -461,7 +387,7 @@ func (m *OutlineMock) SearchDocuments(ctx context.Context, query string, opts *S
 	defer m.mu.RUnlock()
 
 	// Simple substring search
-	var matches []*Document
+	var matches []*outline.Document
 	for _, doc := range m.documents {
 		// Filter by collection if specified
 		if opts != nil && opts.CollectionID != "" && doc.CollectionID != opts.CollectionID {
@@ -474,6 +400,9 @@ func (m *OutlineMock) SearchDocuments(ctx context.Context, query string, opts *S
 		}
 	}
 
+	// Map order is random, so sort to keep pages stable across calls
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+
 	// Apply pagination
 	start := 0
 	end := len(matches)
@@ -487,7 +416,7 @@ func (m *OutlineMock) SearchDocuments(ctx context.Context, query string, opts *S
 		}
 	}
 
-	result := &SearchResult{
+	result := &outline.SearchResult{
 		Documents:  matches[start:end],
 		TotalCount: len(matches),
 	}
@@ -496,7 +425,7 @@ func (m *OutlineMock) SearchDocuments(ctx context.Context, query string, opts *S
 }
 
 // CreateComment creates a comment on a document
-func (m *OutlineMock) CreateComment(ctx context.Context, req *CreateCommentRequest) (*Comment, error) {
+func (m *OutlineMock) CreateComment(ctx context.Context, req *outline.CreateCommentRequest) (*outline.Comment, error) {
 	m.recordCall("CreateComment")
 
 	if err := m.checkError("CreateComment"); err != nil {
@@ -508,7 +437,7 @@ func (m *OutlineMock) CreateComment(ctx context.Context, req *CreateCommentReque
 
 	// Check if document exists
 	if _, ok := m.documents[req.DocumentID]; !ok {
-		return nil, ErrNotFound
+		return nil, outline.ErrNotFound
 	}
 
 	// Generate ID
@@ -518,7 +447,7 @@ func (m *OutlineMock) CreateComment(ctx context.Context, req *CreateCommentReque
 	// Convert CommentContent to string (simplified)
 	data := extractTextFromCommentContent(req.Data)
 
-	comment := &Comment{
+	comment := &outline.Comment{
 		ID:         id,
 		DocumentID: req.DocumentID,
 		Data:       data,
@@ -526,7 +455,7 @@ func (m *OutlineMock) CreateComment(ctx context.Context, req *CreateCommentReque
 	}
 
 	if m.comments[req.DocumentID] == nil {
-		m.comments[req.DocumentID] = make([]*Comment, 0)
+		m.comments[req.DocumentID] = make([]*outline.Comment, 0)
 	}
 	m.comments[req.DocumentID] = append(m.comments[req.DocumentID], comment)
 
@@ -534,7 +463,7 @@ func (m *OutlineMock) CreateComment(ctx context.Context, req *CreateCommentReque
 }
 
 // ListComments returns all comments for a document
-func (m *OutlineMock) ListComments(ctx context.Context, documentID string) ([]*Comment, error) {
+func (m *OutlineMock) ListComments(ctx context.Context, documentID string) ([]*outline.Comment, error) {
 	m.recordCall("ListComments")
 
 	if err := m.checkError("ListComments"); err != nil {
@@ -546,12 +475,12 @@ func (m *OutlineMock) ListComments(ctx context.Context, documentID string) ([]*C
 
 	// Check if document exists
 	if _, ok := m.documents[documentID]; !ok {
-		return nil, ErrNotFound
+		return nil, outline.ErrNotFound
 	}
 
 	comments := m.comments[documentID]
 	if comments == nil {
-		comments = make([]*Comment, 0)
+		comments = make([]*outline.Comment, 0)
 	}
 
 	return comments, nil
@@ -596,7 +525,7 @@ func toLowerSimple(s string) string {
 	return string(result)
 }
 
-func extractTextFromCommentContent(content CommentContent) string {
+func extractTextFromCommentContent(content outline.CommentContent) string {
 	// Simple text extraction from nested content structure
 	var builder strings.Builder
 	for _, node := range content.Content {
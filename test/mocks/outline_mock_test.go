@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"github.com/yourusername/outline-ai/internal/outline"
 )
 
 // Example test showing usage of OutlineMock
@@ -69,7 +71,7 @@ func TestOutlineMock_BasicOperations(t *testing.T) {
 	t.Run("create document", func(t *testing.T) {
 		collection := mock.AddCollection("col-999", "Guides", "How-to guides")
 
-		req := &CreateDocumentRequest{
+		req := &outline.CreateDocumentRequest{
 			CollectionID: collection.ID,
 			Title:        "Getting Started",
 			Text:         "Welcome to our platform",
@@ -95,9 +97,9 @@ func TestOutlineMock_BasicOperations(t *testing.T) {
 		collection := mock.AddCollection("col-111", "Updates", "Update docs")
 		doc := mock.AddDocument("doc-222", collection.ID, "Original Title", "Original content")
 
-		updateReq := &UpdateDocumentRequest{
+		updateReq := &outline.UpdateDocumentRequest{
 			Title: "Updated Title",
-			Text:  "Updated content",
+			Text:  outline.String("Updated content"),
 		}
 
 		updated, err := mock.UpdateDocument(ctx, doc.ID, updateReq)
@@ -142,14 +144,14 @@ func TestOutlineMock_BasicOperations(t *testing.T) {
 		doc := mock.AddDocument("doc-comments", collection.ID, "Doc with Comments", "Content")
 
 		// Create comment
-		commentReq := &CreateCommentRequest{
+		commentReq := &outline.CreateCommentRequest{
 			DocumentID: doc.ID,
-			Data: CommentContent{
+			Data: outline.CommentContent{
 				Type: "doc",
-				Content: []ContentNode{
+				Content: []outline.ContentNode{
 					{
 						Type: "paragraph",
-						Content: []ContentNode{
+						Content: []outline.ContentNode{
 							{Type: "text", Text: "This is a test comment"},
 						},
 					},
@@ -206,7 +208,7 @@ func TestOutlineMock_ErrorScenarios(t *testing.T) {
 	// Test: Not found error
 	t.Run("not found error", func(t *testing.T) {
 		_, err := mock.GetDocument(ctx, "nonexistent")
-		if err != ErrNotFound {
+		if err != outline.ErrNotFound {
 			t.Errorf("Expected ErrNotFound, got %v", err)
 		}
 	})
@@ -216,7 +218,7 @@ func TestOutlineMock_ErrorScenarios(t *testing.T) {
 		mock.SetFailureMode(true)
 
 		_, err := mock.ListCollections(ctx)
-		if err != ErrServerError {
+		if err != outline.ErrServerError {
 			t.Errorf("Expected ErrServerError, got %v", err)
 		}
 
@@ -228,7 +230,7 @@ func TestOutlineMock_ErrorScenarios(t *testing.T) {
 		mock.SetRateLimited(true)
 
 		_, err := mock.GetCollection(ctx, "any-id")
-		if err != ErrRateLimited {
+		if err != outline.ErrRateLimited {
 			t.Errorf("Expected ErrRateLimited, got %v", err)
 		}
 
@@ -237,7 +239,7 @@ func TestOutlineMock_ErrorScenarios(t *testing.T) {
 
 	// Test: Specific method error
 	t.Run("specific method error", func(t *testing.T) {
-		customErr := ErrUnauthorized
+		customErr := outline.ErrUnauthorized
 		mock.SetGetDocumentError(customErr)
 
 		_, err := mock.GetDocument(ctx, "any-id")
@@ -327,7 +329,7 @@ func TestOutlineMock_IntegrationScenario(t *testing.T) {
 		targetCollection := collections[0]
 
 		// 2. Create a new document
-		createReq := &CreateDocumentRequest{
+		createReq := &outline.CreateDocumentRequest{
 			CollectionID: targetCollection.ID,
 			Title:        "Integration Test Doc",
 			Text:         "This document tests the full lifecycle",
@@ -350,14 +352,14 @@ func TestOutlineMock_IntegrationScenario(t *testing.T) {
 		}
 
 		// 4. Add a comment
-		commentReq := &CreateCommentRequest{
+		commentReq := &outline.CreateCommentRequest{
 			DocumentID: doc.ID,
-			Data: CommentContent{
+			Data: outline.CommentContent{
 				Type: "doc",
-				Content: []ContentNode{
+				Content: []outline.ContentNode{
 					{
 						Type: "paragraph",
-						Content: []ContentNode{
+						Content: []outline.ContentNode{
 							{Type: "text", Text: "Reviewed and approved"},
 						},
 					},
@@ -385,7 +387,7 @@ func TestOutlineMock_IntegrationScenario(t *testing.T) {
 		}
 
 		// 6. Update the document
-		updateReq := &UpdateDocumentRequest{
+		updateReq := &outline.UpdateDocumentRequest{
 			Title: "Updated Integration Test Doc",
 		}
 
@@ -4,61 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
-)
 
-// Package-level errors matching persistence package
-var (
-	ErrNotFoundStorage      = errors.New("persistence: record not found")
-	ErrQuestionNotFound     = errors.New("persistence: question not found")
-	ErrDuplicateEntry       = errors.New("persistence: duplicate entry")
-	ErrDatabaseLocked       = errors.New("persistence: database locked")
-	ErrInvalidInput         = errors.New("persistence: invalid input")
+	"github.com/yourusername/outline-ai/internal/persistence"
 )
 
-// QuestionState represents the state of a question
-type QuestionState struct {
-	ID              int64
-	QuestionHash    string
-	DocumentID      string
-	QuestionText    string
-	ProcessedAt     time.Time
-	AnswerDelivered bool
-	CommentID       *string
-	LastError       *string
-	RetryCount      int
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
-}
-
-// CommandLog represents a logged command execution
-type CommandLog struct {
-	ID              int64
-	DocumentID      string
-	CommandType     string
-	CommandArgs     *string
-	ExecutedAt      time.Time
-	Status          string
-	ErrorMessage    *string
-	ExecutionTimeMs *int
-	CreatedAt       time.Time
-}
-
-// Command status constants
-const (
-	CommandStatusSuccess  = "success"
-	CommandStatusFailed   = "failed"
-	CommandStatusRetrying = "retrying"
-)
+var _ persistence.Storage = (*StorageMock)(nil)
 
 // StorageMock is a mock implementation of the persistence.Storage interface
 type StorageMock struct {
 	mu sync.RWMutex
 
 	// In-memory storage
-	questionStates map[string]*QuestionState // keyed by question hash
-	commandLogs    map[string][]*CommandLog  // keyed by document ID
+	questionStates map[string]*persistence.QuestionState // keyed by question hash
+	commandLogs    map[string][]*persistence.CommandLog  // keyed by document ID
+	auditEntries   []*persistence.AuditEntry
+	deadLetters    []*persistence.DeadLetter
+	cursors        map[string]time.Time
 
 	// Configuration
 	failureMode    bool
@@ -66,8 +30,10 @@ type StorageMock struct {
 	callCounts     map[string]int
 
 	// Counters for IDs
-	questionIDCounter int64
-	commandIDCounter  int64
+	questionIDCounter   int64
+	commandIDCounter    int64
+	auditIDCounter      int64
+	deadLetterIDCounter int64
 
 	// Transaction support
 	inTransaction bool
@@ -76,8 +42,9 @@ type StorageMock struct {
 // NewStorageMock creates a new mock storage instance
 func NewStorageMock() *StorageMock {
 	return &StorageMock{
-		questionStates: make(map[string]*QuestionState),
-		commandLogs:    make(map[string][]*CommandLog),
+		questionStates: make(map[string]*persistence.QuestionState),
+		commandLogs:    make(map[string][]*persistence.CommandLog),
+		cursors:        make(map[string]time.Time),
 		specificErrors: make(map[string]error),
 		callCounts:     make(map[string]int),
 	}
@@ -86,7 +53,7 @@ func NewStorageMock() *StorageMock {
 // Helper Methods for Test Setup
 
 // SeedQuestionState adds a question state to the mock storage
-func (m *StorageMock) SeedQuestionState(state *QuestionState) {
+func (m *StorageMock) SeedQuestionState(state *persistence.QuestionState) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -109,7 +76,7 @@ func (m *StorageMock) SeedQuestionState(state *QuestionState) {
 }
 
 // SeedCommandLog adds a command log to the mock storage
-func (m *StorageMock) SeedCommandLog(log *CommandLog) {
+func (m *StorageMock) SeedCommandLog(log *persistence.CommandLog) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -126,13 +93,13 @@ func (m *StorageMock) SeedCommandLog(log *CommandLog) {
 	}
 
 	if m.commandLogs[log.DocumentID] == nil {
-		m.commandLogs[log.DocumentID] = make([]*CommandLog, 0)
+		m.commandLogs[log.DocumentID] = make([]*persistence.CommandLog, 0)
 	}
 	m.commandLogs[log.DocumentID] = append(m.commandLogs[log.DocumentID], log)
 }
 
 // GetQuestionStateByHash returns a question state by hash (for testing)
-func (m *StorageMock) GetQuestionStateByHash(hash string) *QuestionState {
+func (m *StorageMock) GetQuestionStateByHash(hash string) *persistence.QuestionState {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	return m.questionStates[hash]
@@ -166,10 +133,15 @@ func (m *StorageMock) Clear() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.questionStates = make(map[string]*QuestionState)
-	m.commandLogs = make(map[string][]*CommandLog)
+	m.questionStates = make(map[string]*persistence.QuestionState)
+	m.commandLogs = make(map[string][]*persistence.CommandLog)
+	m.auditEntries = nil
+	m.deadLetters = nil
+	m.cursors = make(map[string]time.Time)
 	m.questionIDCounter = 0
 	m.commandIDCounter = 0
+	m.auditIDCounter = 0
+	m.deadLetterIDCounter = 0
 }
 
 // Reset clears all data and configuration
@@ -177,13 +149,18 @@ func (m *StorageMock) Reset() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.questionStates = make(map[string]*QuestionState)
-	m.commandLogs = make(map[string][]*CommandLog)
+	m.questionStates = make(map[string]*persistence.QuestionState)
+	m.commandLogs = make(map[string][]*persistence.CommandLog)
 	m.specificErrors = make(map[string]error)
 	m.callCounts = make(map[string]int)
+	m.auditEntries = nil
+	m.deadLetters = nil
+	m.cursors = make(map[string]time.Time)
 	m.failureMode = false
 	m.questionIDCounter = 0
 	m.commandIDCounter = 0
+	m.auditIDCounter = 0
+	m.deadLetterIDCounter = 0
 	m.inTransaction = false
 }
 
@@ -200,7 +177,7 @@ func (m *StorageMock) checkError(method string) error {
 	defer m.mu.RUnlock()
 
 	if m.failureMode {
-		return ErrDatabaseLocked
+		return persistence.ErrDatabaseLocked
 	}
 
 	if err, ok := m.specificErrors[method]; ok {
@@ -232,7 +209,7 @@ func (m *StorageMock) HasAnsweredQuestion(ctx context.Context, questionHash stri
 }
 
 // MarkQuestionAnswered marks a question as answered
-func (m *StorageMock) MarkQuestionAnswered(ctx context.Context, state *QuestionState) error {
+func (m *StorageMock) MarkQuestionAnswered(ctx context.Context, state *persistence.QuestionState) error {
 	m.recordCall("MarkQuestionAnswered")
 
 	if err := m.checkError("MarkQuestionAnswered"); err != nil {
@@ -244,7 +221,7 @@ func (m *StorageMock) MarkQuestionAnswered(ctx context.Context, state *QuestionS
 
 	// Check for duplicate
 	if _, exists := m.questionStates[state.QuestionHash]; exists {
-		return ErrDuplicateEntry
+		return persistence.ErrDuplicateEntry
 	}
 
 	// Assign ID and timestamps
@@ -260,8 +237,42 @@ func (m *StorageMock) MarkQuestionAnswered(ctx context.Context, state *QuestionS
 	return nil
 }
 
+// RecordQuestionFailure inserts an undelivered question state or bumps the
+// retry count of an existing one
+func (m *StorageMock) RecordQuestionFailure(ctx context.Context, state *persistence.QuestionState) error {
+	m.recordCall("RecordQuestionFailure")
+
+	if err := m.checkError("RecordQuestionFailure"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	existing, exists := m.questionStates[state.QuestionHash]
+	if !exists {
+		m.questionIDCounter++
+		existing = &persistence.QuestionState{
+			ID:           m.questionIDCounter,
+			QuestionHash: state.QuestionHash,
+			DocumentID:   state.DocumentID,
+			QuestionText: state.QuestionText,
+			CreatedAt:    now,
+		}
+		m.questionStates[state.QuestionHash] = existing
+	}
+	existing.LastError = state.LastError
+	existing.RetryCount++
+	existing.ProcessedAt = now
+	existing.UpdatedAt = now
+
+	*state = *existing
+	return nil
+}
+
 // GetQuestionState retrieves a question state by hash
-func (m *StorageMock) GetQuestionState(ctx context.Context, questionHash string) (*QuestionState, error) {
+func (m *StorageMock) GetQuestionState(ctx context.Context, questionHash string) (*persistence.QuestionState, error) {
 	m.recordCall("GetQuestionState")
 
 	if err := m.checkError("GetQuestionState"); err != nil {
@@ -273,14 +284,14 @@ func (m *StorageMock) GetQuestionState(ctx context.Context, questionHash string)
 
 	state, exists := m.questionStates[questionHash]
 	if !exists {
-		return nil, ErrQuestionNotFound
+		return nil, persistence.ErrQuestionNotFound
 	}
 
 	return state, nil
 }
 
 // UpdateQuestionState updates an existing question state
-func (m *StorageMock) UpdateQuestionState(ctx context.Context, state *QuestionState) error {
+func (m *StorageMock) UpdateQuestionState(ctx context.Context, state *persistence.QuestionState) error {
 	m.recordCall("UpdateQuestionState")
 
 	if err := m.checkError("UpdateQuestionState"); err != nil {
@@ -292,7 +303,7 @@ func (m *StorageMock) UpdateQuestionState(ctx context.Context, state *QuestionSt
 
 	existing, exists := m.questionStates[state.QuestionHash]
 	if !exists {
-		return ErrQuestionNotFound
+		return persistence.ErrQuestionNotFound
 	}
 
 	// Update fields
@@ -330,7 +341,7 @@ func (m *StorageMock) DeleteStaleQuestions(ctx context.Context, olderThan time.T
 // Interface Implementation - Command Logging
 
 // LogCommand logs a command execution
-func (m *StorageMock) LogCommand(ctx context.Context, log *CommandLog) error {
+func (m *StorageMock) LogCommand(ctx context.Context, log *persistence.CommandLog) error {
 	m.recordCall("LogCommand")
 
 	if err := m.checkError("LogCommand"); err != nil {
@@ -350,7 +361,7 @@ func (m *StorageMock) LogCommand(ctx context.Context, log *CommandLog) error {
 	}
 
 	if m.commandLogs[log.DocumentID] == nil {
-		m.commandLogs[log.DocumentID] = make([]*CommandLog, 0)
+		m.commandLogs[log.DocumentID] = make([]*persistence.CommandLog, 0)
 	}
 	m.commandLogs[log.DocumentID] = append(m.commandLogs[log.DocumentID], log)
 
@@ -358,7 +369,7 @@ func (m *StorageMock) LogCommand(ctx context.Context, log *CommandLog) error {
 }
 
 // GetCommandHistory retrieves command history for a document
-func (m *StorageMock) GetCommandHistory(ctx context.Context, documentID string, limit int) ([]*CommandLog, error) {
+func (m *StorageMock) GetCommandHistory(ctx context.Context, documentID string, limit int) ([]*persistence.CommandLog, error) {
 	m.recordCall("GetCommandHistory")
 
 	if err := m.checkError("GetCommandHistory"); err != nil {
@@ -370,7 +381,7 @@ func (m *StorageMock) GetCommandHistory(ctx context.Context, documentID string,
 
 	logs := m.commandLogs[documentID]
 	if logs == nil {
-		return []*CommandLog{}, nil
+		return []*persistence.CommandLog{}, nil
 	}
 
 	// Sort by executed_at DESC (newest first)
@@ -383,7 +394,7 @@ func (m *StorageMock) GetCommandHistory(ctx context.Context, documentID string,
 	}
 
 	// Return a copy to prevent external modification
-	result := make([]*CommandLog, len(logs))
+	result := make([]*persistence.CommandLog, len(logs))
 	copy(result, logs)
 
 	// Reverse to get newest first
@@ -395,6 +406,226 @@ func (m *StorageMock) GetCommandHistory(ctx context.Context, documentID string,
 	return result, nil
 }
 
+// GetUsage totals the logged commands query selects, per group in key order
+func (m *StorageMock) GetUsage(ctx context.Context, query persistence.UsageQuery) ([]*persistence.UsageSummary, error) {
+	m.recordCall("GetUsage")
+
+	if err := m.checkError("GetUsage"); err != nil {
+		return nil, err
+	}
+
+	switch query.GroupBy {
+	case "", persistence.UsageByDay, persistence.UsageByCommand, persistence.UsageByCollection, persistence.UsageByUser:
+	default:
+		return nil, fmt.Errorf("%w: unknown usage grouping %q", persistence.ErrInvalidInput, query.GroupBy)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	groups := make(map[string]*persistence.UsageSummary)
+	if query.GroupBy == "" {
+		groups[""] = &persistence.UsageSummary{}
+	}
+	for _, logs := range m.commandLogs {
+		for _, log := range logs {
+			executed := log.ExecutedAt.UTC()
+			if (!query.Since.IsZero() && executed.Before(query.Since)) || (!query.Until.IsZero() && !executed.Before(query.Until)) {
+				continue
+			}
+
+			var key string
+			switch query.GroupBy {
+			case persistence.UsageByDay:
+				key = executed.Format("2006-01-02")
+			case persistence.UsageByCommand:
+				key = log.CommandType
+			case persistence.UsageByCollection:
+				key = log.CollectionID
+			case persistence.UsageByUser:
+				key = log.UserID
+			}
+
+			summary := groups[key]
+			if summary == nil {
+				summary = &persistence.UsageSummary{Key: key}
+				groups[key] = summary
+			}
+			summary.Commands++
+			summary.PromptTokens += int64(log.PromptTokens)
+			summary.CompletionTokens += int64(log.CompletionTokens)
+			summary.CostUSD += log.CostUSD
+		}
+	}
+
+	result := make([]*persistence.UsageSummary, 0, len(groups))
+	for _, summary := range groups {
+		result = append(result, summary)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// Interface Implementation - Audit Log
+
+// RecordAudit appends a change to the audit log
+func (m *StorageMock) RecordAudit(ctx context.Context, entry *persistence.AuditEntry) error {
+	m.recordCall("RecordAudit")
+
+	if err := m.checkError("RecordAudit"); err != nil {
+		return err
+	}
+
+	if entry == nil || entry.DocumentID == "" || entry.Action == "" {
+		return persistence.ErrInvalidInput
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.auditIDCounter++
+	entry.ID = m.auditIDCounter
+	entry.CreatedAt = time.Now()
+
+	m.auditEntries = append(m.auditEntries, entry)
+	return nil
+}
+
+// GetAuditTrail returns the changes made to a document, newest first
+func (m *StorageMock) GetAuditTrail(ctx context.Context, documentID string, limit int) ([]*persistence.AuditEntry, error) {
+	m.recordCall("GetAuditTrail")
+
+	if err := m.checkError("GetAuditTrail"); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*persistence.AuditEntry, 0)
+	for i := len(m.auditEntries) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) == limit {
+			break
+		}
+		if entry := m.auditEntries[i]; entry.DocumentID == documentID {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+// Interface Implementation - Dead Letters
+
+// AddDeadLetter records a task that used up its retries
+func (m *StorageMock) AddDeadLetter(ctx context.Context, letter *persistence.DeadLetter) error {
+	m.recordCall("AddDeadLetter")
+
+	if err := m.checkError("AddDeadLetter"); err != nil {
+		return err
+	}
+
+	if letter == nil || letter.TaskID == "" || letter.TaskType == "" {
+		return persistence.ErrInvalidInput
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.deadLetterIDCounter++
+	letter.ID = m.deadLetterIDCounter
+	letter.CreatedAt = time.Now()
+
+	if letter.FailedAt.IsZero() {
+		letter.FailedAt = time.Now()
+	}
+
+	m.deadLetters = append(m.deadLetters, letter)
+	return nil
+}
+
+// ListDeadLetters returns dead letters, newest first
+func (m *StorageMock) ListDeadLetters(ctx context.Context, limit int) ([]*persistence.DeadLetter, error) {
+	m.recordCall("ListDeadLetters")
+
+	if err := m.checkError("ListDeadLetters"); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Letters are stored in insertion order, so reverse for newest first
+	result := make([]*persistence.DeadLetter, 0, len(m.deadLetters))
+	for i := len(m.deadLetters) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) == limit {
+			break
+		}
+		result = append(result, m.deadLetters[i])
+	}
+
+	return result, nil
+}
+
+// DeleteDeadLetter removes a dead letter by ID
+func (m *StorageMock) DeleteDeadLetter(ctx context.Context, id int64) error {
+	m.recordCall("DeleteDeadLetter")
+
+	if err := m.checkError("DeleteDeadLetter"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, letter := range m.deadLetters {
+		if letter.ID == id {
+			m.deadLetters = append(m.deadLetters[:i], m.deadLetters[i+1:]...)
+			return nil
+		}
+	}
+
+	return persistence.ErrNotFound
+}
+
+// Interface Implementation - Cursors
+
+// GetCursor returns the position stored under name
+func (m *StorageMock) GetCursor(ctx context.Context, name string) (time.Time, error) {
+	m.recordCall("GetCursor")
+
+	if err := m.checkError("GetCursor"); err != nil {
+		return time.Time{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	position, ok := m.cursors[name]
+	if !ok {
+		return time.Time{}, persistence.ErrNotFound
+	}
+	return position, nil
+}
+
+// SetCursor stores position under name
+func (m *StorageMock) SetCursor(ctx context.Context, name string, position time.Time) error {
+	m.recordCall("SetCursor")
+
+	if err := m.checkError("SetCursor"); err != nil {
+		return err
+	}
+
+	if name == "" {
+		return persistence.ErrInvalidInput
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cursors[name] = position
+	return nil
+}
+
 // Interface Implementation - Health and Maintenance
 
 // Ping checks if the storage is available
@@ -419,7 +650,7 @@ func (m *StorageMock) Backup(ctx context.Context, destinationPath string) error
 
 	// Mock implementation - just verify path is not empty
 	if destinationPath == "" {
-		return ErrInvalidInput
+		return persistence.ErrInvalidInput
 	}
 
 	// In a real scenario, would write data to file
@@ -455,7 +686,7 @@ func (m *StorageMock) RunInTransaction(ctx context.Context, fn func(ctx context.
 
 // IsQuestionNotFound checks if error is ErrQuestionNotFound
 func IsQuestionNotFound(err error) bool {
-	return errors.Is(err, ErrQuestionNotFound)
+	return errors.Is(err, persistence.ErrQuestionNotFound)
 }
 
 // GenerateQuestionHash generates a hash for a question
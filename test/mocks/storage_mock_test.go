@@ -2,8 +2,11 @@ package mocks
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/yourusername/outline-ai/internal/persistence"
 )
 
 // Example test showing usage of StorageMock
@@ -15,7 +18,7 @@ func TestStorageMock_BasicOperations(t *testing.T) {
 
 	// Test: Mark question answered
 	t.Run("mark question answered", func(t *testing.T) {
-		state := &QuestionState{
+		state := &persistence.QuestionState{
 			QuestionHash:    "hash-123",
 			DocumentID:      "doc-456",
 			QuestionText:    "What is REST?",
@@ -94,7 +97,7 @@ func TestStorageMock_BasicOperations(t *testing.T) {
 
 		// Try to get non-existent state
 		_, err = mock.GetQuestionState(ctx, "nonexistent")
-		if err != ErrQuestionNotFound {
+		if err != persistence.ErrQuestionNotFound {
 			t.Errorf("Expected ErrQuestionNotFound, got %v", err)
 		}
 	})
@@ -132,11 +135,11 @@ func TestStorageMock_BasicOperations(t *testing.T) {
 		}
 
 		// Try to update non-existent state
-		newState := &QuestionState{
+		newState := &persistence.QuestionState{
 			QuestionHash: "nonexistent",
 		}
 		err = mock.UpdateQuestionState(ctx, newState)
-		if err != ErrQuestionNotFound {
+		if err != persistence.ErrQuestionNotFound {
 			t.Errorf("Expected ErrQuestionNotFound, got %v", err)
 		}
 	})
@@ -144,7 +147,7 @@ func TestStorageMock_BasicOperations(t *testing.T) {
 	// Test: Delete stale questions
 	t.Run("delete stale questions", func(t *testing.T) {
 		// Add some old questions
-		oldState1 := &QuestionState{
+		oldState1 := &persistence.QuestionState{
 			QuestionHash:    "old-hash-1",
 			DocumentID:      "doc-old",
 			QuestionText:    "Old question 1",
@@ -153,7 +156,7 @@ func TestStorageMock_BasicOperations(t *testing.T) {
 		}
 		mock.SeedQuestionState(oldState1)
 
-		oldState2 := &QuestionState{
+		oldState2 := &persistence.QuestionState{
 			QuestionHash:    "old-hash-2",
 			DocumentID:      "doc-old",
 			QuestionText:    "Old question 2",
@@ -163,7 +166,7 @@ func TestStorageMock_BasicOperations(t *testing.T) {
 		mock.SeedQuestionState(oldState2)
 
 		// Add a recent question
-		recentState := &QuestionState{
+		recentState := &persistence.QuestionState{
 			QuestionHash:    "recent-hash",
 			DocumentID:      "doc-recent",
 			QuestionText:    "Recent question",
@@ -185,12 +188,12 @@ func TestStorageMock_BasicOperations(t *testing.T) {
 
 		// Verify old questions are gone
 		_, err = mock.GetQuestionState(ctx, "old-hash-1")
-		if err != ErrQuestionNotFound {
+		if err != persistence.ErrQuestionNotFound {
 			t.Error("Expected old question 1 to be deleted")
 		}
 
 		_, err = mock.GetQuestionState(ctx, "old-hash-2")
-		if err != ErrQuestionNotFound {
+		if err != persistence.ErrQuestionNotFound {
 			t.Error("Expected old question 2 to be deleted")
 		}
 
@@ -203,11 +206,11 @@ func TestStorageMock_BasicOperations(t *testing.T) {
 
 	// Test: Command logging
 	t.Run("log command", func(t *testing.T) {
-		log := &CommandLog{
+		log := &persistence.CommandLog{
 			DocumentID:      "doc-123",
 			CommandType:     "classify",
 			CommandArgs:     StringPtr("--collection=engineering"),
-			Status:          CommandStatusSuccess,
+			Status:          persistence.CommandStatusSuccess,
 			ExecutionTimeMs: IntPtr(150),
 		}
 
@@ -234,10 +237,10 @@ func TestStorageMock_BasicOperations(t *testing.T) {
 	t.Run("get command history", func(t *testing.T) {
 		// Add multiple commands for same document
 		for range 5 {
-			log := &CommandLog{
+			log := &persistence.CommandLog{
 				DocumentID:  "doc-history",
 				CommandType: "test",
-				Status:      CommandStatusSuccess,
+				Status:      persistence.CommandStatusSuccess,
 			}
 			_ = mock.LogCommand(ctx, log)
 			time.Sleep(1 * time.Millisecond) // Ensure different timestamps
@@ -284,7 +287,7 @@ func TestStorageMock_ErrorScenarios(t *testing.T) {
 
 	// Test: Duplicate entry error
 	t.Run("duplicate entry error", func(t *testing.T) {
-		state := &QuestionState{
+		state := &persistence.QuestionState{
 			QuestionHash: "duplicate-hash",
 			DocumentID:   "doc-123",
 			QuestionText: "Duplicate question",
@@ -297,14 +300,14 @@ func TestStorageMock_ErrorScenarios(t *testing.T) {
 		}
 
 		// Second insert with same hash should fail
-		state2 := &QuestionState{
+		state2 := &persistence.QuestionState{
 			QuestionHash: "duplicate-hash",
 			DocumentID:   "doc-456",
 			QuestionText: "Different question, same hash",
 		}
 
 		err = mock.MarkQuestionAnswered(ctx, state2)
-		if err != ErrDuplicateEntry {
+		if err != persistence.ErrDuplicateEntry {
 			t.Errorf("Expected ErrDuplicateEntry, got %v", err)
 		}
 	})
@@ -313,14 +316,14 @@ func TestStorageMock_ErrorScenarios(t *testing.T) {
 	t.Run("failure mode", func(t *testing.T) {
 		mock.SetFailureMode(true)
 
-		state := &QuestionState{
+		state := &persistence.QuestionState{
 			QuestionHash: "fail-hash",
 			DocumentID:   "doc-fail",
 			QuestionText: "This should fail",
 		}
 
 		err := mock.MarkQuestionAnswered(ctx, state)
-		if err != ErrDatabaseLocked {
+		if err != persistence.ErrDatabaseLocked {
 			t.Errorf("Expected ErrDatabaseLocked, got %v", err)
 		}
 
@@ -329,7 +332,7 @@ func TestStorageMock_ErrorScenarios(t *testing.T) {
 
 	// Test: Method-specific error
 	t.Run("method specific error", func(t *testing.T) {
-		customErr := ErrInvalidInput
+		customErr := persistence.ErrInvalidInput
 		mock.SetMethodError("GetQuestionState", customErr)
 
 		_, err := mock.GetQuestionState(ctx, "any-hash")
@@ -347,7 +350,7 @@ func TestStorageMock_ErrorScenarios(t *testing.T) {
 	// Test: Invalid backup path
 	t.Run("invalid backup path", func(t *testing.T) {
 		err := mock.Backup(ctx, "")
-		if err != ErrInvalidInput {
+		if err != persistence.ErrInvalidInput {
 			t.Errorf("Expected ErrInvalidInput, got %v", err)
 		}
 
@@ -368,7 +371,7 @@ func TestStorageMock_SeedingAndHelpers(t *testing.T) {
 
 	// Test: Seed question state
 	t.Run("seed question state", func(t *testing.T) {
-		state := &QuestionState{
+		state := &persistence.QuestionState{
 			QuestionHash:    "seeded-hash",
 			DocumentID:      "doc-seeded",
 			QuestionText:    "Seeded question",
@@ -397,10 +400,10 @@ func TestStorageMock_SeedingAndHelpers(t *testing.T) {
 
 	// Test: Seed command log
 	t.Run("seed command log", func(t *testing.T) {
-		log := &CommandLog{
+		log := &persistence.CommandLog{
 			DocumentID:   "doc-seeded-log",
 			CommandType:  "test-command",
-			Status:       CommandStatusSuccess,
+			Status:       persistence.CommandStatusSuccess,
 			ErrorMessage: StringPtr("No error"),
 		}
 
@@ -443,7 +446,7 @@ func TestStorageMock_SeedingAndHelpers(t *testing.T) {
 
 	// Test: Get question state by hash (testing helper)
 	t.Run("get question state by hash helper", func(t *testing.T) {
-		state := &QuestionState{
+		state := &persistence.QuestionState{
 			QuestionHash: "helper-hash",
 			DocumentID:   "doc-helper",
 			QuestionText: "Helper test",
@@ -470,17 +473,17 @@ func TestStorageMock_SeedingAndHelpers(t *testing.T) {
 	// Test: Clear method
 	t.Run("clear storage", func(t *testing.T) {
 		// Add some data
-		state := &QuestionState{
+		state := &persistence.QuestionState{
 			QuestionHash: "clear-test",
 			DocumentID:   "doc-clear",
 			QuestionText: "Clear test",
 		}
 		mock.SeedQuestionState(state)
 
-		log := &CommandLog{
+		log := &persistence.CommandLog{
 			DocumentID:  "doc-clear",
 			CommandType: "test",
-			Status:      CommandStatusSuccess,
+			Status:      persistence.CommandStatusSuccess,
 		}
 		mock.SeedCommandLog(log)
 
@@ -489,7 +492,7 @@ func TestStorageMock_SeedingAndHelpers(t *testing.T) {
 
 		// Verify data is gone
 		_, err := mock.GetQuestionState(ctx, "clear-test")
-		if err != ErrQuestionNotFound {
+		if err != persistence.ErrQuestionNotFound {
 			t.Error("Expected data to be cleared")
 		}
 
@@ -511,7 +514,7 @@ func TestStorageMock_CallTracking(t *testing.T) {
 	ctx := context.Background()
 
 	// Make several calls
-	state := &QuestionState{
+	state := &persistence.QuestionState{
 		QuestionHash: "track-hash",
 		DocumentID:   "doc-track",
 		QuestionText: "Track test",
@@ -571,7 +574,7 @@ func TestStorageMock_IntegrationScenario(t *testing.T) {
 		}
 
 		// 3. Process question and mark as answered
-		state := &QuestionState{
+		state := &persistence.QuestionState{
 			QuestionHash: questionHash,
 			DocumentID:   documentID,
 			QuestionText: questionText,
@@ -584,11 +587,11 @@ func TestStorageMock_IntegrationScenario(t *testing.T) {
 		}
 
 		// 4. Log the command execution
-		log := &CommandLog{
+		log := &persistence.CommandLog{
 			DocumentID:      documentID,
 			CommandType:     "answer_question",
 			CommandArgs:     StringPtr(questionText),
-			Status:          CommandStatusSuccess,
+			Status:          persistence.CommandStatusSuccess,
 			ExecutionTimeMs: IntPtr(250),
 		}
 
@@ -627,7 +630,7 @@ func TestStorageMock_IntegrationScenario(t *testing.T) {
 			t.Errorf("Expected 1 command in history, got %d", len(history))
 		}
 
-		if history[0].Status != CommandStatusSuccess {
+		if history[0].Status != persistence.CommandStatusSuccess {
 			t.Errorf("Expected status 'success', got '%s'", history[0].Status)
 		}
 
@@ -648,3 +651,51 @@ func TestStorageMock_IntegrationScenario(t *testing.T) {
 		t.Logf("  - Commands logged: %d", len(history))
 	})
 }
+
+func TestStorageMock_DeadLetters(t *testing.T) {
+	mock := NewStorageMock()
+	ctx := context.Background()
+
+	for _, taskType := range []string{"/ai", "/related"} {
+		if err := mock.AddDeadLetter(ctx, &persistence.DeadLetter{TaskID: "task" + taskType, TaskType: taskType}); err != nil {
+			t.Fatalf("Failed to add dead letter: %v", err)
+		}
+	}
+
+	letters, err := mock.ListDeadLetters(ctx, 0)
+	if err != nil || len(letters) != 2 || letters[0].TaskType != "/related" {
+		t.Fatalf("Expected two dead letters, newest first, got %+v (%v)", letters, err)
+	}
+
+	if err := mock.DeleteDeadLetter(ctx, letters[0].ID); err != nil {
+		t.Errorf("Failed to delete dead letter: %v", err)
+	}
+	if err := mock.DeleteDeadLetter(ctx, letters[0].ID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if letters, _ := mock.ListDeadLetters(ctx, 0); len(letters) != 1 {
+		t.Errorf("Expected one dead letter left, got %d", len(letters))
+	}
+}
+
+func TestStorageMock_Cursors(t *testing.T) {
+	mock := NewStorageMock()
+	ctx := context.Background()
+
+	if _, err := mock.GetCursor(ctx, "poller"); !errors.Is(err, persistence.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	position := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := mock.SetCursor(ctx, "poller", position); err != nil {
+		t.Fatalf("Failed to set cursor: %v", err)
+	}
+	if got, err := mock.GetCursor(ctx, "poller"); err != nil || !got.Equal(position) {
+		t.Errorf("Expected %v, got %v (%v)", position, got, err)
+	}
+
+	mock.Clear()
+	if _, err := mock.GetCursor(ctx, "poller"); !errors.Is(err, persistence.ErrNotFound) {
+		t.Errorf("Expected cursors to be cleared, got %v", err)
+	}
+}